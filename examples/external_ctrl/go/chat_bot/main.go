@@ -0,0 +1,84 @@
+// chat_bot greets the players joining the server and answers "!time" in the chat
+//
+//	go run ./examples/external_ctrl/go/chat_bot -address 127.0.0.1:3456 -credential <token>
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"phoenixbuilder/fastbuilder/external/sdk"
+	"strings"
+	"time"
+)
+
+func tellraw(bot *sdk.Bot, text string) {
+	message, _ := json.Marshal(map[string]interface{}{
+		"rawtext": []map[string]string{{"text": text}},
+	})
+	bot.SettingsCommand(fmt.Sprintf("tellraw @a %s", message))
+}
+
+func main() {
+	address := flag.String("address", "127.0.0.1:3456", "the address FastBuilder listens on for external connections")
+	credential := flag.String("credential", "", "a token authorized in the external_auth.json of FastBuilder")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	bot, err := sdk.Dial(ctx, sdk.Options{Address: *address, Credential: *credential})
+	cancel()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer bot.Close()
+
+	bot.OnConnectionEvent(func(event sdk.ConnectionEvent) {
+		switch event.Type {
+		case sdk.EventServerDisconnected:
+			fmt.Printf("The bot is disconnected from the server: %s\n", event.Reason)
+		case sdk.EventServerReconnected:
+			fmt.Println("The bot is back on the server")
+		case sdk.EventLinkLost:
+			fmt.Println("Lost the connection to FastBuilder, reconnecting")
+		case sdk.EventLinkRestored:
+			fmt.Println("Connected to FastBuilder again")
+		}
+	})
+	bot.OnPlayerJoin(func(player sdk.Player) {
+		tellraw(bot, fmt.Sprintf("Welcome, %s!", player.Username))
+	})
+	bot.OnPlayerLeave(func(player sdk.Player) {
+		fmt.Printf("%s left the game\n", player.Username)
+	})
+	bot.OnChat(func(message sdk.ChatMessage) {
+		fmt.Printf("<%s> %s\n", message.Sender, message.Message)
+		if strings.TrimSpace(message.Message) != "!time" {
+			return
+		}
+		// The handlers shouldn't wait for the output of commands
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			output, err := bot.Command(ctx, "time query daytime")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "time query daytime: %v\n", err)
+				return
+			}
+			if len(output.Messages) == 0 || len(output.Messages[0].Parameters) == 0 {
+				return
+			}
+			tellraw(bot, fmt.Sprintf("It is %s ticks into the day", output.Messages[0].Parameters[0]))
+		}()
+	})
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	select {
+	case <-interrupt:
+	case <-bot.Done():
+	}
+}
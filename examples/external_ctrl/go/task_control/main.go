@@ -0,0 +1,93 @@
+// task_control creates a building task, reports its progress until it finishes,
+// and breaks it on Ctrl-C
+//
+//	go run ./examples/external_ctrl/go/task_control -credential <token> -task "round -r 5"
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"phoenixbuilder/fastbuilder/external/sdk"
+	"time"
+)
+
+func main() {
+	address := flag.String("address", "127.0.0.1:3456", "the address FastBuilder listens on for external connections")
+	credential := flag.String("credential", "", "a token authorized in the external_auth.json of FastBuilder")
+	commandLine := flag.String("task", "round -r 5", "the FB command creating the task")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	bot, err := sdk.Dial(ctx, sdk.Options{Address: *address, Credential: *credential})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer bot.Close()
+
+	// The tasks are paused by FastBuilder while it reconnects to the server
+	bot.OnConnectionEvent(func(event sdk.ConnectionEvent) {
+		if event.Type == sdk.EventServerDisconnected {
+			fmt.Println("The bot is disconnected, the task is paused until it reconnects")
+		}
+	})
+	bot.OnUQHolderChange(func(change *sdk.UQHolderChange) {
+		if change.BotMoved {
+			fmt.Printf("The bot moved to %v\n", change.Current.BotPos.Position)
+		}
+	})
+
+	task, err := bot.CreateTask(ctx, *commandLine)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Task %d created\n", task.TaskID)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-interrupt:
+			if err := bot.BreakTask(task.TaskID); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			return
+		case <-bot.Done():
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		tasks, err := bot.ListTasks(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		found := false
+		for _, info := range tasks {
+			if info.TaskID != task.TaskID {
+				continue
+			}
+			found = true
+			switch info.State {
+			case sdk.TaskStatePaused:
+				fmt.Printf("Task %d paused\n", info.TaskID)
+			case sdk.TaskStateCalculating:
+				fmt.Printf("Task %d calculating\n", info.TaskID)
+			default:
+				fmt.Printf("Task %d: %d/%d blocks\n", info.TaskID, info.Built, info.Total)
+			}
+		}
+		if !found {
+			fmt.Printf("Task %d finished\n", task.TaskID)
+			return
+		}
+	}
+}
@@ -41,7 +41,10 @@ struct go_string server_code=EMPTY_GOSTRING;
 struct go_string server_password=EMPTY_GOSTRING;
 struct go_string token_content=EMPTY_GOSTRING;
 struct go_string externalListenAddr=EMPTY_GOSTRING;
+struct go_string externalAuthFile=EMPTY_GOSTRING;
+struct go_string wsListenAddr=EMPTY_GOSTRING;
 struct go_string capture_output_file=EMPTY_GOSTRING;
+struct go_string robot_config=EMPTY_GOSTRING;
 char args_no_readline=0;
 struct go_string pack_scripts=EMPTY_GOSTRING;
 struct go_string pack_scripts_out=EMPTY_GOSTRING;
@@ -59,18 +62,19 @@ void print_help(const char *self_name) {
 	printf("\t-A <url>, --auth-server=<url>: Use the specified authentication server, instead of the default one.\n");
 	printf("\t--no-update-check: Suppress update notifications.\n");
 	printf("\t--force-pyrpc: Enable the PyRpcPacket interaction, client will be kicked automatically by netease's rental server.\n");
-#ifdef WITH_V8
 	printf("\t-S, --script=<*.js>: run a .js script at start\n");
 	printf("\t--script-engine-const key=value: Define a const value for script engine's \"consts\" const. Can be used to replace the default value. Specify multiple items by using this argument for multiple times.\n");
 	printf("\t--script-engine-suppress-const <key>: Undefine a const value for script engine's \"consts\" const. Specify multiple items by using this argument for multiple times.\n");
-#endif
 	printf("\t-c, --code=<server code>: Specify a server code.\n");
 	printf("\t-p, --password=<server password>: Specify the password of the server specified by -c.\n");
 	printf("\t-t, --token=<path of FBToken>: Specify the path of FBToken, and quit if the file is unaccessible.\n");
 	printf("\t-T, --plain-token=<token>: Specify the token content.\n");
 	printf("\t-E, --listen-external: Listen on the specified address and wait for external controlling connection.\n\t\tExample: -E 0.0.0.0:5768 - listen on port 5768 and accept connections from anywhere,\n\t\t\t-E 127.0.0.1:5769 - listen on port 5769 and accept connections from localhost only.\n");
-	printf("\t--capture=<*.bin>: Capture minecraft packet and dump to target file\n");
+	printf("\t--listen-ws=<address>: Serve the external controlling operations as JSON messages over WebSocket on the specified address, using the same clients as -E.\n");
+	printf("\t--external-auth=<*.json>: Specify the clients allowed to connect to the address specified by -E and their scopes, instead of ~/.config/fastbuilder/external_auth.json.\n");
+	printf("\t--capture=<*.bin>: Capture minecraft packet and dump to target file, the packets received and sent are appended with timestamps and can be inspected by pcapview (fastbuilder/capture/pcapview).\n");
 	printf("\t--no-readline: Suppress user input.\n");
+	printf("\t--robot[=<robot.json>]: Run as a headless bot configured by the specified JSON/YAML file (robot.json by default), which logs in, listens for external connections and restarts automatically. Implies --no-readline.\n");
 	printf("\t--pack-scripts <manifest path>: Create a script package.\n");
 	printf("\t--pack-scripts-to <path>: Specify the path for the output script package.\n");
 	printf("\t-N, --gamename <name>: Specify the game name to use interactive commands (e.g. get), instead of using the server provided one.\n");
@@ -96,9 +100,6 @@ void print_version(int detailed) {
 	printf("PhoenixBuilder " FB_VERSION "\n");
 #ifdef FBGUI_VERSION
 	printf("With GUI " FBGUI_VERSION "\n");
-#endif
-#ifdef WITH_V8
-	printf("With V8 linked.\n");
 #endif
 	printf("COMMIT " FB_COMMIT_LONG "\n");
 	printf("\n");
@@ -202,6 +203,16 @@ void config_cleanup() {
 }
 
 int _parse_args(int argc, char **argv) {
+	// The arguments are parsed by a constructor, that is, before the
+	// testing package of Go gets the chance to parse its own flags.
+	// `go test` always runs the test binary with -test.* flags
+	// (e.g. -test.timeout), which getopt rejects by printing the help
+	// and exiting, so no package importing args could be tested.
+	// Leave them to the testing package and keep the defaults.
+	for(int i=1;i<argc;i++) {
+		if(strncmp(argv[i],"-test.",6)==0)
+			return -1;
+	}
 	while(1) {
 		static struct option opts[]={
 			{"debug", no_argument, 0, 0}, // 0
@@ -229,6 +240,9 @@ int _parse_args(int argc, char **argv) {
 			{"gamename", required_argument, 0, 'N'}, // 22
 			{"ingame-response", no_argument, 0, 0}, // 23
 			{"del-userdata", no_argument, 0, 0}, // 24
+			{"external-auth", required_argument, 0, 0}, // 25
+			{"listen-ws", required_argument, 0, 0}, // 26
+			{"robot", optional_argument, 0, 0}, // 27
 			{0, 0, 0, 0}
 		};
 		int option_index;
@@ -256,10 +270,6 @@ int _parse_args(int argc, char **argv) {
 				print_version(0);
 				return 0;
 			case 14:
-#ifndef WITH_V8
-				fprintf(stderr,"--script-engine-const argument isn't available: Non-v8-linked version.\n");
-				return 10;
-#endif
 				{
 					int break_switch_14=0;
 					for(char *ptr=optarg;*ptr!=0;ptr++) {
@@ -277,10 +287,6 @@ int _parse_args(int argc, char **argv) {
 					return 1;
 				}
 			case 15:
-#ifndef WITH_V8
-				fprintf(stderr,"--script-engine-suppress-const argument isn't available: Non-v8-linked version.\n");
-				return 10;
-#endif
 				do_suppress_se_const(optarg);
 				break;
 			case 17:
@@ -301,6 +307,21 @@ int _parse_args(int argc, char **argv) {
 			case 24:
 				config_cleanup();
 				break;
+			case 25:
+				quickset(&externalAuthFile);
+				break;
+			case 26:
+				quickset(&wsListenAddr);
+				break;
+			case 27:
+				if(optarg) {
+					quickset(&robot_config);
+				}else{
+					robot_config.buf="robot.json";
+					robot_config.length=10;
+				}
+				args_no_readline=1;
+				break;
 			};
 			break;
 		case 'h':
@@ -310,10 +331,6 @@ int _parse_args(int argc, char **argv) {
 			quickset(&newAuthServer);
 			break;
 		case 'S':
-#ifndef WITH_V8
-			fprintf(stderr,"-S, --script option isn't available: No V8 linked for this version.\n");
-			return 10;
-#endif
 			quickset(&startup_script);
 			break;
 		case 'c':
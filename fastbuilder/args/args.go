@@ -17,7 +17,10 @@ extern char server_code;
 extern char server_password;
 extern char token_content;
 extern char externalListenAddr;
+extern char externalAuthFile;
+extern char wsListenAddr;
 extern char capture_output_file;
+extern char robot_config;
 extern char args_no_readline;
 extern char pack_scripts;
 extern char pack_scripts_out;
@@ -44,7 +47,10 @@ func referenceHolder() {
 	print(C.server_password)
 	print(C.token_content)
 	print(C.externalListenAddr)
+	print(C.externalAuthFile)
+	print(C.wsListenAddr)
 	print(C.capture_output_file)
+	print(C.robot_config)
 	print(C.args_no_readline)
 	print(C.pack_scripts)
 	print(C.pack_scripts_out)
@@ -86,7 +92,10 @@ func do_suppress_se_const(key *C.char) {
 }
 
 var ExternalListenAddress=*(*string)(unsafe.Pointer(&__cgo_externalListenAddr))
+var ExternalAuthFile=*(*string)(unsafe.Pointer(&__cgo_externalAuthFile))
+var WebSocketListenAddress=*(*string)(unsafe.Pointer(&__cgo_wsListenAddr))
 var CaptureOutputFile=*(*string)(unsafe.Pointer(&__cgo_capture_output_file))
+var RobotConfig=*(*string)(unsafe.Pointer(&__cgo_robot_config))
 var NoReadline=*(*bool)(unsafe.Pointer(&__cgo_args_no_readline))
 var PackScripts=*(*string)(unsafe.Pointer(&__cgo_pack_scripts))
 var PackScriptsOut=*(*string)(unsafe.Pointer(&__cgo_pack_scripts_out))
@@ -13,8 +13,9 @@ import (
 )
 
 type BDump struct {
-	Author string // Should be empty
-	Blocks []*types.Module
+	Author   string // Should be empty
+	Blocks   []*types.Module
+	Entities []*types.Module // Entity field of each module must be non-nil
 }
 
 /*
@@ -81,11 +82,53 @@ func (bdump *BDump) formatBlocks() {
 			min[2] = mdl.Point.Z
 		}
 	}
+	if len(bdump.Blocks) == 0 {
+		min = []int{0, 0, 0}
+	}
 	for _, mdl := range bdump.Blocks {
 		mdl.Point.X -= min[0]
 		mdl.Point.Y -= min[1]
 		mdl.Point.Z -= min[2]
 	}
+	// Entities are shifted with the blocks so that they stay in place
+	for _, mdl := range bdump.Entities {
+		mdl.Point.X -= min[0]
+		mdl.Point.Y -= min[1]
+		mdl.Point.Z -= min[2]
+	}
+}
+
+// moveBrush moves the brush to point, using one 32-bit jump per axis.
+// It is only used for entities, which are few and scattered.
+func moveBrush(writer *BDumpWriter, brushPosition []int, point types.Position) error {
+	if point.X != brushPosition[0] {
+		err := writer.WriteCommand(&command.AddInt32XValue{
+			Value: int32(point.X - brushPosition[0]),
+		})
+		if err != nil {
+			return err
+		}
+		brushPosition[0] = point.X
+	}
+	if point.Y != brushPosition[1] {
+		err := writer.WriteCommand(&command.AddInt32YValue{
+			Value: int32(point.Y - brushPosition[1]),
+		})
+		if err != nil {
+			return err
+		}
+		brushPosition[1] = point.Y
+	}
+	if point.Z != brushPosition[2] {
+		err := writer.WriteCommand(&command.AddInt32ZValue{
+			Value: int32(point.Z - brushPosition[2]),
+		})
+		if err != nil {
+			return err
+		}
+		brushPosition[2] = point.Z
+	}
+	return nil
 }
 
 func (bdump *BDump) writeHeader(w io.Writer) error {
@@ -141,6 +184,21 @@ func (bdump *BDump) writeBlocks(w io.Writer) error {
 		blocksPalette[blkst] = cursor
 		cursor++
 	}
+	for _, mdl := range bdump.Entities {
+		identifier := mdl.Entity.Identifier
+		_, found := blocksPalette[identifier]
+		if found {
+			continue
+		}
+		err := writer.WriteCommand(&command.CreateConstantString{
+			ConstantString: identifier,
+		})
+		if err != nil {
+			return err
+		}
+		blocksPalette[identifier] = cursor
+		cursor++
+	}
 	for _, mdl := range bdump.Blocks {
 		for {
 			if mdl.Point.X != brushPosition[0] {
@@ -315,6 +373,22 @@ func (bdump *BDump) writeBlocks(w io.Writer) error {
 			}
 		*/
 	}
+	// Entities come after all the blocks, so that they are
+	// summoned when the blocks they stand on or hang from exist
+	for _, mdl := range bdump.Entities {
+		err := moveBrush(writer, brushPosition, mdl.Point)
+		if err != nil {
+			return err
+		}
+		err = writer.WriteCommand(&command.PlaceEntityWithNBTData{
+			EntityConstantStringID: uint16(blocksPalette[mdl.Entity.Identifier]),
+			Offset:                 mdl.Entity.Offset,
+			EntityNBT:              mdl.Entity.NBT,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"phoenixbuilder/minecraft/nbt"
+)
+
+// 在画笔所在的方块处生成一个实体。
+// Offset 为实体相对于该方块的坐标偏移
+type PlaceEntityWithNBTData struct {
+	EntityConstantStringID uint16
+	Offset                 [3]float32
+	EntityNBT_bytes        []byte
+	EntityNBT              map[string]interface{}
+}
+
+func (_ *PlaceEntityWithNBTData) ID() uint16 {
+	return 42
+}
+
+func (_ *PlaceEntityWithNBTData) Name() string {
+	return "PlaceEntityWithNBTDataCommand"
+}
+
+func (cmd *PlaceEntityWithNBTData) Marshal(writer io.Writer) error {
+	buf := make([]byte, 14)
+	binary.BigEndian.PutUint16(buf, cmd.EntityConstantStringID)
+	for i, value := range cmd.Offset {
+		binary.BigEndian.PutUint32(buf[2+i*4:], math.Float32bits(value))
+	}
+	_, err := writer.Write(buf)
+	if err != nil {
+		return err
+	}
+	if cmd.EntityNBT_bytes == nil {
+		cmd.EntityNBT_bytes, err = nbt.MarshalEncoding(cmd.EntityNBT, nbt.LittleEndian)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = writer.Write(cmd.EntityNBT_bytes) // cmd.EntityNBT_bytes 以 nbt.LittleEndian 编码
+	return err
+}
+
+func (cmd *PlaceEntityWithNBTData) Unmarshal(reader io.Reader) error {
+	buf := make([]byte, 14)
+	_, err := io.ReadAtLeast(reader, buf, 14)
+	if err != nil {
+		return err
+	}
+	cmd.EntityConstantStringID = binary.BigEndian.Uint16(buf)
+	for i := range cmd.Offset {
+		cmd.Offset[i] = math.Float32frombits(binary.BigEndian.Uint32(buf[2+i*4:]))
+	}
+	err = nbt.NewDecoderWithEncoding(reader, nbt.LittleEndian).Decode(&cmd.EntityNBT)
+	return err
+}
@@ -37,5 +37,6 @@ var BDumpCommandPool map[uint16]func()Command = map[uint16]func()Command {
 	39: func()Command { return &AssignDebugData{} },
 	40: func()Command { return &PlaceBlockWithChestData{} },
 	41: func()Command { return &PlaceBlockWithNBTData{} },
+	42: func()Command { return &PlaceEntityWithNBTData{} },
 	88: func()Command { return &Terminate{} },
 }
\ No newline at end of file
@@ -238,15 +238,32 @@ func (c *Container) MoveItemIntoContainer(
 	// 返回值
 }
 
-// 将 item 所指代的子方块获取到物品栏。
-// 如果 item 有自定义的物品显示名称，
-// 则还会使用铁砧进行改名。
-// 返回的布尔值代表以上操作是否成功，
-// 返回的 uint8 代表子方块在快捷栏的生成位置
+// 取得在 pos 处的容器内构建子方块时所用的临时位置。
+// 每深入一层嵌套，临时位置都会沿 X 轴正方向偏移，
+// 因此不同深度的子方块不会相互覆盖
+func nestedScratchPosition(pos [3]int32) [3]int32 {
+	return [3]int32{pos[0] + NestedContainerScratchOffset, pos[1], pos[2]}
+}
+
+/*
+将 item 所指代的子方块获取到物品栏。
+
+子方块将被放置在 nestedScratchPosition 所指代的临时位置，
+该位置会在操作前被备份，并在操作完成后恢复。
+如果子方块本身也是一个容器或讲台等持有物品的方块，
+则其内容物(包括更深层的子方块)将先于其被构建，
+而它们的生成结果将被合并到 c.Reports 中。
+
+如果 item 有自定义的物品显示名称，
+则还会使用铁砧进行改名。
+返回的布尔值代表以上操作是否成功，
+返回的 uint8 代表子方块在快捷栏的生成位置
+*/
 func (c *Container) GetSubBlock(
 	item GeneralItem,
 ) (bool, uint8, error) {
 	api := c.BlockEntity.Interface.(*GameInterface.GameInterface)
+	scratch := nestedScratchPosition(c.BlockEntity.AdditionalData.Position)
 	// 初始化
 	err := api.SendSettingsCommand("clear", true)
 	if err != nil {
@@ -255,9 +272,9 @@ func (c *Container) GetSubBlock(
 	// 清除物品栏
 	uniqueId, err := api.BackupStructure(
 		GameInterface.MCStructure{
-			BeginX: c.BlockEntity.AdditionalData.Position[0],
-			BeginY: c.BlockEntity.AdditionalData.Position[1],
-			BeginZ: c.BlockEntity.AdditionalData.Position[2],
+			BeginX: scratch[0],
+			BeginY: scratch[1],
+			BeginZ: scratch[2],
 			SizeX:  1,
 			SizeY:  1,
 			SizeZ:  1,
@@ -266,8 +283,8 @@ func (c *Container) GetSubBlock(
 	if err != nil {
 		return false, 0, fmt.Errorf("GetSubBlock: %v", err)
 	}
-	defer api.RevertStructure(uniqueId, c.BlockEntity.AdditionalData.Position)
-	// 备份容器
+	defer api.RevertStructure(uniqueId, scratch)
+	// 备份临时位置处的方块
 	err = item.Custom.SubBlockData.Decode()
 	if err != nil {
 		return false, 0, fmt.Errorf("GetSubBlock: %v", err)
@@ -276,16 +293,20 @@ func (c *Container) GetSubBlock(
 	if err != nil {
 		return false, 0, fmt.Errorf("GetSubBlock: %v", err)
 	}
-	// 解码并放置子方块
+	if reporter, ok := item.Custom.SubBlockData.(itemReporter); ok {
+		for _, value := range reporter.itemReports() {
+			value.Name = fmt.Sprintf("%s (slot %d) > %s", item.Basic.Name, item.Basic.Slot, value.Name)
+			c.Reports = append(c.Reports, value)
+		}
+	}
+	// 解码并放置子方块，
+	// 然后合并子方块内各物品的生成结果
 	err = api.AwaitChangesGeneral()
 	if err != nil {
 		return false, 0, fmt.Errorf("GetSubBlock: %v", err)
 	}
 	// 等待更改
-	success, spawnLocation, err := api.PickBlock(
-		c.BlockEntity.AdditionalData.Position,
-		true,
-	)
+	success, spawnLocation, err := api.PickBlock(scratch, true)
 	if err != nil {
 		return false, 0, fmt.Errorf("GetSubBlock: %v", err)
 	}
@@ -295,7 +316,7 @@ func (c *Container) GetSubBlock(
 	// 获取方块到物品栏
 	if item.Enhancement != nil && len(item.Enhancement.DisplayName) != 0 {
 		resp, err := api.RenameItemByAnvil(
-			c.BlockEntity.AdditionalData.Position,
+			scratch,
 			`["direction": 0, "damage": "undamaged"]`,
 			5,
 			[]GameInterface.ItemRenamingRequest{
@@ -315,7 +336,7 @@ func (c *Container) GetSubBlock(
 	}
 	// 如果这个子方块有自定义的物品显示名称
 	if item.Basic.Count > 1 {
-		err = api.CopyItem(spawnLocation, c.BlockEntity.AdditionalData.Position, item.Basic.Count)
+		err = api.CopyItem(spawnLocation, scratch, item.Basic.Count)
 		if err != nil {
 			return false, 0, fmt.Errorf("GetSubBlock: %v", err)
 		}
@@ -325,19 +346,79 @@ func (c *Container) GetSubBlock(
 	// 返回值
 }
 
+/*
+构建 contents 中的子方块与 NBT 物品，并将它们移入容器。
+
+这是 ItemPlanner 的第一步。
+子方块会通过 GetSubBlock 递归地构建，
+因此诸如“箱子中装有带自定义名称的潜影盒，
+而潜影盒中又装有成书”这样的嵌套结构
+将自底向上地被构建。
+
+无法被构建的子方块将被记录在 c.Reports 中，
+然后移除其自定义数据，
+以便在之后作为普通物品放入容器。
+
+返回的物品列表与 contents 一一对应
+*/
+func (c *Container) planNestedItems(contents []ItemPackage) ([]GeneralItem, error) {
+	result := make([]GeneralItem, 0, len(contents))
+	// 初始化
+	for key, value := range contents {
+		item := value.Item
+		if ContainerCouldOpen(c.BlockEntity.Block.Name) && item.Custom != nil && item.Custom.SubBlockData != nil {
+			success, spawnLocation, err := c.GetSubBlock(item)
+			if err == nil && success {
+				err = c.MoveItemIntoContainer(spawnLocation, item.Basic.Slot)
+			}
+			if err != nil || !success {
+				report := NewItemReport(item)
+				if err != nil {
+					report.Lose(fmt.Sprintf("block data (%v)", err))
+				} else {
+					report.Lose("block data (failed to pick up the block)")
+				}
+				c.Reports = append(c.Reports, report)
+				item.Custom = nil
+			}
+			result = append(result, item)
+			continue
+		}
+		// 子方块
+		if item.Custom != nil && item.Custom.ItemTag != nil {
+			success, report, err := c.GetNBTItem(value)
+			if err != nil {
+				return nil, fmt.Errorf("planNestedItems: Failed to process the nbt item from contents[%d]; contents[%d].Item.Custom.ItemTag = %#v; err = %v", key, key, item.Custom.ItemTag, err)
+			}
+			if success {
+				c.Reports = append(c.Reports, report)
+				err = c.MoveItemIntoContainer(5, item.Basic.Slot)
+				if err != nil {
+					return nil, fmt.Errorf("planNestedItems: Failed to process the nbt item from contents[%d]; contents[%d].Item.Custom.ItemTag = %#v; err = %v", key, key, item.Custom.ItemTag, err)
+				}
+			}
+		}
+		// NBT 物品
+		result = append(result, item)
+	}
+	return result, nil
+	// 返回值
+}
+
 // 获取 itemPackage.Item 所指代的 NBT 物品到快捷栏 5 。
 // 如果 itemPackage.Item 有自定义的物品显示名称或附魔属性，
 // 则还会使用铁砧进行改名并使用 enchant 命令附魔。
 //
-// 返回的布尔值代表以上操作是否成功
+// 返回的布尔值代表以上操作是否成功，
+// 而 ItemReport 则描述了该物品各项数据的还原情况
 func (c *Container) GetNBTItem(
 	itemPackage ItemPackage,
-) (bool, error) {
+) (bool, ItemReport, error) {
 	api := c.BlockEntity.Interface.(*GameInterface.GameInterface)
 	// 初始化
 	err := api.SendSettingsCommand("clear", true)
 	if err != nil {
-		return false, fmt.Errorf("GetNBTItem: %v", err)
+		return false, ItemReport{}, fmt.Errorf("GetNBTItem: %v", err)
 	}
 	// 清除物品栏
 	uniqueId, err := api.BackupStructure(
@@ -351,7 +432,7 @@ func (c *Container) GetNBTItem(
 		},
 	)
 	if err != nil {
-		return false, fmt.Errorf("GetNBTItem: %v", err)
+		return false, ItemReport{}, fmt.Errorf("GetNBTItem: %v", err)
 	}
 	defer api.RevertStructure(uniqueId, c.BlockEntity.AdditionalData.Position)
 	// 备份容器
@@ -359,34 +440,42 @@ func (c *Container) GetNBTItem(
 	// 得到获取该 NBT 物品的方法
 	err = method.Decode()
 	if err != nil {
-		return false, fmt.Errorf("GetNBTItem: %v", err)
+		return false, ItemReport{}, fmt.Errorf("GetNBTItem: %v", err)
 	}
 	err = method.WriteData()
 	if err != nil {
-		return false, fmt.Errorf("GetNBTItem: %v", err)
+		return false, ItemReport{}, fmt.Errorf("GetNBTItem: %v", err)
 	}
 	// 解码并取得该 NBT 物品
 	err = api.AwaitChangesGeneral()
 	if err != nil {
-		return false, fmt.Errorf("GetNBTItem: %v", err)
+		return false, ItemReport{}, fmt.Errorf("GetNBTItem: %v", err)
 	}
 	// 等待更改
-	return true, nil
+	return true, method.Report(), nil
 	// 返回值
 }
 
-// 将 contents 中仅包含附魔属性、
+// 规划并放入 packages 中的所有物品。
+//
+// 首先使用 planNestedItems 递归地构建子方块与 NBT 物品，
+// 然后将仅包含附魔属性、
 // 物品组件和自定义物品显示名称的物品
 // 放入容器。
 // 返回的物品列表代表应当直接在容器上
 // 应用 replaceitem 命令的物品项目
-func (c *Container) ItemPlanner(contents []GeneralItem) ([]GeneralItem, error) {
+func (c *Container) ItemPlanner(packages []ItemPackage) ([]GeneralItem, error) {
 	var needOpenInventory bool
 	var needOpenContainer bool
 	moveIndex := map[uint8]GeneralItem{}
 	defaultSituation := []GeneralItem{}
 	api := c.BlockEntity.Interface.(*GameInterface.GameInterface)
 	// 初始化
+	contents, err := c.planNestedItems(packages)
+	if err != nil {
+		return []GeneralItem{}, fmt.Errorf("ItemPlanner: %v", err)
+	}
+	// 构建子方块与 NBT 物品
 	{
 		current := 0
 		firstFiltration := []GeneralItem{}
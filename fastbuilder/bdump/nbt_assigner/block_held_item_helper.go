@@ -0,0 +1,148 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 描述会记录其中物品生成结果的方块实体，
+// 例如容器与讲台
+type itemReporter interface {
+	// 取得该方块实体中各物品的生成结果
+	itemReports() []ItemReport
+}
+
+// 从 b.Block.NBT[key] 取得该方块所持有的单个物品。
+// 如果该物品不存在或为空气，则返回 nil
+func (b *BlockEntity) getHeldItem(key string) (ItemOrigin, error) {
+	item_origin, ok := b.Block.NBT[key]
+	if !ok {
+		return nil, nil
+	}
+	item_got, normal := item_origin.(map[string]interface{})
+	if !normal {
+		return nil, fmt.Errorf("getHeldItem: Crashed at b.Block.NBT[%#v]; b.Block.NBT = %#v", key, b.Block.NBT)
+	}
+	if name, _ := item_got["Name"].(string); len(name) == 0 {
+		return nil, nil
+	}
+	return item_got, nil
+}
+
+// 将 origin 解析为 b 所持有的物品，
+// 该物品将在快捷栏 hotBarSlot 处生成
+func (b *BlockEntity) decodeHeldItem(origin ItemOrigin, hotBarSlot uint8) (ItemPackage, error) {
+	newPackage := ItemPackage{
+		Interface: b.Interface,
+		Item:      GeneralItem{},
+		AdditionalData: ItemAdditionalData{
+			HotBarSlot: hotBarSlot,
+			Position:   b.AdditionalData.Position,
+			Type:       "",
+			Settings:   b.AdditionalData.Settings,
+			FastMode:   b.AdditionalData.FastMode,
+			Others:     b.AdditionalData.Others,
+			Depth:      b.AdditionalData.Depth,
+		},
+	}
+	err := newPackage.ParseItemFromNBT(origin)
+	if err != nil {
+		return ItemPackage{}, fmt.Errorf("decodeHeldItem: %v", err)
+	}
+	if newPackage.Item.Custom != nil && newPackage.Item.Custom.SubBlockData != nil {
+		newPackage.Item.Custom = nil
+	}
+	// 方块实体所持有的物品不会是子方块
+	return newPackage, nil
+	// 返回值
+}
+
+// 在快捷栏 item.AdditionalData.HotBarSlot 处生成 item ，
+// 然后返回其生成结果。
+// 由于生成物品时可能会在方块所在的位置放置铁砧，
+// 因此应当先于放置方块执行此函数
+func generateHeldItem(item ItemPackage) (ItemReport, error) {
+	method := GetGenerateItemMethod(&item)
+	if !item.AdditionalData.Decoded {
+		err := method.Decode()
+		if err != nil {
+			return ItemReport{}, fmt.Errorf("generateHeldItem: %v", err)
+		}
+	}
+	// 解码
+	err := method.WriteData()
+	if err != nil {
+		return ItemReport{}, fmt.Errorf("generateHeldItem: %v", err)
+	}
+	// 生成物品
+	return method.Report(), nil
+	// 返回值
+}
+
+// 传送到方块 b 的上方，
+// 然后使用快捷栏 hotBarSlot 中的物品点击它
+func (b *BlockEntity) clickWithHotbarItem(hotBarSlot uint8) error {
+	api := b.Interface.(*GameInterface.GameInterface)
+	pos := b.AdditionalData.Position
+	// 初始化
+	err := api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1]+1, pos[2]), true)
+	if err != nil {
+		return fmt.Errorf("clickWithHotbarItem: %v", err)
+	}
+	err = api.ChangeSelectedHotbarSlot(hotBarSlot)
+	if err != nil {
+		return fmt.Errorf("clickWithHotbarItem: %v", err)
+	}
+	// 传送并切换物品栏
+	err = api.ClickBlock(GameInterface.UseItemOnBlocks{
+		HotbarSlotID: hotBarSlot,
+		BlockPos:     pos,
+		BlockName:    fmt.Sprintf("minecraft:%s", b.Block.Name),
+		BlockStates:  b.Block.States,
+	})
+	if err != nil {
+		return fmt.Errorf("clickWithHotbarItem: %v", err)
+	}
+	err = api.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("clickWithHotbarItem: %v", err)
+	}
+	// 点击方块并等待更改
+	return nil
+	// 返回值
+}
+
+// 取得 c 中各物品的生成结果
+func (c *Container) itemReports() []ItemReport {
+	return c.Reports
+}
+
+// 取得讲台上的书的生成结果
+func (l *Lectern) itemReports() []ItemReport {
+	return l.Reports
+}
+
+// 取得唱片机中唱片的生成结果
+func (j *Jukebox) itemReports() []ItemReport {
+	return j.Reports
+}
+
+// 取得酿造台中各物品的生成结果
+func (b *BrewingStand) itemReports() []ItemReport {
+	return b.Reports
+}
+
+// 取得告示牌文本样式的还原结果
+func (s *Sign) itemReports() []ItemReport {
+	return s.Reports
+}
+
+// 取得刷怪笼的还原结果
+func (m *MobSpawner) itemReports() []ItemReport {
+	return m.Reports
+}
+
+// 取得信标效果的还原结果
+func (b *Beacon) itemReports() []ItemReport {
+	return b.Reports
+}
@@ -0,0 +1,148 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 描述方块各个面的朝向，其索引即为面的编号。
+// 0 为下，1 为上，2 为北，3 为南，4 为西，5 为东
+var blockFaceOffset = [6][3]int32{
+	{0, -1, 0},
+	{0, 1, 0},
+	{0, 0, -1},
+	{0, 0, 1},
+	{-1, 0, 0},
+	{1, 0, 0},
+}
+
+// 取得 facing_direction 为 2~5 时，
+// 面向该方向的机器人所应使用的水平朝向
+var facingDirectionToYaw = map[int32]float32{
+	2: 180,
+	3: 0,
+	4: 90,
+	5: 270,
+}
+
+// 从方块状态 states 中读取名为 key 的整数型方块状态，
+// 不存在时返回 0
+func getIntBlockState(states map[string]interface{}, key string) int32 {
+	switch value := states[key].(type) {
+	case int32:
+		return value
+	case byte:
+		return int32(value)
+	}
+	return 0
+}
+
+// 在快捷栏 hotBarSlot 生成 item 所指代的物品
+func generateItemInHotbar(api *GameInterface.GameInterface, hotBarSlot uint8, item types.ChestSlot) error {
+	err := api.ReplaceItemInInventory(
+		GameInterface.TargetMySelf,
+		GameInterface.ItemGenerateLocation{
+			Path: "slot.hotbar",
+			Slot: hotBarSlot,
+		},
+		item,
+		"",
+		true,
+	)
+	if err != nil {
+		return fmt.Errorf("generateItemInHotbar: %v", err)
+	}
+	return nil
+}
+
+/*
+使用快捷栏 hotBarSlot 中的物品，
+以点击方块的方式在 pos 处放置方块。
+
+face 指代被点击方块的面，
+被点击的方块位于 pos 沿 face 的反方向上，
+此处会临时生成 PlaceBlockBase 作为依附方块，并在放置完成后恢复。
+yaw 指代放置时机器人的水平朝向，
+这决定了床、旗帜和头颅等方块的朝向。
+
+与告示牌的处理类似，放置完成后会再生成一次被放置的方块，
+以防止其因依附方块被恢复而掉落
+*/
+func placeBlockByItem(
+	api *GameInterface.GameInterface,
+	pos [3]int32,
+	hotBarSlot uint8,
+	face int32,
+	yaw float32,
+) error {
+	offset := blockFaceOffset[face]
+	support := [3]int32{pos[0] - offset[0], pos[1] - offset[1], pos[2] - offset[2]}
+	// 取得依附方块的位置
+	err := api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d %g 0", pos[0], pos[1]+2, pos[2], yaw), true)
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	// 传送机器人到目标位置的上方并调整其朝向
+	err = api.SendSettingsCommand(fmt.Sprintf("setblock %d %d %d air", pos[0], pos[1], pos[2]), true)
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	// 清除目标位置处的方块
+	uniqueID_1, err := api.BackupStructure(GameInterface.MCStructure{
+		BeginX: support[0],
+		BeginY: support[1],
+		BeginZ: support[2],
+		SizeX:  1,
+		SizeY:  1,
+		SizeZ:  1,
+	})
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	err = api.SetBlock(support, GameInterface.PlaceBlockBase, "[]")
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	// 备份依附方块处的方块并生成依附方块
+	err = api.ChangeSelectedHotbarSlot(hotBarSlot)
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	err = api.PlaceBlock(
+		GameInterface.UseItemOnBlocks{
+			HotbarSlotID: hotBarSlot,
+			BlockPos:     support,
+			BlockName:    GameInterface.PlaceBlockBase,
+			BlockStates:  map[string]interface{}{},
+		},
+		face,
+	)
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	err = api.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	// 放置方块
+	uniqueID_2, err := api.BackupStructure(GameInterface.MCStructure{
+		BeginX: pos[0],
+		BeginY: pos[1],
+		BeginZ: pos[2],
+		SizeX:  1,
+		SizeY:  1,
+		SizeZ:  1,
+	})
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	err = api.RevertStructure(uniqueID_1, GameInterface.BlockPos{support[0], support[1], support[2]})
+	if err != nil {
+		return fmt.Errorf("placeBlockByItem: %v", err)
+	}
+	api.RevertStructure(uniqueID_2, GameInterface.BlockPos{pos[0], pos[1], pos[2]})
+	// 恢复依附方块处的方块，然后再生成一次被放置的方块
+	return nil
+	// 返回值
+}
@@ -49,6 +49,10 @@ type BlockAdditionalData struct {
 	FastMode bool
 	// 部分情况下可能会携带的不定数据，通常情况下应该为空 [目前还未使用此字段]
 	Others interface{}
+	// 该方块在容器中的嵌套深度。
+	// 直接放置在世界中的方块为 0 ，
+	// 而容器内的子方块为其所在容器的嵌套深度加 1
+	Depth int
 }
 
 // BlockEntity 是用于包装每个方块实体的结构体
@@ -101,12 +105,23 @@ type ContainerConstantData struct {
 	ContainerID uint8
 }
 
+// 容器的最大嵌套深度。
+// 超过此深度的子方块将作为普通物品放入容器
+const MaxContainerNestingDepth = 3
+
+// 构建子方块时所用的临时区域
+// 相对于其所在容器在 X 轴上的偏移量
+const NestedContainerScratchOffset = 2
+
 // 描述一个容器
 type Container struct {
 	// 该方块实体的详细数据
 	BlockEntity *BlockEntity
 	// 容器的内容物
 	Contents []ItemPackage
+	// 容器内各物品的生成结果，
+	// 其中也包含子方块内的物品
+	Reports []ItemReport
 }
 
 // 未被支持的容器会被应用此错误信息。
@@ -115,8 +130,213 @@ var ErrNotASupportedContainer error = fmt.Errorf("getContainerContents: Not a su
 
 // ------------------------- sign -------------------------
 
-// 描述一个告示牌
+// 描述告示牌单面的文本数据
+type SignText struct {
+	Text              string // Text(TAG_String) = ""
+	SignTextColor     int32  // SignTextColor(TAG_Int) = -16777216
+	IgnoreLighting    bool   // IgnoreLighting(TAG_Byte) = 0
+	HideGlowOutline   bool   // HideGlowOutline(TAG_Byte) = 0
+	PersistFormatting bool   // PersistFormatting(TAG_Byte) = 1
+	TextOwner         string // TextOwner(TAG_String) = ""
+}
+
+// 描述一个告示牌或悬挂式告示牌
 type Sign struct {
 	// 该方块实体的详细数据
 	BlockEntity *BlockEntity
+	// 告示牌正面的文本
+	FrontText SignText
+	// 告示牌背面的文本
+	BackText SignText
+	// 告示牌是否已被涂蜡
+	IsWaxed bool
+	// 该告示牌是否是悬挂式告示牌
+	IsHangingSign bool
+	// 告示牌的还原结果，
+	// 记录了染色、发光及涂蜡等需要交互才能完成的数据
+	Reports []ItemReport
+}
+
+// ------------------------- note_block -------------------------
+
+// 音符盒最多可被调整的音高数
+const NoteBlockPitchCount = 25
+
+// 描述一个音符盒
+type NoteBlock struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 音符盒的音高，即需要点击音符盒的次数
+	Note uint8
+}
+
+// ------------------------- banner -------------------------
+
+// 描述旗帜上的单个图案
+type BannerPattern struct {
+	Color   int32  // Color(TAG_Int) = 0
+	Pattern string // Pattern(TAG_String) = ""
+}
+
+// 描述一个旗帜
+type Banner struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 旗帜的底色
+	Base int32
+	// 旗帜的图案
+	Patterns []BannerPattern
+	// 旗帜的类型，为 1 时代表不祥旗帜
+	Type int32
+}
+
+// ------------------------- skull -------------------------
+
+// 描述一个头颅
+type Skull struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 头颅的种类，同时也是头颅物品的数据值
+	SkullType byte
+	// 放置在地面上时头颅的旋转角度
+	Rotation float32
+}
+
+// ------------------------- flower_pot -------------------------
+
+// 描述一个花盆
+type FlowerPot struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 花盆中植物的方块名称(含命名空间)，为空时代表花盆为空
+	PlantName string
+	// 花盆中植物的方块状态
+	PlantStates map[string]interface{}
+}
+
+// ------------------------- bed -------------------------
+
+// 描述一个床
+type Bed struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 床的颜色，同时也是床物品的数据值
+	Color byte
+}
+
+// ------------------------- item_frame -------------------------
+
+// 描述一个物品展示框或荧光物品展示框
+type ItemFrame struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 展示框中的物品，为空指针时代表展示框为空
+	Item ItemOrigin
+	// 展示框中物品的旋转角度，每次点击展示框都会使其增加 45 度
+	ItemRotation float32
+}
+
+// ------------------------- lectern -------------------------
+
+// 描述一个讲台
+type Lectern struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 讲台上的书，为空指针时代表讲台上没有书
+	Book *ItemPackage
+	// 书当前被翻到的页码(从 0 开始)
+	Page int32
+	// 书的总页数
+	TotalPages int32
+	// 书的生成结果
+	Reports []ItemReport
+}
+
+// ------------------------- jukebox -------------------------
+
+// 描述一个唱片机
+type Jukebox struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 唱片机中的唱片，为空指针时代表唱片机中没有唱片
+	Record *ItemPackage
+	// 唱片的生成结果
+	Reports []ItemReport
+}
+
+// ------------------------- brewing_stand -------------------------
+
+// 描述一个酿造台
+type BrewingStand struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 酿造台中的物品，
+	// 槽位 0 为原料，1~3 为药水，4 为燃料
+	Contents []ItemPackage
+	// 酿造台剩余的燃料值
+	FuelAmount int16
+	// 当前酿造的剩余时间(游戏刻)
+	CookTime int16
+	// 各物品的生成结果
+	Reports []ItemReport
+}
+
+// ------------------------- mob_spawner -------------------------
+
+// 描述一个刷怪笼
+type MobSpawner struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 刷怪笼将生成的实体的标识符，
+	// 例如 minecraft:zombie ，为空时代表刷怪笼为空
+	EntityIdentifier string
+	// 与默认值不同的刷怪笼配置项，
+	// 例如 SpawnCount ，它们无法通过合法手段修改
+	Unhandled []string
+	// 刷怪笼的还原结果
+	Reports []ItemReport
+}
+
+// ------------------------- beacon -------------------------
+
+// 描述一个信标
+type Beacon struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 信标的主效果，为 0 时代表未选择
+	Primary int32
+	// 信标的辅助效果，为 0 时代表未选择
+	Secondary int32
+	// 信标的还原结果
+	Reports []ItemReport
+}
+
+// ------------------------- structure_block -------------------------
+
+// 描述单个结构方块中已解码的部分
+type StructureBlockData struct {
+	Mode             int32    // data(TAG_Int) = 1
+	StructureName    string   // structureName(TAG_String) = ""
+	DataField        string   // dataField(TAG_String) = ""
+	IncludePlayers   bool     // includePlayers(TAG_Byte) = 0
+	ShowBoundingBox  bool     // showBoundingBox(TAG_Byte) = 1
+	IgnoreEntities   bool     // ignoreEntities(TAG_Byte) = 0
+	RemoveBlocks     bool     // removeBlocks(TAG_Byte) = 0
+	Rotation         byte     // rotation(TAG_Byte) = 0
+	Mirror           byte     // mirror(TAG_Byte) = 0
+	Integrity        float32  // integrity(TAG_Float) = 100
+	Seed             int64    // seed(TAG_Long) = 0
+	Offset           [3]int32 // [x|y|z]StructureOffset(TAG_Int) = [0, -1, 0]
+	Size             [3]int32 // [x|y|z]StructureSize(TAG_Int) = [5, 5, 5]
+	RedstoneSaveMode int32    // redstoneSaveMode(TAG_Int) = 0
+	AnimationMode    byte     // animationMode(TAG_Byte) = 0
+	AnimationSeconds float32  // animationSeconds(TAG_Float) = 0
+}
+
+// 描述一个结构方块
+type StructureBlock struct {
+	// 该方块实体的详细数据
+	BlockEntity *BlockEntity
+	// 结构方块的配置
+	StructureBlockData StructureBlockData
 }
@@ -27,7 +27,8 @@ func (b *Book) Decode() error {
 	var pages []string = []string{}
 	var author string = ""
 	var title string = ""
-	tag := b.ItemPackage.Item.Custom.ItemTag
+	var generation int32 = 0
+	tag := b.ItemPackage.getItemTag()
 	// 初始化
 	if pages_origin, ok := tag["pages"]; ok {
 		pages_got, success := pages_origin.([]interface{})
@@ -67,15 +68,31 @@ func (b *Book) Decode() error {
 		title = title_got
 	}
 	// title
+	if generation_origin, ok := tag["generation"]; ok {
+		generation_got, success := generation_origin.(int32)
+		if !success {
+			return fmt.Errorf("Decode: Failed to convert generation_origin into int32; tag = %#v", tag)
+		}
+		generation = generation_got
+	}
+	// generation
 	b.BookData = BookData{
-		Pages:  pages,
-		Author: author,
-		Title:  title,
+		Pages:      pages,
+		Author:     author,
+		Title:      title,
+		Generation: generation,
 	}
 	return nil
 	// return
 }
 
+// 取得该物品的生成结果
+func (b *Book) Report() ItemReport {
+	return b.Result
+}
+
+// 使用书与笔写入文字，
+// 然后以签名的方式得到成书
 func (b *Book) WriteData() error {
 	api := b.ItemPackage.Interface.(*GameInterface.GameInterface)
 	// 初始化
@@ -86,6 +103,8 @@ func (b *Book) WriteData() error {
 	if err != nil {
 		return fmt.Errorf("OpenBook: %v", err)
 	}
+	b.Result = newRequest.Report()
+	b.Result.Name = b.ItemPackage.Item.Basic.Name
 	// 获取成书
 	err = api.ChangeSelectedHotbarSlot(b.ItemPackage.AdditionalData.HotBarSlot)
 	if err != nil {
@@ -144,6 +163,47 @@ func (b *Book) WriteData() error {
 		}
 	}
 	// 对于堆叠型物品的处理
+	err = b.checkResult()
+	if err != nil {
+		return fmt.Errorf("OpenBook: %v", err)
+	}
+	// 检查成书数据是否已被还原
+	return nil
+	// 返回值
+}
+
+// 依据快捷栏中实际得到的书，
+// 检查页面、标题、作者及副本级别是否已被还原，
+// 并将结果记录在 b.Result 中
+func (b *Book) checkResult() error {
+	actual, err := b.ItemPackage.GetItemNBTInHotbar()
+	if err != nil {
+		return fmt.Errorf("checkResult: %v", err)
+	}
+	// 取得实际得到的书
+	check := func(data string, success bool) {
+		if success {
+			b.Result.Restore(data)
+		} else {
+			b.Result.Lose(data)
+		}
+	}
+	pages, _ := actual["pages"].([]interface{})
+	check(fmt.Sprintf("%d pages", len(b.BookData.Pages)), len(pages) >= len(b.BookData.Pages))
+	// pages
+	if b.ItemPackage.Item.Basic.Name != "written_book" {
+		return nil
+	}
+	title, _ := actual["title"].(string)
+	check("title", title == b.BookData.Title)
+	author, _ := actual["author"].(string)
+	check("author", author == b.BookData.Author)
+	// title and author
+	generation, _ := actual["generation"].(int32)
+	check(fmt.Sprintf("generation %d", b.BookData.Generation), generation == b.BookData.Generation)
+	// generation.
+	// 签名得到的成书总是原稿，
+	// 而基岩版的成书副本只能通过合成得到
 	return nil
 	// 返回值
 }
@@ -8,6 +8,7 @@ import (
 // 任何未被支持的 NBT 物品都会被重定向为此结构体
 type DefaultItem struct {
 	ItemPackage *ItemPackage // 该 NBT 物品的详细数据
+	Result      ItemReport   // 该物品的生成结果
 }
 
 // 这只是为了保证接口一致而设
@@ -20,9 +21,15 @@ func (d *DefaultItem) SpecialCheck() (bool, error) {
 	return false, nil
 }
 
+// 取得该物品的生成结果
+func (d *DefaultItem) Report() ItemReport {
+	return d.Result
+}
+
 // 生成目标物品到快捷栏但不写入 NBT 数据
 func (d *DefaultItem) WriteData() error {
 	item := d.ItemPackage.Item
+	d.Result = NewItemReport(item)
 	// 初始化
 	err := d.ItemPackage.ReplaceItemInInventory()
 	if err != nil {
@@ -37,6 +44,10 @@ func (d *DefaultItem) WriteData() error {
 	}
 	// 附加附魔属性
 	if item.Enhancement != nil && item.Enhancement.ItemComponents != nil && len(item.Enhancement.ItemComponents.ItemLock) != 0 {
+		err = d.ItemPackage.CheckEnhancement(&d.Result)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
 		return nil
 	}
 	// 如果该物品存在 item_lock 物品组件，
@@ -49,6 +60,11 @@ func (d *DefaultItem) WriteData() error {
 		}
 	}
 	// 附加物品的自定义显示名称
+	err = d.ItemPackage.CheckEnhancement(&d.Result)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 检查附魔属性、自定义显示名称及物品描述是否已被还原
 	return nil
 	// 返回值
 }
@@ -0,0 +1,190 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// 烟花爆炸效果的形状，其索引即为 FireworkType
+var fireworkShapes = []string{"small_ball", "large_ball", "star", "creeper", "burst"}
+
+// 从 explosion 解码单个爆炸效果
+func decodeFireworkExplosion(explosion map[string]interface{}) (FireworkExplosion, error) {
+	result := FireworkExplosion{Colors: []byte{}, FadeColors: []byte{}}
+	// 初始化
+	if type_origin, ok := explosion["FireworkType"]; ok {
+		type_got, success := type_origin.(byte)
+		if !success {
+			return FireworkExplosion{}, fmt.Errorf("decodeFireworkExplosion: Failed to convert type_origin into byte; explosion = %#v", explosion)
+		}
+		result.Type = type_got
+	}
+	// FireworkType
+	if colors_origin, ok := explosion["FireworkColor"]; ok {
+		colors_got, success := nbtByteArray(colors_origin)
+		if !success {
+			return FireworkExplosion{}, fmt.Errorf("decodeFireworkExplosion: Failed to convert colors_origin into []byte; explosion = %#v", explosion)
+		}
+		result.Colors = colors_got
+	}
+	// FireworkColor
+	if fade_origin, ok := explosion["FireworkFade"]; ok {
+		fade_got, success := nbtByteArray(fade_origin)
+		if !success {
+			return FireworkExplosion{}, fmt.Errorf("decodeFireworkExplosion: Failed to convert fade_origin into []byte; explosion = %#v", explosion)
+		}
+		result.FadeColors = fade_got
+	}
+	// FireworkFade
+	flicker, _ := explosion["FireworkFlicker"].(byte)
+	trail, _ := explosion["FireworkTrail"].(byte)
+	result.Flicker, result.Trail = flicker == 1, trail == 1
+	// FireworkFlicker and FireworkTrail
+	return result, nil
+	// 返回值
+}
+
+// 从 tag 提取烟花火箭或烟火之星的数据。
+// isStar 指代该物品是否是烟火之星
+func decodeFireworkData(tag ItemOrigin, isStar bool) (FireworkData, error) {
+	result := FireworkData{Explosions: []FireworkExplosion{}}
+	// 初始化
+	if isStar {
+		explosion_origin, ok := tag["FireworksItem"]
+		if !ok {
+			return result, nil
+		}
+		explosion_got, success := explosion_origin.(map[string]interface{})
+		if !success {
+			return FireworkData{}, fmt.Errorf("decodeFireworkData: Failed to convert explosion_origin into map[string]interface{}; tag = %#v", tag)
+		}
+		explosion, err := decodeFireworkExplosion(explosion_got)
+		if err != nil {
+			return FireworkData{}, fmt.Errorf("decodeFireworkData: %v", err)
+		}
+		result.Explosions = append(result.Explosions, explosion)
+		return result, nil
+	}
+	// 烟火之星
+	fireworks_origin, ok := tag["Fireworks"]
+	if !ok {
+		return result, nil
+	}
+	fireworks_got, success := fireworks_origin.(map[string]interface{})
+	if !success {
+		return FireworkData{}, fmt.Errorf("decodeFireworkData: Failed to convert fireworks_origin into map[string]interface{}; tag = %#v", tag)
+	}
+	result.Flight, _ = fireworks_got["Flight"].(byte)
+	// Flight
+	if explosions_origin, ok := fireworks_got["Explosions"]; ok {
+		explosions_got, success := explosions_origin.([]interface{})
+		if !success {
+			return FireworkData{}, fmt.Errorf("decodeFireworkData: Failed to convert explosions_origin into []interface{}; tag = %#v", tag)
+		}
+		for key, value := range explosions_got {
+			explosion_got, success := value.(map[string]interface{})
+			if !success {
+				return FireworkData{}, fmt.Errorf("decodeFireworkData: Failed to convert explosions_got[%d] into map[string]interface{}; tag = %#v", key, tag)
+			}
+			explosion, err := decodeFireworkExplosion(explosion_got)
+			if err != nil {
+				return FireworkData{}, fmt.Errorf("decodeFireworkData: %v", err)
+			}
+			result.Explosions = append(result.Explosions, explosion)
+		}
+	}
+	// Explosions
+	return result, nil
+	// 返回值
+}
+
+// 检查 f.ItemPackage.Item.Custom.ItemTag 是否可以仅使用命令生成
+func (f *Firework) SpecialCheck() (bool, error) {
+	err := f.Decode()
+	if err != nil {
+		return false, fmt.Errorf("SpecialCheck: %v", err)
+	}
+	f.ItemPackage.AdditionalData.Decoded = true
+	// 解码
+	if len(f.FireworkData.Explosions) == 0 && f.FireworkData.Flight <= 1 {
+		return false, nil
+	}
+	return true, nil
+	// 判断并返回值
+}
+
+// 从 f.ItemPackage.Item.Custom.ItemTag 提取烟花数据，
+// 然后保存在 f.FireworkData 中
+func (f *Firework) Decode() error {
+	fireworkData, err := decodeFireworkData(
+		f.ItemPackage.getItemTag(),
+		f.ItemPackage.Item.Basic.Name == "firework_star",
+	)
+	if err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	f.FireworkData = fireworkData
+	return nil
+	// return
+}
+
+// 取得该物品的生成结果
+func (f *Firework) Report() ItemReport {
+	return f.Result
+}
+
+/*
+生成烟花火箭或烟火之星。
+
+合成烟花需要使用工作台配方的网络 ID ，
+而 PhoenixBuilder 目前不会保存 CraftingData 数据包中的配方，
+因此这里只能使用 replaceitem 命令作为后备方案：
+烟火之星的主颜色由其数据值决定，因此可以被还原；
+而飞行时间与爆炸效果通常会丢失。
+
+生成后会检查实际得到的物品，
+并将各项数据的还原情况记录在生成结果中
+*/
+func (f *Firework) WriteData() error {
+	newRequest := DefaultItem{ItemPackage: f.ItemPackage}
+	err := newRequest.WriteData()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	f.Result = newRequest.Report()
+	// 生成烟花并附加附魔属性与自定义显示名称
+	actualTag, err := f.ItemPackage.GetItemNBTInHotbar()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	isStar := f.ItemPackage.Item.Basic.Name == "firework_star"
+	actual, err := decodeFireworkData(actualTag, isStar)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 取得实际得到的烟花
+	if !isStar && f.FireworkData.Flight > 1 {
+		description := fmt.Sprintf("flight %d", f.FireworkData.Flight)
+		if actual.Flight == f.FireworkData.Flight {
+			f.Result.Restore(description)
+		} else {
+			f.Result.Lose(description)
+		}
+	}
+	// 飞行时间
+	for key, value := range f.FireworkData.Explosions {
+		shape := fmt.Sprintf("%d", value.Type)
+		if int(value.Type) < len(fireworkShapes) {
+			shape = fireworkShapes[value.Type]
+		}
+		description := fmt.Sprintf("explosion %d (%s)", key, shape)
+		if key < len(actual.Explosions) && reflect.DeepEqual(actual.Explosions[key], value) {
+			f.Result.Restore(description)
+		} else {
+			f.Result.Lose(description)
+		}
+	}
+	// 爆炸效果
+	return nil
+	// 返回值
+}
@@ -0,0 +1,195 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 描述单种染料的名称与颜色，
+// 其索引与旗帜等方块中的颜色编号一致
+var dyeColors = []struct {
+	Name string
+	RGB  int32
+}{
+	{"black", 0x1D1D21},
+	{"red", 0xB02E26},
+	{"green", 0x5E7C16},
+	{"brown", 0x835432},
+	{"blue", 0x3C44AA},
+	{"purple", 0x8932B8},
+	{"cyan", 0x169C9C},
+	{"light_gray", 0x9D9D97},
+	{"gray", 0x474F52},
+	{"pink", 0xF38BAA},
+	{"lime", 0x80C71F},
+	{"yellow", 0xFED83D},
+	{"light_blue", 0x3AB3DA},
+	{"magenta", 0xC74EBD},
+	{"orange", 0xF9801D},
+	{"white", 0xF9FFFE},
+}
+
+// 装有水的炼药锅的方块状态
+var waterCauldronStates = map[string]interface{}{
+	"fill_level":      int32(6),
+	"cauldron_liquid": "water",
+}
+
+// 取得与 rgb 最接近的染料在 dyeColors 中的索引
+func nearestDyeColor(rgb int32) int {
+	result, minDistance := 0, -1
+	for key, value := range dyeColors {
+		distance := 0
+		for _, shift := range []uint{16, 8, 0} {
+			delta := int((rgb>>shift)&0xFF) - int((value.RGB>>shift)&0xFF)
+			distance += delta * delta
+		}
+		if minDistance == -1 || distance < minDistance {
+			result, minDistance = key, distance
+		}
+	}
+	return result
+}
+
+// 检查 l.ItemPackage.Item.Custom.ItemTag 是否可以仅使用命令生成
+func (l *LeatherArmor) SpecialCheck() (bool, error) {
+	err := l.Decode()
+	if err != nil {
+		return false, fmt.Errorf("SpecialCheck: %v", err)
+	}
+	l.ItemPackage.AdditionalData.Decoded = true
+	// 解码
+	return l.HasColor, nil
+	// 判断并返回值
+}
+
+// 从 l.ItemPackage.Item.Custom.ItemTag 提取皮革盔甲的颜色
+func (l *LeatherArmor) Decode() error {
+	tag := l.ItemPackage.getItemTag()
+	color_origin, ok := tag["customColor"]
+	if !ok {
+		l.Color, l.HasColor = 0, false
+		return nil
+	}
+	color_got, success := color_origin.(int32)
+	if !success {
+		return fmt.Errorf("Decode: Failed to convert color_origin into int32; tag = %#v", tag)
+	}
+	l.Color, l.HasColor = color_got, true
+	return nil
+	// return
+}
+
+// 取得该物品的生成结果
+func (l *LeatherArmor) Report() ItemReport {
+	return l.Result
+}
+
+/*
+生成皮革盔甲，然后使用炼药锅为其染色。
+
+染色时会在 l.ItemPackage.AdditionalData.Position 处
+临时生成一个装有水的炼药锅，
+向其中加入染料后再使用盔甲点击炼药锅。
+
+由于目前只会向炼药锅中加入一种染料，
+因此只有与某种染料颜色完全相同的盔甲才能被精确还原，
+其他颜色将使用最接近的染料代替
+*/
+func (l *LeatherArmor) WriteData() error {
+	newRequest := DefaultItem{ItemPackage: l.ItemPackage}
+	err := newRequest.WriteData()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	l.Result = newRequest.Report()
+	// 生成盔甲并附加附魔属性与自定义显示名称
+	if !l.HasColor {
+		return nil
+	}
+	dye := nearestDyeColor(l.Color)
+	err = l.dyeByCauldron(dye)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 染色
+	actual, err := l.ItemPackage.GetItemNBTInHotbar()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	actualColor, _ := actual["customColor"].(int32)
+	description := fmt.Sprintf("color #%06X", l.Color&0xFFFFFF)
+	switch {
+	case actualColor&0xFFFFFF == l.Color&0xFFFFFF:
+		l.Result.Restore(description)
+	case actualColor&0xFFFFFF == dyeColors[dye].RGB:
+		l.Result.Omit(fmt.Sprintf("%s (approximated with %s dye)", description, dyeColors[dye].Name))
+	default:
+		l.Result.Lose(description)
+	}
+	// 检查实际得到的颜色
+	return nil
+	// 返回值
+}
+
+// 在 l.ItemPackage.AdditionalData.Position 处生成装有水的炼药锅，
+// 然后使用颜色为 dye 的染料和快捷栏中的盔甲依次点击它
+func (l *LeatherArmor) dyeByCauldron(dye int) error {
+	api := l.ItemPackage.Interface.(*GameInterface.GameInterface)
+	pos := l.ItemPackage.AdditionalData.Position
+	armorSlot := l.ItemPackage.AdditionalData.HotBarSlot
+	dyeSlot := uint8(0)
+	if armorSlot == 0 {
+		dyeSlot = 1
+	}
+	// 初始化
+	uniqueId, err := api.BackupStructure(GameInterface.MCStructure{
+		BeginX: pos[0],
+		BeginY: pos[1],
+		BeginZ: pos[2],
+		SizeX:  1,
+		SizeY:  1,
+		SizeZ:  1,
+	})
+	if err != nil {
+		return fmt.Errorf("dyeByCauldron: %v", err)
+	}
+	defer api.RevertStructure(uniqueId, GameInterface.BlockPos{pos[0], pos[1], pos[2]})
+	// 备份炼药锅处的方块
+	err = generateItemInHotbar(api, dyeSlot, types.ChestSlot{Name: "dye", Count: 1, Damage: bannerColorToDyeData(int32(dye))})
+	if err != nil {
+		return fmt.Errorf("dyeByCauldron: %v", err)
+	}
+	err = api.SetBlock(pos, "cauldron", `["fill_level": 6, "cauldron_liquid": "water"]`)
+	if err != nil {
+		return fmt.Errorf("dyeByCauldron: %v", err)
+	}
+	err = api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1]+1, pos[2]), true)
+	if err != nil {
+		return fmt.Errorf("dyeByCauldron: %v", err)
+	}
+	// 获取染料并生成炼药锅
+	for _, slot := range []uint8{dyeSlot, armorSlot} {
+		err = api.ChangeSelectedHotbarSlot(slot)
+		if err != nil {
+			return fmt.Errorf("dyeByCauldron: %v", err)
+		}
+		err = api.ClickBlock(GameInterface.UseItemOnBlocks{
+			HotbarSlotID: slot,
+			BlockPos:     pos,
+			BlockName:    "minecraft:cauldron",
+			BlockStates:  waterCauldronStates,
+		})
+		if err != nil {
+			return fmt.Errorf("dyeByCauldron: %v", err)
+		}
+		err = api.AwaitChangesGeneral()
+		if err != nil {
+			return fmt.Errorf("dyeByCauldron: %v", err)
+		}
+	}
+	// 先加入染料，再浸入盔甲
+	return nil
+	// 返回值
+}
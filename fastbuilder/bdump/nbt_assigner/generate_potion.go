@@ -0,0 +1,93 @@
+package NBTAssigner
+
+import (
+	"fmt"
+)
+
+// 基岩版中各药水 ID 所对应的名称，其索引即为药水的 ID
+var potionNames = []string{
+	"water", "mundane", "long_mundane", "thick", "awkward",
+	"night_vision", "long_night_vision",
+	"invisibility", "long_invisibility",
+	"leaping", "long_leaping", "strong_leaping",
+	"fire_resistance", "long_fire_resistance",
+	"swiftness", "long_swiftness", "strong_swiftness",
+	"slowness", "long_slowness",
+	"water_breathing", "long_water_breathing",
+	"healing", "strong_healing",
+	"harming", "strong_harming",
+	"poison", "long_poison", "strong_poison",
+	"regeneration", "long_regeneration", "strong_regeneration",
+	"strength", "long_strength", "strong_strength",
+	"weakness", "long_weakness",
+	"wither",
+	"turtle_master", "long_turtle_master", "strong_turtle_master",
+	"slow_falling", "long_slow_falling",
+	"strong_slowness",
+	"wind_charged", "weaving", "oozing", "infested",
+}
+
+// 检查 p.ItemPackage.Item.Custom.ItemTag 是否可以仅使用命令生成
+func (p *Potion) SpecialCheck() (bool, error) {
+	err := p.Decode()
+	if err != nil {
+		return false, fmt.Errorf("SpecialCheck: %v", err)
+	}
+	p.ItemPackage.AdditionalData.Decoded = true
+	// 解码
+	return len(p.PotionData.Unhandled) != 0, nil
+	// 判断并返回值
+}
+
+// 从 p.ItemPackage.Item 提取药水数据，
+// 然后保存在 p.PotionData 中
+func (p *Potion) Decode() error {
+	potionID := int16(p.ItemPackage.Item.Basic.MetaData)
+	if p.ItemPackage.Item.Basic.Name == "arrow" {
+		potionID--
+	}
+	// 药箭的数据值为药水 ID 加 1
+	p.PotionData = PotionData{
+		PotionID:  potionID,
+		Unhandled: getUnhandledItemTagKeys(p.ItemPackage.getItemTag()),
+	}
+	return nil
+	// return
+}
+
+// 取得该物品的生成结果
+func (p *Potion) Report() ItemReport {
+	return p.Result
+}
+
+/*
+生成药水或药箭。
+
+基岩版的药水效果完全由物品的数据值决定，
+因此使用 replaceitem 命令即可得到与酿造所得完全相同的药水，
+而无需真正使用酿造台。
+
+物品 NBT 中的其他标签无法被还原，
+它们将被记录在生成结果中
+*/
+func (p *Potion) WriteData() error {
+	newRequest := DefaultItem{ItemPackage: p.ItemPackage}
+	err := newRequest.WriteData()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	p.Result = newRequest.Report()
+	// 生成药水并附加附魔属性与自定义显示名称
+	switch id := p.PotionData.PotionID; {
+	case id >= 0 && int(id) < len(potionNames):
+		p.Result.Restore(fmt.Sprintf("potion %s", potionNames[id]))
+	case id >= 0:
+		p.Result.Restore(fmt.Sprintf("potion %d", id))
+	}
+	for _, value := range p.PotionData.Unhandled {
+		p.Result.Omit(fmt.Sprintf("tag %s", value))
+	}
+	// 记录生成结果
+	return nil
+	// 返回值
+}
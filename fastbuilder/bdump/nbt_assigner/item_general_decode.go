@@ -143,7 +143,7 @@ func (g *GeneralItem) DecodeItemBasicData(singleItem ItemOrigin) error {
 }
 
 // 从 singleItem 解码单个物品的增强数据，
-// 其中包含物品组件、显示名称和附魔属性。
+// 其中包含物品组件、显示名称、物品描述和附魔属性。
 // 特别地，如果此物品存在 item_lock 物品组件，
 // 则只会解析物品组件和附魔的相关数据，
 // 因为存在 item_lock 的物品无法使用铁砧修改名称
@@ -151,6 +151,7 @@ func (g *GeneralItem) DecodeItemEnhancementData(
 	singleItem ItemOrigin,
 ) error {
 	var displayName string
+	var lore []string
 	var enchantments *[]Enchantment
 	var itemComponents *ItemComponents
 	var nbt_tag_got map[string]interface{}
@@ -289,6 +290,20 @@ func (g *GeneralItem) DecodeItemEnhancementData(
 		if !normal {
 			return fmt.Errorf(`DecodeItemEnhancementData: Can not convert display_origin into map[string]interface{}; singleItem = %#v`, singleItem)
 		}
+		if lore_origin, ok := display_got["Lore"]; ok {
+			lore_got, normal := lore_origin.([]interface{})
+			if !normal {
+				return fmt.Errorf(`DecodeItemEnhancementData: Can not convert lore_origin into []interface{}; singleItem = %#v`, singleItem)
+			}
+			for key, value := range lore_got {
+				line, normal := value.(string)
+				if !normal {
+					return fmt.Errorf(`DecodeItemEnhancementData: Can not convert lore_got[%d] into string; singleItem = %#v`, key, singleItem)
+				}
+				lore = append(lore, line)
+			}
+		}
+		// 物品描述
 		name_origin, ok := display_got["Name"]
 		if !ok {
 			break
@@ -299,10 +314,11 @@ func (g *GeneralItem) DecodeItemEnhancementData(
 		}
 		displayName = name_got
 	}
-	// 物品的显示名称
-	if len(displayName) != 0 || enchantments != nil || itemComponents != nil {
+	// 物品的显示名称及物品描述
+	if len(displayName) != 0 || len(lore) != 0 || enchantments != nil || itemComponents != nil {
 		g.Enhancement = &ItemEnhancementData{
 			DisplayName:    displayName,
+			Lore:           lore,
 			Enchantments:   enchantments,
 			ItemComponents: itemComponents,
 		}
@@ -353,6 +369,11 @@ func (i *ItemPackage) DecodeItemCustomData(
 				return nil
 			}
 			// 检查当前方块实体是否真的需要注入 NBT 数据
+			if i.AdditionalData.Depth >= MaxContainerNestingDepth {
+				i.AdditionalData.Truncated = true
+				return nil
+			}
+			// 超出最大嵌套深度的子方块将被视为普通物品
 			blockStates, err = get_block_states_from_legacy_block(
 				blockName, i.Item.Basic.MetaData,
 			)
@@ -376,11 +397,12 @@ func (i *ItemPackage) DecodeItemCustomData(
 						},
 						AdditionalData: BlockAdditionalData{
 							BlockStates: blockStatesString,
-							Position:    i.AdditionalData.Position,
+							Position:    nestedScratchPosition(i.AdditionalData.Position),
 							Type:        blockType,
 							Settings:    i.AdditionalData.Settings,
 							FastMode:    false,
 							Others:      i.AdditionalData.Others,
+							Depth:       i.AdditionalData.Depth + 1,
 						},
 					},
 				),
@@ -0,0 +1,211 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+// 这些 NBT 标签已由 DecodeItemEnhancementData 处理，
+// 或对物品本身没有影响
+var itemEnhancementTagKeys = map[string]bool{
+	"ench":                    true,
+	"display":                 true,
+	"RepairCost":              true,
+	"Damage":                  true,
+	"minecraft:item_lock":     true,
+	"minecraft:keep_on_death": true,
+}
+
+// 为 item 创建一个空白的生成结果
+func NewItemReport(item GeneralItem) ItemReport {
+	return ItemReport{
+		Name: item.Basic.Name,
+		Slot: item.Basic.Slot,
+	}
+}
+
+// 记录 data 已被还原
+func (r *ItemReport) Restore(data string) {
+	r.Restored = append(r.Restored, data)
+}
+
+// 记录 data 未能被还原
+func (r *ItemReport) Lose(data string) {
+	r.Lost = append(r.Lost, data)
+}
+
+// 记录 data 无法通过合法手段或命令还原。
+// 这是游戏本身的限制，因此不被视为还原失败
+func (r *ItemReport) Omit(data string) {
+	r.Omitted = append(r.Omitted, data)
+}
+
+// 判断该物品的所有数据是否均已被还原，
+// 无法通过合法手段或命令还原的数据除外
+func (r ItemReport) Success() bool {
+	return len(r.Lost) == 0
+}
+
+// 将生成结果转换为便于阅读的字符串
+func (r ItemReport) String() string {
+	result := fmt.Sprintf("%s (slot %d)", r.Name, r.Slot)
+	if len(r.Restored) > 0 {
+		result += fmt.Sprintf("; restored: %s", strings.Join(r.Restored, ", "))
+	}
+	if len(r.Lost) > 0 {
+		result += fmt.Sprintf("; lost: %s", strings.Join(r.Lost, ", "))
+	}
+	if len(r.Omitted) > 0 {
+		result += fmt.Sprintf("; unavoidably lost: %s", strings.Join(r.Omitted, ", "))
+	}
+	return result
+}
+
+/*
+将 reports 中未能完全还原的物品汇总为一个错误。
+如果所有物品均已被完全还原，则返回 nil 。
+
+无法通过合法手段或命令还原的数据(例如物品描述)不被视为还原失败，
+因此仅丢失了这些数据的物品只会被打印为警告
+*/
+func SummarizeItemReports(reports []ItemReport) error {
+	failed := []string{}
+	omitted := []string{}
+	for _, value := range reports {
+		if !value.Success() {
+			failed = append(failed, value.String())
+		} else if len(value.Omitted) > 0 {
+			omitted = append(omitted, value.String())
+		}
+	}
+	if len(omitted) != 0 {
+		pterm.Warning.Printf("SummarizeItemReports: %d of %d NBT items lost the data that cannot be restored in game: %s\n", len(omitted), len(reports), strings.Join(omitted, " | "))
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("SummarizeItemReports: %d of %d NBT items could not be fully restored: %s", len(failed), len(reports), strings.Join(failed, " | "))
+}
+
+// 取得 tag 中除 handled 与 itemEnhancementTagKeys 外的所有标签，
+// 返回的列表已按字典序排序
+func getUnhandledItemTagKeys(tag ItemOrigin, handled ...string) []string {
+	result := []string{}
+	for key := range tag {
+		if itemEnhancementTagKeys[key] {
+			continue
+		}
+		found := false
+		for _, value := range handled {
+			if key == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// 将 TAG_Byte_Array 转换为 []byte 。
+// 解码后的 TAG_Byte_Array 是一个定长数组，
+// 因此需要使用反射
+func nbtByteArray(value interface{}) ([]byte, bool) {
+	if bytes, ok := value.([]byte); ok {
+		return bytes, true
+	}
+	reflectValue := reflect.ValueOf(value)
+	if reflectValue.Kind() != reflect.Array || reflectValue.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	result := make([]byte, reflectValue.Len())
+	for i := range result {
+		result[i] = byte(reflectValue.Index(i).Uint())
+	}
+	return result, true
+}
+
+// 取得快捷栏 i.AdditionalData.HotBarSlot 处
+// 实际生成的物品的 NBT 数据
+func (i *ItemPackage) GetItemNBTInHotbar() (map[string]interface{}, error) {
+	api := i.Interface.(*GameInterface.GameInterface)
+	err := api.AwaitChangesGeneral()
+	if err != nil {
+		return nil, fmt.Errorf("GetItemNBTInHotbar: %v", err)
+	}
+	// 等待物品栏的更改
+	datas, err := api.Resources.Inventory.GetItemStackInfo(0, i.AdditionalData.HotBarSlot)
+	if err != nil {
+		return nil, fmt.Errorf("GetItemNBTInHotbar: %v", err)
+	}
+	if datas.Stack.NBTData == nil {
+		return map[string]interface{}{}, nil
+	}
+	return datas.Stack.NBTData, nil
+}
+
+// 依据快捷栏中实际生成的物品，
+// 检查 i.Item.Enhancement 中的附魔属性、
+// 自定义显示名称及物品描述是否均已被还原，
+// 并将结果记录在 report 中
+func (i *ItemPackage) CheckEnhancement(report *ItemReport) error {
+	enhancement := i.Item.Enhancement
+	if enhancement == nil {
+		return nil
+	}
+	actual, err := i.GetItemNBTInHotbar()
+	if err != nil {
+		return fmt.Errorf("CheckEnhancement: %v", err)
+	}
+	// 取得实际生成的物品
+	if enhancement.Enchantments != nil {
+		levels := map[int16]int16{}
+		ench, _ := actual["ench"].([]interface{})
+		for _, value := range ench {
+			got, _ := value.(map[string]interface{})
+			id, _ := got["id"].(int16)
+			lvl, _ := got["lvl"].(int16)
+			levels[id] = lvl
+		}
+		for _, value := range *enhancement.Enchantments {
+			description := fmt.Sprintf("enchantment %d level %d", value.ID, value.Level)
+			if levels[int16(value.ID)] == value.Level {
+				report.Restore(description)
+			} else {
+				report.Lose(description)
+			}
+		}
+	}
+	// 附魔属性。
+	// enchant 命令无法为不兼容的物品附魔，
+	// 例如附魔书，但它不会因此而报错
+	display, _ := actual["display"].(map[string]interface{})
+	if len(enhancement.DisplayName) != 0 {
+		if name, _ := display["Name"].(string); name == enhancement.DisplayName {
+			report.Restore("display name")
+		} else {
+			report.Lose("display name")
+		}
+	}
+	// 自定义显示名称
+	if len(enhancement.Lore) != 0 {
+		lore, _ := display["Lore"].([]interface{})
+		if len(lore) == len(enhancement.Lore) {
+			report.Restore(fmt.Sprintf("%d lore lines", len(enhancement.Lore)))
+		} else {
+			report.Omit(fmt.Sprintf("%d lore lines", len(enhancement.Lore)))
+		}
+	}
+	// 物品描述。
+	// 基岩版没有任何合法手段或命令可以修改物品描述
+	return nil
+	// 返回值
+}
@@ -0,0 +1,27 @@
+package NBTAssigner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeItemReportsOnlyFailsOnRealLosses(t *testing.T) {
+	lore := ItemReport{Name: "diamond_sword", Slot: 1}
+	lore.Restore("display name")
+	lore.Omit("2 lore lines")
+	restored := ItemReport{Name: "written_book", Slot: 2}
+	restored.Restore("3 pages")
+	if err := SummarizeItemReports([]ItemReport{lore, restored}); err != nil {
+		t.Fatalf("the lore can't be restored in game, got %v", err)
+	}
+
+	enchantment := ItemReport{Name: "bow", Slot: 3}
+	enchantment.Lose("enchantment 19 level 5")
+	err := SummarizeItemReports([]ItemReport{lore, restored, enchantment})
+	if err == nil {
+		t.Fatal("the lost enchantment is not reported")
+	}
+	if !strings.Contains(err.Error(), "1 of 3") || !strings.Contains(err.Error(), "enchantment 19 level 5") || strings.Contains(err.Error(), "diamond_sword") {
+		t.Fatalf("got %v, want only the bow reported", err)
+	}
+}
@@ -22,6 +22,9 @@ type GeneralItemNBT interface {
 	// 应当只被外部实现调用，
 	// 用于判断当前物品是否可以仅使用命令生成
 	SpecialCheck() (bool, error)
+	// 取得该物品的生成结果，
+	// 应当在 WriteData 后调用
+	Report() ItemReport
 }
 
 // ------------------------- general -------------------------
@@ -104,6 +107,7 @@ type ItemBasicData struct {
 // 描述单个物品的附加数据
 type ItemEnhancementData struct {
 	DisplayName    string          // 该物品的显示名称
+	Lore           []string        // 该物品的物品描述
 	Enchantments   *[]Enchantment  // 该物品的附魔属性
 	ItemComponents *ItemComponents // 该物品的物品组件
 }
@@ -137,6 +141,11 @@ type ItemAdditionalData struct {
 	FastMode bool
 	// 部分情况下可能会携带的不定数据，通常情况下应该为空 [目前还未使用此字段]
 	Others interface{}
+	// 该物品所在容器的嵌套深度
+	Depth int
+	// 如果该物品是一个因超出 MaxContainerNestingDepth
+	// 而被视为普通物品的子方块，则此字段为真
+	Truncated bool
 }
 
 // ItemPackage 是用于包装每个物品的结构体
@@ -151,13 +160,25 @@ type ItemPackage struct {
 	AdditionalData ItemAdditionalData
 }
 
+// 描述单个 NBT 物品的生成结果。
+// 由于部分 NBT 数据无法通过合法手段或命令还原，
+// 因此我们需要逐一报告每个物品的还原情况
+type ItemReport struct {
+	Name     string   // 该物品的名称
+	Slot     uint8    // 该物品原本所在的槽位
+	Restored []string // 已被还原的数据
+	Lost     []string // 未能被还原的数据
+	Omitted  []string // 无法通过合法手段或命令还原的数据
+}
+
 // ------------------------- book -------------------------
 
 // 描述单个成书中已解码的部分
 type BookData struct {
-	Pages  []string // pages(TAG_List) = []string{}
-	Author string   // author(TAG_String) = ""
-	Title  string   // title(TAG_String) = ""
+	Pages      []string // pages(TAG_List) = []string{}
+	Author     string   // author(TAG_String) = ""
+	Title      string   // title(TAG_String) = ""
+	Generation int32    // generation(TAG_Int) = 0
 }
 
 // Book 结构体用于描述一个完整的成书的数据
@@ -166,4 +187,75 @@ type Book struct {
 	ItemPackage *ItemPackage
 	// 存放已解码的成书数据
 	BookData BookData
+	// 该物品的生成结果
+	Result ItemReport
+}
+
+// ------------------------- potion -------------------------
+
+// 描述单个药水或药箭中已解码的部分
+type PotionData struct {
+	// 药水的 ID 。
+	// 对于药箭，这是其数据值减去 1 ；
+	// 为 -1 时代表这是一支普通的箭
+	PotionID int16
+	// 无法使用命令还原的其他 NBT 标签
+	Unhandled []string
+}
+
+// Potion 结构体用于描述一个完整的药水或药箭的数据
+type Potion struct {
+	// 该 NBT 物品的详细数据
+	ItemPackage *ItemPackage
+	// 存放已解码的药水数据
+	PotionData PotionData
+	// 该物品的生成结果
+	Result ItemReport
+}
+
+// ------------------------- firework -------------------------
+
+// 描述烟花火箭或烟火之星中的单个爆炸效果
+type FireworkExplosion struct {
+	Type       byte   // FireworkType(TAG_Byte) = 0
+	Colors     []byte // FireworkColor(TAG_Byte_Array) = []byte{}
+	FadeColors []byte // FireworkFade(TAG_Byte_Array) = []byte{}
+	Flicker    bool   // FireworkFlicker(TAG_Byte) = 0
+	Trail      bool   // FireworkTrail(TAG_Byte) = 0
+}
+
+// 描述单个烟花火箭或烟火之星中已解码的部分
+type FireworkData struct {
+	// 烟花火箭的飞行时间。
+	// 对于烟火之星，此字段无意义
+	Flight byte
+	// 爆炸效果。
+	// 对于烟火之星，此列表至多只有一个元素
+	Explosions []FireworkExplosion
+}
+
+// Firework 结构体用于描述一个完整的烟花火箭或烟火之星的数据
+type Firework struct {
+	// 该 NBT 物品的详细数据
+	ItemPackage *ItemPackage
+	// 存放已解码的烟花数据
+	FireworkData FireworkData
+	// 该物品的生成结果
+	Result ItemReport
+}
+
+// ------------------------- leather armor -------------------------
+
+// LeatherArmor 结构体用于描述一个完整的皮革盔甲的数据
+type LeatherArmor struct {
+	// 该 NBT 物品的详细数据
+	ItemPackage *ItemPackage
+	// 皮革盔甲的颜色，
+	// 以 ARGB 形式表示，
+	// 仅当 HasColor 为真时有效
+	Color int32
+	// 皮革盔甲是否已被染色
+	HasColor bool
+	// 该物品的生成结果
+	Result ItemReport
 }
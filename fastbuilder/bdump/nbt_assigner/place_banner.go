@@ -0,0 +1,179 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 织布机最多可为一个旗帜添加的图案数
+const BannerMaxPatterns = 6
+
+// 需要旗帜图案物品才能合成的图案，
+// 值为对应的 banner_pattern 物品的数据值
+var bannerPatternMaterials = map[string]uint16{
+	"cre": 0,
+	"sku": 1,
+	"flo": 2,
+	"moj": 3,
+	"bri": 4,
+	"cbo": 5,
+	"pig": 6,
+	"glb": 7,
+}
+
+// 取得颜色为 color 的染料物品的数据值。
+// 墨囊、可可豆、青金石和骨粉无法在织布机中使用，
+// 因此使用对应的新版染料代替
+func bannerColorToDyeData(color int32) uint16 {
+	switch color {
+	case 0:
+		return 16
+	case 3:
+		return 17
+	case 4:
+		return 18
+	case 15:
+		return 19
+	}
+	return uint16(color)
+}
+
+// 从 b.BlockEntity.Block.NBT 提取旗帜的底色及图案
+func (b *Banner) Decode() error {
+	var normal bool
+	if _, ok := b.BlockEntity.Block.NBT["Base"]; ok {
+		b.Base, normal = b.BlockEntity.Block.NBT["Base"].(int32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"Base\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+		}
+	}
+	// Base
+	if _, ok := b.BlockEntity.Block.NBT["Type"]; ok {
+		b.Type, normal = b.BlockEntity.Block.NBT["Type"].(int32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"Type\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+		}
+	}
+	// Type
+	if _, ok := b.BlockEntity.Block.NBT["Patterns"]; ok {
+		patterns, normal := b.BlockEntity.Block.NBT["Patterns"].([]interface{})
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"Patterns\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+		}
+		for key, value := range patterns {
+			pattern, normal := value.(map[string]interface{})
+			if !normal {
+				return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"Patterns\"][%d]; b.BlockEntity.Block.NBT = %#v", key, b.BlockEntity.Block.NBT)
+			}
+			color, normal1 := pattern["Color"].(int32)
+			name, normal2 := pattern["Pattern"].(string)
+			if !normal1 || !normal2 {
+				return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"Patterns\"][%d]; b.BlockEntity.Block.NBT = %#v", key, b.BlockEntity.Block.NBT)
+			}
+			b.Patterns = append(b.Patterns, BannerPattern{Color: color, Pattern: name})
+		}
+	}
+	// Patterns
+	return nil
+	// return
+}
+
+/*
+使用织布机合成带有图案的旗帜，然后放置它。
+
+由于织布机最多只能为旗帜添加 BannerMaxPatterns 个图案，
+超出的图案将被丢弃；不祥旗帜也只能按照其图案重新合成
+*/
+func (b *Banner) WriteData() error {
+	if b.BlockEntity.AdditionalData.FastMode {
+		err := b.BlockEntity.Interface.SetBlockAsync(b.BlockEntity.AdditionalData.Position, b.BlockEntity.Block.Name, b.BlockEntity.AdditionalData.BlockStates)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		return nil
+	}
+	gameInterface := b.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := b.BlockEntity.AdditionalData.Position
+	// 放置旗帜(快速导入模式下)
+	patterns := b.Patterns
+	if len(patterns) > BannerMaxPatterns {
+		patterns = patterns[:BannerMaxPatterns]
+	}
+	// 取得可被合成的图案
+	err := generateItemInHotbar(gameInterface, 0, types.ChestSlot{Name: "banner", Count: 1, Damage: uint16(b.Base)})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = generateItemInHotbar(gameInterface, 1, types.ChestSlot{Name: "air", Count: 1})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 获取旗帜到快捷栏 0 ，并清空快捷栏 1 以用于打开织布机
+	requests := make([]GameInterface.BannerPatternRequest, 0, len(patterns))
+	for key, value := range patterns {
+		dyeSlot := uint8(key)
+		err = gameInterface.ReplaceItemInInventory(
+			GameInterface.TargetMySelf,
+			GameInterface.ItemGenerateLocation{Path: "slot.inventory", Slot: dyeSlot},
+			types.ChestSlot{Name: "dye", Count: 1, Damage: bannerColorToDyeData(value.Color)},
+			"",
+			true,
+		)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		request := GameInterface.BannerPatternRequest{
+			Pattern: value.Pattern,
+			Color:   value.Color,
+			DyeSlot: dyeSlot + 9,
+		}
+		if material, ok := bannerPatternMaterials[value.Pattern]; ok {
+			materialSlot := uint8(BannerMaxPatterns + key)
+			err = gameInterface.ReplaceItemInInventory(
+				GameInterface.TargetMySelf,
+				GameInterface.ItemGenerateLocation{Path: "slot.inventory", Slot: materialSlot},
+				types.ChestSlot{Name: "banner_pattern", Count: 1, Damage: material},
+				"",
+				true,
+			)
+			if err != nil {
+				return fmt.Errorf("WriteData: %v", err)
+			}
+			materialSlot += 9
+			request.MaterialSlot = &materialSlot
+		}
+		requests = append(requests, request)
+	}
+	// 准备每个图案所需的染料及旗帜图案。
+	// 背包中 slot.inventory 的第 N 格对应槽位 N+9
+	applied := 0
+	if len(requests) > 0 {
+		applied, err = gameInterface.ApplyBannerPatternsByLoom(pos, 1, 0, requests)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+	}
+	// 使用织布机添加图案
+	face, yaw := int32(1), float32(0)
+	if b.BlockEntity.Block.Name == "wall_banner" {
+		face = getIntBlockState(b.BlockEntity.Block.States, "facing_direction")
+		if face < 2 || face > 5 {
+			face = 2
+		}
+		yaw = facingDirectionToYaw[face] + 180
+	} else {
+		yaw = float32(getIntBlockState(b.BlockEntity.Block.States, "ground_sign_direction"))*22.5 - 180
+	}
+	err = placeBlockByItem(gameInterface, pos, 0, face, yaw)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置旗帜。
+	// 旗帜总是面向放置它的玩家
+	if applied != len(b.Patterns) {
+		return fmt.Errorf("WriteData: Only %d of %d patterns were applied to the banner", applied, len(b.Patterns))
+	}
+	return nil
+	// 返回值
+}
@@ -0,0 +1,79 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 从 b.BlockEntity.Block.NBT 提取信标的主效果与辅助效果
+func (b *Beacon) Decode() error {
+	var normal bool
+	if _, ok := b.BlockEntity.Block.NBT["primary"]; ok {
+		b.Primary, normal = b.BlockEntity.Block.NBT["primary"].(int32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"primary\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+		}
+	}
+	// primary
+	if _, ok := b.BlockEntity.Block.NBT["secondary"]; ok {
+		b.Secondary, normal = b.BlockEntity.Block.NBT["secondary"].(int32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"secondary\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+		}
+	}
+	// secondary
+	return nil
+	// return
+}
+
+/*
+放置一个信标，然后打开它并支付一个铁锭以选择其效果。
+
+铁锭会先被生成在快捷栏 0 ，
+而快捷栏 5 将被保持为空以用于打开信标。
+
+信标只有在其下方的金字塔已经存在时才能选择效果，
+因此应当确保金字塔先于信标被导入，
+否则效果将无法被还原并被记录在生成结果中
+*/
+func (b *Beacon) WriteData() error {
+	err := b.BlockEntity.Interface.SetBlock(b.BlockEntity.AdditionalData.Position, b.BlockEntity.Block.Name, b.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	if b.BlockEntity.AdditionalData.FastMode || (b.Primary == 0 && b.Secondary == 0) {
+		return nil
+	}
+	api := b.BlockEntity.Interface.(*GameInterface.GameInterface)
+	// 放置信标(快速导入模式下或信标未选择效果时不再设置效果)
+	err = generateItemInHotbar(api, 0, types.ChestSlot{Name: "iron_ingot", Count: 1})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = generateItemInHotbar(api, 5, types.ChestSlot{Name: "air", Count: 1})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 生成铁锭到快捷栏 0 ，并清空快捷栏 5
+	report := ItemReport{Name: b.BlockEntity.Block.Name}
+	effects := fmt.Sprintf("effects (primary %d, secondary %d)", b.Primary, b.Secondary)
+	err = api.SetBeaconEffects(b.BlockEntity.AdditionalData.Position, 5, 0, b.Primary, b.Secondary)
+	if err != nil {
+		report.Lose(fmt.Sprintf("%s (%v)", effects, err))
+	} else {
+		report.Restore(effects)
+	}
+	b.Reports = append(b.Reports, report)
+	// 选择信标效果
+	if b.BlockEntity.AdditionalData.Depth > 0 {
+		return nil
+	}
+	err = SummarizeItemReports(b.Reports)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告未能还原的效果
+	return nil
+	// 返回值
+}
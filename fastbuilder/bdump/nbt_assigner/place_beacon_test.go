@@ -0,0 +1,47 @@
+package NBTAssigner
+
+import (
+	"testing"
+)
+
+func TestBeaconDecode(t *testing.T) {
+	nbt := map[string]interface{}{
+		"id":        "Beacon",
+		"x":         int32(0),
+		"y":         int32(64),
+		"z":         int32(0),
+		"isMovable": byte(1),
+		"primary":   int32(1),
+		"secondary": int32(10),
+	}
+	beacon := Beacon{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "beacon", NBT: nbt}}}
+	if err := beacon.Decode(); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if beacon.Primary != 1 || beacon.Secondary != 10 {
+		t.Errorf("effects = (%d, %d), want (1, 10)", beacon.Primary, beacon.Secondary)
+	}
+	// 已选择效果的信标
+	lost, err := analyzeBlockNBT(GeneralBlock{Name: "beacon", NBT: nbt}, [3]int32{0, 64, 0}, 0)
+	if err != nil {
+		t.Fatalf("analyzeBlockNBT: %v", err)
+	}
+	if len(lost) != 0 {
+		t.Errorf("lost = %v, want none", lost)
+	}
+	// 分析
+	beacon = Beacon{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "beacon", NBT: map[string]interface{}{"id": "Beacon"}}}}
+	if err := beacon.Decode(); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if beacon.Primary != 0 || beacon.Secondary != 0 {
+		t.Errorf("effects = (%d, %d), want (0, 0)", beacon.Primary, beacon.Secondary)
+	}
+	// 未选择效果的信标
+	nbt["primary"] = byte(1)
+	beacon = Beacon{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "beacon", NBT: nbt}}}
+	if err := beacon.Decode(); err == nil {
+		t.Errorf("Decode accepted a non-int32 primary effect")
+	}
+	// 错误的类型
+}
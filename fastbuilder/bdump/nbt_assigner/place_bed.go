@@ -0,0 +1,75 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 从 b.BlockEntity.Block.NBT 提取床的颜色
+func (b *Bed) Decode() error {
+	if _, ok := b.BlockEntity.Block.NBT["color"]; !ok {
+		return nil
+	}
+	color, normal := b.BlockEntity.Block.NBT["color"].(byte)
+	if !normal {
+		return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"color\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+	}
+	b.Color = color
+	return nil
+	// return
+}
+
+/*
+手持对应颜色的床并以正确的朝向放置它。
+
+床由床脚和床头两部分组成，放置床脚时床头会被一同放置，
+因此床头部分将被直接跳过
+*/
+func (b *Bed) WriteData() error {
+	if getIntBlockState(b.BlockEntity.Block.States, "head_piece_bit") != 0 {
+		return nil
+	}
+	// 跳过床头
+	if b.BlockEntity.AdditionalData.FastMode {
+		err := b.BlockEntity.Interface.SetBlockAsync(b.BlockEntity.AdditionalData.Position, b.BlockEntity.Block.Name, b.BlockEntity.AdditionalData.BlockStates)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		return nil
+	}
+	gameInterface := b.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := b.BlockEntity.AdditionalData.Position
+	// 放置床(快速导入模式下)
+	direction := getIntBlockState(b.BlockEntity.Block.States, "direction") & 3
+	head := pos
+	switch direction {
+	case 0:
+		head[2]++
+	case 1:
+		head[0]--
+	case 2:
+		head[2]--
+	case 3:
+		head[0]++
+	}
+	err := gameInterface.SendSettingsCommand(fmt.Sprintf("setblock %d %d %d air", head[0], head[1], head[2]), true)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 床的 direction 为 0~3 时床头分别朝向南、西、北、东，
+	// 放置前需要清空床头处的方块
+	err = generateItemInHotbar(gameInterface, 0, types.ChestSlot{Name: "bed", Count: 1, Damage: uint16(b.Color)})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 获取对应颜色的床到快捷栏 0
+	err = placeBlockByItem(gameInterface, pos, 0, 1, float32(direction)*90)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置床。
+	// 床头总是位于放置它的玩家所面向的方向
+	return nil
+	// 返回值
+}
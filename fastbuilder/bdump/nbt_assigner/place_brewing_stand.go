@@ -0,0 +1,193 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 酿造台的燃料槽位
+const brewingStandFuelSlot = uint8(4)
+
+// 取得酿造台中槽位 slot 所对应的容器 ID 。
+// 槽位 0 为原料，1~3 为药水，4 为燃料
+func brewingStandContainerID(slot uint8) byte {
+	switch {
+	case slot == 0:
+		return GameInterface.ContainerIDBrewingStandInput
+	case slot == brewingStandFuelSlot:
+		return GameInterface.ContainerIDBrewingStandFuel
+	default:
+		return GameInterface.ContainerIDBrewingStandResult
+	}
+}
+
+// 从 b.BlockEntity.Block.NBT 提取酿造台中的物品、燃料值及酿造进度
+func (b *BrewingStand) Decode() error {
+	var normal bool
+	container := Container{BlockEntity: b.BlockEntity}
+	err := container.Decode()
+	if err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	for _, value := range container.Contents {
+		if value.Item.Basic.Slot > brewingStandFuelSlot {
+			continue
+		}
+		if value.Item.Custom != nil && value.Item.Custom.SubBlockData != nil {
+			value.Item.Custom = nil
+		}
+		value.AdditionalData.HotBarSlot = value.Item.Basic.Slot
+		b.Contents = append(b.Contents, value)
+	}
+	b.Reports = container.Reports
+	// Items
+	if _, ok := b.BlockEntity.Block.NBT["FuelAmount"]; ok {
+		b.FuelAmount, normal = b.BlockEntity.Block.NBT["FuelAmount"].(int16)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"FuelAmount\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+		}
+	}
+	// FuelAmount
+	if _, ok := b.BlockEntity.Block.NBT["CookTime"]; ok {
+		b.CookTime, normal = b.BlockEntity.Block.NBT["CookTime"].(int16)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at b.BlockEntity.Block.NBT[\"CookTime\"]; b.BlockEntity.Block.NBT = %#v", b.BlockEntity.Block.NBT)
+		}
+	}
+	// CookTime
+	return nil
+	// return
+}
+
+/*
+放置一个酿造台，然后打开它并将各物品移入对应的槽位。
+
+物品会先被生成在与其槽位相同的快捷栏中，
+而快捷栏 5 将被保持为空以用于打开酿造台。
+
+燃料值与酿造进度无法被直接写入：
+放入烈焰粉后酿造台会按照游戏规则自行补充燃料，
+因此这些数据将被记录在生成结果中
+*/
+func (b *BrewingStand) WriteData() error {
+	if b.BlockEntity.AdditionalData.FastMode {
+		err := (&Container{BlockEntity: b.BlockEntity, Contents: b.Contents}).FastWrite()
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		return nil
+	}
+	api := b.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := b.BlockEntity.AdditionalData.Position
+	// 放置酿造台并填充物品(快速导入模式下)
+	err := api.SendSettingsCommand("clear", true)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	for _, value := range b.Contents {
+		report, err := generateHeldItem(value)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		b.Reports = append(b.Reports, report)
+	}
+	err = generateItemInHotbar(api, 5, types.ChestSlot{Name: "air", Count: 1})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 生成各物品到快捷栏，并清空快捷栏 5
+	err = api.SetBlock(pos, b.BlockEntity.Block.Name, b.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1]+1, pos[2]), true)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = b.moveItemsIntoBrewingStand()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置酿造台并移入物品
+	state := ItemReport{Name: b.BlockEntity.Block.Name, Slot: brewingStandFuelSlot}
+	if b.FuelAmount > 0 {
+		state.Omit(fmt.Sprintf("fuel level %d", b.FuelAmount))
+	}
+	if b.CookTime > 0 {
+		state.Omit(fmt.Sprintf("brewing progress (%d ticks left)", b.CookTime))
+	}
+	if len(state.Omitted) != 0 {
+		b.Reports = append(b.Reports, state)
+	}
+	// 记录无法被还原的燃料值与酿造进度
+	if b.BlockEntity.AdditionalData.Depth > 0 {
+		return nil
+	}
+	err = SummarizeItemReports(b.Reports)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告未能完全还原的物品
+	return nil
+	// 返回值
+}
+
+// 打开已放置的酿造台，
+// 然后将快捷栏中的各物品移动到酿造台的对应槽位。
+//
+// 此函数将会自动占用、释放容器资源
+func (b *BrewingStand) moveItemsIntoBrewingStand() error {
+	api := b.BlockEntity.Interface.(*GameInterface.GameInterface)
+	// 初始化
+	holder := api.Resources.Container.Occupy()
+	defer api.Resources.Container.Release(holder)
+	// 占用容器资源
+	err := api.ChangeSelectedHotbarSlot(5)
+	if err != nil {
+		return fmt.Errorf("moveItemsIntoBrewingStand: %v", err)
+	}
+	success, err := api.OpenContainer(
+		b.BlockEntity.AdditionalData.Position,
+		fmt.Sprintf("minecraft:%s", b.BlockEntity.Block.Name),
+		b.BlockEntity.Block.States,
+		5,
+	)
+	if err != nil {
+		return fmt.Errorf("moveItemsIntoBrewingStand: %v", err)
+	}
+	if !success {
+		return fmt.Errorf("moveItemsIntoBrewingStand: Failed to open the brewing stand")
+	}
+	defer api.CloseContainer()
+	containerOpeningData := api.Resources.Container.GetContainerOpeningData()
+	// 打开酿造台
+	for _, value := range b.Contents {
+		slot := value.Item.Basic.Slot
+		itemData, err := api.Resources.Inventory.GetItemStackInfo(0, slot)
+		if err != nil {
+			return fmt.Errorf("moveItemsIntoBrewingStand: %v", err)
+		}
+		_, err = api.MoveItem(
+			GameInterface.ItemLocation{
+				WindowID:    0,
+				ContainerID: GameInterface.ContainerIDInventory,
+				Slot:        slot,
+			},
+			GameInterface.ItemLocation{
+				WindowID:    containerOpeningData.WindowID,
+				ContainerID: brewingStandContainerID(slot),
+				Slot:        slot,
+			},
+			uint8(itemData.Stack.Count),
+			GameInterface.AirItem,
+			itemData,
+		)
+		if err != nil && err != GameInterface.ErrMoveItemCheckFailure {
+			return fmt.Errorf("moveItemsIntoBrewingStand: %v", err)
+		}
+	}
+	// 将物品移动到酿造台的对应槽位
+	return nil
+	// 返回值
+}
@@ -64,6 +64,7 @@ func (c *Container) Decode() error {
 				Settings:   c.BlockEntity.AdditionalData.Settings,
 				FastMode:   c.BlockEntity.AdditionalData.FastMode,
 				Others:     c.BlockEntity.AdditionalData.Others,
+				Depth:      c.BlockEntity.AdditionalData.Depth,
 			},
 		}
 		err := newPackage.ParseItemFromNBT(value)
@@ -71,6 +72,11 @@ func (c *Container) Decode() error {
 			return fmt.Errorf("Decode: %v", err)
 		}
 		c.Contents = append(c.Contents, newPackage)
+		if newPackage.AdditionalData.Truncated {
+			report := NewItemReport(newPackage.Item)
+			report.Omit(fmt.Sprintf("contents (nesting depth limit %d reached)", MaxContainerNestingDepth))
+			c.Reports = append(c.Reports, report)
+		}
 	}
 	// 解码
 	return nil
@@ -119,47 +125,9 @@ func (c *Container) WriteData() error {
 		return fmt.Errorf("WriteData: %v", err)
 	}
 	// 放置容器
-	for key, value := range c.Contents {
-		if ContainerCouldOpen(c.BlockEntity.Block.Name) && value.Item.Custom != nil && value.Item.Custom.SubBlockData != nil {
-			success, spawnLocation, err := c.GetSubBlock(value.Item)
-			if err != nil {
-				return fmt.Errorf("WriteData: Failed to process the sub block from c.Contents[%d]; c.Contents[%d].Item.Custom.SubBlockData = %#v; err = %v", key, key, value.Item.Custom.SubBlockData, err)
-			}
-			if !success {
-				continue
-			}
-			// 获取子方块的物品形式
-			err = c.MoveItemIntoContainer(spawnLocation, value.Item.Basic.Slot)
-			if err != nil {
-				return fmt.Errorf("WriteData: Failed to process the sub block from c.Contents[%d]; c.Contents[%d].Item.Custom.SubBlockData = %#v; err = %v", key, key, value.Item.Custom.SubBlockData, err)
-			}
-			// 将子方块移动到容器中
-			continue
-		}
-		// 子方块
-		if value.Item.Custom != nil && value.Item.Custom.ItemTag != nil {
-			success, err := c.GetNBTItem(value)
-			if err != nil {
-				return fmt.Errorf("WriteData: Failed to process the nbt item from c.Contents[%d]; c.Contents[%d].Item.Custom.ItemTag = %#v; err = %v", key, key, value.Item.Custom.ItemTag, err)
-			}
-			if !success {
-				continue
-			}
-			err = c.MoveItemIntoContainer(5, value.Item.Basic.Slot)
-			if err != nil {
-				return fmt.Errorf("WriteData: Failed to process the nbt item from c.Contents[%d]; c.Contents[%d].Item.Custom.ItemTag = %#v; err = %v", key, key, value.Item.Custom.ItemTag, err)
-			}
-		}
-		// NBT 物品
-	}
-	// 针对子方块或 NBT 物品的特殊化处理
-	newList := []GeneralItem{}
-	for _, value := range c.Contents {
-		newList = append(newList, value.Item)
-	}
-	defaultSituation, err := c.ItemPlanner(newList)
+	defaultSituation, err := c.ItemPlanner(c.Contents)
 	if err != nil {
-		return fmt.Errorf("GetSubBlock: %v", err)
+		return fmt.Errorf("WriteData: %v", err)
 	}
 	// 仅包含附魔属性、 物品组件和自定义物品显示名称的物品
 	for _, value := range defaultSituation {
@@ -182,6 +150,28 @@ func (c *Container) WriteData() error {
 		}
 	}
 	// 对于可以直接在容器上应用 replaceitem 命令的物品
+	for _, value := range c.Contents {
+		if value.Item.Custom != nil && value.Item.Custom.ItemTag != nil {
+			continue
+		}
+		if value.Item.Enhancement != nil && len(value.Item.Enhancement.Lore) != 0 {
+			report := NewItemReport(value.Item)
+			report.Omit(fmt.Sprintf("%d lore lines", len(value.Item.Enhancement.Lore)))
+			c.Reports = append(c.Reports, report)
+		}
+	}
+	// 基岩版没有任何合法手段或命令可以修改物品描述，
+	// 因此其他带有物品描述的物品也需要被报告
+	if c.BlockEntity.AdditionalData.Depth > 0 {
+		return nil
+	}
+	err = SummarizeItemReports(c.Reports)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告未能完全还原的物品。
+	// 子方块中的生成结果将由其所在的容器合并，
+	// 因此只有最外层的容器才需要报告
 	return nil
 	// 返回值
 }
@@ -0,0 +1,90 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	"phoenixbuilder/mirror/chunk"
+	"strings"
+)
+
+// 从 f.BlockEntity.Block.NBT 提取花盆中的植物
+func (f *FlowerPot) Decode() error {
+	if _, ok := f.BlockEntity.Block.NBT["PlantBlock"]; !ok {
+		return nil
+	}
+	plant, normal := f.BlockEntity.Block.NBT["PlantBlock"].(map[string]interface{})
+	if !normal {
+		return fmt.Errorf("Decode: Crashed at f.BlockEntity.Block.NBT[\"PlantBlock\"]; f.BlockEntity.Block.NBT = %#v", f.BlockEntity.Block.NBT)
+	}
+	name, normal := plant["name"].(string)
+	if !normal {
+		return fmt.Errorf("Decode: Crashed at f.BlockEntity.Block.NBT[\"PlantBlock\"][\"name\"]; f.BlockEntity.Block.NBT = %#v", f.BlockEntity.Block.NBT)
+	}
+	states, _ := plant["states"].(map[string]interface{})
+	if states == nil {
+		states = map[string]interface{}{}
+	}
+	if !strings.HasPrefix(name, "minecraft:") {
+		name = "minecraft:" + name
+	}
+	f.PlantName, f.PlantStates = name, states
+	return nil
+	// return
+}
+
+// 放置花盆，然后手持对应的植物点击花盆以将其种入
+func (f *FlowerPot) WriteData() error {
+	err := f.BlockEntity.Interface.SetBlock(f.BlockEntity.AdditionalData.Position, f.BlockEntity.Block.Name, f.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	if f.BlockEntity.AdditionalData.FastMode || len(f.PlantName) == 0 || f.PlantName == "minecraft:air" {
+		return nil
+	}
+	gameInterface := f.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := f.BlockEntity.AdditionalData.Position
+	// 放置花盆
+	runtimeID, found := chunk.StateToRuntimeID(f.PlantName, f.PlantStates)
+	if !found {
+		return fmt.Errorf("WriteData: Unknown plant %s; states = %#v", f.PlantName, f.PlantStates)
+	}
+	legacyBlock, found := chunk.RuntimeIDToLegacyBlock(runtimeID)
+	if !found {
+		return fmt.Errorf("WriteData: Unknown plant %s; states = %#v", f.PlantName, f.PlantStates)
+	}
+	// 植物物品的数据值与其方块的数据值一致
+	err = gameInterface.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1]+1, pos[2]), true)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = generateItemInHotbar(gameInterface, 0, types.ChestSlot{
+		Name:   strings.TrimPrefix(f.PlantName, "minecraft:"),
+		Count:  1,
+		Damage: legacyBlock.Val,
+	})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = gameInterface.ChangeSelectedHotbarSlot(0)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 传送机器人到花盆处，并获取植物到快捷栏 0
+	err = gameInterface.ClickBlock(GameInterface.UseItemOnBlocks{
+		HotbarSlotID: 0,
+		BlockPos:     pos,
+		BlockName:    fmt.Sprintf("minecraft:%s", f.BlockEntity.Block.Name),
+		BlockStates:  f.BlockEntity.Block.States,
+	})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = gameInterface.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 将植物种入花盆
+	return nil
+	// 返回值
+}
@@ -0,0 +1,114 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 从 i.BlockEntity.Block.NBT 提取展示框中的物品及其旋转角度
+func (i *ItemFrame) Decode() error {
+	var normal bool
+	if _, ok := i.BlockEntity.Block.NBT["Item"]; ok {
+		item, normal := i.BlockEntity.Block.NBT["Item"].(map[string]interface{})
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at i.BlockEntity.Block.NBT[\"Item\"]; i.BlockEntity.Block.NBT = %#v", i.BlockEntity.Block.NBT)
+		}
+		if name, _ := item["Name"].(string); len(name) != 0 {
+			i.Item = item
+		}
+	}
+	// Item
+	if _, ok := i.BlockEntity.Block.NBT["ItemRotation"]; ok {
+		i.ItemRotation, normal = i.BlockEntity.Block.NBT["ItemRotation"].(float32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at i.BlockEntity.Block.NBT[\"ItemRotation\"]; i.BlockEntity.Block.NBT = %#v", i.BlockEntity.Block.NBT)
+		}
+	}
+	// ItemRotation
+	return nil
+	// return
+}
+
+/*
+放置一个展示框，然后通过点击它以放入物品并调整物品的旋转角度。
+
+物品会先被生成在快捷栏 0 ，
+其附魔属性和自定义名称也会被一同写入
+*/
+func (i *ItemFrame) WriteData() error {
+	if i.BlockEntity.AdditionalData.FastMode || i.Item == nil {
+		err := i.BlockEntity.Interface.SetBlock(i.BlockEntity.AdditionalData.Position, i.BlockEntity.Block.Name, i.BlockEntity.AdditionalData.BlockStates)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		return nil
+	}
+	gameInterface := i.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := i.BlockEntity.AdditionalData.Position
+	// 空展示框或快速导入模式下只需要放置展示框
+	itemPackage := ItemPackage{
+		Interface: i.BlockEntity.Interface,
+		AdditionalData: ItemAdditionalData{
+			HotBarSlot: 0,
+			Position:   pos,
+			Settings:   i.BlockEntity.AdditionalData.Settings,
+			FastMode:   false,
+		},
+	}
+	err := itemPackage.ParseItemFromNBT(i.Item)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	itemPackage.Item.Basic.Count = 1
+	method := GetGenerateItemMethod(&itemPackage)
+	err = method.Decode()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = method.WriteData()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	report := method.Report()
+	// 生成物品到快捷栏 0 。
+	// 此时展示框尚未放置，因此 pos 可以被用于生成铁砧
+	err = gameInterface.SetBlock(pos, i.BlockEntity.Block.Name, i.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = gameInterface.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1]+1, pos[2]), true)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = gameInterface.ChangeSelectedHotbarSlot(0)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置展示框并传送机器人到展示框所在的位置
+	clicks := 1 + (int(i.ItemRotation/45)%8+8)%8
+	for j := 0; j < clicks; j++ {
+		err = gameInterface.ClickBlock(GameInterface.UseItemOnBlocks{
+			HotbarSlotID: 0,
+			BlockPos:     pos,
+			BlockName:    fmt.Sprintf("minecraft:%s", i.BlockEntity.Block.Name),
+			BlockStates:  i.BlockEntity.Block.States,
+		})
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+	}
+	// 第一次点击放入物品，
+	// 其后的每次点击都会使物品旋转 45 度
+	err = gameInterface.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 等待更改
+	err = SummarizeItemReports([]ItemReport{report})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告展示框内的物品是否被完全还原
+	return nil
+	// 返回值
+}
@@ -0,0 +1,65 @@
+package NBTAssigner
+
+import (
+	"fmt"
+)
+
+// 从 j.BlockEntity.Block.NBT 提取唱片机中的唱片
+func (j *Jukebox) Decode() error {
+	record, err := j.BlockEntity.getHeldItem("RecordItem")
+	if err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	if record != nil {
+		newPackage, err := j.BlockEntity.decodeHeldItem(record, 0)
+		if err != nil {
+			return fmt.Errorf("Decode: %v", err)
+		}
+		j.Record = &newPackage
+	}
+	// RecordItem
+	return nil
+	// return
+}
+
+/*
+放置一个唱片机，然后手持唱片点击它以放入唱片。
+
+唱片会先被生成在快捷栏 0 ，
+因此放入后唱片机将从头开始播放它
+*/
+func (j *Jukebox) WriteData() error {
+	if j.BlockEntity.AdditionalData.FastMode || j.Record == nil {
+		err := j.BlockEntity.Interface.SetBlock(j.BlockEntity.AdditionalData.Position, j.BlockEntity.Block.Name, j.BlockEntity.AdditionalData.BlockStates)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		return nil
+	}
+	// 放置空唱片机(快速导入模式下或唱片机中没有唱片时)
+	report, err := generateHeldItem(*j.Record)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	j.Reports = append(j.Reports, report)
+	// 生成唱片到快捷栏 0
+	err = j.BlockEntity.Interface.SetBlock(j.BlockEntity.AdditionalData.Position, j.BlockEntity.Block.Name, j.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = j.BlockEntity.clickWithHotbarItem(0)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置唱片机并放入唱片
+	if j.BlockEntity.AdditionalData.Depth > 0 {
+		return nil
+	}
+	err = SummarizeItemReports(j.Reports)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告未能完全还原的唱片
+	return nil
+	// 返回值
+}
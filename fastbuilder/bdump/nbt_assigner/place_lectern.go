@@ -0,0 +1,105 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+)
+
+// 从 l.BlockEntity.Block.NBT 提取讲台上的书及其页码
+func (l *Lectern) Decode() error {
+	var normal bool
+	book, err := l.BlockEntity.getHeldItem("book")
+	if err != nil {
+		return fmt.Errorf("Decode: %v", err)
+	}
+	if book != nil {
+		newPackage, err := l.BlockEntity.decodeHeldItem(book, 0)
+		if err != nil {
+			return fmt.Errorf("Decode: %v", err)
+		}
+		l.Book = &newPackage
+	}
+	// book
+	if _, ok := l.BlockEntity.Block.NBT["page"]; ok {
+		l.Page, normal = l.BlockEntity.Block.NBT["page"].(int32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at l.BlockEntity.Block.NBT[\"page\"]; l.BlockEntity.Block.NBT = %#v", l.BlockEntity.Block.NBT)
+		}
+	}
+	// page
+	if _, ok := l.BlockEntity.Block.NBT["totalPages"]; ok {
+		l.TotalPages, normal = l.BlockEntity.Block.NBT["totalPages"].(int32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at l.BlockEntity.Block.NBT[\"totalPages\"]; l.BlockEntity.Block.NBT = %#v", l.BlockEntity.Block.NBT)
+		}
+	}
+	// totalPages
+	return nil
+	// return
+}
+
+/*
+放置一个讲台，然后手持书点击它以将书放到讲台上。
+
+书会先被生成在快捷栏 0 ，
+其内容、签名及自定义名称也会被一同写入；
+如果书没有停留在第一页，
+则还会发送 LecternUpdate 数据包以翻到对应的页码
+*/
+func (l *Lectern) WriteData() error {
+	if l.BlockEntity.AdditionalData.FastMode || l.Book == nil {
+		err := l.BlockEntity.Interface.SetBlock(l.BlockEntity.AdditionalData.Position, l.BlockEntity.Block.Name, l.BlockEntity.AdditionalData.BlockStates)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		return nil
+	}
+	gameInterface := l.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := l.BlockEntity.AdditionalData.Position
+	// 放置空讲台(快速导入模式下或讲台上没有书时)
+	report, err := generateHeldItem(*l.Book)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	l.Reports = append(l.Reports, report)
+	// 生成书到快捷栏 0
+	err = gameInterface.SetBlock(pos, l.BlockEntity.Block.Name, l.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = l.BlockEntity.clickWithHotbarItem(0)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置讲台并放入书
+	if l.Page > 0 {
+		totalPages := l.TotalPages
+		if l.Book.Item.Custom != nil {
+			if pages, _ := l.Book.Item.Custom.ItemTag["pages"].([]interface{}); len(pages) > 0 {
+				totalPages = int32(len(pages))
+			}
+		}
+		err = gameInterface.WritePacket(&packet.LecternUpdate{
+			Page:      byte(l.Page),
+			PageCount: byte(totalPages),
+			Position:  protocol.BlockPos{pos[0], pos[1], pos[2]},
+			DropBook:  false,
+		})
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+	}
+	// 翻到对应的页码
+	if l.BlockEntity.AdditionalData.Depth > 0 {
+		return nil
+	}
+	err = SummarizeItemReports(l.Reports)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告未能完全还原的书
+	return nil
+	// 返回值
+}
@@ -0,0 +1,104 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	"strings"
+)
+
+// 描述刷怪笼中各配置项的默认值。
+// 这些配置项无法通过合法手段修改，
+// 因此与默认值不同的项将被视为无法还原
+var spawnerDefaults = []struct {
+	Key   string
+	Value int16
+}{
+	{"MinSpawnDelay", 200},
+	{"MaxSpawnDelay", 800},
+	{"SpawnCount", 4},
+	{"MaxNearbyEntities", 6},
+	{"RequiredPlayerRange", 16},
+	{"SpawnRange", 4},
+}
+
+// 取得可以使刷怪笼生成 identifier 所指代实体的刷怪蛋的名称，
+// 例如 minecraft:zombie 对应 zombie_spawn_egg
+func spawnEggName(identifier string) string {
+	return fmt.Sprintf("%s_spawn_egg", strings.TrimPrefix(identifier, "minecraft:"))
+}
+
+// 从 m.BlockEntity.Block.NBT 提取刷怪笼将生成的实体及其配置
+func (m *MobSpawner) Decode() error {
+	var normal bool
+	if _, ok := m.BlockEntity.Block.NBT["EntityIdentifier"]; ok {
+		m.EntityIdentifier, normal = m.BlockEntity.Block.NBT["EntityIdentifier"].(string)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at m.BlockEntity.Block.NBT[\"EntityIdentifier\"]; m.BlockEntity.Block.NBT = %#v", m.BlockEntity.Block.NBT)
+		}
+	}
+	// EntityIdentifier
+	for _, value := range spawnerDefaults {
+		if _, ok := m.BlockEntity.Block.NBT[value.Key]; !ok {
+			continue
+		}
+		got, normal := m.BlockEntity.Block.NBT[value.Key].(int16)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at m.BlockEntity.Block.NBT[\"%s\"]; m.BlockEntity.Block.NBT = %#v", value.Key, m.BlockEntity.Block.NBT)
+		}
+		if got != value.Value {
+			m.Unhandled = append(m.Unhandled, value.Key)
+		}
+	}
+	// MinSpawnDelay, MaxSpawnDelay, SpawnCount and so on
+	return nil
+	// return
+}
+
+/*
+放置一个刷怪笼，然后手持对应的刷怪蛋点击它以设置其生成的实体。
+
+刷怪蛋会先被生成在快捷栏 0 。
+刷怪笼的其他配置项(如 SpawnCount)无法被还原，
+因此它们将被记录在生成结果中
+*/
+func (m *MobSpawner) WriteData() error {
+	err := m.BlockEntity.Interface.SetBlock(m.BlockEntity.AdditionalData.Position, m.BlockEntity.Block.Name, m.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	if m.BlockEntity.AdditionalData.FastMode || len(m.EntityIdentifier) == 0 {
+		return nil
+	}
+	// 放置刷怪笼(快速导入模式下或刷怪笼为空时不再设置实体)
+	report := ItemReport{Name: m.BlockEntity.Block.Name}
+	err = generateItemInHotbar(
+		m.BlockEntity.Interface.(*GameInterface.GameInterface),
+		0,
+		types.ChestSlot{Name: spawnEggName(m.EntityIdentifier), Count: 1},
+	)
+	if err == nil {
+		err = m.BlockEntity.clickWithHotbarItem(0)
+	}
+	if err != nil {
+		report.Lose(fmt.Sprintf("entity %s (%v)", m.EntityIdentifier, err))
+	} else {
+		report.Restore(fmt.Sprintf("entity %s", m.EntityIdentifier))
+	}
+	// 使用刷怪蛋设置刷怪笼生成的实体
+	for _, value := range m.Unhandled {
+		report.Omit(fmt.Sprintf("tag %s", value))
+	}
+	m.Reports = append(m.Reports, report)
+	// 记录无法被还原的配置项
+	if m.BlockEntity.AdditionalData.Depth > 0 {
+		return nil
+	}
+	err = SummarizeItemReports(m.Reports)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告未能完全还原的数据
+	return nil
+	// 返回值
+}
@@ -0,0 +1,95 @@
+package NBTAssigner
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 取自导出结果的僵尸刷怪笼
+func sampleMobSpawnerNBT() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                  "MobSpawner",
+		"x":                   int32(12),
+		"y":                   int32(-60),
+		"z":                   int32(7),
+		"isMovable":           byte(1),
+		"EntityIdentifier":    "minecraft:zombie",
+		"Delay":               int16(237),
+		"MinSpawnDelay":       int16(200),
+		"MaxSpawnDelay":       int16(800),
+		"SpawnCount":          int16(4),
+		"MaxNearbyEntities":   int16(6),
+		"RequiredPlayerRange": int16(16),
+		"SpawnRange":          int16(4),
+		"DisplayEntityWidth":  float32(0.6),
+		"DisplayEntityHeight": float32(1.9),
+		"DisplayEntityScale":  float32(1),
+	}
+}
+
+func TestMobSpawnerDecode(t *testing.T) {
+	nbt := sampleMobSpawnerNBT()
+	spawner := MobSpawner{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "mob_spawner", NBT: nbt}}}
+	if err := spawner.Decode(); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if spawner.EntityIdentifier != "minecraft:zombie" {
+		t.Errorf("EntityIdentifier = %q, want %q", spawner.EntityIdentifier, "minecraft:zombie")
+	}
+	if len(spawner.Unhandled) != 0 {
+		t.Errorf("Unhandled = %v, want none", spawner.Unhandled)
+	}
+	// 默认配置
+	nbt["SpawnCount"] = int16(8)
+	nbt["RequiredPlayerRange"] = int16(32)
+	spawner = MobSpawner{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "mob_spawner", NBT: nbt}}}
+	if err := spawner.Decode(); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if want := []string{"SpawnCount", "RequiredPlayerRange"}; !reflect.DeepEqual(spawner.Unhandled, want) {
+		t.Errorf("Unhandled = %v, want %v", spawner.Unhandled, want)
+	}
+	// 修改过的配置
+	nbt["EntityIdentifier"] = int32(32)
+	spawner = MobSpawner{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "mob_spawner", NBT: nbt}}}
+	if err := spawner.Decode(); err == nil {
+		t.Errorf("Decode accepted a non-string EntityIdentifier")
+	}
+	// 错误的类型
+}
+
+func TestSpawnEggName(t *testing.T) {
+	cases := map[string]string{
+		"minecraft:zombie":       "zombie_spawn_egg",
+		"minecraft:cave_spider":  "cave_spider_spawn_egg",
+		"chicken":                "chicken_spawn_egg",
+		"minecraft:zombie_horse": "zombie_horse_spawn_egg",
+	}
+	for identifier, want := range cases {
+		if got := spawnEggName(identifier); got != want {
+			t.Errorf("spawnEggName(%q) = %q, want %q", identifier, got, want)
+		}
+	}
+}
+
+func TestAnalyzeMobSpawner(t *testing.T) {
+	nbt := sampleMobSpawnerNBT()
+	lost, err := analyzeBlockNBT(GeneralBlock{Name: "mob_spawner", NBT: nbt}, [3]int32{12, -60, 7}, 0)
+	if err != nil {
+		t.Fatalf("analyzeBlockNBT: %v", err)
+	}
+	if len(lost) != 0 {
+		t.Errorf("lost = %v, want none", lost)
+	}
+	// 可被完全还原的刷怪笼
+	nbt["SpawnCount"] = int16(8)
+	nbt["SpawnData"] = map[string]interface{}{"TypeId": "minecraft:zombie"}
+	lost, err = analyzeBlockNBT(GeneralBlock{Name: "mob_spawner", NBT: nbt}, [3]int32{12, -60, 7}, 0)
+	if err != nil {
+		t.Fatalf("analyzeBlockNBT: %v", err)
+	}
+	if want := []string{"tag SpawnData", "spawner setting SpawnCount"}; !reflect.DeepEqual(lost, want) {
+		t.Errorf("lost = %v, want %v", lost, want)
+	}
+	// 部分数据将会丢失的刷怪笼
+}
@@ -0,0 +1,68 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 从 n.BlockEntity.Block.NBT 提取音符盒的音高并保存在 n.Note 中
+func (n *NoteBlock) Decode() error {
+	_, ok := n.BlockEntity.Block.NBT["note"]
+	if !ok {
+		return nil
+	}
+	note, normal := n.BlockEntity.Block.NBT["note"].(byte)
+	if !normal {
+		return fmt.Errorf("Decode: Crashed at n.BlockEntity.Block.NBT[\"note\"]; n.BlockEntity.Block.NBT = %#v", n.BlockEntity.Block.NBT)
+	}
+	n.Note = note % NoteBlockPitchCount
+	return nil
+	// return
+}
+
+// 放置一个音符盒并通过点击它以调整音高
+func (n *NoteBlock) WriteData() error {
+	err := n.BlockEntity.Interface.SetBlock(n.BlockEntity.AdditionalData.Position, n.BlockEntity.Block.Name, n.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	if n.BlockEntity.AdditionalData.FastMode || n.Note == 0 {
+		return nil
+	}
+	gameInterface := n.BlockEntity.Interface.(*GameInterface.GameInterface)
+	// 放置音符盒
+	err = gameInterface.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", n.BlockEntity.AdditionalData.Position[0], n.BlockEntity.AdditionalData.Position[1]+1, n.BlockEntity.AdditionalData.Position[2]), true)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 传送机器人到音符盒所在的位置
+	err = gameInterface.SendSettingsCommand("replaceitem entity @s slot.hotbar 0 air", true)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	err = gameInterface.ChangeSelectedHotbarSlot(0)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 清空快捷栏 0 并切换到该物品栏，
+	// 这样点击音符盒时就不会在其上放置方块
+	for i := uint8(0); i < n.Note; i++ {
+		err = gameInterface.ClickBlock(GameInterface.UseItemOnBlocks{
+			HotbarSlotID: 0,
+			BlockPos:     n.BlockEntity.AdditionalData.Position,
+			BlockName:    fmt.Sprintf("minecraft:%s", n.BlockEntity.Block.Name),
+			BlockStates:  n.BlockEntity.Block.States,
+		})
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+	}
+	// 每次点击音符盒都会使其音高增加 1
+	err = gameInterface.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 等待更改
+	return nil
+	// 返回值
+}
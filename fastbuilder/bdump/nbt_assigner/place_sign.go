@@ -2,19 +2,157 @@ package NBTAssigner
 
 import (
 	"fmt"
+	"math"
+	"phoenixbuilder/fastbuilder/types"
 	GameInterface "phoenixbuilder/game_control/game_interface"
 	"phoenixbuilder/minecraft/protocol/packet"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
-// 我们不再检查用户提供的告示牌的 NBT 是否正确，
-// 我们信任并且永远认为它们是正确且完整的
+// 告示牌文本的默认颜色，即不透明的黑色
+const defaultSignTextColor = int32(-16777216)
+
+// 从 text 解码告示牌单面的文本数据。
+// text 可以是新版的 FrontText 或 BackText 复合标签，
+// 也可以是旧版告示牌的整个 NBT
+func decodeSignText(text map[string]interface{}) (SignText, error) {
+	var normal bool
+	result := SignText{
+		SignTextColor:     defaultSignTextColor,
+		PersistFormatting: true,
+	}
+	// 初始化
+	if _, ok := text["Text"]; ok {
+		result.Text, normal = text["Text"].(string)
+		if !normal {
+			return SignText{}, fmt.Errorf("decodeSignText: Crashed at text[\"Text\"]; text = %#v", text)
+		}
+	}
+	// Text
+	if _, ok := text["SignTextColor"]; ok {
+		result.SignTextColor, normal = text["SignTextColor"].(int32)
+		if !normal {
+			return SignText{}, fmt.Errorf("decodeSignText: Crashed at text[\"SignTextColor\"]; text = %#v", text)
+		}
+	}
+	// SignTextColor
+	if _, ok := text["TextOwner"]; ok {
+		result.TextOwner, normal = text["TextOwner"].(string)
+		if !normal {
+			return SignText{}, fmt.Errorf("decodeSignText: Crashed at text[\"TextOwner\"]; text = %#v", text)
+		}
+	}
+	// TextOwner
+	for key, value := range map[string]*bool{
+		"IgnoreLighting":    &result.IgnoreLighting,
+		"HideGlowOutline":   &result.HideGlowOutline,
+		"PersistFormatting": &result.PersistFormatting,
+	} {
+		if _, ok := text[key]; !ok {
+			continue
+		}
+		got, normal := text[key].(byte)
+		if !normal {
+			return SignText{}, fmt.Errorf("decodeSignText: Crashed at text[%#v]; text = %#v", key, text)
+		}
+		*value = got == 1
+	}
+	// IgnoreLighting, HideGlowOutline and PersistFormatting
+	return result, nil
+	// 返回值
+}
+
+// 将 s 编码为新版告示牌中 FrontText 或 BackText 的格式
+func (s SignText) toNBT() map[string]interface{} {
+	boolToByte := func(value bool) byte {
+		if value {
+			return 1
+		}
+		return 0
+	}
+	return map[string]interface{}{
+		"Text":              s.Text,
+		"SignTextColor":     s.SignTextColor,
+		"IgnoreLighting":    boolToByte(s.IgnoreLighting),
+		"HideGlowOutline":   boolToByte(s.HideGlowOutline),
+		"PersistFormatting": boolToByte(s.PersistFormatting),
+		"TextOwner":         s.TextOwner,
+	}
+}
+
+/*
+从 s.BlockEntity.Block.NBT 提取告示牌两面的文本及涂蜡状态。
+
+新版告示牌的文本存放在 FrontText 和 BackText 复合标签中；
+而旧版告示牌只有一面，其文本直接存放在 NBT 的顶层，
+此时它将被视为告示牌的正面
+*/
 func (s *Sign) Decode() error {
+	var err error
+	s.IsHangingSign = strings.Contains(s.BlockEntity.Block.Name, "hanging_sign")
+	// 初始化
+	if _, ok := s.BlockEntity.Block.NBT["FrontText"]; ok {
+		for key, value := range map[string]*SignText{
+			"FrontText": &s.FrontText,
+			"BackText":  &s.BackText,
+		} {
+			text := map[string]interface{}{}
+			if _, ok := s.BlockEntity.Block.NBT[key]; ok {
+				var normal bool
+				text, normal = s.BlockEntity.Block.NBT[key].(map[string]interface{})
+				if !normal {
+					return fmt.Errorf("Decode: Crashed at s.BlockEntity.Block.NBT[%#v]; s.BlockEntity.Block.NBT = %#v", key, s.BlockEntity.Block.NBT)
+				}
+			}
+			*value, err = decodeSignText(text)
+			if err != nil {
+				return fmt.Errorf("Decode: %v", err)
+			}
+		}
+	} else {
+		s.FrontText, err = decodeSignText(s.BlockEntity.Block.NBT)
+		if err != nil {
+			return fmt.Errorf("Decode: %v", err)
+		}
+		s.BackText, _ = decodeSignText(map[string]interface{}{})
+	}
+	// FrontText and BackText
+	if _, ok := s.BlockEntity.Block.NBT["IsWaxed"]; ok {
+		got, normal := s.BlockEntity.Block.NBT["IsWaxed"].(byte)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at s.BlockEntity.Block.NBT[\"IsWaxed\"]; s.BlockEntity.Block.NBT = %#v", s.BlockEntity.Block.NBT)
+		}
+		s.IsWaxed = got == 1
+	}
+	// IsWaxed
 	return nil
+	// return
 }
 
-// 放置一个告示牌并写入告示牌数据
+// 取得通过 BlockActorData 写入告示牌的 NBT 数据。
+// 旧版告示牌的文本将被同时写入新版的 FrontText 中，
+// 而涂蜡状态总是被置为假，
+// 因为已涂蜡的告示牌无法再被修改
+func (s *Sign) getSignNBT() map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range s.BlockEntity.Block.NBT {
+		result[key] = value
+	}
+	result["FrontText"] = s.FrontText.toNBT()
+	result["BackText"] = s.BackText.toNBT()
+	result["IsWaxed"] = byte(0)
+	return result
+}
+
+/*
+放置一个告示牌并写入告示牌数据。
+
+两面的文本将通过 BlockActorData 数据包写入，
+然后再手持对应的物品点击告示牌，
+以还原文本颜色、发光效果与涂蜡状态
+*/
 func (s *Sign) WriteData() error {
 	var uniqueID_1 uuid.UUID
 	var uniqueID_2 uuid.UUID
@@ -49,14 +187,19 @@ func (s *Sign) WriteData() error {
 		// 清除当前告示牌处的方块。
 		// 如果不这么做且原本该处的方块是告示牌的话，
 		// 那么 NBT 数据将会注入失败
+		signItem := "oak_sign"
+		if s.IsHangingSign {
+			signItem = "oak_hanging_sign"
+		}
 		err = gameInterface.SendSettingsCommand(
-			"replaceitem entity @s slot.hotbar 0 oak_sign",
+			fmt.Sprintf("replaceitem entity @s slot.hotbar 0 %s", signItem),
 			true,
 		)
 		if err != nil {
 			return fmt.Errorf("WriteData: %v", err)
 		}
-		// 获取一个告示牌到快捷栏 0
+		// 获取一个告示牌到快捷栏 0 。
+		// 悬挂式告示牌放置在方块侧面时也不需要额外的依附方块
 		err = gameInterface.ChangeSelectedHotbarSlot(0)
 		if err != nil {
 			return fmt.Errorf("WriteData: %v", err)
@@ -132,7 +275,7 @@ func (s *Sign) WriteData() error {
 	// 放置告示牌
 	err := gameInterface.WritePacket(&packet.BlockActorData{
 		Position: s.BlockEntity.AdditionalData.Position,
-		NBTData:  s.BlockEntity.Block.NBT,
+		NBTData:  s.getSignNBT(),
 	})
 	if err != nil {
 		return fmt.Errorf("WriteData: %v", err)
@@ -190,6 +333,141 @@ func (s *Sign) WriteData() error {
 		},
 	)
 	// 再强行生成一次告示牌本身以抑制其可能发生的掉落
+	report := ItemReport{Name: s.BlockEntity.Block.Name}
+	for _, side := range []bool{true, false} {
+		err = s.applyTextStyle(side, &report)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+	}
+	// 还原两面文本的颜色与发光效果
+	if s.IsWaxed {
+		err = s.clickSign(true, types.ChestSlot{Name: "honeycomb", Count: 1})
+		if err != nil {
+			report.Lose(fmt.Sprintf("waxed (%v)", err))
+		} else {
+			report.Restore("waxed")
+		}
+	}
+	// 涂蜡。
+	// 这必须在最后进行，因为已涂蜡的告示牌无法再被修改
+	if len(report.Restored) > 0 || len(report.Lost) > 0 || len(report.Omitted) > 0 {
+		s.Reports = append(s.Reports, report)
+	}
+	if s.BlockEntity.AdditionalData.Depth > 0 {
+		return nil
+	}
+	err = SummarizeItemReports(s.Reports)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 报告未能完全还原的数据
+	return nil
+	// 返回值
+}
+
+// 取得告示牌正面所朝向的水平方向，
+// 返回值分别为 X 轴和 Z 轴上的偏移量
+func (s *Sign) getFrontDirection() (int32, int32) {
+	states := s.BlockEntity.Block.States
+	standing := strings.Contains(s.BlockEntity.Block.Name, "standing_sign")
+	if standing || (s.IsHangingSign && getIntBlockState(states, "attached_bit") == 1) {
+		angle := float64(getIntBlockState(states, "ground_sign_direction")) * 22.5 * math.Pi / 180
+		return int32(math.Round(-math.Sin(angle))), int32(math.Round(math.Cos(angle)))
+	}
+	// 站立式告示牌或悬挂在方块下方且带有链条的悬挂式告示牌。
+	// ground_sign_direction 为 0 时告示牌的正面朝向南方
+	switch getIntBlockState(states, "facing_direction") {
+	case 2:
+		return 0, -1
+	case 4:
+		return -1, 0
+	case 5:
+		return 1, 0
+	}
+	return 0, 1
+	// 墙上的告示牌
+}
+
+// 站在告示牌的正面(front 为真时)或背面，
+// 然后手持 item 点击告示牌
+func (s *Sign) clickSign(front bool, item types.ChestSlot) error {
+	gameInterface := s.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := s.BlockEntity.AdditionalData.Position
+	dx, dz := s.getFrontDirection()
+	if !front {
+		dx, dz = -dx, -dz
+	}
+	// 初始化
+	err := generateItemInHotbar(gameInterface, 0, item)
+	if err != nil {
+		return fmt.Errorf("clickSign: %v", err)
+	}
+	err = gameInterface.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0]+dx, pos[1], pos[2]+dz), true)
+	if err != nil {
+		return fmt.Errorf("clickSign: %v", err)
+	}
+	err = gameInterface.ChangeSelectedHotbarSlot(0)
+	if err != nil {
+		return fmt.Errorf("clickSign: %v", err)
+	}
+	// 获取物品并传送到告示牌对应的一面
+	err = gameInterface.ClickBlock(GameInterface.UseItemOnBlocks{
+		HotbarSlotID: 0,
+		BlockPos:     pos,
+		BlockName:    fmt.Sprintf("minecraft:%s", s.BlockEntity.Block.Name),
+		BlockStates:  s.BlockEntity.Block.States,
+	})
+	if err != nil {
+		return fmt.Errorf("clickSign: %v", err)
+	}
+	err = gameInterface.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("clickSign: %v", err)
+	}
+	// 点击告示牌
+	return nil
+	// 返回值
+}
+
+// 使用染料和荧光墨囊还原告示牌正面(front 为真时)或背面的文本颜色与发光效果，
+// 并将结果记录在 report 中。
+// 没有文本的一面将被跳过。
+// 由于告示牌只能使用染料染色，
+// 因此与染料颜色不同的文本颜色将使用最接近的染料代替
+func (s *Sign) applyTextStyle(front bool, report *ItemReport) error {
+	side, text := "front", s.FrontText
+	if !front {
+		side, text = "back", s.BackText
+	}
+	if len(text.Text) == 0 {
+		return nil
+	}
+	// 初始化
+	if text.SignTextColor != defaultSignTextColor {
+		dye := nearestDyeColor(text.SignTextColor)
+		description := fmt.Sprintf("%s text color #%06X", side, text.SignTextColor&0xFFFFFF)
+		err := s.clickSign(front, types.ChestSlot{Name: "dye", Count: 1, Damage: bannerColorToDyeData(int32(dye))})
+		switch {
+		case err != nil:
+			report.Lose(fmt.Sprintf("%s (%v)", description, err))
+		case dyeColors[dye].RGB != text.SignTextColor&0xFFFFFF:
+			report.Omit(fmt.Sprintf("%s (approximated with %s dye)", description, dyeColors[dye].Name))
+		default:
+			report.Restore(description)
+		}
+	}
+	// 文本颜色
+	if text.IgnoreLighting {
+		description := fmt.Sprintf("%s glowing text", side)
+		err := s.clickSign(front, types.ChestSlot{Name: "glow_ink_sac", Count: 1})
+		if err != nil {
+			report.Lose(fmt.Sprintf("%s (%v)", description, err))
+		} else {
+			report.Restore(description)
+		}
+	}
+	// 发光效果
 	return nil
 	// 返回值
 }
@@ -0,0 +1,61 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+)
+
+// 从 s.BlockEntity.Block.NBT 提取头颅的种类及旋转角度
+func (s *Skull) Decode() error {
+	var normal bool
+	if _, ok := s.BlockEntity.Block.NBT["SkullType"]; ok {
+		s.SkullType, normal = s.BlockEntity.Block.NBT["SkullType"].(byte)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at s.BlockEntity.Block.NBT[\"SkullType\"]; s.BlockEntity.Block.NBT = %#v", s.BlockEntity.Block.NBT)
+		}
+	}
+	// SkullType
+	if _, ok := s.BlockEntity.Block.NBT["Rotation"]; ok {
+		s.Rotation, normal = s.BlockEntity.Block.NBT["Rotation"].(float32)
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at s.BlockEntity.Block.NBT[\"Rotation\"]; s.BlockEntity.Block.NBT = %#v", s.BlockEntity.Block.NBT)
+		}
+	}
+	// Rotation
+	return nil
+	// return
+}
+
+// 手持对应种类的头颅并以正确的朝向放置它
+func (s *Skull) WriteData() error {
+	if s.BlockEntity.AdditionalData.FastMode {
+		err := s.BlockEntity.Interface.SetBlockAsync(s.BlockEntity.AdditionalData.Position, s.BlockEntity.Block.Name, s.BlockEntity.AdditionalData.BlockStates)
+		if err != nil {
+			return fmt.Errorf("WriteData: %v", err)
+		}
+		return nil
+	}
+	gameInterface := s.BlockEntity.Interface.(*GameInterface.GameInterface)
+	// 放置头颅(快速导入模式下)
+	err := generateItemInHotbar(gameInterface, 0, types.ChestSlot{Name: "skull", Count: 1, Damage: uint16(s.SkullType)})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 获取对应种类的头颅到快捷栏 0
+	face := getIntBlockState(s.BlockEntity.Block.States, "facing_direction")
+	yaw := s.Rotation - 180
+	if face >= 2 && face <= 5 {
+		yaw = facingDirectionToYaw[face] + 180
+	} else {
+		face = 1
+	}
+	err = placeBlockByItem(gameInterface, s.BlockEntity.AdditionalData.Position, 0, face, yaw)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置头颅。
+	// 放置在地面上的头颅总是面向放置它的玩家
+	return nil
+	// 返回值
+}
@@ -0,0 +1,123 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+)
+
+// 从 s.BlockEntity.Block.NBT 提取结构方块的配置
+func (s *StructureBlock) Decode() error {
+	data := StructureBlockData{
+		Mode:            packet.StructureBlockSave,
+		ShowBoundingBox: true,
+		Integrity:       100,
+		Offset:          [3]int32{0, -1, 0},
+		Size:            [3]int32{5, 5, 5},
+	}
+	// 初始化
+	fields := []struct {
+		Key    string
+		Target interface{}
+	}{
+		{"data", &data.Mode},
+		{"structureName", &data.StructureName},
+		{"dataField", &data.DataField},
+		{"includePlayers", &data.IncludePlayers},
+		{"showBoundingBox", &data.ShowBoundingBox},
+		{"ignoreEntities", &data.IgnoreEntities},
+		{"removeBlocks", &data.RemoveBlocks},
+		{"rotation", &data.Rotation},
+		{"mirror", &data.Mirror},
+		{"integrity", &data.Integrity},
+		{"seed", &data.Seed},
+		{"xStructureOffset", &data.Offset[0]},
+		{"yStructureOffset", &data.Offset[1]},
+		{"zStructureOffset", &data.Offset[2]},
+		{"xStructureSize", &data.Size[0]},
+		{"yStructureSize", &data.Size[1]},
+		{"zStructureSize", &data.Size[2]},
+		{"redstoneSaveMode", &data.RedstoneSaveMode},
+		{"animationMode", &data.AnimationMode},
+		{"animationSeconds", &data.AnimationSeconds},
+	}
+	for _, field := range fields {
+		value, ok := s.BlockEntity.Block.NBT[field.Key]
+		if !ok {
+			continue
+		}
+		var normal bool
+		switch target := field.Target.(type) {
+		case *int32:
+			*target, normal = value.(int32)
+		case *int64:
+			*target, normal = value.(int64)
+		case *float32:
+			*target, normal = value.(float32)
+		case *string:
+			*target, normal = value.(string)
+		case *byte:
+			*target, normal = value.(byte)
+		case *bool:
+			var got byte
+			got, normal = value.(byte)
+			*target = got != 0
+		}
+		if !normal {
+			return fmt.Errorf("Decode: Crashed at s.BlockEntity.Block.NBT[\"%s\"]; s.BlockEntity.Block.NBT = %#v", field.Key, s.BlockEntity.Block.NBT)
+		}
+	}
+	s.StructureBlockData = data
+	// 按表解码各配置项
+	return nil
+	// return
+}
+
+/*
+放置一个结构方块，
+然后发送 StructureBlockUpdate 数据包以写入其模式、结构名称、偏移及大小等配置。
+
+该数据包不会触发结构方块，
+因此导入时不会保存或加载任何结构
+*/
+func (s *StructureBlock) WriteData() error {
+	api := s.BlockEntity.Interface.(*GameInterface.GameInterface)
+	pos := s.BlockEntity.AdditionalData.Position
+	data := s.StructureBlockData
+	// 初始化
+	err := api.SetBlock(pos, s.BlockEntity.Block.Name, s.BlockEntity.AdditionalData.BlockStates)
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 放置结构方块
+	err = api.WritePacket(&packet.StructureBlockUpdate{
+		Position:           protocol.BlockPos{pos[0], pos[1], pos[2]},
+		StructureName:      data.StructureName,
+		DataField:          data.DataField,
+		IncludePlayers:     data.IncludePlayers,
+		ShowBoundingBox:    data.ShowBoundingBox,
+		StructureBlockType: data.Mode,
+		Settings: protocol.StructureSettings{
+			PaletteName:       "default",
+			IgnoreEntities:    data.IgnoreEntities,
+			IgnoreBlocks:      data.RemoveBlocks,
+			Size:              protocol.BlockPos{data.Size[0], data.Size[1], data.Size[2]},
+			Offset:            protocol.BlockPos{data.Offset[0], data.Offset[1], data.Offset[2]},
+			Rotation:          data.Rotation,
+			Mirror:            data.Mirror,
+			AnimationMode:     data.AnimationMode,
+			AnimationDuration: data.AnimationSeconds,
+			Integrity:         data.Integrity / 100,
+			Seed:              uint32(data.Seed),
+		},
+		RedstoneSaveMode: data.RedstoneSaveMode,
+		ShouldTrigger:    false,
+	})
+	if err != nil {
+		return fmt.Errorf("WriteData: %v", err)
+	}
+	// 写入结构方块的配置
+	return nil
+	// 返回值
+}
@@ -0,0 +1,97 @@
+package NBTAssigner
+
+import (
+	"phoenixbuilder/minecraft/protocol/packet"
+	"testing"
+)
+
+// 取自导出结果的处于加载模式的结构方块
+func sampleStructureBlockNBT() map[string]interface{} {
+	return map[string]interface{}{
+		"id":               "StructureBlock",
+		"x":                int32(100),
+		"y":                int32(70),
+		"z":                int32(-20),
+		"isMovable":        byte(0),
+		"isPowered":        byte(0),
+		"data":             int32(packet.StructureBlockLoad),
+		"structureName":    "mystructure:house",
+		"dataField":        "",
+		"includePlayers":   byte(0),
+		"showBoundingBox":  byte(1),
+		"ignoreEntities":   byte(1),
+		"removeBlocks":     byte(0),
+		"rotation":         byte(1),
+		"mirror":           byte(2),
+		"integrity":        float32(80),
+		"seed":             int64(12345),
+		"xStructureOffset": int32(2),
+		"yStructureOffset": int32(0),
+		"zStructureOffset": int32(-3),
+		"xStructureSize":   int32(16),
+		"yStructureSize":   int32(8),
+		"zStructureSize":   int32(12),
+		"redstoneSaveMode": int32(1),
+		"animationMode":    byte(1),
+		"animationSeconds": float32(2.5),
+	}
+}
+
+func TestStructureBlockDecode(t *testing.T) {
+	block := StructureBlock{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "structure_block", NBT: sampleStructureBlockNBT()}}}
+	if err := block.Decode(); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := StructureBlockData{
+		Mode:             packet.StructureBlockLoad,
+		StructureName:    "mystructure:house",
+		ShowBoundingBox:  true,
+		IgnoreEntities:   true,
+		Rotation:         1,
+		Mirror:           2,
+		Integrity:        80,
+		Seed:             12345,
+		Offset:           [3]int32{2, 0, -3},
+		Size:             [3]int32{16, 8, 12},
+		RedstoneSaveMode: 1,
+		AnimationMode:    1,
+		AnimationSeconds: 2.5,
+	}
+	if block.StructureBlockData != want {
+		t.Errorf("StructureBlockData = %+v, want %+v", block.StructureBlockData, want)
+	}
+}
+
+func TestStructureBlockDecodeDefaults(t *testing.T) {
+	block := StructureBlock{BlockEntity: &BlockEntity{Block: GeneralBlock{Name: "structure_block", NBT: map[string]interface{}{"id": "StructureBlock"}}}}
+	if err := block.Decode(); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := StructureBlockData{
+		Mode:            packet.StructureBlockSave,
+		ShowBoundingBox: true,
+		Integrity:       100,
+		Offset:          [3]int32{0, -1, 0},
+		Size:            [3]int32{5, 5, 5},
+	}
+	if block.StructureBlockData != want {
+		t.Errorf("StructureBlockData = %+v, want %+v", block.StructureBlockData, want)
+	}
+	// 默认值
+	block.BlockEntity.Block.NBT["xStructureSize"] = int16(16)
+	if err := block.Decode(); err == nil {
+		t.Errorf("Decode accepted a non-int32 structure size")
+	}
+	// 错误的类型
+}
+
+func TestAnalyzeStructureBlock(t *testing.T) {
+	nbt := sampleStructureBlockNBT()
+	lost, err := analyzeBlockNBT(GeneralBlock{Name: "structure_block", NBT: nbt}, [3]int32{100, 70, -20}, 0)
+	if err != nil {
+		t.Fatalf("analyzeBlockNBT: %v", err)
+	}
+	if len(lost) != 0 {
+		t.Errorf("lost = %v, want none", lost)
+	}
+}
@@ -0,0 +1,435 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	"sort"
+)
+
+// 描述方块实体在导入时的支持程度
+const (
+	// 所有 NBT 数据均可被还原
+	NBTSupportFull = "full"
+	// 方块实体可被放置，但部分 NBT 数据将会丢失
+	NBTSupportPartial = "partial"
+	// 方块实体尚未被支持，或其 NBT 数据无法被解码
+	NBTSupportNone = "unsupported"
+)
+
+// 此表描述了各类方块实体在导入时会被处理的 NBT 标签。
+// 值为 nil 时代表该类方块实体的所有 NBT 标签都将被写入，
+// 例如告示牌的 NBT 数据将被整体写入
+var analysisHandledBlockKeys = map[string][]string{
+	"CommandBlock": {
+		"Command", "CustomName", "LastOutput", "TickDelay",
+		"ExecuteOnFirstTick", "TrackOutput", "conditionalMode", "auto",
+	},
+	"Container":    {"facing"},
+	"Sign":         nil,
+	"NoteBlock":    {"note"},
+	"Banner":       {"Base", "Type", "Patterns"},
+	"Skull":        {"SkullType", "Rotation"},
+	"FlowerPot":    {"PlantBlock"},
+	"Bed":          {"color"},
+	"ItemFrame":    {"Item", "ItemRotation"},
+	"Lectern":      {"book", "page", "totalPages"},
+	"Jukebox":      {"RecordItem"},
+	"BrewingStand": {"Items", "FuelAmount", "CookTime"},
+	"MobSpawner": {
+		"EntityIdentifier", "MinSpawnDelay", "MaxSpawnDelay", "SpawnCount",
+		"MaxNearbyEntities", "RequiredPlayerRange", "SpawnRange",
+	},
+	"Beacon": {"primary", "secondary"},
+	"StructureBlock": {
+		"data", "structureName", "dataField", "includePlayers", "showBoundingBox",
+		"ignoreEntities", "removeBlocks", "rotation", "mirror", "integrity", "seed",
+		"xStructureOffset", "yStructureOffset", "zStructureOffset",
+		"xStructureSize", "yStructureSize", "zStructureSize",
+		"redstoneSaveMode", "animationMode", "animationSeconds",
+	},
+}
+
+// 此表描述了各类方块实体中可以被忽略的 NBT 标签，
+// 它们通常是坐标或由游戏在运行时自动维护的状态
+var analysisIgnoredBlockKeys = map[string][]string{
+	"": {"id", "x", "y", "z", "isMovable"},
+	"CommandBlock": {
+		"Version", "SuccessCount", "LastExecution", "powered", "conditionMet",
+		"LPCommandMode", "LPCondionalMode", "LPRedstoneMode",
+	},
+	"Container":    {"pairx", "pairz", "pairlead", "Findable"},
+	"Skull":        {"MouthMoving", "MouthTickCount"},
+	"Lectern":      {"hasBook"},
+	"BrewingStand": {"FuelTotal"},
+	"MobSpawner": {
+		"Delay", "DisplayEntityWidth", "DisplayEntityHeight", "DisplayEntityScale",
+	},
+	"StructureBlock": {"isPowered", "lastTouchedPlayerID"},
+}
+
+// 描述单个方块实体的分析结果
+type BlockNBTAnalysis struct {
+	// 方块名称(不含命名空间)
+	Name string `json:"name"`
+	// 该方块实体的类型，未被支持时为空
+	Type string `json:"type,omitempty"`
+	// 方块坐标(绝对坐标)
+	Position [3]int32 `json:"position"`
+	// 支持程度，为 NBTSupportFull 等常量之一
+	Support string `json:"support"`
+	// 导入时将会丢失的数据
+	Lost []string `json:"lost,omitempty"`
+	// 该方块实体未被支持的原因
+	Reason string `json:"reason,omitempty"`
+}
+
+// 描述单种方块在各支持程度下的数量
+type NBTSupportCount struct {
+	Full        int `json:"full"`
+	Partial     int `json:"partial"`
+	Unsupported int `json:"unsupported"`
+}
+
+// 描述对一组方块实体的分析结果的统计
+type NBTAnalysisSummary struct {
+	Total       int `json:"total"`
+	Full        int `json:"full"`
+	Partial     int `json:"partial"`
+	Unsupported int `json:"unsupported"`
+	// 按方块名统计的数量
+	Blocks map[string]*NBTSupportCount `json:"blocks"`
+	// 各项将会丢失的数据及其出现次数，
+	// 键的格式为 方块名: 数据
+	Lost map[string]int `json:"lost"`
+	// 所有未被完全支持的方块实体
+	Details []BlockNBTAnalysis `json:"details"`
+}
+
+// 创建一个空白的统计
+func NewNBTAnalysisSummary() *NBTAnalysisSummary {
+	return &NBTAnalysisSummary{
+		Blocks:  map[string]*NBTSupportCount{},
+		Lost:    map[string]int{},
+		Details: []BlockNBTAnalysis{},
+	}
+}
+
+// 将 result 计入统计
+func (s *NBTAnalysisSummary) Add(result BlockNBTAnalysis) {
+	count, ok := s.Blocks[result.Name]
+	if !ok {
+		count = &NBTSupportCount{}
+		s.Blocks[result.Name] = count
+	}
+	s.Total++
+	switch result.Support {
+	case NBTSupportFull:
+		s.Full++
+		count.Full++
+		return
+	case NBTSupportPartial:
+		s.Partial++
+		count.Partial++
+	default:
+		s.Unsupported++
+		count.Unsupported++
+	}
+	for _, value := range result.Lost {
+		s.Lost[fmt.Sprintf("%s: %s", result.Name, value)]++
+	}
+	s.Details = append(s.Details, result)
+}
+
+// 取得 s.Lost 中的所有键，
+// 返回的列表按出现次数从多到少排序
+func (s *NBTAnalysisSummary) SortedLost() []string {
+	result := make([]string, 0, len(s.Lost))
+	for key := range s.Lost {
+		result = append(result, key)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if s.Lost[result[i]] != s.Lost[result[j]] {
+			return s.Lost[result[i]] > s.Lost[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+/*
+在不放置任何方块的前提下分析 blockInfo 所代表的方块实体，
+并判断启用 -nbt 导入时其 NBT 数据能被还原的程度。
+
+分析仅依据已被支持的类型及它们的 Decode 方法进行，
+因此实际导入时仍然可能因为游戏环境的原因而丢失更多数据
+*/
+func AnalyzeBlockModule(blockInfo *types.Module) BlockNBTAnalysis {
+	position := [3]int32{int32(blockInfo.Point.X), int32(blockInfo.Point.Y), int32(blockInfo.Point.Z)}
+	generalBlock, err := ParseBlockModule(blockInfo)
+	if err != nil {
+		return BlockNBTAnalysis{
+			Name:     *blockInfo.Block.Name,
+			Position: position,
+			Support:  NBTSupportNone,
+			Reason:   err.Error(),
+		}
+	}
+	// get general block
+	result := BlockNBTAnalysis{
+		Name:     generalBlock.Name,
+		Type:     IsNBTBlockSupported(generalBlock.Name),
+		Position: position,
+	}
+	result.Lost, err = analyzeBlockNBT(generalBlock, position, 0)
+	switch {
+	case len(result.Type) == 0:
+		result.Support = NBTSupportNone
+		result.Reason = "block entity not supported"
+		result.Lost = nil
+	case err != nil:
+		result.Support = NBTSupportNone
+		result.Reason = err.Error()
+		result.Lost = nil
+	case len(result.Lost) != 0:
+		result.Support = NBTSupportPartial
+	default:
+		result.Support = NBTSupportFull
+	}
+	// classify
+	return result
+	// return
+}
+
+// 分析位于 position 且嵌套深度为 depth 的方块 block ，
+// 并返回导入时将会丢失的数据
+func analyzeBlockNBT(block GeneralBlock, position [3]int32, depth int) ([]string, error) {
+	blockType := IsNBTBlockSupported(block.Name)
+	if len(blockType) == 0 {
+		return nil, nil
+	}
+	blockEntity := BlockEntity{
+		Interface: nil,
+		Block:     block,
+		AdditionalData: BlockAdditionalData{
+			Position: position,
+			Type:     blockType,
+			Settings: &types.MainConfig{AssignNBTData: true},
+			Depth:    depth,
+		},
+	}
+	method := GetPlaceBlockMethod(&blockEntity)
+	err := method.Decode()
+	if err != nil {
+		return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+	}
+	// 解码
+	lost := []string{}
+	handled, ok := analysisHandledBlockKeys[blockType]
+	if ok && handled != nil {
+		keys := []string{}
+		keys = append(keys, handled...)
+		keys = append(keys, analysisIgnoredBlockKeys[""]...)
+		keys = append(keys, analysisIgnoredBlockKeys[blockType]...)
+		if blockType == "Container" {
+			keys = append(keys, SupportContainerPool[block.Name].StorageItemValue)
+		}
+		for _, key := range getUnhandledBlockKeys(block.NBT, keys) {
+			lost = append(lost, fmt.Sprintf("tag %s", key))
+		}
+	}
+	// 未被处理的标签
+	switch got := method.(type) {
+	case *Banner:
+		if len(got.Patterns) > BannerMaxPatterns {
+			lost = append(lost, fmt.Sprintf("patterns beyond %d", BannerMaxPatterns))
+		}
+	case *Container:
+		itemContents, err := got.getContainerContents()
+		if err != nil {
+			return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+		}
+		for key := range got.Contents {
+			itemLost, err := analyzeItemNBT(&got.Contents[key], itemContents[key], depth)
+			if err != nil {
+				return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+			}
+			lost = append(lost, itemLost...)
+		}
+	case *Sign:
+		for key, text := range []SignText{got.FrontText, got.BackText} {
+			side := []string{"front", "back"}[key]
+			if len(text.Text) == 0 || text.SignTextColor == defaultSignTextColor {
+				continue
+			}
+			if dyeColors[nearestDyeColor(text.SignTextColor)].RGB != text.SignTextColor&0xFFFFFF {
+				lost = append(lost, fmt.Sprintf("%s text color (approximated with dye)", side))
+			}
+		}
+	case *Lectern:
+		if got.Book != nil {
+			book, _ := blockEntity.getHeldItem("book")
+			itemLost, err := analyzeItemNBT(got.Book, book, depth)
+			if err != nil {
+				return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+			}
+			lost = append(lost, itemLost...)
+		}
+	case *Jukebox:
+		if got.Record != nil {
+			record, _ := blockEntity.getHeldItem("RecordItem")
+			itemLost, err := analyzeItemNBT(got.Record, record, depth)
+			if err != nil {
+				return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+			}
+			lost = append(lost, itemLost...)
+		}
+	case *BrewingStand:
+		itemContents, err := (&Container{BlockEntity: &blockEntity}).getContainerContents()
+		if err != nil {
+			return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+		}
+		for key := range got.Contents {
+			for _, origin := range itemContents {
+				if slot, _ := origin["Slot"].(byte); slot != got.Contents[key].Item.Basic.Slot {
+					continue
+				}
+				itemLost, err := analyzeItemNBT(&got.Contents[key], origin, depth)
+				if err != nil {
+					return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+				}
+				lost = append(lost, itemLost...)
+				break
+			}
+		}
+		if got.FuelAmount > 0 {
+			lost = append(lost, "fuel level")
+		}
+		if got.CookTime > 0 {
+			lost = append(lost, "brewing progress")
+		}
+	case *MobSpawner:
+		for _, value := range got.Unhandled {
+			lost = append(lost, fmt.Sprintf("spawner setting %s", value))
+		}
+	case *ItemFrame:
+		if got.Item != nil {
+			newPackage := ItemPackage{
+				AdditionalData: ItemAdditionalData{
+					Position: position,
+					Settings: blockEntity.AdditionalData.Settings,
+					Depth:    depth,
+				},
+			}
+			err = newPackage.ParseItemFromNBT(got.Item)
+			if err != nil {
+				return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+			}
+			itemLost, err := analyzeItemNBT(&newPackage, got.Item, depth)
+			if err != nil {
+				return nil, fmt.Errorf("analyzeBlockNBT: %v", err)
+			}
+			lost = append(lost, itemLost...)
+		}
+	}
+	// 特定类型的方块实体
+	return lost, nil
+	// 返回值
+}
+
+// 分析已被解码的物品 item ，其原始数据为 origin 。
+// depth 指代该物品所在容器的嵌套深度。
+// 返回的每项数据均以物品名称作为前缀
+func analyzeItemNBT(item *ItemPackage, origin ItemOrigin, depth int) ([]string, error) {
+	lost := []string{}
+	prefix := fmt.Sprintf("item %s > ", item.Item.Basic.Name)
+	// 初始化
+	if item.AdditionalData.Truncated {
+		lost = append(lost, prefix+fmt.Sprintf("contents (nesting depth limit %d reached)", MaxContainerNestingDepth))
+	}
+	if item.Item.Enhancement != nil && len(item.Item.Enhancement.Lore) != 0 {
+		lost = append(lost, prefix+"lore")
+	}
+	// 超出嵌套深度的子方块及物品描述
+	tag, _ := origin["tag"].(map[string]interface{})
+	if tag == nil {
+		return lost, nil
+	}
+	if item.Item.Custom != nil && item.Item.Custom.SubBlockData != nil {
+		subLost, err := analyzeBlockNBT(
+			GeneralBlock{Name: ItemNameToBlockNamePool[item.Item.Basic.Name], NBT: tag},
+			nestedScratchPosition(item.AdditionalData.Position),
+			depth+1,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("analyzeItemNBT: %v", err)
+		}
+		for _, value := range subLost {
+			lost = append(lost, prefix+value)
+		}
+		return lost, nil
+	}
+	// 子方块
+	if item.AdditionalData.Truncated {
+		return lost, nil
+	}
+	var unhandled []string
+	switch item.AdditionalData.Type {
+	case "Book":
+		unhandled = getUnhandledItemTagKeys(tag, "pages", "title", "author", "generation", "xuid")
+	case "Potion":
+		potion := Potion{ItemPackage: item}
+		if err := potion.Decode(); err != nil {
+			return nil, fmt.Errorf("analyzeItemNBT: %v", err)
+		}
+		unhandled = potion.PotionData.Unhandled
+	case "Firework":
+		firework := Firework{ItemPackage: item}
+		if item.Item.Custom != nil {
+			if err := firework.Decode(); err != nil {
+				return nil, fmt.Errorf("analyzeItemNBT: %v", err)
+			}
+		}
+		if len(firework.FireworkData.Explosions) != 0 {
+			lost = append(lost, prefix+"firework explosions")
+		}
+		if item.Item.Basic.Name != "firework_star" && firework.FireworkData.Flight > 1 {
+			lost = append(lost, prefix+"firework flight")
+		}
+	case "LeatherArmor":
+		armor := LeatherArmor{ItemPackage: item}
+		if err := armor.Decode(); err != nil {
+			return nil, fmt.Errorf("analyzeItemNBT: %v", err)
+		}
+		if armor.HasColor && dyeColors[nearestDyeColor(armor.Color)].RGB != armor.Color&0xFFFFFF {
+			lost = append(lost, prefix+"color (approximated with dye)")
+		}
+	default:
+		unhandled = getUnhandledItemTagKeys(tag)
+	}
+	for _, value := range unhandled {
+		lost = append(lost, prefix+fmt.Sprintf("tag %s", value))
+	}
+	// NBT 物品
+	return lost, nil
+	// 返回值
+}
+
+// 取得 nbt 中除 handled 外的所有标签，
+// 返回的列表已按字典序排序
+func getUnhandledBlockKeys(nbt map[string]interface{}, handled []string) []string {
+	result := []string{}
+	for key := range nbt {
+		found := false
+		for _, value := range handled {
+			if key == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
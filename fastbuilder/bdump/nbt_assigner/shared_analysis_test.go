@@ -0,0 +1,161 @@
+package NBTAssigner
+
+import (
+	"strings"
+	"testing"
+)
+
+// 取自导出结果的各类方块实体，
+// analysisHandledBlockKeys 中的每种方块实体都应当在此列出
+func sampleBlockEntities() map[string]GeneralBlock {
+	general := func(id string, nbt map[string]interface{}) map[string]interface{} {
+		nbt["id"] = id
+		nbt["x"] = int32(3)
+		nbt["y"] = int32(64)
+		nbt["z"] = int32(-5)
+		nbt["isMovable"] = byte(1)
+		return nbt
+	}
+	apple := func() map[string]interface{} {
+		return map[string]interface{}{"Name": "minecraft:apple", "Count": byte(1), "Damage": int16(0), "WasPickedUp": byte(0)}
+	}
+	return map[string]GeneralBlock{
+		"CommandBlock": {Name: "command_block", NBT: general("CommandBlock", map[string]interface{}{
+			"Command":            "say hello",
+			"CustomName":         "greeter",
+			"LastOutput":         "commands.say.success",
+			"TickDelay":          int32(10),
+			"ExecuteOnFirstTick": byte(0),
+			"TrackOutput":        byte(0),
+			"conditionalMode":    byte(1),
+			"auto":               byte(1),
+			"Version":            int32(34),
+			"SuccessCount":       int32(1),
+			"LastExecution":      int64(1234),
+			"powered":            byte(0),
+			"conditionMet":       byte(1),
+			"LPCommandMode":      int32(0),
+			"LPCondionalMode":    byte(0),
+			"LPRedstoneMode":     byte(0),
+		})},
+		"Container": {Name: "undyed_shulker_box", NBT: general("ShulkerBox", map[string]interface{}{
+			"Items":    []interface{}{map[string]interface{}{"Name": "minecraft:apple", "Count": byte(3), "Damage": int16(0), "Slot": byte(0), "WasPickedUp": byte(0)}},
+			"facing":   byte(2),
+			"Findable": byte(0),
+		})},
+		"Sign": {Name: "standing_sign", NBT: general("Sign", map[string]interface{}{
+			"FrontText": map[string]interface{}{"Text": "hello", "SignTextColor": int32(-16777216)},
+			"IsWaxed":   byte(0),
+		})},
+		"NoteBlock": {Name: "noteblock", NBT: general("Music", map[string]interface{}{
+			"note": byte(5),
+		})},
+		"Banner": {Name: "standing_banner", NBT: general("Banner", map[string]interface{}{
+			"Base":     int32(15),
+			"Type":     int32(0),
+			"Patterns": []interface{}{map[string]interface{}{"Color": int32(1), "Pattern": "bo"}},
+		})},
+		"Skull": {Name: "skull", NBT: general("Skull", map[string]interface{}{
+			"SkullType":      byte(1),
+			"Rotation":       float32(45),
+			"MouthMoving":    byte(0),
+			"MouthTickCount": int32(0),
+		})},
+		"FlowerPot": {Name: "flower_pot", NBT: general("FlowerPot", map[string]interface{}{
+			"PlantBlock": map[string]interface{}{"name": "minecraft:red_flower", "states": map[string]interface{}{"flower_type": "poppy"}},
+		})},
+		"Bed": {Name: "bed", NBT: general("Bed", map[string]interface{}{
+			"color": byte(14),
+		})},
+		"ItemFrame": {Name: "frame", NBT: general("ItemFrame", map[string]interface{}{
+			"Item":         apple(),
+			"ItemRotation": float32(90),
+		})},
+		"Lectern": {Name: "lectern", NBT: general("Lectern", map[string]interface{}{
+			"book":       map[string]interface{}{"Name": "minecraft:book", "Count": byte(1), "Damage": int16(0), "WasPickedUp": byte(0)},
+			"page":       int32(1),
+			"totalPages": int32(3),
+			"hasBook":    byte(1),
+		})},
+		"Jukebox": {Name: "jukebox", NBT: general("Jukebox", map[string]interface{}{
+			"RecordItem": map[string]interface{}{"Name": "minecraft:music_disc_cat", "Count": byte(1), "Damage": int16(0), "WasPickedUp": byte(0)},
+		})},
+		"BrewingStand": {Name: "brewing_stand", NBT: general("BrewingStand", map[string]interface{}{
+			"Items":      []interface{}{map[string]interface{}{"Name": "minecraft:nether_wart", "Count": byte(1), "Damage": int16(0), "Slot": byte(0), "WasPickedUp": byte(0)}},
+			"FuelAmount": int16(0),
+			"CookTime":   int16(0),
+			"FuelTotal":  int16(0),
+		})},
+		"MobSpawner":     {Name: "mob_spawner", NBT: sampleMobSpawnerNBT()},
+		"Beacon":         {Name: "beacon", NBT: general("Beacon", map[string]interface{}{"primary": int32(1), "secondary": int32(10)})},
+		"StructureBlock": {Name: "structure_block", NBT: sampleStructureBlockNBT()},
+	}
+}
+
+// 以与导入时相同的方式解码 block ，
+// 并返回所遇到的错误
+func decodeForAnalysis(block GeneralBlock) error {
+	_, err := analyzeBlockNBT(block, [3]int32{3, 64, -5}, 0)
+	if err != nil {
+		return err
+	}
+	if IsNBTBlockSupported(block.Name) == "Container" {
+		// 潜影盒的朝向仅在放置时被读取
+		_, err = (&Container{BlockEntity: &BlockEntity{Block: block}}).getFacingOfShulkerBox()
+	}
+	return err
+}
+
+func TestAnalysisHandledBlockKeysMatchDecoders(t *testing.T) {
+	samples := sampleBlockEntities()
+	for blockType, handled := range analysisHandledBlockKeys {
+		block, ok := samples[blockType]
+		if !ok {
+			t.Errorf("%s: no sample", blockType)
+			continue
+		}
+		if handled == nil {
+			continue
+		}
+		// 所有 NBT 标签均被写入的方块实体无需检查
+		if blockType == "Container" {
+			handled = append(handled[:len(handled):len(handled)], SupportContainerPool[block.Name].StorageItemValue)
+		}
+		// 容器的物品标签因容器而异
+		lost, err := analyzeBlockNBT(block, [3]int32{3, 64, -5}, 0)
+		if err != nil {
+			t.Errorf("%s: %v", blockType, err)
+			continue
+		}
+		for _, value := range lost {
+			if strings.HasPrefix(value, "tag ") {
+				t.Errorf("%s: the sample is reported to lose %s", blockType, value)
+			}
+		}
+		// 样本中的每个标签都应当被处理或忽略
+		listed := map[string]bool{}
+		for _, key := range handled {
+			listed[key] = true
+			if _, ok := block.NBT[key]; !ok {
+				t.Errorf("%s: the sample has no tag %s", blockType, key)
+			}
+		}
+		for key := range block.NBT {
+			nbt := map[string]interface{}{}
+			for k, v := range block.NBT {
+				nbt[k] = v
+			}
+			nbt[key] = struct{}{}
+			// 解码器会拒绝类型错误的标签，
+			// 因此能以此判断该标签是否被解码器读取
+			read := decodeForAnalysis(GeneralBlock{Name: block.Name, NBT: nbt}) != nil
+			switch {
+			case read && !listed[key]:
+				t.Errorf("%s: tag %s is read by the decoder but not listed in analysisHandledBlockKeys", blockType, key)
+			case !read && listed[key]:
+				t.Errorf("%s: tag %s is listed in analysisHandledBlockKeys but not read by the decoder", blockType, key)
+			}
+		}
+		// 比对已列出的标签与解码器实际读取的标签
+	}
+}
@@ -9,6 +9,30 @@ func GetPlaceBlockMethod(block *BlockEntity) GeneralBlockNBT {
 		return &Container{BlockEntity: block}
 	case "Sign":
 		return &Sign{BlockEntity: block}
+	case "NoteBlock":
+		return &NoteBlock{BlockEntity: block}
+	case "Banner":
+		return &Banner{BlockEntity: block}
+	case "Skull":
+		return &Skull{BlockEntity: block}
+	case "FlowerPot":
+		return &FlowerPot{BlockEntity: block}
+	case "Bed":
+		return &Bed{BlockEntity: block}
+	case "ItemFrame":
+		return &ItemFrame{BlockEntity: block}
+	case "Lectern":
+		return &Lectern{BlockEntity: block}
+	case "Jukebox":
+		return &Jukebox{BlockEntity: block}
+	case "BrewingStand":
+		return &BrewingStand{BlockEntity: block}
+	case "MobSpawner":
+		return &MobSpawner{BlockEntity: block}
+	case "Beacon":
+		return &Beacon{BlockEntity: block}
+	case "StructureBlock":
+		return &StructureBlock{BlockEntity: block}
 	default:
 		return &DefaultBlock{BlockEntity: block}
 		// 其他尚且未被支持的方块实体
@@ -20,6 +44,12 @@ func GetGenerateItemMethod(item *ItemPackage) GeneralItemNBT {
 	switch item.AdditionalData.Type {
 	case "Book":
 		return &Book{ItemPackage: item}
+	case "Potion":
+		return &Potion{ItemPackage: item}
+	case "Firework":
+		return &Firework{ItemPackage: item}
+	case "LeatherArmor":
+		return &LeatherArmor{ItemPackage: item}
 	default:
 		return &DefaultItem{ItemPackage: item}
 		// 其他尚且未被支持的 NBT 物品
@@ -21,11 +21,11 @@ var SupportBlocksPool map[string]string = map[string]string{
 	"hopper":             "Container",
 	"dispenser":          "Container",
 	"dropper":            "Container",
-	"jukebox":            "Container",
-	"brewing_stand":      "Container",
+	"jukebox":            "Jukebox",
+	"brewing_stand":      "BrewingStand",
 	"undyed_shulker_box": "Container",
 	"shulker_box":        "Container",
-	"lectern":            "Container",
+	"lectern":            "Lectern",
 	// 容器
 	"standing_sign":         "Sign",
 	"spruce_standing_sign":  "Sign",
@@ -44,6 +44,45 @@ var SupportBlocksPool map[string]string = map[string]string{
 	"crimson_wall_sign":     "Sign",
 	"warped_wall_sign":      "Sign",
 	// 告示牌
+	"mangrove_standing_sign": "Sign",
+	"mangrove_wall_sign":     "Sign",
+	"cherry_standing_sign":   "Sign",
+	"cherry_wall_sign":       "Sign",
+	"bamboo_standing_sign":   "Sign",
+	"bamboo_wall_sign":       "Sign",
+	// 新木材的告示牌
+	"oak_hanging_sign":      "Sign",
+	"spruce_hanging_sign":   "Sign",
+	"birch_hanging_sign":    "Sign",
+	"jungle_hanging_sign":   "Sign",
+	"acacia_hanging_sign":   "Sign",
+	"dark_oak_hanging_sign": "Sign",
+	"crimson_hanging_sign":  "Sign",
+	"warped_hanging_sign":   "Sign",
+	"mangrove_hanging_sign": "Sign",
+	"cherry_hanging_sign":   "Sign",
+	"bamboo_hanging_sign":   "Sign",
+	// 悬挂式告示牌
+	"noteblock": "NoteBlock",
+	// 音符盒
+	"standing_banner": "Banner",
+	"wall_banner":     "Banner",
+	// 旗帜
+	"skull": "Skull",
+	// 头颅
+	"flower_pot": "FlowerPot",
+	// 花盆
+	"bed": "Bed",
+	// 床
+	"frame":      "ItemFrame",
+	"glow_frame": "ItemFrame",
+	// 物品展示框
+	"mob_spawner": "MobSpawner",
+	// 刷怪笼
+	"beacon": "Beacon",
+	// 信标
+	"structure_block": "StructureBlock",
+	// 结构方块
 }
 
 // 此表描述了现阶段已经支持了的特殊物品，如烟花等物品。
@@ -52,6 +91,21 @@ var SupportItemsPool map[string]string = map[string]string{
 	"writable_book": "Book",
 	"written_book":  "Book",
 	// 成书
+	"potion":           "Potion",
+	"splash_potion":    "Potion",
+	"lingering_potion": "Potion",
+	"arrow":            "Potion",
+	// 药水及药箭
+	"firework_rocket": "Firework",
+	"fireworks":       "Firework",
+	"firework_star":   "Firework",
+	// 烟花火箭及烟火之星
+	"leather_helmet":      "LeatherArmor",
+	"leather_chestplate":  "LeatherArmor",
+	"leather_leggings":    "LeatherArmor",
+	"leather_boots":       "LeatherArmor",
+	"leather_horse_armor": "LeatherArmor",
+	// 皮革盔甲
 }
 
 // 此表描述了现阶段已支持的方块实体中，
@@ -90,6 +144,26 @@ var ItemNameToBlockNamePool map[string]string = map[string]string{
 	"crimson_sign": "crimson_wall_sign",
 	"warped_sign":  "warped_wall_sign",
 	// 告示牌
+	"mangrove_sign": "mangrove_wall_sign",
+	"cherry_sign":   "cherry_wall_sign",
+	"bamboo_sign":   "bamboo_wall_sign",
+	// 新木材的告示牌
+	"oak_hanging_sign":      "oak_hanging_sign",
+	"spruce_hanging_sign":   "spruce_hanging_sign",
+	"birch_hanging_sign":    "birch_hanging_sign",
+	"jungle_hanging_sign":   "jungle_hanging_sign",
+	"acacia_hanging_sign":   "acacia_hanging_sign",
+	"dark_oak_hanging_sign": "dark_oak_hanging_sign",
+	"crimson_hanging_sign":  "crimson_hanging_sign",
+	"warped_hanging_sign":   "warped_hanging_sign",
+	"mangrove_hanging_sign": "mangrove_hanging_sign",
+	"cherry_hanging_sign":   "cherry_hanging_sign",
+	"bamboo_hanging_sign":   "bamboo_hanging_sign",
+	// 悬挂式告示牌
+	"mob_spawner":     "mob_spawner",
+	"beacon":          "beacon",
+	"structure_block": "structure_block",
+	// 刷怪笼、信标与结构方块
 }
 
 // 此表描述了可被 replaceitem 生效的容器
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	env_interfaces "phoenixbuilder/fastbuilder/environment/interfaces"
 	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
 	"sync"
 )
 
@@ -35,13 +36,14 @@ func PlaceBlockWithNBTData(
 	newRequest.AdditionalData.Type = IsNBTBlockSupported(newRequest.Block.Name)
 	// get new request of place nbt block
 	var placeBlockMethod GeneralBlockNBT
-	if additionalData.Settings.AssignNBTData || newRequest.AdditionalData.Type == "CommandBlock" {
+	if additionalData.Settings.AssignNBTData || blockInfo.ForceNBTAssignment || newRequest.AdditionalData.Type == "CommandBlock" {
 		placeBlockMethod = GetPlaceBlockMethod(&newRequest)
 		err = placeBlockMethod.Decode()
 		if err != nil {
 			return fmt.Errorf("PlaceBlockWithNBTData: Failed to place the entity block named %v at (%d,%d,%d), and the error log is %v", *blockInfo.Block.Name, blockInfo.Point.X, blockInfo.Point.Y, blockInfo.Point.Z, err)
 		}
 		// if the user wants us to assign NBT data,
+		// or the block requires it (e.g. the note blocks of the music builder),
 		// or the target block is a command block
 	} else {
 		placeBlockMethod = &DefaultBlock{BlockEntity: &newRequest}
@@ -91,6 +93,30 @@ func GenerateItemWithNBTData(
 		return fmt.Errorf("GenerateItemWithNBTData: Failed to generate the NBT item in hotbar %d, and the error log is %v", additionalData.HotBarSlot, err)
 	}
 	// assign nbt data
+	err = SummarizeItemReports([]ItemReport{generateNBTItemMethod.Report()})
+	if err != nil {
+		return fmt.Errorf("GenerateItemWithNBTData: The NBT item in hotbar %d was generated, but %v", additionalData.HotBarSlot, err)
+	}
+	// report lost data
+	return nil
+	// return
+}
+
+// 生成实体并尽可能恢复其 NBT 数据，
+// 这应当在所有方块都被放置后进行
+func SummonEntityWithNBTData(
+	intf env_interfaces.GameInterface,
+	entityInfo *types.Module,
+	additionalData *BlockAdditionalData,
+) error {
+	defer interfaceLock.Unlock()
+	interfaceLock.Lock()
+	// lock(or unlock) api
+	err := summonEntity(intf.(*GameInterface.GameInterface), entityInfo, additionalData.FastMode)
+	if err != nil {
+		return fmt.Errorf("SummonEntityWithNBTData: Failed to summon the entity %v at (%d,%d,%d), and the error log is %v", entityInfo.Entity.Identifier, entityInfo.Point.X, entityInfo.Point.Y, entityInfo.Point.Z, err)
+	}
+	// summon entity
 	return nil
 	// return
 }
@@ -0,0 +1,213 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/types"
+	MockServer "phoenixbuilder/game_control/mock_server"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// 匹配结构命令中随机生成的结构名称
+var structureNamePattern = regexp.MustCompile(`structure (save|load|delete) "([^"]*)"`)
+
+// 启动模拟服务器，并返回已连接到它的客户端
+func connectMockServer(t *testing.T) (*MockServer.Server, *MockServer.Client) {
+	t.Helper()
+	server, err := MockServer.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	client, err := server.Connect()
+	if err != nil {
+		server.Close()
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return server, client
+}
+
+// 以便于比较的形式描述数据包 pk
+func describePacket(pk packet.Packet) string {
+	switch p := pk.(type) {
+	case *packet.CommandRequest:
+		return "command " + p.CommandLine
+	case *packet.SettingsCommand:
+		return "settings " + p.CommandLine
+	case *packet.CommandBlockUpdate:
+		return fmt.Sprintf("command block %v mode=%d command=%q", p.Position, p.Mode, p.Command)
+	case *packet.BlockActorData:
+		return fmt.Sprintf("block actor data %v", p.Position)
+	}
+	return fmt.Sprintf("%T", pk)
+}
+
+// 在模拟服务器上放置 block 并写入其 NBT 数据，
+// 并返回服务器按顺序收到的数据包
+func placeOnMockServer(t *testing.T, block *types.Module) []string {
+	t.Helper()
+	return placeOnMockServerWithSettings(t, block, &types.MainConfig{AssignNBTData: true})
+}
+
+// 以 settings 在模拟服务器上放置 block ，
+// 并返回服务器按顺序收到的数据包
+func placeOnMockServerWithSettings(t *testing.T, block *types.Module, settings *types.MainConfig) []string {
+	t.Helper()
+	server, client := connectMockServer(t)
+	server.Reset()
+	err := PlaceBlockWithNBTData(
+		client.GameInterface,
+		block,
+		&BlockAdditionalData{Settings: settings},
+	)
+	if err != nil {
+		t.Fatalf("PlaceBlockWithNBTData: %v", err)
+	}
+	err = client.GameInterface.AwaitChangesGeneral()
+	if err != nil {
+		t.Fatalf("AwaitChangesGeneral: %v", err)
+	}
+	// 数据包按顺序处理，因此在此之前发送的数据包都已被服务器收到
+	result := []string{}
+	names := map[string]string{}
+	for _, value := range server.Packets() {
+		description := structureNamePattern.ReplaceAllStringFunc(describePacket(value), func(command string) string {
+			match := structureNamePattern.FindStringSubmatch(command)
+			if _, ok := names[match[2]]; !ok {
+				names[match[2]] = fmt.Sprintf("#%d", len(names)+1)
+			}
+			return fmt.Sprintf("structure %s %s", match[1], names[match[2]])
+		})
+		result = append(result, description)
+	}
+	// 结构名称是随机生成的，
+	// 因此按照其首次出现的顺序将其替换为 #1 、 #2 等
+	return result
+}
+
+// 比较 got 与 want 是否一致
+func compareSequence(t *testing.T, got []string, want []string) {
+	t.Helper()
+	if reflect.DeepEqual(got, want) {
+		return
+	}
+	t.Errorf("got %d packets, want %d", len(got), len(want))
+	for key, value := range got {
+		t.Logf("got[%d] = %s", key, value)
+	}
+}
+
+// 构造一个位于 pos 的方块
+func mockModule(name string, states string, pos types.Position, nbt map[string]interface{}) *types.Module {
+	return &types.Module{
+		Block:  &types.Block{Name: &name, BlockStates: states},
+		NBTMap: nbt,
+		Point:  pos,
+	}
+}
+
+func TestPlaceCommandBlockSequence(t *testing.T) {
+	got := placeOnMockServer(t, mockModule(
+		"repeating_command_block",
+		`["conditional_bit":false,"facing_direction":1]`,
+		types.Position{X: 1, Y: 2, Z: 3},
+		map[string]interface{}{
+			"id":                 "CommandBlock",
+			"Command":            "say hi",
+			"CustomName":         "",
+			"LastOutput":         "",
+			"TickDelay":          int32(0),
+			"ExecuteOnFirstTick": byte(1),
+			"TrackOutput":        byte(1),
+			"conditionalMode":    byte(0),
+			"auto":               byte(1),
+			"Version":            int32(36),
+		},
+	))
+	compareSequence(t, got, []string{
+		`command setblock 1 2 3 repeating_command_block ["conditional_bit":false,"facing_direction":1] `,
+		`settings execute @a[name="MockBot"] ~ ~ ~ tp 1 2 3`,
+		`command block [1 2 3] mode=1 command="say hi"`,
+	})
+}
+
+func TestPlaceSignSequence(t *testing.T) {
+	got := placeOnMockServer(t, mockModule(
+		"standing_sign",
+		`["ground_sign_direction":0]`,
+		types.Position{X: 4, Y: 5, Z: 6},
+		map[string]interface{}{
+			"id":   "Sign",
+			"Text": "hello",
+		},
+	))
+	compareSequence(t, got, []string{
+		`settings execute @a[name="MockBot"] ~ ~ ~ tp 4 5 6`,
+		`settings execute @a[name="MockBot"] ~ ~ ~ setblock 4 5 6 air`,
+		`settings execute @a[name="MockBot"] ~ ~ ~ replaceitem entity @s slot.hotbar 0 oak_sign`,
+		`*packet.PlayerHotBar`,
+		`command structure save #1 5 5 6 5 5 6`,
+		`settings execute @a[name="MockBot"] ~ ~ ~ setblock 5 5 6 minecraft:emerald_block`,
+		`*packet.PlayerAction`,
+		`*packet.InventoryTransaction`,
+		`*packet.PlayerAction`,
+		`settings execute @a[name="MockBot"] ~ ~ ~ setblock 4 5 6 standing_sign ["ground_sign_direction":0] `,
+		`block actor data [4 5 6]`,
+		`command structure save #2 4 5 6 4 5 6`,
+		`command structure load #1 5 5 6`,
+		`settings structure delete #1`,
+		`command structure load #2 4 5 6`,
+		`settings structure delete #2`,
+	})
+}
+
+func TestPlaceContainerSequence(t *testing.T) {
+	got := placeOnMockServer(t, mockModule(
+		"chest",
+		`["facing_direction":2]`,
+		types.Position{X: 7, Y: 8, Z: 9},
+		map[string]interface{}{
+			"id": "Chest",
+			"Items": []interface{}{
+				map[string]interface{}{"Name": "minecraft:stone", "Count": byte(16), "Damage": int16(0), "Slot": byte(0)},
+				map[string]interface{}{"Name": "minecraft:apple", "Count": byte(3), "Damage": int16(0), "Slot": byte(5)},
+			},
+		},
+	))
+	compareSequence(t, got, []string{
+		`command setblock 7 8 9 chest ["facing_direction":2] `,
+		`settings execute @a[name="MockBot"] ~ ~ ~ clear`,
+		`settings execute @a[name="MockBot"] ~ ~ ~ replaceitem block 7 8 9 slot.container 0 stone 16 0`,
+		`settings execute @a[name="MockBot"] ~ ~ ~ replaceitem block 7 8 9 slot.container 5 apple 3 0`,
+	})
+}
+
+func TestPlaceNoteBlockSequence(t *testing.T) {
+	noteBlock := func(force bool) *types.Module {
+		block := mockModule("noteblock", "[]", types.Position{X: 1, Y: 2, Z: 3}, map[string]interface{}{"note": byte(2)})
+		block.ForceNBTAssignment = force
+		return block
+	}
+	// 未指定 -nbt 时，导入的音符盒只被放置
+	got := placeOnMockServerWithSettings(t, noteBlock(false), &types.MainConfig{})
+	compareSequence(t, got, []string{
+		`settings execute @a[name="MockBot"] ~ ~ ~ setblock 1 2 3 noteblock [] `,
+	})
+	// 由音乐构建器生成的音符盒总是需要调整音高
+	got = placeOnMockServerWithSettings(t, noteBlock(true), &types.MainConfig{})
+	compareSequence(t, got, []string{
+		`command setblock 1 2 3 noteblock [] `,
+		`settings execute @a[name="MockBot"] ~ ~ ~ tp 1 3 3`,
+		`settings execute @a[name="MockBot"] ~ ~ ~ replaceitem entity @s slot.hotbar 0 air`,
+		"*packet.PlayerHotBar",
+		"*packet.InventoryTransaction",
+		"*packet.PlayerAction",
+		"*packet.InventoryTransaction",
+		"*packet.PlayerAction",
+	})
+}
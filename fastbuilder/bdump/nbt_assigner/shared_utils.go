@@ -173,3 +173,12 @@ func (i *ItemPackage) ParseItemFromNBT(singleItem ItemOrigin) error {
 	return nil
 	// return
 }
+
+// 取得 i.Item.Custom.ItemTag 。
+// 如果该物品没有自定义数据，则返回 nil
+func (i *ItemPackage) getItemTag() ItemOrigin {
+	if i.Item.Custom == nil {
+		return nil
+	}
+	return i.Item.Custom.ItemTag
+}
@@ -0,0 +1,379 @@
+package NBTAssigner
+
+import (
+	"fmt"
+	"math"
+	"phoenixbuilder/fastbuilder/commands_generator"
+	"phoenixbuilder/fastbuilder/mcstructure"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"strings"
+)
+
+// 盔甲架 Armor 列表中各项所对应的槽位
+var armorStandArmorSlots = []string{
+	"slot.armor.head",
+	"slot.armor.chest",
+	"slot.armor.legs",
+	"slot.armor.feet",
+}
+
+// 实体的手持物品列表及其对应的槽位
+var entityHandSlots = map[string]string{
+	"Mainhand": "slot.weapon.mainhand",
+	"Offhand":  "slot.weapon.offhand",
+}
+
+// 画的 Direction 所对应的被点击方块的面。
+// 0 为南，1 为西，2 为北，3 为东
+var paintingDirectionToFace = map[byte]int32{
+	0: 3,
+	1: 4,
+	2: 2,
+	3: 5,
+}
+
+// 画的图案由游戏随机选择，
+// 此常量描述了为得到正确的图案最多尝试放置画的次数
+const PaintingMaxAttempts = 8
+
+/*
+生成 module 所指代的实体并尽可能恢复其数据。
+
+画无法通过命令生成，因此会使用画物品放置；
+其余实体通过 summon 命令生成，
+然后恢复其朝向、装备以及盔甲架的姿势。
+
+无法恢复的数据 (例如拴绳和装备的附魔) 会以错误的形式报告，
+但此时实体本身已经被生成
+*/
+func summonEntity(api *GameInterface.GameInterface, module *types.Module, fastMode bool) error {
+	entity := module.Entity
+	if entity.Identifier == "minecraft:painting" {
+		err := placePainting(api, module, fastMode)
+		if err != nil {
+			return fmt.Errorf("summonEntity: %v", err)
+		}
+		return nil
+	}
+	// 画
+	lost := make([]string, 0)
+	x, y, z := commands_generator.EntityPosition(module)
+	err := api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", module.Point.X, module.Point.Y+2, module.Point.Z), true)
+	if err != nil {
+		return fmt.Errorf("summonEntity: %v", err)
+	}
+	err = api.SendSettingsCommand(commands_generator.SummonRequest(module, nil), true)
+	if err != nil {
+		return fmt.Errorf("summonEntity: %v", err)
+	}
+	err = api.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("summonEntity: %v", err)
+	}
+	// 生成实体
+	selector := commands_generator.EntitySelector(module)
+	if rotation, ok := entity.NBT["Rotation"].([]interface{}); ok && len(rotation) == 2 {
+		yaw, _ := rotation[0].(float32)
+		pitch, _ := rotation[1].(float32)
+		err = api.SendSettingsCommand(fmt.Sprintf("tp %s %.4f %.4f %.4f %g %g", selector, x, y, z, yaw, pitch), true)
+		if err != nil {
+			return fmt.Errorf("summonEntity: %v", err)
+		}
+	}
+	// 恢复朝向
+	dropped, err := equipEntity(api, module, selector)
+	if err != nil {
+		return fmt.Errorf("summonEntity: %v", err)
+	}
+	lost = append(lost, dropped...)
+	// 恢复装备
+	if pose, ok := entity.NBT["Pose"].(map[string]interface{}); ok && !fastMode {
+		poseIndex, _ := pose["PoseIndex"].(int32)
+		if poseIndex > 0 {
+			err = setArmorStandPose(api, module, poseIndex)
+			if err != nil {
+				return fmt.Errorf("summonEntity: %v", err)
+			}
+		}
+	}
+	// 恢复盔甲架的姿势
+	if leasherID, ok := entity.NBT["LeasherID"].(int64); ok && leasherID != -1 {
+		lost = append(lost, "lead")
+	}
+	// 拴绳无法通过命令恢复
+	if len(lost) != 0 {
+		return fmt.Errorf("summonEntity: %s at (%.2f,%.2f,%.2f) was summoned, but the following data could not be restored: %s", entity.Identifier, x, y, z, strings.Join(lost, ", "))
+	}
+	return nil
+	// 返回值
+}
+
+/*
+使用 replaceitem 命令为 selector 所指代的实体恢复装备及手持物品。
+
+replaceitem 只能给予物品的名称、数量和数据值，
+因此带有附魔、自定义名称等 NBT 数据的物品会被记录在返回的列表中
+*/
+func equipEntity(api *GameInterface.GameInterface, module *types.Module, selector string) ([]string, error) {
+	lost := make([]string, 0)
+	equip := func(item interface{}, slot string) error {
+		origin, normal := item.(map[string]interface{})
+		if !normal {
+			return fmt.Errorf("Crashed at %s; item = %#v", slot, item)
+		}
+		if name, _ := origin["Name"].(string); len(name) == 0 {
+			return nil
+		}
+		var general GeneralItem
+		err := general.DecodeItemBasicData(origin)
+		if err != nil {
+			return err
+		}
+		if general.Basic.Count == 0 {
+			return nil
+		}
+		err = api.ReplaceItemInInventory(
+			selector,
+			GameInterface.ItemGenerateLocation{Path: slot, Slot: 0},
+			types.ChestSlot{
+				Name:   general.Basic.Name,
+				Count:  general.Basic.Count,
+				Damage: general.Basic.MetaData,
+			},
+			"",
+			true,
+		)
+		if err != nil {
+			return err
+		}
+		if _, ok := origin["tag"]; ok {
+			lost = append(lost, fmt.Sprintf("NBT of %s", general.Basic.Name))
+		}
+		return nil
+	}
+	// 在 slot 处装备 item
+	if armor, ok := module.Entity.NBT["Armor"].([]interface{}); ok {
+		for key, value := range armor {
+			if key >= len(armorStandArmorSlots) {
+				break
+			}
+			err := equip(value, armorStandArmorSlots[key])
+			if err != nil {
+				return nil, fmt.Errorf("equipEntity: %v", err)
+			}
+		}
+	}
+	// 盔甲
+	for key, slot := range entityHandSlots {
+		items, ok := module.Entity.NBT[key].([]interface{})
+		if !ok || len(items) == 0 {
+			continue
+		}
+		err := equip(items[0], slot)
+		if err != nil {
+			return nil, fmt.Errorf("equipEntity: %v", err)
+		}
+	}
+	// 手持物品
+	return lost, nil
+	// 返回值
+}
+
+/*
+通过红石信号恢复盔甲架的姿势。
+
+盔甲架在受到强度为 N 的红石信号时会切换到第 N 种姿势，
+因此这里在盔甲架所在的方块处沿 +X 方向临时铺设一条红石线路，
+使盔甲架处的红石粉的信号强度恰好为 poseIndex ，
+完成后再恢复这些方块。
+
+备份时不包括实体，以防止附近已生成的实体被复制
+*/
+func setArmorStandPose(api *GameInterface.GameInterface, module *types.Module, poseIndex int32) error {
+	x, y, z := int32(module.Point.X), int32(module.Point.Y), int32(module.Point.Z)
+	length := 16 - poseIndex
+	// 红石块距离盔甲架 length 格时，
+	// 盔甲架处的红石粉的信号强度为 16 - length
+	uniqueId, err := api.BackupBlocks(GameInterface.MCStructure{
+		BeginX: x,
+		BeginY: y - 1,
+		BeginZ: z,
+		SizeX:  length + 1,
+		SizeY:  2,
+		SizeZ:  1,
+	})
+	if err != nil {
+		return fmt.Errorf("setArmorStandPose: %v", err)
+	}
+	// 备份被占用的方块
+	commands := []string{
+		fmt.Sprintf("fill %d %d %d %d %d %d stone", x, y-1, z, x+length, y-1, z),
+		fmt.Sprintf("fill %d %d %d %d %d %d redstone_wire", x, y, z, x+length-1, y, z),
+		fmt.Sprintf("setblock %d %d %d redstone_block", x+length, y, z),
+	}
+	for _, command := range commands {
+		err = api.SendSettingsCommand(command, true)
+		if err != nil {
+			return fmt.Errorf("setArmorStandPose: %v", err)
+		}
+	}
+	err = api.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("setArmorStandPose: %v", err)
+	}
+	// 铺设红石线路
+	err = api.RevertStructure(uniqueId, GameInterface.BlockPos{x, y - 1, z})
+	if err != nil {
+		return fmt.Errorf("setArmorStandPose: %v", err)
+	}
+	// 恢复方块
+	return nil
+	// 返回值
+}
+
+/*
+使用画物品在 module 所指代的位置放置画。
+
+被点击的方块处会临时生成 PlaceBlockBase 作为依附方块。
+画的图案由游戏从能够放下的图案中随机选择，
+因此放置后会检查图案是否正确，
+不正确时会移除它并重试，最多尝试 PaintingMaxAttempts 次。
+快速导入模式下只放置一次且不检查图案
+*/
+func placePainting(api *GameInterface.GameInterface, module *types.Module, fastMode bool) error {
+	motive, _ := module.Entity.NBT["Motive"].(string)
+	direction, _ := module.Entity.NBT["Direction"].(byte)
+	face, ok := paintingDirectionToFace[direction]
+	if !ok {
+		return fmt.Errorf("placePainting: Unknown direction %d", direction)
+	}
+	offset := blockFaceOffset[face]
+	pos := [3]int32{int32(module.Point.X), int32(module.Point.Y), int32(module.Point.Z)}
+	wall := [3]int32{pos[0] - offset[0], pos[1] - offset[1], pos[2] - offset[2]}
+	// 取得画所依附的方块的位置
+	err := generateItemInHotbar(api, 0, types.ChestSlot{Name: "painting", Count: 1})
+	if err != nil {
+		return fmt.Errorf("placePainting: %v", err)
+	}
+	err = api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0]+2*offset[0], pos[1], pos[2]+2*offset[2]), true)
+	if err != nil {
+		return fmt.Errorf("placePainting: %v", err)
+	}
+	err = api.ChangeSelectedHotbarSlot(0)
+	if err != nil {
+		return fmt.Errorf("placePainting: %v", err)
+	}
+	// 获取画到快捷栏 0 ，并传送机器人到画的前方
+	x, y, z := commands_generator.EntityPosition(module)
+	got := ""
+	for attempt := 0; attempt < PaintingMaxAttempts; attempt++ {
+		err = api.SendSettingsCommand(fmt.Sprintf("kill @e[type=minecraft:painting,x=%.4f,y=%.4f,z=%.4f,r=0.5]", x, y, z), true)
+		if err != nil {
+			return fmt.Errorf("placePainting: %v", err)
+		}
+		// 移除上一次放置的画
+		uniqueId, err := api.BackupBlocks(GameInterface.MCStructure{
+			BeginX: wall[0],
+			BeginY: wall[1],
+			BeginZ: wall[2],
+			SizeX:  1,
+			SizeY:  1,
+			SizeZ:  1,
+		})
+		if err != nil {
+			return fmt.Errorf("placePainting: %v", err)
+		}
+		err = api.SetBlock(wall, GameInterface.PlaceBlockBase, "[]")
+		if err != nil {
+			return fmt.Errorf("placePainting: %v", err)
+		}
+		err = api.PlaceBlock(
+			GameInterface.UseItemOnBlocks{
+				HotbarSlotID: 0,
+				BlockPos:     wall,
+				BlockName:    GameInterface.PlaceBlockBase,
+				BlockStates:  map[string]interface{}{},
+			},
+			face,
+		)
+		if err != nil {
+			return fmt.Errorf("placePainting: %v", err)
+		}
+		err = api.AwaitChangesGeneral()
+		if err != nil {
+			return fmt.Errorf("placePainting: %v", err)
+		}
+		err = api.RevertStructure(uniqueId, GameInterface.BlockPos{wall[0], wall[1], wall[2]})
+		if err != nil {
+			return fmt.Errorf("placePainting: %v", err)
+		}
+		// 放置画，然后恢复依附方块
+		if fastMode || len(motive) == 0 {
+			return nil
+		}
+		got, err = readPaintingMotive(api, module)
+		if err != nil {
+			return fmt.Errorf("placePainting: %v", err)
+		}
+		if got == motive {
+			return nil
+		}
+		// 检查图案
+	}
+	return fmt.Errorf("placePainting: The painting at (%d,%d,%d) should be %s, but it is %#v after %d attempts", pos[0], pos[1], pos[2], motive, got, PaintingMaxAttempts)
+	// 返回值
+}
+
+// 取得 module 所指代的位置附近的画的图案。
+// 找不到画时返回空字符串
+func readPaintingMotive(api *GameInterface.GameInterface, module *types.Module) (string, error) {
+	const radius = 2
+	holder := api.Resources.Structure.Occupy()
+	resp, err := api.SendStructureRequestWithResponse(
+		&packet.StructureTemplateDataRequest{
+			StructureName: "mystructure:painting",
+			Position:      protocol.BlockPos{int32(module.Point.X) - radius, int32(module.Point.Y) - radius, int32(module.Point.Z) - radius},
+			Settings: protocol.StructureSettings{
+				PaletteName:               "default",
+				IgnoreEntities:            false,
+				IgnoreBlocks:              true,
+				Size:                      protocol.BlockPos{2*radius + 1, 2*radius + 1, 2*radius + 1},
+				Offset:                    protocol.BlockPos{0, 0, 0},
+				LastEditingPlayerUniqueID: api.ClientInfo.EntityUniqueID,
+				Integrity:                 100,
+			},
+			RequestType: packet.StructureTemplateRequestExportFromSave,
+		},
+	)
+	api.Resources.Structure.Release(holder)
+	if err != nil {
+		return "", fmt.Errorf("readPaintingMotive: %v", err)
+	}
+	// 获取画附近的实体
+	entities, err := mcstructure.GetStructureEntities(resp.StructureTemplate)
+	if err != nil {
+		return "", fmt.Errorf("readPaintingMotive: %v", err)
+	}
+	x, y, z := commands_generator.EntityPosition(module)
+	motive, nearest := "", math.Inf(1)
+	for _, entity := range entities {
+		if identifier, _ := entity["identifier"].(string); identifier != "minecraft:painting" {
+			continue
+		}
+		pos, err := mcstructure.GetEntityPosition(entity)
+		if err != nil {
+			return "", fmt.Errorf("readPaintingMotive: %v", err)
+		}
+		distance := math.Abs(float64(pos[0])-x) + math.Abs(float64(pos[1])-y) + math.Abs(float64(pos[2])-z)
+		if distance < nearest {
+			motive, _ = entity["Motive"].(string)
+			nearest = distance
+		}
+	}
+	// 取得距离最近的画的图案
+	return motive, nil
+	// 返回值
+}
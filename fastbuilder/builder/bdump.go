@@ -86,6 +86,8 @@ func BDump(config *types.MainConfig, blc chan *types.Module) error {
 	brushPosition := []int{0, 0, 0}
 	var blocksStrPool []string
 	var runtimeIdPoolUsing []*types.ConstBlock
+	// 实体将在所有方块被放置后再生成
+	var entities []*types.Module
 	for {
 		_cmd, err := command.ReadCommand(br)
 		if err != nil {
@@ -391,10 +393,29 @@ func BDump(config *types.MainConfig, blc chan *types.Module) error {
 					Z: brushPosition[2] + config.Position.Z,
 				},
 			}
+		case *command.PlaceEntityWithNBTData:
+			if int(cmd.EntityConstantStringID) >= len(blocksStrPool) {
+				return fmt.Errorf("Error: EntityID exceeded StringPool")
+			}
+			entities = append(entities, &types.Module{
+				Entity: &types.Entity{
+					Identifier: blocksStrPool[int(cmd.EntityConstantStringID)],
+					Offset:     cmd.Offset,
+					NBT:        cmd.EntityNBT,
+				},
+				Point: types.Position{
+					X: brushPosition[0] + config.Position.X,
+					Y: brushPosition[1] + config.Position.Y,
+					Z: brushPosition[2] + config.Position.Z,
+				},
+			})
 		default:
 			fmt.Printf("WARNING: BDump/Import: Unknown method found: %#v\n\n", _cmd)
 			fmt.Printf("WARNING: BDump/Import: THIS IS A BUG\n")
 		}
 	}
+	for _, entity := range entities {
+		blc <- entity
+	}
 	return nil
 }
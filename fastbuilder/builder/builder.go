@@ -17,6 +17,10 @@ var Builder = map[string]func(config *types.MainConfig, blc chan *types.Module)
 	"acme":      Acme,
 	"bdump":     BDump,
 	"mapart":    MapArt,
+	"litematic": Litematic,
+	"javastructure": JavaStructure,
+	"music":     Music,
+	"cbcompile": CommandBlockCompile,
 }
 
 func Generate(config *types.MainConfig, blc chan *types.Module) error {
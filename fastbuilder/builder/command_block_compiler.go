@@ -0,0 +1,350 @@
+package builder
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"phoenixbuilder/fastbuilder/types"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 未指定宽度 (-w) 时每行所放置的命令方块数
+const CommandBlockDefaultWidth = 16
+
+// 命令方块数据值中表示条件模式的位
+const commandBlockConditionalBit = 8
+
+// 命令方块的 facing_direction
+const (
+	commandBlockFacingSouth = 3 // +Z
+	commandBlockFacingWest  = 4 // -X
+	commandBlockFacingEast  = 5 // +X
+)
+
+// 命令中形如 ${label} 的标签引用
+var commandBlockLabelPattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+/*
+描述命令方块程序中的单个命令方块。
+
+程序文件的格式如下
+
+	# 以 # 或 // 开头的行是注释
+	section timer repeat auto
+	scoreboard players add @a timer 1
+	[conditional delay=20 name=Hint label=hint] say ${timer}
+
+每个 section 都是一条独立的命令链，
+其后可以指定首个命令方块的类型 (impulse 或 repeat ，默认为 impulse)
+以及是否需要红石激活 (redstone 或 auto ，
+脉冲型默认需要红石，循环型默认保持开启)。
+section 中的其余命令方块均为连锁型且保持开启。
+
+命令前可以用方括号指定以下选项
+
+	conditional (或 ?)  条件模式
+	delay=N            延迟 N 刻
+	name=...           自定义名称
+	label=...          标签，section 的名称同时也是其首个命令方块的标签
+	nooutput           不记录命令输出
+	impulse/repeat/chain, redstone/auto  覆盖命令方块的类型与激活方式
+
+命令中的 ${label} 会被替换为对应命令方块的绝对坐标
+*/
+type commandBlockInstruction struct {
+	// 所在的行号，用于报告错误
+	Line int
+	// 所属 section 的名称
+	Section string
+	// 标签
+	Label string
+	// 命令方块数据
+	Data types.CommandBlockData
+	// 命令方块的绝对坐标
+	Position types.Position
+	// 命令方块的朝向
+	Facing uint16
+}
+
+// 描述程序中的一个 section
+type commandBlockSection struct {
+	Name         string
+	Instructions []*commandBlockInstruction
+	// section 头部所在的行号
+	Line int
+}
+
+// 解析方括号内的选项并将其应用于 instruction
+func applyCommandBlockOptions(instruction *commandBlockInstruction, options string) error {
+	for _, option := range strings.Fields(options) {
+		key, value, hasValue := strings.Cut(option, "=")
+		switch strings.ToLower(key) {
+		case "conditional", "?":
+			instruction.Data.Conditional = true
+		case "nooutput":
+			instruction.Data.TrackOutput = false
+		case "impulse":
+			instruction.Data.Mode = packet.CommandBlockImpulse
+		case "repeat":
+			instruction.Data.Mode = packet.CommandBlockRepeating
+		case "chain":
+			instruction.Data.Mode = packet.CommandBlockChain
+		case "redstone":
+			instruction.Data.NeedsRedstone = true
+		case "auto":
+			instruction.Data.NeedsRedstone = false
+		case "delay":
+			delay, err := strconv.Atoi(value)
+			if !hasValue || err != nil || delay < 0 {
+				return fmt.Errorf("applyCommandBlockOptions: Invalid delay %#v", value)
+			}
+			instruction.Data.TickDelay = int32(delay)
+		case "name":
+			instruction.Data.CustomName = value
+		case "label":
+			if len(value) == 0 {
+				return fmt.Errorf("applyCommandBlockOptions: Empty label")
+			}
+			instruction.Label = value
+		default:
+			return fmt.Errorf("applyCommandBlockOptions: Unknown option %#v", option)
+		}
+	}
+	return nil
+}
+
+// 读取 path 处的命令方块程序
+func readCommandBlockProgram(path string) ([]*commandBlockSection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("readCommandBlockProgram: %v", err)
+	}
+	defer file.Close()
+	sections := make([]*commandBlockSection, 0)
+	labels := map[string]int{}
+	var current *commandBlockSection
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	// 没有命令的 section 将被拒绝，
+	// 否则其头部会被放置为一个空的命令方块
+	checkSection := func() error {
+		if current != nil && current.Instructions[0].Line == 0 {
+			return fmt.Errorf("readCommandBlockProgram: Line %d: Section %#v has no commands", current.Line, current.Name)
+		}
+		return nil
+	}
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		// 跳过空行与注释
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		// section 的头部，其选项将被应用于首个命令方块
+		if fields := strings.Fields(line); fields[0] == "section" {
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("readCommandBlockProgram: Line %d: Section has no name", lineNumber)
+			}
+			if err := checkSection(); err != nil {
+				return nil, err
+			}
+			current = &commandBlockSection{Name: fields[1], Line: lineNumber}
+			sections = append(sections, current)
+			header := &commandBlockInstruction{
+				Label: current.Name,
+				Data: types.CommandBlockData{
+					Mode:               packet.CommandBlockImpulse,
+					ExecuteOnFirstTick: true,
+					TrackOutput:        true,
+					NeedsRedstone:      true,
+				},
+			}
+			for _, option := range fields[2:] {
+				if option == "repeat" {
+					header.Data.NeedsRedstone = false
+				}
+			}
+			err := applyCommandBlockOptions(header, strings.Join(fields[2:], " "))
+			if err != nil {
+				return nil, fmt.Errorf("readCommandBlockProgram: Line %d: %v", lineNumber, err)
+			}
+			current.Instructions = append(current.Instructions, header)
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("readCommandBlockProgram: Line %d: Command outside of any section", lineNumber)
+		}
+		instruction := &commandBlockInstruction{
+			Data: types.CommandBlockData{
+				Mode:               packet.CommandBlockChain,
+				ExecuteOnFirstTick: true,
+				TrackOutput:        true,
+			},
+		}
+		// section 的首个命令沿用头部的设置
+		if len(current.Instructions) == 1 && current.Instructions[0].Line == 0 {
+			instruction = current.Instructions[0]
+		} else {
+			current.Instructions = append(current.Instructions, instruction)
+		}
+		instruction.Line = lineNumber
+		instruction.Section = current.Name
+		// 解析选项与命令
+		if strings.HasPrefix(line, "?") {
+			instruction.Data.Conditional = true
+			line = strings.TrimSpace(line[1:])
+		}
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("readCommandBlockProgram: Line %d: Unclosed options", lineNumber)
+			}
+			err := applyCommandBlockOptions(instruction, line[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("readCommandBlockProgram: Line %d: %v", lineNumber, err)
+			}
+			line = strings.TrimSpace(line[end+1:])
+		}
+		instruction.Data.Command = strings.TrimPrefix(line, "/")
+		// 检查标签是否重复
+		if len(instruction.Label) != 0 {
+			if previous, ok := labels[instruction.Label]; ok {
+				return nil, fmt.Errorf("readCommandBlockProgram: Line %d: Label %#v is already defined at line %d", lineNumber, instruction.Label, previous)
+			}
+			labels[instruction.Label] = lineNumber
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("readCommandBlockProgram: %v", err)
+	}
+	if err := checkSection(); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+/*
+以蛇形布局放置 sections 中的所有命令方块。
+
+每个 section 从新的一行开始，
+奇数行沿 +X 方向延伸，偶数行沿 -X 方向延伸，
+行末的命令方块朝向 +Z 以连接到下一行。
+当前层的行数 (-l) 不足以容纳整个 section 时，
+该 section 将被放置到上一层
+*/
+func layoutCommandBlockProgram(config *types.MainConfig, sections []*commandBlockSection) error {
+	width := config.Width
+	if width <= 0 {
+		width = CommandBlockDefaultWidth
+	}
+	row, layer := 0, 0
+	for _, section := range sections {
+		// 确定该 section 的起始行与层
+		rows := (len(section.Instructions) + width - 1) / width
+		if config.Length > 0 && rows > config.Length {
+			return fmt.Errorf("layoutCommandBlockProgram: Section %#v needs %d rows, but the volume only has %d", section.Name, rows, config.Length)
+		}
+		if config.Length > 0 && row+rows > config.Length {
+			row, layer = 0, layer+1
+		}
+		if config.Height > 0 && layer >= config.Height {
+			return fmt.Errorf("layoutCommandBlockProgram: The program does not fit into the volume %dx%dx%d", width, config.Length, config.Height)
+		}
+		for index, instruction := range section.Instructions {
+			currentRow, column := index/width, index%width
+			x := column
+			facing := uint16(commandBlockFacingEast)
+			if currentRow%2 == 1 {
+				x = width - 1 - column
+				facing = commandBlockFacingWest
+			}
+			if column == width-1 && index != len(section.Instructions)-1 {
+				facing = commandBlockFacingSouth
+			}
+			instruction.Facing = facing
+			instruction.Position = types.Position{
+				X: config.Position.X + x,
+				Y: config.Position.Y + layer,
+				Z: config.Position.Z + row + currentRow,
+			}
+		}
+		row += rows
+	}
+	return nil
+}
+
+// 将命令中的 ${label} 替换为对应命令方块的坐标
+func resolveCommandBlockLabels(sections []*commandBlockSection) error {
+	positions := map[string]types.Position{}
+	for _, section := range sections {
+		for _, instruction := range section.Instructions {
+			if len(instruction.Label) != 0 {
+				positions[instruction.Label] = instruction.Position
+			}
+		}
+	}
+	for _, section := range sections {
+		for _, instruction := range section.Instructions {
+			var unknown string
+			instruction.Data.Command = commandBlockLabelPattern.ReplaceAllStringFunc(
+				instruction.Data.Command,
+				func(reference string) string {
+					label := commandBlockLabelPattern.FindStringSubmatch(reference)[1]
+					pos, ok := positions[label]
+					if !ok {
+						unknown = label
+						return reference
+					}
+					return fmt.Sprintf("%d %d %d", pos.X, pos.Y, pos.Z)
+				},
+			)
+			if len(unknown) != 0 {
+				return fmt.Errorf("resolveCommandBlockLabels: Line %d: Unknown label %#v", instruction.Line, unknown)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+编译并放置命令方块程序。
+
+程序的格式见 commandBlockInstruction 。
+命令方块从 Position 开始放置，
+-w 指定每行的命令方块数，-l 指定每层的行数，
+-h 指定最多可使用的层数；
+-l 与 -h 为 0 时不作限制
+*/
+func CommandBlockCompile(config *types.MainConfig, blc chan *types.Module) error {
+	sections, err := readCommandBlockProgram(config.Path)
+	if err != nil {
+		return err
+	}
+	err = layoutCommandBlockProgram(config, sections)
+	if err != nil {
+		return err
+	}
+	err = resolveCommandBlockLabels(sections)
+	if err != nil {
+		return err
+	}
+	for _, section := range sections {
+		for _, instruction := range section.Instructions {
+			data := instruction.Facing
+			if instruction.Data.Conditional {
+				data |= commandBlockConditionalBit
+			}
+			name := "command_block"
+			commandBlockData := instruction.Data
+			blc <- &types.Module{
+				Block:            &types.Block{Name: &name, Data: data},
+				CommandBlockData: &commandBlockData,
+				Point:            instruction.Position,
+			}
+		}
+	}
+	return nil
+}
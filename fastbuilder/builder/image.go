@@ -20,7 +20,28 @@ func Paint(config *types.MainConfig, blc chan *types.Module) error {
 	height := config.Height
 	facing := config.Facing
 	pos := config.Position
+	switch facing {
+	case "x", "y", "z":
+	default:
+		return errors.New("Facing (-f) not defined")
+	}
+	palette := ColorTable
+	if len(config.Palette) != 0 {
+		loaded, err := LoadPalette(config.Palette)
+		if err != nil {
+			return err
+		}
+		palette = loaded
+	}
+	q, err := newQuantizer(palette, config.ColorDistance)
+	if err != nil {
+		return err
+	}
 	file, err := os.Open(config.Path)
+	if err != nil {
+		return I18n.ProcessSystemFileError(err)
+	}
+	defer file.Close()
 	img, err := imaging.Decode(file)
 	if err != nil {
 		return I18n.ProcessSystemFileError(err)
@@ -28,21 +49,25 @@ func Paint(config *types.MainConfig, blc chan *types.Module) error {
 	if width != 0 && height != 0 {
 		img = imaging.Resize(img, width, height, imaging.Lanczos)
 	}
-	Max := img.Bounds().Max
+	blocks, quantized, err := q.quantize(img, config.Dither, config.AlphaThreshold)
+	if err != nil {
+		return err
+	}
+	if len(config.Preview) != 0 {
+		err = savePreview(config.Preview, img, quantized)
+		if err != nil {
+			return err
+		}
+	}
+	Max := quantized.Bounds().Max
 	X, Y := Max.X, Max.Y
-	//BlockSet := make([]*types.Module, X*Y)
-	index := 0
 	for x := 0; x < X; x++ {
 		for y := 0; y < Y; y++ {
-			r, g, b, _ := img.At(x, y).RGBA()
-			c := colorful.Color{
-				R: float64(r >> 8),
-				G: float64(g >> 8),
-				B: float64(b >> 8),
+			cb := blocks[y][x]
+			if cb == nil {
+				continue
 			}
 			switch facing {
-			default:
-				return errors.New("Facing (-f) not defined")
 			case "x":
 				blc <- &types.Module{
 					Point: types.Position{
@@ -50,7 +75,7 @@ func Paint(config *types.MainConfig, blc chan *types.Module) error {
 						Y: x + pos.Y,
 						Z: y + pos.Z,
 					},
-					Block: getBlock(c),
+					Block: cb.Block.Take(),
 				}
 			case "y":
 				blc <- &types.Module{
@@ -59,7 +84,7 @@ func Paint(config *types.MainConfig, blc chan *types.Module) error {
 						Y: pos.Y,
 						Z: y + pos.Z,
 					},
-					Block: getBlock(c),
+					Block: cb.Block.Take(),
 				}
 			case "z":
 				blc <- &types.Module{
@@ -68,36 +93,10 @@ func Paint(config *types.MainConfig, blc chan *types.Module) error {
 						Y: y + pos.Y,
 						Z: pos.Z,
 					},
-					Block: getBlock(c),
+					Block: cb.Block.Take(),
 				}
 			}
-
-			index++
 		}
 	}
 	return nil
 }
-
-func getBlock(c colorful.Color) *types.Block {
-	if _, _, _, a := c.RGBA(); a == 0 {
-		return AirBlock.Take()
-	}
-	var List []float64
-	for _, v := range ColorTable {
-		s := c.DistanceRgb(v.Color)
-		List = append(List, s)
-	}
-	return ColorTable[getMin(List)].Block.Take()
-}
-
-func getMin(t []float64) int {
-	min := t[0]
-	index := 0
-	for i, v := range t {
-		if v < min {
-			min = v
-			index = i
-		}
-	}
-	return index
-}
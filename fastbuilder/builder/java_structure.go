@@ -0,0 +1,409 @@
+package builder
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	I18n "phoenixbuilder/fastbuilder/i18n"
+	"phoenixbuilder/fastbuilder/mcstructure"
+	"phoenixbuilder/fastbuilder/types"
+	"phoenixbuilder/minecraft/nbt"
+	"phoenixbuilder/mirror/chunk"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// 描述 Java 版的单个方块
+type javaBlock struct {
+	// 方块名称(含命名空间)
+	Name string
+	// 方块状态
+	Properties map[string]string
+}
+
+// 描述已被转换为基岩版形式的方块
+type bedrockBlock struct {
+	// 方块名称(不含命名空间)
+	Name string
+	// 字符串形式的方块状态
+	BlockStates string
+}
+
+// 这些方块在导入时将被跳过
+var javaSkippedBlocks = map[string]bool{
+	"minecraft:air":            true,
+	"minecraft:cave_air":       true,
+	"minecraft:void_air":       true,
+	"minecraft:structure_void": true,
+}
+
+// 读取以 gzip 压缩且以大端序编码的 NBT 文件
+func readJavaNBTFile(path string) (map[string]interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, I18n.ProcessSystemFileError(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("readJavaNBTFile: %v", err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("readJavaNBTFile: %v", err)
+	}
+	var result map[string]interface{}
+	err = nbt.UnmarshalEncoding(content, &result, nbt.BigEndian)
+	if err != nil {
+		return nil, fmt.Errorf("readJavaNBTFile: %v", err)
+	}
+	return result, nil
+}
+
+// 将调色板中的单个条目解析为 javaBlock
+func parseJavaPaletteEntry(entry interface{}) (javaBlock, error) {
+	compound, ok := entry.(map[string]interface{})
+	if !ok {
+		return javaBlock{}, fmt.Errorf("parseJavaPaletteEntry: Unexpected palette entry %#v", entry)
+	}
+	name, ok := compound["Name"].(string)
+	if !ok {
+		return javaBlock{}, fmt.Errorf("parseJavaPaletteEntry: Palette entry has no name; entry = %#v", entry)
+	}
+	block := javaBlock{Name: name, Properties: map[string]string{}}
+	if properties, ok := compound["Properties"].(map[string]interface{}); ok {
+		for key, value := range properties {
+			block.Properties[key] = fmt.Sprintf("%v", value)
+		}
+	}
+	return block, nil
+}
+
+// 返回 Java 版方块的字符串形式，
+// 例如 minecraft:chest[facing=north,type=single]
+func (j javaBlock) String() string {
+	if len(j.Properties) == 0 {
+		return j.Name
+	}
+	keys := make([]string, 0, len(j.Properties))
+	for key := range j.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, j.Properties[key]))
+	}
+	return fmt.Sprintf("%s[%s]", j.Name, strings.Join(pairs, ","))
+}
+
+// 将 Java 版方块转换为基岩版方块。
+// 无法转换的方块将被转换为空气，此时返回的布尔值为假
+func javaToBedrockBlock(block javaBlock) (bedrockBlock, bool) {
+	runtimeID, found := chunk.JavaToRuntimeID(block.String())
+	if !found {
+		return bedrockBlock{Name: "air", BlockStates: "[]"}, false
+	}
+	generalBlock, found := chunk.RuntimeIDToBlock(runtimeID)
+	if !found {
+		return bedrockBlock{Name: "air", BlockStates: "[]"}, false
+	}
+	blockStates, err := mcstructure.MarshalBlockStates(generalBlock.Properties)
+	if err != nil {
+		return bedrockBlock{Name: "air", BlockStates: "[]"}, false
+	}
+	return bedrockBlock{
+		Name:        strings.Replace(generalBlock.Name, "minecraft:", "", 1),
+		BlockStates: blockStates,
+	}, true
+}
+
+// 将 Java 版调色板转换为基岩版调色板。
+// 返回的布尔切片描述对应条目是否应被跳过
+func convertJavaPalette(palette []interface{}) ([]bedrockBlock, []bool, error) {
+	converted := make([]bedrockBlock, len(palette))
+	skipped := make([]bool, len(palette))
+	for index, entry := range palette {
+		block, err := parseJavaPaletteEntry(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("convertJavaPalette: %v", err)
+		}
+		if javaSkippedBlocks[block.Name] {
+			skipped[index] = true
+			continue
+		}
+		bedrock, success := javaToBedrockBlock(block)
+		if !success {
+			types.ForwardedBrokSender <- fmt.Sprintf("Unable to convert Java block %s, replaced with air", block.String())
+			skipped[index] = true
+			continue
+		}
+		converted[index] = bedrock
+	}
+	return converted, skipped, nil
+}
+
+// 将 NBT 中的 TAG_Long_Array 转换为 []int64
+func nbtLongArray(value interface{}) ([]int64, bool) {
+	reflectValue := reflect.ValueOf(value)
+	if reflectValue.Kind() != reflect.Array && reflectValue.Kind() != reflect.Slice {
+		return nil, false
+	}
+	if reflectValue.Type().Elem().Kind() != reflect.Int64 {
+		return nil, false
+	}
+	result := make([]int64, reflectValue.Len())
+	for i := range result {
+		result[i] = reflectValue.Index(i).Int()
+	}
+	return result, true
+}
+
+// 将 NBT 中的数字转换为 int
+func nbtInt(value interface{}) (int, bool) {
+	switch val := value.(type) {
+	case byte:
+		return int(val), true
+	case int16:
+		return int(val), true
+	case int32:
+		return int(val), true
+	case int64:
+		return int(val), true
+	}
+	return 0, false
+}
+
+// 将 NBT 中形如 [x, y, z] 的列表转换为坐标
+func nbtIntTriple(value interface{}) ([3]int, bool) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) != 3 {
+		return [3]int{}, false
+	}
+	var result [3]int
+	for i := 0; i < 3; i++ {
+		result[i], ok = nbtInt(list[i])
+		if !ok {
+			return [3]int{}, false
+		}
+	}
+	return result, true
+}
+
+// 将 NBT 中形如 {x, y, z} 的复合标签转换为坐标
+func nbtIntCompound(value interface{}) ([3]int, bool) {
+	compound, ok := value.(map[string]interface{})
+	if !ok {
+		return [3]int{}, false
+	}
+	var result [3]int
+	for index, key := range []string{"x", "y", "z"} {
+		result[index], ok = nbtInt(compound[key])
+		if !ok {
+			return [3]int{}, false
+		}
+	}
+	return result, true
+}
+
+// 将 Java 版的 JSON 文本组件转换为纯文本
+func flattenJavaTextComponent(raw string) string {
+	var component interface{}
+	if err := json.Unmarshal([]byte(raw), &component); err != nil {
+		return raw
+	}
+	var flatten func(value interface{}) string
+	flatten = func(value interface{}) string {
+		switch val := value.(type) {
+		case string:
+			return val
+		case []interface{}:
+			result := ""
+			for _, item := range val {
+				result += flatten(item)
+			}
+			return result
+		case map[string]interface{}:
+			result, _ := val["text"].(string)
+			if extra, ok := val["extra"]; ok {
+				result += flatten(extra)
+			}
+			return result
+		}
+		return ""
+	}
+	return flatten(component)
+}
+
+// 将 Java 版物品转换为基岩版容器所使用的物品形式
+func javaItemToBedrock(item map[string]interface{}) (map[string]interface{}, bool) {
+	id, ok := item["id"].(string)
+	if !ok {
+		return nil, false
+	}
+	count, _ := nbtInt(item["Count"])
+	if count <= 0 {
+		count = 1
+	}
+	result := map[string]interface{}{
+		"Name":   id,
+		"Count":  byte(count),
+		"Damage": int16(0),
+	}
+	if slot, ok := nbtInt(item["Slot"]); ok {
+		result["Slot"] = byte(slot)
+	}
+	if tag, ok := item["tag"].(map[string]interface{}); ok {
+		newTag := map[string]interface{}{}
+		if damage, ok := nbtInt(tag["Damage"]); ok {
+			newTag["Damage"] = int32(damage)
+		}
+		if display, ok := tag["display"].(map[string]interface{}); ok {
+			if name, ok := display["Name"].(string); ok {
+				newTag["display"] = map[string]interface{}{"Name": flattenJavaTextComponent(name)}
+			}
+		}
+		if len(newTag) != 0 {
+			result["tag"] = newTag
+		}
+	}
+	return result, true
+}
+
+// 将 Java 版的方块实体数据转换为基岩版形式。
+// 目前支持容器、告示牌和命令方块，
+// 对于其他方块实体，将返回空映射
+func javaTileEntityToBedrock(bedrockName string, tileEntity map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	// 容器
+	if items, ok := tileEntity["Items"].([]interface{}); ok {
+		converted := []interface{}{}
+		for _, value := range items {
+			item, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if newItem, success := javaItemToBedrock(item); success {
+				converted = append(converted, newItem)
+			}
+		}
+		result["Items"] = converted
+	}
+	// 告示牌
+	if strings.Contains(bedrockName, "sign") {
+		lines := []string{}
+		if frontText, ok := tileEntity["front_text"].(map[string]interface{}); ok {
+			messages, _ := frontText["messages"].([]interface{})
+			for _, value := range messages {
+				message, _ := value.(string)
+				lines = append(lines, flattenJavaTextComponent(message))
+			}
+		} else {
+			for i := 1; i <= 4; i++ {
+				message, _ := tileEntity[fmt.Sprintf("Text%d", i)].(string)
+				lines = append(lines, flattenJavaTextComponent(message))
+			}
+		}
+		result["Text"] = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	}
+	// 命令方块
+	if strings.Contains(bedrockName, "command_block") {
+		command, _ := tileEntity["Command"].(string)
+		result["Command"] = command
+		if customName, ok := tileEntity["CustomName"].(string); ok {
+			result["CustomName"] = flattenJavaTextComponent(customName)
+		}
+		if lastOutput, ok := tileEntity["LastOutput"].(string); ok {
+			result["LastOutput"] = flattenJavaTextComponent(lastOutput)
+		}
+		for _, key := range []string{"auto", "TrackOutput"} {
+			if value, ok := nbtInt(tileEntity[key]); ok {
+				result[key] = byte(value)
+			}
+		}
+	}
+	return result
+}
+
+// 将已转换的方块及其方块实体数据写入 blc
+func emitJavaBlock(
+	blc chan *types.Module,
+	block bedrockBlock,
+	pos types.Position,
+	tileEntity map[string]interface{},
+) {
+	name := block.Name
+	module := &types.Module{
+		Block: &types.Block{
+			Name:        &name,
+			BlockStates: block.BlockStates,
+		},
+		Point: pos,
+	}
+	if tileEntity != nil {
+		if nbtMap := javaTileEntityToBedrock(name, tileEntity); len(nbtMap) != 0 {
+			module.NBTMap = nbtMap
+		}
+	}
+	blc <- module
+}
+
+// 导入 Java 版结构方块所保存的 .nbt 结构文件
+func JavaStructure(config *types.MainConfig, blc chan *types.Module) error {
+	structure, err := readJavaNBTFile(config.Path)
+	if err != nil {
+		return err
+	}
+	paletteOrigin, ok := structure["palette"].([]interface{})
+	if !ok {
+		palettes, _ := structure["palettes"].([]interface{})
+		if len(palettes) == 0 {
+			return fmt.Errorf("JavaStructure: The structure has no palette")
+		}
+		paletteOrigin, ok = palettes[0].([]interface{})
+		if !ok {
+			return fmt.Errorf("JavaStructure: Unexpected palette %#v", palettes[0])
+		}
+	}
+	palette, skipped, err := convertJavaPalette(paletteOrigin)
+	if err != nil {
+		return fmt.Errorf("JavaStructure: %v", err)
+	}
+	blocks, ok := structure["blocks"].([]interface{})
+	if !ok {
+		return fmt.Errorf("JavaStructure: The structure has no blocks")
+	}
+	for index, value := range blocks {
+		block, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("JavaStructure: Unexpected blocks[%d]; blocks[%d] = %#v", index, index, value)
+		}
+		state, ok := nbtInt(block["state"])
+		if !ok || state < 0 || state >= len(palette) {
+			return fmt.Errorf("JavaStructure: Invalid state of blocks[%d]; blocks[%d] = %#v", index, index, value)
+		}
+		if skipped[state] {
+			continue
+		}
+		pos, ok := nbtIntTriple(block["pos"])
+		if !ok {
+			return fmt.Errorf("JavaStructure: Invalid position of blocks[%d]; blocks[%d] = %#v", index, index, value)
+		}
+		tileEntity, _ := block["nbt"].(map[string]interface{})
+		emitJavaBlock(
+			blc,
+			palette[state],
+			types.Position{
+				X: config.Position.X + pos[0],
+				Y: config.Position.Y + pos[1],
+				Z: config.Position.Z + pos[2],
+			},
+			tileEntity,
+		)
+	}
+	return nil
+}
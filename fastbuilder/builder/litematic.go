@@ -0,0 +1,185 @@
+package builder
+
+import (
+	"fmt"
+	"math/bits"
+	"phoenixbuilder/fastbuilder/types"
+	"sort"
+)
+
+// 描述 .litematic 文件中的单个区域
+type litematicRegion struct {
+	// 区域名称
+	Name string
+	// 区域最小角的坐标(相对于原理图原点)
+	Min [3]int
+	// 区域的尺寸，各分量均为正数
+	Size [3]int
+	// 该区域的原始 NBT 数据
+	Data map[string]interface{}
+}
+
+// 将 Position 和 Size 复合标签解析为区域的最小角与尺寸。
+// Litematica 允许尺寸为负数，此时区域向负方向延伸
+func parseLitematicRegion(name string, data map[string]interface{}) (litematicRegion, error) {
+	position, ok := nbtIntCompound(data["Position"])
+	if !ok {
+		return litematicRegion{}, fmt.Errorf("parseLitematicRegion: Region %s has no valid position", name)
+	}
+	size, ok := nbtIntCompound(data["Size"])
+	if !ok {
+		return litematicRegion{}, fmt.Errorf("parseLitematicRegion: Region %s has no valid size", name)
+	}
+	region := litematicRegion{Name: name, Data: data}
+	for i := 0; i < 3; i++ {
+		region.Min[i] = position[i]
+		region.Size[i] = size[i]
+		if size[i] < 0 {
+			region.Min[i] = position[i] + size[i] + 1
+			region.Size[i] = -size[i]
+		}
+	}
+	return region, nil
+}
+
+/*
+从以紧密方式打包的 long 数组中读取第 index 个条目。
+
+与原版区块格式不同，Litematica 允许一个条目跨越两个 long ，
+因此需要同时考虑相邻的两个 long
+*/
+func unpackLitematicEntry(storage []int64, bitsPerEntry int, index int) (int, error) {
+	startBit := index * bitsPerEntry
+	startLong := startBit / 64
+	startOffset := uint(startBit % 64)
+	endLong := (startBit + bitsPerEntry - 1) / 64
+	if endLong >= len(storage) {
+		return 0, fmt.Errorf("unpackLitematicEntry: Index %d out of range", index)
+	}
+	mask := uint64(1)<<uint(bitsPerEntry) - 1
+	value := uint64(storage[startLong]) >> startOffset
+	if startLong != endLong {
+		value |= uint64(storage[endLong]) << (64 - startOffset)
+	}
+	return int(value & mask), nil
+}
+
+// 导入 Litematica 模组所保存的 .litematic 原理图。
+// 多个区域将按照其保存的相对位置被放置
+func Litematic(config *types.MainConfig, blc chan *types.Module) error {
+	schematic, err := readJavaNBTFile(config.Path)
+	if err != nil {
+		return err
+	}
+	regionsOrigin, ok := schematic["Regions"].(map[string]interface{})
+	if !ok || len(regionsOrigin) == 0 {
+		return fmt.Errorf("Litematic: The schematic has no regions")
+	}
+	names := make([]string, 0, len(regionsOrigin))
+	for name := range regionsOrigin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// 解析所有区域
+	regions := make([]litematicRegion, 0, len(names))
+	for _, name := range names {
+		data, ok := regionsOrigin[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Litematic: Unexpected region %s", name)
+		}
+		region, err := parseLitematicRegion(name, data)
+		if err != nil {
+			return fmt.Errorf("Litematic: %v", err)
+		}
+		regions = append(regions, region)
+	}
+	// 以所有区域的最小角作为原理图的原点
+	origin := regions[0].Min
+	for _, region := range regions[1:] {
+		for i := 0; i < 3; i++ {
+			if region.Min[i] < origin[i] {
+				origin[i] = region.Min[i]
+			}
+		}
+	}
+	for _, region := range regions {
+		err := placeLitematicRegion(config, blc, region, origin)
+		if err != nil {
+			return fmt.Errorf("Litematic: %v", err)
+		}
+	}
+	return nil
+}
+
+// 放置单个区域。
+// origin 指代原理图的原点，
+// 区域将被放置在其相对于原点的位置
+func placeLitematicRegion(
+	config *types.MainConfig,
+	blc chan *types.Module,
+	region litematicRegion,
+	origin [3]int,
+) error {
+	paletteOrigin, ok := region.Data["BlockStatePalette"].([]interface{})
+	if !ok || len(paletteOrigin) == 0 {
+		return fmt.Errorf("placeLitematicRegion: Region %s has no palette", region.Name)
+	}
+	palette, skipped, err := convertJavaPalette(paletteOrigin)
+	if err != nil {
+		return fmt.Errorf("placeLitematicRegion: %v", err)
+	}
+	// 取得方块状态的存储格式
+	storage, ok := nbtLongArray(region.Data["BlockStates"])
+	if !ok {
+		return fmt.Errorf("placeLitematicRegion: Region %s has no block states", region.Name)
+	}
+	bitsPerEntry := bits.Len(uint(len(palette) - 1))
+	if bitsPerEntry < 2 {
+		bitsPerEntry = 2
+	}
+	// 以相对于区域最小角的坐标索引方块实体
+	tileEntities := map[[3]int]map[string]interface{}{}
+	if list, ok := region.Data["TileEntities"].([]interface{}); ok {
+		for _, value := range list {
+			tileEntity, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			pos, ok := nbtIntCompound(tileEntity)
+			if !ok {
+				continue
+			}
+			tileEntities[pos] = tileEntity
+		}
+	}
+	base := types.Position{
+		X: config.Position.X + region.Min[0] - origin[0],
+		Y: config.Position.Y + region.Min[1] - origin[1],
+		Z: config.Position.Z + region.Min[2] - origin[2],
+	}
+	sizeX, sizeY, sizeZ := region.Size[0], region.Size[1], region.Size[2]
+	for y := 0; y < sizeY; y++ {
+		for z := 0; z < sizeZ; z++ {
+			for x := 0; x < sizeX; x++ {
+				index := (y*sizeZ+z)*sizeX + x
+				state, err := unpackLitematicEntry(storage, bitsPerEntry, index)
+				if err != nil {
+					return fmt.Errorf("placeLitematicRegion: Region %s is corrupted; err = %v", region.Name, err)
+				}
+				if state >= len(palette) {
+					return fmt.Errorf("placeLitematicRegion: Region %s refers to palette entry %d, but the palette only has %d entries", region.Name, state, len(palette))
+				}
+				if skipped[state] {
+					continue
+				}
+				emitJavaBlock(
+					blc,
+					palette[state],
+					types.Position{X: base.X + x, Y: base.Y + y, Z: base.Z + z},
+					tileEntities[[3]int{x, y, z}],
+				)
+			}
+		}
+	}
+	return nil
+}
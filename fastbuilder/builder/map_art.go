@@ -19,6 +19,9 @@ const (
 	Heigth3D_Normal = Height2D
 )
 
+// 单张地图(缩放等级为 0)所覆盖的方块边长
+const MapTileSize = 128
+
 type colorBlock struct {
 	Name   string
 	Meta   int
@@ -207,6 +210,28 @@ func GetYMap(blocks [][]*colorBlock, MapY int) [][]int {
 	return YMap
 }
 
+// 返回坐标 v 所在的地图网格的起始坐标。
+// 缩放等级为 0 的地图以 128*k-64 为边界
+func MapGridOrigin(v int) int {
+	shifted := v + MapTileSize/2
+	cell := shifted / MapTileSize
+	if shifted%MapTileSize < 0 {
+		cell--
+	}
+	return cell*MapTileSize - MapTileSize/2
+}
+
+// 返回地图画实际的起始坐标。
+// 若启用了网格对齐，则 X 和 Z 将被对齐到其所在的地图网格
+func MapArtOrigin(config *types.MainConfig) types.Position {
+	pos := config.Position
+	if config.MapAlign {
+		pos.X = MapGridOrigin(pos.X)
+		pos.Z = MapGridOrigin(pos.Z)
+	}
+	return pos
+}
+
 func MapArt(config *types.MainConfig, blc chan *types.Module) error {
 	//path := config.Path
 	MapX := config.MapX
@@ -218,8 +243,12 @@ func MapArt(config *types.MainConfig, blc chan *types.Module) error {
 		}
 
 	}
-	pos := config.Position
+	pos := MapArtOrigin(config)
 	file, err := os.Open(config.Path)
+	if err != nil {
+		return I18n.ProcessSystemFileError(err)
+	}
+	defer file.Close()
 	img, err := imaging.Decode(file)
 	if err != nil {
 		return I18n.ProcessSystemFileError(err)
@@ -251,40 +280,38 @@ func MapArt(config *types.MainConfig, blc chan *types.Module) error {
 		}
 		img = imaging.Crop(img, image.Rect(0, sY, origSize.X, eY))
 	}
-	img = imaging.Resize(img, MapX*128, MapZ*128, imaging.Lanczos)
+	img = imaging.Resize(img, MapX*MapTileSize, MapZ*MapTileSize, imaging.Lanczos)
 
+	var blockImg [][]*colorBlock
+	var YMap [][]int
 	if MapY == 0 {
-		_, blockImg := Dither(img, &colorArray2D, &blockArray2D)
-		for Z, row := range blockImg {
-			for X, blk := range row {
-				blc <- &types.Module{
-					Point: types.Position{
-						X: X + pos.X,
-						Y: pos.Y,
-						Z: Z + pos.Z,
-					},
-					Block: &types.Block{
-						Name: &blk.Name,
-						Data: uint16(blk.Meta),
-					},
-				}
-			}
-		}
+		_, blockImg = Dither(img, &colorArray2D, &blockArray2D)
 	} else {
-		_, blockImg := Dither(img, &colorArray3D, &blockArray3D)
-		YMap := GetYMap(blockImg, MapY)
-		for Z, row := range blockImg {
-			for X, blk := range row {
-				blc <- &types.Module{
-					Point: types.Position{
-						X: X + pos.X,
-						Y: pos.Y + YMap[Z][X],
-						Z: Z + pos.Z,
-					},
-					Block: &types.Block{
-						Name: &blk.Name,
-						Data: uint16(blk.Meta),
-					},
+		_, blockImg = Dither(img, &colorArray3D, &blockArray3D)
+		YMap = GetYMap(blockImg, MapY)
+	}
+	// 逐个地图网格地放置方块，
+	// 这样每张地图所对应的区域都将被连续地完成
+	for tileZ := 0; tileZ < MapZ; tileZ++ {
+		for tileX := 0; tileX < MapX; tileX++ {
+			for Z := tileZ * MapTileSize; Z < (tileZ+1)*MapTileSize; Z++ {
+				for X := tileX * MapTileSize; X < (tileX+1)*MapTileSize; X++ {
+					blk := blockImg[Z][X]
+					Y := pos.Y
+					if YMap != nil {
+						Y += YMap[Z][X]
+					}
+					blc <- &types.Module{
+						Point: types.Position{
+							X: X + pos.X,
+							Y: Y,
+							Z: Z + pos.Z,
+						},
+						Block: &types.Block{
+							Name: &blk.Name,
+							Data: uint16(blk.Meta),
+						},
+					}
 				}
 			}
 		}
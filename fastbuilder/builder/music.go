@@ -0,0 +1,274 @@
+package builder
+
+import (
+	"fmt"
+	"path/filepath"
+	"phoenixbuilder/fastbuilder/mcstructure"
+	"phoenixbuilder/fastbuilder/types"
+	"sort"
+	"strings"
+)
+
+// 每秒的红石刻数，中继器每档延迟为 1 红石刻
+const redstoneTicksPerSecond = 10
+
+// 单个中继器所能提供的最大延迟 (红石刻)
+const maxRepeaterDelay = 4
+
+// 音符盒的音域为两个八度，共 25 个音高
+const noteBlockPitchRange = 25
+
+/*
+同一时刻最多能同时演奏的音符数。
+
+所有声部由拉杆旁的一行红石粉同时触发，
+而红石信号在 15 格后衰减为 0
+*/
+const MusicMaxLanes = 15
+
+// 红石线路下方的支撑方块，同时用于填充无音符的位置
+const musicSupportBlock = "stone"
+
+// 中继器朝向 +X (东) 时的 direction
+const musicRepeaterDirection = int32(3)
+
+// 描述一种音符盒乐器
+type noteInstrument struct {
+	// 乐器名称
+	Name string
+	// 放置在音符盒下方以选择该乐器的方块
+	BaseBlock string
+	// 基座方块的数据值
+	BaseData uint16
+	// 音符盒不被点击时该乐器所演奏的 MIDI 音高
+	LowestKey int
+}
+
+// 所有音符盒乐器，其索引与 Note Block Studio 的乐器编号一致
+var noteInstruments = []noteInstrument{
+	{Name: "harp", BaseBlock: "dirt", LowestKey: 54},
+	{Name: "bass", BaseBlock: "planks", LowestKey: 30},
+	{Name: "basedrum", BaseBlock: "stone", LowestKey: 54},
+	{Name: "snare", BaseBlock: "sand", LowestKey: 54},
+	{Name: "hat", BaseBlock: "glass", LowestKey: 54},
+	{Name: "guitar", BaseBlock: "wool", LowestKey: 42},
+	{Name: "flute", BaseBlock: "clay", LowestKey: 66},
+	{Name: "bell", BaseBlock: "gold_block", LowestKey: 78},
+	{Name: "chime", BaseBlock: "packed_ice", LowestKey: 78},
+	{Name: "xylophone", BaseBlock: "bone_block", LowestKey: 78},
+	{Name: "iron_xylophone", BaseBlock: "iron_block", LowestKey: 54},
+	{Name: "cow_bell", BaseBlock: "soul_sand", LowestKey: 66},
+	{Name: "didgeridoo", BaseBlock: "pumpkin", LowestKey: 30},
+	{Name: "bit", BaseBlock: "emerald_block", LowestKey: 54},
+	{Name: "banjo", BaseBlock: "hay_block", LowestKey: 54},
+	{Name: "pling", BaseBlock: "glowstone", LowestKey: 54},
+}
+
+// 会受重力影响的基座方块，需要在其下方放置支撑方块
+var noteGravityBaseBlocks = map[string]bool{
+	"sand": true,
+}
+
+// 描述乐曲中的单个音符
+type musicNote struct {
+	// 音符开始的时间，以红石刻为单位
+	Tick int
+	// 乐器在 noteInstruments 中的索引
+	Instrument int
+	// 音高，即需要点击音符盒的次数 (0~24)
+	Pitch int
+}
+
+// 将音高按八度移入音符盒的音域
+func foldNotePitch(pitch int) int {
+	for pitch < 0 {
+		pitch += 12
+	}
+	for pitch >= noteBlockPitchRange {
+		pitch -= 12
+	}
+	return pitch
+}
+
+// 描述同一时刻演奏的一组音符
+type musicSlice struct {
+	Tick  int
+	Notes []musicNote
+}
+
+// 将音符按时间分组并去除重复的音符。
+// 返回的切片以时间升序排列，同时返回被丢弃的音符数
+func groupMusicNotes(notes []musicNote) ([]musicSlice, int) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].Tick != notes[j].Tick {
+			return notes[i].Tick < notes[j].Tick
+		}
+		if notes[i].Instrument != notes[j].Instrument {
+			return notes[i].Instrument < notes[j].Instrument
+		}
+		return notes[i].Pitch < notes[j].Pitch
+	})
+	slices := make([]musicSlice, 0)
+	dropped := 0
+	for index, note := range notes {
+		if index > 0 && notes[index-1] == note {
+			continue
+		}
+		if len(slices) == 0 || slices[len(slices)-1].Tick != note.Tick {
+			slices = append(slices, musicSlice{Tick: note.Tick})
+		}
+		current := &slices[len(slices)-1]
+		if len(current.Notes) >= MusicMaxLanes {
+			dropped++
+			continue
+		}
+		current.Notes = append(current.Notes, note)
+	}
+	return slices, dropped
+}
+
+// 依据文件扩展名读取 .nbs 或 MIDI 文件
+func readMusicFile(path string) ([]musicNote, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".nbs":
+		return readNBSFile(path)
+	case ".mid", ".midi":
+		return readMIDIFile(path)
+	}
+	return nil, fmt.Errorf("readMusicFile: Unsupported file %s, expected .nbs, .mid or .midi", path)
+}
+
+// 在 pos 处放置一个传统方块
+func emitMusicBlock(blc chan *types.Module, name string, data uint16, pos types.Position) {
+	blc <- &types.Module{
+		Block: &types.Block{Name: &name, Data: data},
+		Point: pos,
+	}
+}
+
+/*
+将 .nbs 或 MIDI 文件转换为音符盒乐曲。
+
+线路以 Position 为起点沿 +X 方向延伸，
+每个声部占据一条沿 +X 的线路，各声部沿 +Z 方向并排，
+和弦中的各个音符由不同的声部同时演奏。
+
+每条线路由中继器与音符盒交替组成，
+中继器的延迟即为相邻两组音符的时间间隔，
+超过 4 红石刻的间隔由多个中继器串联而成。
+乐器由音符盒下方的方块决定，
+音高则作为音符盒的 NBT 数据经由 NBT 分配器写入。
+
+拉杆位于起点的 -Z 方向，拉下拉杆即可开始演奏
+*/
+func Music(config *types.MainConfig, blc chan *types.Module) error {
+	notes, err := readMusicFile(config.Path)
+	if err != nil {
+		return err
+	}
+	slices, dropped := groupMusicNotes(notes)
+	if len(slices) == 0 {
+		return fmt.Errorf("Music: The song has no notes")
+	}
+	if dropped != 0 {
+		types.ForwardedBrokSender <- fmt.Sprintf("%d notes were dropped because more than %d notes are played at the same time", dropped, MusicMaxLanes)
+	}
+	// 声部数为同一时刻演奏的最大音符数
+	lanes := 0
+	for _, slice := range slices {
+		if len(slice.Notes) > lanes {
+			lanes = len(slice.Notes)
+		}
+	}
+	// 预先生成中继器与拉杆的方块状态
+	repeaterStates := make([]string, maxRepeaterDelay)
+	for delay := range repeaterStates {
+		repeaterStates[delay], err = mcstructure.MarshalBlockStates(map[string]interface{}{
+			"direction":      musicRepeaterDirection,
+			"repeater_delay": int32(delay),
+		})
+		if err != nil {
+			return fmt.Errorf("Music: %v", err)
+		}
+	}
+	leverStates, err := mcstructure.MarshalBlockStates(map[string]interface{}{
+		"lever_direction": "up_north_south",
+		"open_bit":        byte(0),
+	})
+	if err != nil {
+		return fmt.Errorf("Music: %v", err)
+	}
+	// 工具函数
+	origin := config.Position
+	at := func(x int, z int) types.Position {
+		return types.Position{X: origin.X + x, Y: origin.Y, Z: origin.Z + z}
+	}
+	below := func(pos types.Position, depth int) types.Position {
+		return types.Position{X: pos.X, Y: pos.Y - depth, Z: pos.Z}
+	}
+	emitStates := func(name string, states string, pos types.Position) {
+		blc <- &types.Module{
+			Block: &types.Block{Name: &name, BlockStates: states},
+			Point: pos,
+		}
+	}
+	// 起点处的拉杆与红石粉
+	for lane := -1; lane < lanes; lane++ {
+		pos := at(0, lane)
+		emitMusicBlock(blc, musicSupportBlock, 0, below(pos, 1))
+		if lane == -1 {
+			emitStates("lever", leverStates, pos)
+			continue
+		}
+		emitMusicBlock(blc, "redstone_wire", 0, pos)
+	}
+	x := 1
+	repeaters := func(delay int) {
+		for lane := 0; lane < lanes; lane++ {
+			pos := at(x, lane)
+			emitMusicBlock(blc, musicSupportBlock, 0, below(pos, 1))
+			emitStates("unpowered_repeater", repeaterStates[delay-1], pos)
+		}
+		x++
+	}
+	previous := slices[0].Tick - 1
+	for _, slice := range slices {
+		gap := slice.Tick - previous
+		previous = slice.Tick
+		// 以中继器延迟演奏间隔
+		for gap > maxRepeaterDelay {
+			repeaters(maxRepeaterDelay)
+			for lane := 0; lane < lanes; lane++ {
+				emitMusicBlock(blc, musicSupportBlock, 0, at(x, lane))
+			}
+			x++
+			gap -= maxRepeaterDelay
+		}
+		repeaters(gap)
+		// 放置音符盒及其乐器方块
+		for lane := 0; lane < lanes; lane++ {
+			pos := at(x, lane)
+			if lane >= len(slice.Notes) {
+				emitMusicBlock(blc, musicSupportBlock, 0, pos)
+				continue
+			}
+			note := slice.Notes[lane]
+			instrument := noteInstruments[note.Instrument]
+			if noteGravityBaseBlocks[instrument.BaseBlock] {
+				emitMusicBlock(blc, musicSupportBlock, 0, below(pos, 2))
+			}
+			emitMusicBlock(blc, instrument.BaseBlock, instrument.BaseData, below(pos, 1))
+			name := "noteblock"
+			blc <- &types.Module{
+				Block:  &types.Block{Name: &name, BlockStates: "[]"},
+				NBTMap: map[string]interface{}{"note": byte(note.Pitch)},
+				Point:  pos,
+				// 音高只能通过点击音符盒调整，
+				// 因此即使未指定 -nbt 也需要写入
+				ForceNBTAssignment: true,
+			}
+		}
+		x++
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// 打击乐所使用的 MIDI 通道 (通道 10)
+const midiPercussionChannel = 9
+
+// 未指定速度时每个四分音符的时长 (微秒)
+const midiDefaultTempo = 500000
+
+// 通用 MIDI 中每 8 个音色为一族，
+// 此表描述了每族音色所对应的 noteInstruments 的索引
+var midiProgramFamilies = [16]int{
+	0,  // 钢琴 -> harp
+	7,  // 半音打击乐器 -> bell
+	6,  // 风琴 -> flute
+	5,  // 吉他 -> guitar
+	1,  // 贝斯 -> bass
+	0,  // 弦乐 -> harp
+	0,  // 合奏 -> harp
+	13, // 铜管 -> bit
+	6,  // 簧管 -> flute
+	6,  // 吹管 -> flute
+	13, // 合成主音 -> bit
+	15, // 合成音色 -> pling
+	15, // 合成效果 -> pling
+	14, // 民族乐器 -> banjo
+	11, // 打击乐器 -> cow_bell
+	4,  // 音效 -> hat
+}
+
+// 描述 MIDI 文件中的单个事件
+type midiEvent struct {
+	// 事件发生的绝对时间 (以 MIDI tick 为单位)
+	Tick int
+	// 事件在文件中的顺序，用于稳定排序
+	Order int
+	// 状态字节，对于元事件则为 0xFF
+	Status uint8
+	// 元事件的类型
+	MetaType uint8
+	// 事件数据
+	Data []byte
+}
+
+// 读取 MIDI 的变长整数
+func readMIDIVarInt(r *bytes.Reader) (int, error) {
+	value := 0
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value = value<<7 | int(b&0x7f)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("readMIDIVarInt: Variable-length quantity is too long")
+}
+
+// 读取单个 MTrk 音轨中的所有事件
+func readMIDITrack(data []byte, order *int) ([]midiEvent, error) {
+	r := bytes.NewReader(data)
+	events := make([]midiEvent, 0)
+	tick, running := 0, uint8(0)
+	for r.Len() > 0 {
+		delta, err := readMIDIVarInt(r)
+		if err != nil {
+			return nil, fmt.Errorf("readMIDITrack: %v", err)
+		}
+		tick += delta
+		status, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("readMIDITrack: %v", err)
+		}
+		event := midiEvent{Tick: tick, Order: *order, Status: status}
+		*order++
+		switch {
+		// 元事件与系统独占事件
+		case status == 0xFF:
+			metaType, err := r.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("readMIDITrack: %v", err)
+			}
+			event.MetaType = metaType
+			fallthrough
+		case status == 0xF0 || status == 0xF7:
+			length, err := readMIDIVarInt(r)
+			if err != nil {
+				return nil, fmt.Errorf("readMIDITrack: %v", err)
+			}
+			if length > r.Len() {
+				return nil, fmt.Errorf("readMIDITrack: Event length %d out of range", length)
+			}
+			event.Data = make([]byte, length)
+			r.Read(event.Data)
+			if status == 0xFF && event.MetaType == 0x2F {
+				return events, nil
+			}
+		// 通道事件，支持运行状态
+		default:
+			if status < 0x80 {
+				if running == 0 {
+					return nil, fmt.Errorf("readMIDITrack: Running status without a previous status")
+				}
+				r.UnreadByte()
+				status = running
+				event.Status = status
+			}
+			running = status
+			length := 2
+			if status&0xF0 == 0xC0 || status&0xF0 == 0xD0 {
+				length = 1
+			}
+			event.Data = make([]byte, length)
+			if _, err := io.ReadFull(r, event.Data); err != nil {
+				return nil, fmt.Errorf("readMIDITrack: %v", err)
+			}
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// 将 MIDI 音色 program 及通道 channel 上的音符 key 映射到音符盒的乐器
+func midiInstrument(channel uint8, program uint8, key int) int {
+	if channel == midiPercussionChannel {
+		switch key {
+		case 35, 36, 41, 43, 45, 47, 48, 50:
+			return 2 // 底鼓与通鼓 -> basedrum
+		case 37, 38, 39, 40:
+			return 3 // 军鼓与拍手 -> snare
+		default:
+			return 4 // 镲片等 -> hat
+		}
+	}
+	switch program {
+	case 11:
+		return 10 // 颤音琴 -> iron_xylophone
+	case 12, 13:
+		return 9 // 马林巴与木琴 -> xylophone
+	case 14:
+		return 8 // 管钟 -> chime
+	}
+	return midiProgramFamilies[program/8]
+}
+
+/*
+读取标准 MIDI 文件 (.mid/.midi) 。
+
+所有音轨将被合并，速度变化会被正确处理。
+第 10 通道被视作打击乐，其他通道依据音色选择乐器，
+超出乐器音域的音符会被按八度移入音域内
+*/
+func readMIDIFile(path string) ([]musicNote, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("readMIDIFile: %v", err)
+	}
+	r := bytes.NewReader(content)
+	var division uint16
+	events := make([]midiEvent, 0)
+	order := 0
+	// 读取文件头与所有音轨
+	for r.Len() > 0 {
+		var chunkType [4]byte
+		var length uint32
+		if _, err := io.ReadFull(r, chunkType[:]); err != nil {
+			return nil, fmt.Errorf("readMIDIFile: %s is corrupted; err = %v", path, err)
+		}
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return nil, fmt.Errorf("readMIDIFile: %s is corrupted; err = %v", path, err)
+		}
+		if int64(length) > int64(r.Len()) {
+			return nil, fmt.Errorf("readMIDIFile: %s is truncated", path)
+		}
+		data := make([]byte, length)
+		r.Read(data)
+		switch string(chunkType[:]) {
+		case "MThd":
+			if len(data) < 6 {
+				return nil, fmt.Errorf("readMIDIFile: %s has an invalid header", path)
+			}
+			division = binary.BigEndian.Uint16(data[4:6])
+		case "MTrk":
+			trackEvents, err := readMIDITrack(data, &order)
+			if err != nil {
+				return nil, fmt.Errorf("readMIDIFile: %v", err)
+			}
+			events = append(events, trackEvents...)
+		}
+	}
+	if division == 0 {
+		return nil, fmt.Errorf("readMIDIFile: %s is not a MIDI file", path)
+	}
+	// 合并所有音轨
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Tick != events[j].Tick {
+			return events[i].Tick < events[j].Tick
+		}
+		return events[i].Order < events[j].Order
+	})
+	secondsPerTick := float64(midiDefaultTempo) / 1e6 / float64(division)
+	smpte := division&0x8000 != 0
+	// SMPTE 格式的时间与速度无关
+	if smpte {
+		fps := -float64(int8(division >> 8))
+		secondsPerTick = 1 / (fps * float64(division&0xFF))
+	}
+	var programs [16]uint8
+	notes := make([]musicNote, 0)
+	lastTick, seconds := 0, 0.0
+	for _, event := range events {
+		seconds += float64(event.Tick-lastTick) * secondsPerTick
+		lastTick = event.Tick
+		if event.Status == 0xFF {
+			if event.MetaType == 0x51 && len(event.Data) == 3 && !smpte {
+				tempo := int(event.Data[0])<<16 | int(event.Data[1])<<8 | int(event.Data[2])
+				secondsPerTick = float64(tempo) / 1e6 / float64(division)
+			}
+			continue
+		}
+		channel := event.Status & 0x0F
+		switch event.Status & 0xF0 {
+		case 0xC0:
+			programs[channel] = event.Data[0] & 0x7F
+		case 0x90:
+			key, velocity := int(event.Data[0]), event.Data[1]
+			// 力度为 0 的 Note On 实际上是 Note Off
+			if velocity == 0 {
+				continue
+			}
+			instrument := midiInstrument(channel, programs[channel], key)
+			notes = append(notes, musicNote{
+				Tick:       int(math.Round(seconds * redstoneTicksPerSecond)),
+				Instrument: instrument,
+				Pitch:      foldNotePitch(key - noteInstruments[instrument].LowestKey),
+			})
+		}
+	}
+	return notes, nil
+}
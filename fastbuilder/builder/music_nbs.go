@@ -0,0 +1,175 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Note Block Studio 中的音符盒可演奏的最低音 (F#3) 所对应的 key
+const nbsLowestKey = 33
+
+// nbsReader 用于按小端序读取 .nbs 文件
+type nbsReader struct {
+	*bytes.Reader
+}
+
+func (r nbsReader) byte() (uint8, error) {
+	return r.ReadByte()
+}
+
+func (r nbsReader) short() (int16, error) {
+	var value int16
+	err := binary.Read(r.Reader, binary.LittleEndian, &value)
+	return value, err
+}
+
+func (r nbsReader) int() (int32, error) {
+	var value int32
+	err := binary.Read(r.Reader, binary.LittleEndian, &value)
+	return value, err
+}
+
+func (r nbsReader) string() (string, error) {
+	length, err := r.int()
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int64(length) > int64(r.Len()) {
+		return "", fmt.Errorf("string: Invalid length %d", length)
+	}
+	buf := make([]byte, length)
+	_, err = r.Read(buf)
+	return string(buf), err
+}
+
+// 跳过 count 个由 read 读取的字段
+func (r nbsReader) skip(count int, read func() error) error {
+	for i := 0; i < count; i++ {
+		if err := read(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+读取 Note Block Studio 保存的 .nbs 文件。
+
+同时支持旧版格式与 OpenNBS 的新版格式 (版本 1~5) 。
+自定义乐器将被视作钢琴 (harp) 演奏
+*/
+func readNBSFile(path string) ([]musicNote, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("readNBSFile: %v", err)
+	}
+	r := nbsReader{bytes.NewReader(content)}
+	fail := func(err error) ([]musicNote, error) {
+		return nil, fmt.Errorf("readNBSFile: %s is corrupted; err = %v", path, err)
+	}
+	// 新版格式以 0 开头，随后是版本号与原版乐器的数量
+	first, err := r.short()
+	if err != nil {
+		return fail(err)
+	}
+	version, vanillaInstruments := uint8(0), uint8(10)
+	if first == 0 {
+		if version, err = r.byte(); err != nil {
+			return fail(err)
+		}
+		if vanillaInstruments, err = r.byte(); err != nil {
+			return fail(err)
+		}
+		if version >= 3 {
+			if _, err = r.short(); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	// 图层数、歌曲名、作者、原作者和描述
+	if _, err = r.short(); err != nil {
+		return fail(err)
+	}
+	err = r.skip(4, func() error { _, err := r.string(); return err })
+	if err != nil {
+		return fail(err)
+	}
+	tempo, err := r.short()
+	if err != nil {
+		return fail(err)
+	}
+	if tempo <= 0 {
+		return fail(fmt.Errorf("invalid tempo %d", tempo))
+	}
+	// 跳过自动保存、编辑统计、导入文件名及循环设置
+	err = r.skip(3, func() error { _, err := r.byte(); return err })
+	if err == nil {
+		err = r.skip(5, func() error { _, err := r.int(); return err })
+	}
+	if err == nil {
+		_, err = r.string()
+	}
+	if err == nil && version >= 4 {
+		err = r.skip(2, func() error { _, err := r.byte(); return err })
+		if err == nil {
+			_, err = r.short()
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+	secondsPerTick := 100.0 / float64(tempo)
+	// 读取所有音符，图层信息对于音符盒而言没有意义
+	notes := make([]musicNote, 0)
+	tick := -1
+	for {
+		jump, err := r.short()
+		if err != nil {
+			return fail(err)
+		}
+		if jump == 0 {
+			break
+		}
+		tick += int(jump)
+		for {
+			layerJump, err := r.short()
+			if err != nil {
+				return fail(err)
+			}
+			if layerJump == 0 {
+				break
+			}
+			instrument, err := r.byte()
+			if err != nil {
+				return fail(err)
+			}
+			key, err := r.byte()
+			if err != nil {
+				return fail(err)
+			}
+			// 音量、声道与微调
+			var finePitch int16
+			if version >= 4 {
+				err = r.skip(2, func() error { _, err := r.byte(); return err })
+				if err == nil {
+					finePitch, err = r.short()
+				}
+				if err != nil {
+					return fail(err)
+				}
+			}
+			if instrument >= vanillaInstruments || int(instrument) >= len(noteInstruments) {
+				instrument = 0
+			}
+			notes = append(notes, musicNote{
+				Tick:       int(math.Round(float64(tick) * secondsPerTick * redstoneTicksPerSecond)),
+				Instrument: int(instrument),
+				Pitch:      foldNotePitch(int(key) - nbsLowestKey + int(math.Round(float64(finePitch)/100))),
+			})
+		}
+	}
+	return notes, nil
+}
@@ -0,0 +1,231 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"phoenixbuilder/fastbuilder/types"
+
+	"github.com/disintegration/imaging"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+const (
+	DitherNone    = "none"
+	DitherFloyd   = "floyd"
+	DitherOrdered = "ordered"
+)
+
+const (
+	ColorDistanceRGB       = "rgb"
+	ColorDistanceLab       = "lab"
+	ColorDistanceCIEDE2000 = "ciede2000"
+)
+
+// 有序抖动所使用的 4*4 Bayer 矩阵
+var bayerMatrix4x4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// 有序抖动时单个像素所能偏移的最大幅度 (0~255)
+const orderedDitherSpread = 32.0
+
+// 描述调色板文件中的单个条目，
+// 与地图画使用的颜色表格式一致
+type paletteFileEntry struct {
+	Name  string    `json:"block"`
+	Meta  int       `json:"meta"`
+	Color []float64 `json:"color"`
+}
+
+// 从 path 指定的 JSON 文件读取调色板。
+// 文件应为一个数组，每个元素形如
+// {"block": "concrete", "meta": 0, "color": [207, 213, 214]}
+func LoadPalette(path string) ([]ColorBlock, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPalette: %v", err)
+	}
+	var entries []paletteFileEntry
+	err = json.Unmarshal(content, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPalette: Failed to decode %s; err = %v", path, err)
+	}
+	palette := make([]ColorBlock, 0, len(entries))
+	for index, value := range entries {
+		if len(value.Name) == 0 {
+			return nil, fmt.Errorf("LoadPalette: Entry %d has no block name", index)
+		}
+		if len(value.Color) != 3 {
+			return nil, fmt.Errorf("LoadPalette: Entry %d (%s) should have exactly 3 color components", index, value.Name)
+		}
+		palette = append(palette, ColorBlock{
+			Block: &types.ConstBlock{Name: value.Name, Data: uint16(value.Meta)},
+			Color: colorful.Color{R: value.Color[0], G: value.Color[1], B: value.Color[2]},
+		})
+	}
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("LoadPalette: %s contains no blocks", path)
+	}
+	return palette, nil
+}
+
+// 返回 name 所指代的颜色距离算法
+func getColorDistanceFunc(name string) (func(c1, c2 colorful.Color) float64, error) {
+	switch name {
+	case "", ColorDistanceRGB:
+		return colorful.Color.DistanceRgb, nil
+	case ColorDistanceLab:
+		return colorful.Color.DistanceLab, nil
+	case ColorDistanceCIEDE2000:
+		return colorful.Color.DistanceCIEDE2000, nil
+	}
+	return nil, fmt.Errorf("getColorDistanceFunc: Unknown color distance %#v, expected rgb, lab or ciede2000", name)
+}
+
+// quantizer 用于将任意颜色映射到调色板中最接近的方块
+type quantizer struct {
+	palette  []ColorBlock
+	colors   []colorful.Color
+	distance func(c1, c2 colorful.Color) float64
+	cache    map[uint32]int
+}
+
+// 以 palette 作为调色板、distance 作为颜色距离算法创建新的 quantizer 。
+// palette 中的颜色应以 0~255 表示
+func newQuantizer(palette []ColorBlock, distance string) (*quantizer, error) {
+	distanceFunc, err := getColorDistanceFunc(distance)
+	if err != nil {
+		return nil, fmt.Errorf("newQuantizer: %v", err)
+	}
+	colors := make([]colorful.Color, len(palette))
+	for index, value := range palette {
+		colors[index] = colorful.Color{
+			R: value.Color.R / 255.0,
+			G: value.Color.G / 255.0,
+			B: value.Color.B / 255.0,
+		}
+	}
+	return &quantizer{
+		palette:  palette,
+		colors:   colors,
+		distance: distanceFunc,
+		cache:    make(map[uint32]int),
+	}, nil
+}
+
+// 返回与 (r, g, b) 最接近的调色板条目的索引。
+// r, g, b 以 0~255 表示，超出范围的值将被截断
+func (q *quantizer) nearest(r, g, b float64) int {
+	r8, g8, b8 := uint32(clip(int64(r+0.5))), uint32(clip(int64(g+0.5))), uint32(clip(int64(b+0.5)))
+	key := r8<<16 | g8<<8 | b8
+	if index, ok := q.cache[key]; ok {
+		return index
+	}
+	target := colorful.Color{
+		R: float64(r8) / 255.0,
+		G: float64(g8) / 255.0,
+		B: float64(b8) / 255.0,
+	}
+	best, bestDistance := 0, q.distance(target, q.colors[0])
+	for index, value := range q.colors[1:] {
+		if current := q.distance(target, value); current < bestDistance {
+			best, bestDistance = index+1, current
+		}
+	}
+	q.cache[key] = best
+	return best
+}
+
+// 按照 ditherMode 所指示的抖动方式量化 img 。
+// 不透明度低于 alphaThreshold 的像素不会被量化，
+// 其在返回的矩阵中对应 nil 。
+// 返回的矩阵以 [y][x] 索引，同时返回量化后的图像
+func (q *quantizer) quantize(
+	img image.Image,
+	ditherMode string,
+	alphaThreshold int,
+) ([][]*ColorBlock, *image.NRGBA, error) {
+	source := imaging.Clone(img)
+	W, H := source.Bounds().Dx(), source.Bounds().Dy()
+	result := make([][]*ColorBlock, H)
+	matrix := make([][][3]float64, H)
+	transparent := make([][]bool, H)
+	for y := 0; y < H; y++ {
+		result[y] = make([]*ColorBlock, W)
+		matrix[y] = make([][3]float64, W)
+		transparent[y] = make([]bool, W)
+		for x := 0; x < W; x++ {
+			c := source.NRGBAAt(x, y)
+			matrix[y][x] = [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+			transparent[y][x] = int(c.A) < alphaThreshold
+		}
+	}
+	// 将 (x, y) 处的量化误差按 weight 扩散到该像素
+	diffuse := func(x, y int, err [3]float64, weight float64) {
+		if x < 0 || x >= W || y >= H || transparent[y][x] {
+			return
+		}
+		for i := 0; i < 3; i++ {
+			matrix[y][x][i] += err[i] * weight
+		}
+	}
+	switch ditherMode {
+	case "", DitherNone, DitherFloyd, DitherOrdered:
+	default:
+		return nil, nil, fmt.Errorf("quantize: Unknown dither mode %#v, expected none, floyd or ordered", ditherMode)
+	}
+	preview := imaging.New(W, H, color.NRGBA{})
+	for y := 0; y < H; y++ {
+		for x := 0; x < W; x++ {
+			if transparent[y][x] {
+				continue
+			}
+			current := matrix[y][x]
+			if ditherMode == DitherOrdered {
+				offset := (bayerMatrix4x4[y%4][x%4]/16.0 - 0.5) * orderedDitherSpread
+				current = [3]float64{current[0] + offset, current[1] + offset, current[2] + offset}
+			}
+			index := q.nearest(current[0], current[1], current[2])
+			result[y][x] = &q.palette[index]
+			realColor := q.palette[index].Color
+			preview.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(clip(int64(realColor.R))),
+				G: uint8(clip(int64(realColor.G))),
+				B: uint8(clip(int64(realColor.B))),
+				A: 255,
+			})
+			if ditherMode != DitherFloyd {
+				continue
+			}
+			err := [3]float64{
+				matrix[y][x][0] - realColor.R,
+				matrix[y][x][1] - realColor.G,
+				matrix[y][x][2] - realColor.B,
+			}
+			diffuse(x+1, y, err, 7.0/16.0)
+			diffuse(x-1, y+1, err, 3.0/16.0)
+			diffuse(x, y+1, err, 5.0/16.0)
+			diffuse(x+1, y+1, err, 1.0/16.0)
+		}
+	}
+	return result, preview, nil
+}
+
+// 将原图 original 与量化结果 quantized 左右并排后保存到 path
+func savePreview(path string, original image.Image, quantized image.Image) error {
+	W, H := quantized.Bounds().Dx(), quantized.Bounds().Dy()
+	canvas := imaging.New(W*2, H, color.NRGBA{})
+	canvas = imaging.Paste(canvas, original, image.Pt(0, 0))
+	canvas = imaging.Paste(canvas, quantized, image.Pt(W, 0))
+	err := imaging.Save(canvas, path)
+	if err != nil {
+		return fmt.Errorf("savePreview: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+// Package capture records the game packets of a connection into a file,
+// which can be inspected or replayed offline with pcapview.
+//
+// A capture file starts with Magic, followed by records of
+//
+//	[8 bytes] unix nano timestamp, little endian
+//	[1 byte]  direction
+//	[4 bytes] length of the content, little endian
+//	[n bytes] content
+//
+// The content of DirectionInbound and DirectionOutbound records is
+// the packet header followed by the payload, and that of DirectionSession
+// records is the JSON of SessionInfo. Every connection starts with a DirectionSession
+// record followed by all of its packets, beginning with the login sequence.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"sync"
+	"time"
+)
+
+var Magic = []byte("FBCAP\x01")
+
+const (
+	// Packets received from the server
+	DirectionInbound = iota
+	// Packets sent to the server
+	DirectionOutbound
+	// A connection is established, all the following packets belong to it
+	DirectionSession
+)
+
+const recordHeaderLength = 13
+
+// The largest content a record may have, records beyond are regarded as corrupted
+const MaximumRecordLength = 1 << 28
+
+// SessionInfo describes the bot of a connection
+type SessionInfo struct {
+	ServerCode      string
+	EntityRuntimeID uint64
+	EntityUniqueID  int64
+}
+
+type Record struct {
+	Time      time.Time
+	Direction uint8
+	Content   []byte
+}
+
+func DirectionName(direction uint8) string {
+	switch direction {
+	case DirectionInbound:
+		return "in"
+	case DirectionOutbound:
+		return "out"
+	case DirectionSession:
+		return "session"
+	default:
+		return "unknown"
+	}
+}
+
+// Writer appends records to a capture file, it is safe for concurrent use.
+// All the methods do nothing on a nil Writer.
+type Writer struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// Open opens path for appending records, Magic is written if the file is empty,
+// so that captures of restarted processes are kept in the same file.
+func Open(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		if _, err := file.Write(Magic); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return &Writer{file: file}, nil
+}
+
+// WriteRecord writes a record in a single write, so that a crash never leaves half of it
+func (w *Writer) WriteRecord(direction uint8, content []byte) error {
+	return w.writeRecord(time.Now(), direction, content)
+}
+
+func (w *Writer) writeRecord(recordTime time.Time, direction uint8, content []byte) error {
+	if w == nil {
+		return nil
+	}
+	record := make([]byte, recordHeaderLength, recordHeaderLength+len(content))
+	binary.LittleEndian.PutUint64(record, uint64(recordTime.UnixNano()))
+	record[8] = direction
+	binary.LittleEndian.PutUint32(record[9:], uint32(len(content)))
+	record = append(record, content...)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err := w.file.Write(record)
+	return err
+}
+
+func (w *Writer) WriteSession(info SessionInfo) error {
+	return w.writeSession(time.Now(), info)
+}
+
+func (w *Writer) writeSession(recordTime time.Time, info SessionInfo) error {
+	if w == nil {
+		return nil
+	}
+	content, _ := json.Marshal(info)
+	return w.writeRecord(recordTime, DirectionSession, content)
+}
+
+// Session records every packet of a connection, including those of the login sequence.
+// As SessionInfo is known only after logging in, the packets are held until Start
+// writes the session record, and are written directly afterwards.
+// All the methods do nothing on a nil Session.
+type Session struct {
+	writer  *Writer
+	created time.Time
+	lock    sync.Mutex
+	// A client always writes the first packet, so its source is the local address,
+	// which tells the direction of the rest
+	localAddress string
+	started      bool
+	pending      []Record
+}
+
+// NewSession returns a Session for a connection about to be dialed, nil if w is nil.
+// A new Session is needed for every connection.
+func (w *Writer) NewSession() *Session {
+	if w == nil {
+		return nil
+	}
+	return &Session{writer: w, created: time.Now()}
+}
+
+// PacketFunc is a function for minecraft.Dialer.PacketFunc,
+// which is called for the packets both read from and written to the connection
+func (s *Session) PacketFunc(header packet.Header, payload []byte, src, _ net.Addr) {
+	if s == nil {
+		return
+	}
+	now := time.Now()
+	buf := bytes.NewBuffer(make([]byte, 0, len(payload)+4))
+	header.Write(buf)
+	buf.Write(payload)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.localAddress == "" {
+		s.localAddress = src.String()
+	}
+	direction := uint8(DirectionInbound)
+	if src.String() == s.localAddress {
+		direction = DirectionOutbound
+	}
+	if !s.started {
+		s.pending = append(s.pending, Record{Time: now, Direction: direction, Content: buf.Bytes()})
+		return
+	}
+	s.writer.writeRecord(now, direction, buf.Bytes())
+}
+
+// Start writes the session record, timed when the Session was created,
+// followed by the packets held so far
+func (s *Session) Start(info SessionInfo) error {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started {
+		return nil
+	}
+	s.started = true
+	pending := s.pending
+	s.pending = nil
+	if err := s.writer.writeSession(s.created, info); err != nil {
+		return err
+	}
+	for _, record := range pending {
+		if err := s.writer.writeRecord(record.Time, record.Direction, record.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.file.Close()
+}
+
+type Reader struct {
+	reader *bufio.Reader
+}
+
+// NewReader checks Magic and returns a Reader for the records following it
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := bufio.NewReader(r)
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return nil, fmt.Errorf("NewReader: %v", err)
+	}
+	if !bytes.Equal(magic, Magic) {
+		return nil, fmt.Errorf("NewReader: not a capture file")
+	}
+	return &Reader{reader: reader}, nil
+}
+
+// Next returns the next record, or io.EOF if there is none.
+// A record cut off at the end of the file, e.g. by a crash, results in io.ErrUnexpectedEOF.
+func (r *Reader) Next() (*Record, error) {
+	header := make([]byte, recordHeaderLength)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(header[9:])
+	if length > MaximumRecordLength {
+		return nil, fmt.Errorf("Next: record of %d bytes is too large", length)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, content); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return &Record{
+		Time:      time.Unix(0, int64(binary.LittleEndian.Uint64(header))),
+		Direction: header[8],
+		Content:   content,
+	}, nil
+}
+
+// Session parses the content of a DirectionSession record
+func (r *Record) Session() (SessionInfo, error) {
+	info := SessionInfo{}
+	if r.Direction != DirectionSession {
+		return info, fmt.Errorf("Session: not a session record")
+	}
+	err := json.Unmarshal(r.Content, &info)
+	return info, err
+}
@@ -0,0 +1,97 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"testing"
+)
+
+func TestSessionRecordsLoginPacketsAfterSessionRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.bin")
+	writer, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	local := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50000}
+	remote := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 19132}
+	session := writer.NewSession()
+
+	// The login sequence, before the session is known
+	login := []byte{1}
+	session.PacketFunc(packet.Header{PacketID: packet.IDLogin}, login, local, remote)
+	startGame := []byte{2, 3}
+	session.PacketFunc(packet.Header{PacketID: packet.IDStartGame}, startGame, remote, local)
+	if err := session.Start(SessionInfo{ServerCode: "123456", EntityRuntimeID: 1}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	sent := []byte{4, 5, 6}
+	session.PacketFunc(packet.Header{PacketID: packet.IDCommandRequest}, sent, local, remote)
+	received := []byte{7, 8}
+	session.PacketFunc(packet.Header{PacketID: packet.IDText}, received, remote, local)
+	writer.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer file.Close()
+	reader, err := NewReader(file)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	record, err := reader.Next()
+	if err != nil {
+		t.Fatalf("session record: %v", err)
+	}
+	if info, err := record.Session(); err != nil || info.ServerCode != "123456" || info.EntityRuntimeID != 1 {
+		t.Fatalf("session record: got %+v, %v", info, err)
+	}
+	want := []struct {
+		direction uint8
+		packetID  uint32
+		payload   []byte
+	}{
+		{DirectionOutbound, packet.IDLogin, login},
+		{DirectionInbound, packet.IDStartGame, startGame},
+		{DirectionOutbound, packet.IDCommandRequest, sent},
+		{DirectionInbound, packet.IDText, received},
+	}
+	for index, expected := range want {
+		record, err := reader.Next()
+		if err != nil {
+			t.Fatalf("record #%d: %v", index, err)
+		}
+		content := bytes.NewBuffer(record.Content)
+		header := packet.Header{}
+		if err := header.Read(content); err != nil {
+			t.Fatalf("record #%d: %v", index, err)
+		}
+		if record.Direction != expected.direction || header.PacketID != expected.packetID || !bytes.Equal(content.Bytes(), expected.payload) {
+			t.Fatalf(
+				"record #%d: got %s packet %d %v, want %s packet %d %v",
+				index,
+				DirectionName(record.Direction), header.PacketID, content.Bytes(),
+				DirectionName(expected.direction), expected.packetID, expected.payload,
+			)
+		}
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatalf("got %v after the last record, want io.EOF", err)
+	}
+}
+
+func TestNilWriterSession(t *testing.T) {
+	var writer *Writer
+	session := writer.NewSession()
+	if session != nil {
+		t.Fatalf("got %v, want a nil Session", session)
+	}
+	session.PacketFunc(packet.Header{PacketID: packet.IDText}, nil, &net.UDPAddr{}, &net.UDPAddr{})
+	if err := session.Start(SessionInfo{}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+// pcapview inspects the capture files written by --capture
+// and replays them without connecting to any server.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"phoenixbuilder/fastbuilder/capture"
+	"phoenixbuilder/fastbuilder/external/connection"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+type jsonRecord struct {
+	Index     int         `json:"index"`
+	Time      time.Time   `json:"time"`
+	Direction string      `json:"direction"`
+	Packet    string      `json:"packet,omitempty"`
+	PacketID  uint32      `json:"packet_id"`
+	Length    int         `json:"length"`
+	Body      interface{} `json:"body,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// replayer feeds the inbound packets into a UQHolder and Resources
+// the same way as the worker thread does
+type replayer struct {
+	holder        *uqHolder.UQHolder
+	resources     *ResourcesControl.Resources
+	handle        func(pk *packet.Packet)
+	propagate     bool
+	replayed      int
+	failed        int
+	sessionsCount int
+}
+
+func (r *replayer) startSession(info capture.SessionInfo) {
+	r.holder = uqHolder.NewUQHolder(info.EntityRuntimeID)
+	r.resources = &ResourcesControl.Resources{}
+	r.handle = r.resources.Init()
+	r.sessionsCount++
+}
+
+func (r *replayer) replay(index int, pk packet.Packet) {
+	if r.holder == nil {
+		// Captured without a session record, the runtime ID of the bot is unknown
+		r.startSession(capture.SessionInfo{})
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if r.propagate {
+				panic(recovered)
+			}
+			r.failed++
+			fmt.Fprintf(os.Stderr, "replay: #%d %s: %v\n%s", index, connection.PacketTypeName(pk), recovered, debug.Stack())
+		}
+	}()
+	if startGame, ok := pk.(*packet.StartGame); ok {
+		// The connection handles StartGame itself, and the worker thread
+		// applies it by UQHolder.UpdateFromConn after logging in
+		r.holder.BotUniqueID = startGame.EntityUniqueID
+		r.holder.BotRuntimeID = startGame.EntityRuntimeID
+		r.holder.WorldName = startGame.WorldName
+		r.holder.WorldGameMode = startGame.WorldGameMode
+		r.holder.WorldDifficulty = uint32(startGame.Difficulty)
+		r.holder.OnConnectWoldSpawnPosition = startGame.WorldSpawn
+	}
+	r.holder.Update(pk)
+	r.handle(&pk)
+	r.replayed++
+}
+
+func main() {
+	types := flag.String("type", "", "only show the packets of these comma separated types, e.g. Text,IDCommandOutput")
+	direction := flag.String("direction", "all", "only show the packets of this direction: in, out or all")
+	printJSON := flag.Bool("json", false, "print every packet as a line of JSON, including its decoded body")
+	replay := flag.Bool("replay", false, "replay the inbound packets into UQHolder and Resources")
+	propagate := flag.Bool("panic", false, "stop with the original panic when replaying a packet fails")
+	uqholderOutput := flag.String("uqholder", "", "write the UQHolder as JSON to this file after replaying, implies -replay")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s [options] <capture file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *direction != "all" && *direction != "in" && *direction != "out" {
+		fmt.Fprintf(os.Stderr, "Unknown direction %#v\n", *direction)
+		os.Exit(1)
+	}
+	wantedIDs := map[uint32]bool{}
+	if *types != "" {
+		for _, name := range strings.Split(*types, ",") {
+			id, err := connection.PacketTypeToID(strings.TrimSpace(name))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			wantedIDs[id] = true
+		}
+	}
+	if *uqholderOutput != "" {
+		*replay = true
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open the capture file: %v\n", err)
+		os.Exit(2)
+	}
+	defer file.Close()
+	reader, err := capture.NewReader(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	replayer := &replayer{propagate: *propagate}
+	encoder := json.NewEncoder(os.Stdout)
+	var startTime time.Time
+	for index := 0; ; index++ {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Stopped at record #%d: %v\n", index, err)
+			break
+		}
+		if startTime.IsZero() {
+			startTime = record.Time
+		}
+		if record.Direction == capture.DirectionSession {
+			info, err := record.Session()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid session record #%d: %v\n", index, err)
+				continue
+			}
+			if *replay {
+				replayer.startSession(info)
+			}
+			if !*printJSON {
+				fmt.Printf("[%10.3fs] session server=%s runtime_id=%d\n", record.Time.Sub(startTime).Seconds(), info.ServerCode, info.EntityRuntimeID)
+			}
+			continue
+		}
+		id := connection.GamePacketID(record.Content)
+		pk, decodeErr := connection.DecodeGamePacket(record.Content)
+		if *replay && record.Direction == capture.DirectionInbound && decodeErr == nil {
+			replayer.replay(index, pk)
+		}
+		if len(wantedIDs) != 0 && !wantedIDs[id] {
+			continue
+		}
+		if *direction != "all" && *direction != capture.DirectionName(record.Direction) {
+			continue
+		}
+		name := ""
+		if decodeErr == nil {
+			name = connection.PacketTypeName(pk)
+		}
+		if *printJSON {
+			line := jsonRecord{
+				Index:     index,
+				Time:      record.Time,
+				Direction: capture.DirectionName(record.Direction),
+				Packet:    name,
+				PacketID:  id,
+				Length:    len(record.Content),
+			}
+			if decodeErr != nil {
+				line.Error = decodeErr.Error()
+			} else {
+				line.Body = pk
+			}
+			if err := encoder.Encode(line); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to encode record #%d: %v\n", index, err)
+			}
+			continue
+		}
+		if decodeErr != nil {
+			name = fmt.Sprintf("<%v>", decodeErr)
+		}
+		fmt.Printf(
+			"[%10.3fs] %-3s #%d %s (id %d, %d bytes)\n",
+			record.Time.Sub(startTime).Seconds(),
+			capture.DirectionName(record.Direction),
+			index,
+			name,
+			id,
+			len(record.Content),
+		)
+	}
+
+	if !*replay {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Replayed %d inbound packets in %d sessions, %d failed\n", replayer.replayed, replayer.sessionsCount, replayer.failed)
+	if *uqholderOutput != "" && replayer.holder != nil {
+		content, err := json.MarshalIndent(replayer.holder, "", "\t")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode the UQHolder: %v\n", err)
+			os.Exit(3)
+		}
+		if err := os.WriteFile(*uqholderOutput, content, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write the UQHolder: %v\n", err)
+			os.Exit(3)
+		}
+	}
+}
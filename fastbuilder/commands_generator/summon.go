@@ -3,12 +3,42 @@ package commands_generator
 import (
 	"fmt"
 	"phoenixbuilder/fastbuilder/types"
+	"strings"
 )
 
 
 func SummonRequest(module *types.Module, config *types.MainConfig) string {
+	if module.Entity != nil {
+		return summonEntityRequest(module)
+	}
 	entity := config.Entity
 	point := module.Point
 	return fmt.Sprintf("summon %s %d %d %d", entity, point.X, point.Y, point.Z)
 }
 
+// Summons the entity carried by module at its exact position,
+// keeping its custom name if there is one
+func summonEntityRequest(module *types.Module) string {
+	x, y, z := EntityPosition(module)
+	customName, _ := module.Entity.NBT["CustomName"].(string)
+	if len(customName) == 0 {
+		return fmt.Sprintf("summon %s %.4f %.4f %.4f", module.Entity.Identifier, x, y, z)
+	}
+	customName = strings.ReplaceAll(customName, `\`, `\\`)
+	customName = strings.ReplaceAll(customName, `"`, `\"`)
+	return fmt.Sprintf(`summon %s "%s" %.4f %.4f %.4f`, module.Entity.Identifier, customName, x, y, z)
+}
+
+// Returns the absolute position of the entity carried by module
+func EntityPosition(module *types.Module) (float64, float64, float64) {
+	return float64(module.Point.X) + float64(module.Entity.Offset[0]),
+		float64(module.Point.Y) + float64(module.Entity.Offset[1]),
+		float64(module.Point.Z) + float64(module.Entity.Offset[2])
+}
+
+// Returns a selector that matches the entity carried by module
+// and nothing else, provided that it has been summoned already
+func EntitySelector(module *types.Module) string {
+	x, y, z := EntityPosition(module)
+	return fmt.Sprintf("@e[type=%s,x=%.4f,y=%.4f,z=%.4f,r=0.5,c=1]", module.Entity.Identifier, x, y, z)
+}
@@ -54,6 +54,9 @@ func CreateFullConfig() *FullConfig {
 		MapX:      1,
 		MapZ:      1,
 		MapY:      0,
+		Dither:         "none",
+		ColorDistance:  "rgb",
+		AlphaThreshold: 128,
 	}
 	dConf := types.DelayConfig {
 		Delay:     decideDelay(types.DelayModeContinuous),
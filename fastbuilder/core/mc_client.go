@@ -3,12 +3,14 @@ package core
 import (
 	"context"
 	"fmt"
+	"net"
 	"phoenixbuilder/minecraft"
 	"phoenixbuilder/minecraft/protocol/packet"
 	"phoenixbuilder/fastbuilder/py_rpc"
 )
 
-func InitializeMinecraftConnection(ctx context.Context, authentication minecraft.Authenticator, options ...Option) (conn *minecraft.Conn, err error) {
+// packetFunc is called for every packet written to the connection if not nil, see minecraft.Dialer
+func InitializeMinecraftConnection(ctx context.Context, authentication minecraft.Authenticator, packetFunc func(header packet.Header, payload []byte, src, dst net.Addr), options ...Option) (conn *minecraft.Conn, err error) {
 	if checkOption(options, OptionDebug) {
 		conn = &minecraft.Conn{
 			DebugMode: true,
@@ -16,6 +18,7 @@ func InitializeMinecraftConnection(ctx context.Context, authentication minecraft
 	} else {
 		dialer := minecraft.Dialer{
 			Authenticator: authentication,
+			PacketFunc:    packetFunc,
 		}
 		conn, err = dialer.DialContext(ctx, "raknet")
 	}
@@ -4,6 +4,7 @@ package environment
 import (
 	"phoenixbuilder/fastbuilder/environment/interfaces"
 	fbauth "phoenixbuilder/fastbuilder/pv4"
+	"sync"
 )
 
 type LoginInfo struct {
@@ -33,12 +34,15 @@ type PBEnvironment struct {
 	OmegaAdaptorHolder        interface{}
 	ActivateTaskStatus        chan bool
 	ExternalConnectionHandler interface{}
+	WebSocketGateway          interface{}
 	Destructors               []func()
 	isStopping                bool
 	stoppedWaiter             chan struct{}
 	LRUMemoryChunkCacher      interface{}
 	ChunkFeeder               interface{}
 	ClientOptions             *fbauth.ClientOptions
+	// Guards GameInterface, which is replaced when reconnecting
+	gameInterfaceLock sync.RWMutex
 }
 
 func (env *PBEnvironment) Stop() {
@@ -59,3 +63,21 @@ func (env *PBEnvironment) WaitStopped() {
 	//fmt.Println("waitting stopped")
 	<-env.stoppedWaiter
 }
+
+// CurrentGameInterface returns the GameInterface of the current connection.
+// Anything running across reconnections (e.g. tasks) should call it
+// whenever it needs GameInterface, rather than keeping the one it started with.
+func (env *PBEnvironment) CurrentGameInterface() interfaces.GameInterface {
+	env.gameInterfaceLock.RLock()
+	defer env.gameInterfaceLock.RUnlock()
+	return env.GameInterface
+}
+
+// SetGameInterface publishes the GameInterface of a new connection.
+// The previous one is left untouched, so those still using it
+// only see the requests to the lost connection fail.
+func (env *PBEnvironment) SetGameInterface(gameInterface interfaces.GameInterface) {
+	env.gameInterfaceLock.Lock()
+	defer env.gameInterfaceLock.Unlock()
+	env.GameInterface = gameInterface
+}
@@ -0,0 +1,75 @@
+package external
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/external/packet"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"time"
+)
+
+// The time to wait for the output of a command if the client doesn't specify one
+const DefaultCommandTimeout = 30 * time.Second
+
+// sendCommandWithResponse sends command through ResourcesControl and waits for its output,
+// commandType is either packet.CommandTypeNormal or packet.CommandTypeWebsocket.
+func sendCommandWithResponse(
+	env *environment.PBEnvironment,
+	commandType uint8,
+	command string,
+	timeout time.Duration,
+) ResourcesControl.CommandRespond {
+	gameInterface := env.CurrentGameInterface()
+	if gameInterface == nil {
+		return ResourcesControl.CommandRespond{
+			Error:     fmt.Errorf("not connected to the server yet"),
+			ErrorType: ResourcesControl.ErrCommandRequestOthers,
+		}
+	}
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	options := ResourcesControl.CommandRequestOptions{TimeOut: timeout}
+	switch commandType {
+	case packet.CommandTypeNormal:
+		return gameInterface.SendCommandWithResponse(command, options)
+	case packet.CommandTypeWebsocket:
+		return gameInterface.SendWSCommandWithResponse(command, options)
+	default:
+		return ResourcesControl.CommandRespond{
+			Error:     fmt.Errorf("command type %d has no response", commandType),
+			ErrorType: ResourcesControl.ErrCommandRequestOthers,
+		}
+	}
+}
+
+// handleCommandRequest answers the request with a CommandResponsePacket carrying the same RequestID
+func handleCommandRequest(env *environment.PBEnvironment, request *packet.CommandRequestPacket) *packet.CommandResponsePacket {
+	resp := sendCommandWithResponse(
+		env,
+		request.CommandType,
+		request.Command,
+		time.Duration(request.TimeoutMS)*time.Millisecond,
+	)
+	response := &packet.CommandResponsePacket{
+		RequestID:      request.RequestID,
+		ErrorType:      resp.ErrorType,
+		OutputMessages: []packet.CommandOutputMessage{},
+	}
+	if resp.Error != nil {
+		response.Error = resp.Error.Error()
+		if response.ErrorType == ResourcesControl.CommandRequestOK {
+			response.ErrorType = ResourcesControl.ErrCommandRequestOthers
+		}
+		return response
+	}
+	response.SuccessCount = resp.Respond.SuccessCount
+	for _, message := range resp.Respond.OutputMessages {
+		response.OutputMessages = append(response.OutputMessages, packet.CommandOutputMessage{
+			Success:    message.Success,
+			Message:    message.Message,
+			Parameters: message.Parameters,
+		})
+	}
+	return response
+}
@@ -0,0 +1,372 @@
+package connection
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Frame types used by the authentication handshake,
+// which happens right after the encrypted session is established.
+const (
+	authChallengeFrame = 0x10
+	authResponseFrame  = 0x11
+	authResultFrame    = 0x12
+)
+
+const (
+	authMethodToken = iota
+	authMethodKeyPair
+)
+
+const (
+	authResultAccepted = iota
+	authResultRejected
+)
+
+const authNonceLength = 32
+
+// Scope describes what an external client is allowed to do.
+type Scope uint8
+
+const (
+	// Receive game packets and query the UQHolder
+	ScopeReadPackets Scope = 1 << iota
+	// Send Minecraft commands
+	ScopeMCCommands
+	// Execute PhoenixBuilder commands
+	ScopeFBCommands
+	// Write raw game packets to the server
+	ScopeRawPackets
+
+	ScopeAll = ScopeReadPackets | ScopeMCCommands | ScopeFBCommands | ScopeRawPackets
+)
+
+var scopeNames = map[string]Scope{
+	"read_packets": ScopeReadPackets,
+	"mc_commands":  ScopeMCCommands,
+	"fb_commands":  ScopeFBCommands,
+	"raw_packets":  ScopeRawPackets,
+	"all":          ScopeAll,
+}
+
+func ParseScopes(names []string) (Scope, error) {
+	scope := Scope(0)
+	for _, name := range names {
+		value, found := scopeNames[name]
+		if !found {
+			return 0, fmt.Errorf("unknown scope %#v", name)
+		}
+		scope |= value
+	}
+	return scope, nil
+}
+
+func (s Scope) Has(scope Scope) bool {
+	return s&scope == scope
+}
+
+func (s Scope) String() string {
+	names := []string{}
+	for _, name := range []string{"read_packets", "mc_commands", "fb_commands", "raw_packets"} {
+		if s.Has(scopeNames[name]) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// AuthorizedClient is a client that may connect to the external listener,
+// it is identified either by a pre-shared token or by its public key.
+type AuthorizedClient struct {
+	Name      string
+	Token     string
+	PublicKey *ecdsa.PublicKey
+	Scopes    Scope
+}
+
+type Authenticator struct {
+	Clients []AuthorizedClient
+}
+
+type authorizedClientFile struct {
+	Name      string   `json:"name"`
+	Token     string   `json:"token,omitempty"`
+	PublicKey string   `json:"public_key,omitempty"`
+	Scopes    []string `json:"scopes"`
+}
+
+type authenticatorFile struct {
+	Clients []authorizedClientFile `json:"clients"`
+}
+
+// LoadAuthenticator reads the authorized clients from a json file like
+//
+//	{"clients": [
+//		{"name": "bot", "token": "...", "scopes": ["read_packets", "mc_commands"]},
+//		{"name": "admin", "public_key": "-----BEGIN PUBLIC KEY-----...", "scopes": ["all"]}
+//	]}
+func LoadAuthenticator(path string) (*Authenticator, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := authenticatorFile{}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	authenticator := &Authenticator{}
+	for i, client := range file.Clients {
+		scopes, err := ParseScopes(client.Scopes)
+		if err != nil {
+			return nil, fmt.Errorf("%v: client %d: %v", path, i, err)
+		}
+		authorized := AuthorizedClient{Name: client.Name, Token: client.Token, Scopes: scopes}
+		if client.PublicKey != "" {
+			authorized.PublicKey, err = ParsePublicKey(client.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("%v: client %d: %v", path, i, err)
+			}
+		}
+		if authorized.Token == "" && authorized.PublicKey == nil {
+			return nil, fmt.Errorf("%v: client %d has neither a token nor a public key", path, i)
+		}
+		authenticator.Clients = append(authenticator.Clients, authorized)
+	}
+	if len(authenticator.Clients) == 0 {
+		return nil, fmt.Errorf("%v: no client is authorized", path)
+	}
+	return authenticator, nil
+}
+
+// CreateAuthenticatorFile writes an authenticator file with a single client
+// that owns a newly generated token and all scopes, the token is returned.
+func CreateAuthenticatorFile(path string) (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(tokenBytes)
+	content, _ := json.MarshalIndent(authenticatorFile{
+		Clients: []authorizedClientFile{{Name: "default", Token: token, Scopes: []string{"all"}}},
+	}, "", "\t")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func ParsePublicKey(content string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(content))
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected ECDSA public key, but got %T", key)
+	}
+	return ecdsaKey, nil
+}
+
+// ClientCredential is what a client uses to prove its identity,
+// either Token or PrivateKey should be set.
+type ClientCredential struct {
+	Token      string
+	PrivateKey *ecdsa.PrivateKey
+}
+
+// ParseClientCredential treats a PEM encoded EC private key as a key pair credential,
+// and anything else as a token.
+func ParseClientCredential(content string) (*ClientCredential, error) {
+	block, _ := pem.Decode([]byte(content))
+	if block == nil {
+		return &ClientCredential{Token: strings.TrimSpace(content)}, nil
+	}
+	var key interface{}
+	var err error
+	if block.Type == "EC PRIVATE KEY" {
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected ECDSA private key, but got %T", key)
+	}
+	return &ClientCredential{PrivateKey: ecdsaKey}, nil
+}
+
+// AuthenticatedConnection is a connection which has passed the handshake,
+// Scopes are the permissions of the client.
+type AuthenticatedConnection struct {
+	ReliableConnection
+	ClientName string
+	Scopes     Scope
+}
+
+func tokenProof(token string, role string, nonce []byte, transcript []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(role))
+	mac.Write(nonce)
+	mac.Write(transcript)
+	return mac.Sum(nil)
+}
+
+func keyPairDigest(nonce []byte, transcript []byte) []byte {
+	digest := sha256.Sum256(append(append([]byte("client"), nonce...), transcript...))
+	return digest[:]
+}
+
+// Authenticate runs the responder side of the handshake on an established encrypted channel.
+// Proofs are bound to the transcript of the key exchange,
+// so they can't be relayed by a man in the middle.
+func (a *Authenticator) Authenticate(channel *EncryptedChannel) (*AuthenticatedConnection, error) {
+	nonce := make([]byte, authNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	if err := channel.SendFrame(append([]byte{authChallengeFrame}, nonce...)); err != nil {
+		return nil, err
+	}
+	response, err := channel.RecvFrame()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read authentication response: %v", err)
+	}
+	if len(response) < 2 || response[0] != authResponseFrame {
+		return nil, fmt.Errorf("unexpected frame received when authenticating")
+	}
+	var client *AuthorizedClient
+	var serverProof []byte
+	switch response[1] {
+	case authMethodToken:
+		for i := range a.Clients {
+			candidate := &a.Clients[i]
+			if candidate.Token == "" {
+				continue
+			}
+			expected := tokenProof(candidate.Token, "client", nonce, channel.transcript)
+			if subtle.ConstantTimeCompare(expected, response[2:]) == 1 {
+				client = candidate
+				serverProof = tokenProof(candidate.Token, "server", nonce, channel.transcript)
+				break
+			}
+		}
+	case authMethodKeyPair:
+		if len(response) < 4 {
+			break
+		}
+		keyLength := int(response[2])<<8 | int(response[3])
+		if len(response) < 4+keyLength {
+			break
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(response[4 : 4+keyLength])
+		if err != nil {
+			break
+		}
+		ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			break
+		}
+		for i := range a.Clients {
+			candidate := &a.Clients[i]
+			if candidate.PublicKey != nil && candidate.PublicKey.Equal(ecdsaKey) {
+				if ecdsa.VerifyASN1(ecdsaKey, keyPairDigest(nonce, channel.transcript), response[4+keyLength:]) {
+					client = candidate
+				}
+				break
+			}
+		}
+	}
+	if client == nil {
+		channel.SendFrame([]byte{authResultFrame, authResultRejected})
+		return nil, fmt.Errorf("authentication failed")
+	}
+	result := append([]byte{authResultFrame, authResultAccepted, byte(client.Scopes)}, serverProof...)
+	if err := channel.SendFrame(result); err != nil {
+		return nil, err
+	}
+	return &AuthenticatedConnection{
+		ReliableConnection: channel,
+		ClientName:         client.Name,
+		Scopes:             client.Scopes,
+	}, nil
+}
+
+// authenticate runs the initiator side of the handshake and returns the granted scopes.
+// When a token is used, the responder has to prove that it knows the token as well.
+func (c *ClientCredential) authenticate(channel *EncryptedChannel) (Scope, error) {
+	challenge, err := channel.RecvFrame()
+	if err != nil {
+		return 0, fmt.Errorf("cannot read authentication challenge: %v", err)
+	}
+	if len(challenge) != 1+authNonceLength || challenge[0] != authChallengeFrame {
+		return 0, fmt.Errorf("unexpected frame received when authenticating")
+	}
+	nonce := challenge[1:]
+	var response []byte
+	if c.PrivateKey != nil {
+		encodedPublicKey, err := x509.MarshalPKIXPublicKey(&c.PrivateKey.PublicKey)
+		if err != nil {
+			return 0, err
+		}
+		signature, err := ecdsa.SignASN1(rand.Reader, c.PrivateKey, keyPairDigest(nonce, channel.transcript))
+		if err != nil {
+			return 0, err
+		}
+		response = []byte{authResponseFrame, authMethodKeyPair, byte(len(encodedPublicKey) >> 8), byte(len(encodedPublicKey))}
+		response = append(append(response, encodedPublicKey...), signature...)
+	} else {
+		response = append([]byte{authResponseFrame, authMethodToken}, tokenProof(c.Token, "client", nonce, channel.transcript)...)
+	}
+	if err := channel.SendFrame(response); err != nil {
+		return 0, err
+	}
+	result, err := channel.RecvFrame()
+	if err != nil {
+		return 0, fmt.Errorf("cannot read authentication result: %v", err)
+	}
+	if len(result) < 2 || result[0] != authResultFrame {
+		return 0, fmt.Errorf("unexpected frame received when authenticating")
+	}
+	if result[1] != authResultAccepted || len(result) < 3 {
+		return 0, fmt.Errorf("authentication rejected by server")
+	}
+	if c.PrivateKey == nil {
+		expected := tokenProof(c.Token, "server", nonce, channel.transcript)
+		if subtle.ConstantTimeCompare(expected, result[3:]) != 1 {
+			return 0, fmt.Errorf("server failed to prove the knowledge of the token")
+		}
+	}
+	return Scope(result[2]), nil
+}
+
+// AuthenticateToken finds the client owning token,
+// it is used by transports that can't run the handshake, e.g. WebSocket.
+func (a *Authenticator) AuthenticateToken(token string) (*AuthorizedClient, bool) {
+	if token == "" {
+		return nil, false
+	}
+	for i := range a.Clients {
+		candidate := &a.Clients[i]
+		if candidate.Token != "" && subtle.ConstantTimeCompare([]byte(candidate.Token), []byte(token)) == 1 {
+			return candidate, true
+		}
+	}
+	return nil, false
+}
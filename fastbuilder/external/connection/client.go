@@ -1,13 +1,14 @@
 package connection
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"phoenixbuilder/fastbuilder/external/packet"
 	"phoenixbuilder/fastbuilder/uqHolder"
-	"phoenixbuilder/minecraft/protocol"
 	mc_packet "phoenixbuilder/minecraft/protocol/packet"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,16 +16,28 @@ import (
 
 var ErrSendOnClosedConnection = fmt.Errorf("send on closed connection")
 var ErrRecvOnClosedConnection = fmt.Errorf("recv on closed connection")
-var TypePool mc_packet.Pool
 
 type Client struct {
-	conn             ReliableConnection
+	conn ReliableConnection
+	// Scopes granted by the server
+	Scopes           Scope
 	close            chan struct{}
 	byeSent          bool
 	closed           bool
 	pongDeadline     time.Time
 	gamePackets      chan []byte
 	uqHolderWaitChan chan []byte
+	statsWaitChan    chan packet.ConsumerStats
+	taskListWaitChan chan *packet.TaskListResponsePacket
+	// only one task list request is in flight, as the responses are not correlated
+	taskListLock sync.Mutex
+	// only one UQHolder request is in flight for the same reason
+	uqHolderLock sync.Mutex
+	// command requests waiting for their responses, keyed by RequestID
+	pendingCommands     map[uint32]chan *packet.CommandResponsePacket
+	pendingCommandsLock sync.Mutex
+	nextRequestID       uint32
+	onConnectionStatus  func(*packet.ConnectionStatusPacket)
 }
 
 //func (c *Client) SendFrame(f []byte) error {
@@ -66,12 +79,7 @@ func (c *Client) SendFBCmd(cmd string) error {
 }
 
 func (c *Client) SendMCPacket(pk mc_packet.Packet) error {
-	b := &bytes.Buffer{}
-	w := protocol.NewWriter(b, 0)
-	hdr := pk.ID()
-	w.Varuint32(&hdr)
-	pk.Marshal(w)
-	p := &packet.GamePacket{Content: b.Bytes()}
+	p := &packet.GamePacket{Content: EncodeGamePacket(pk)}
 	return c.Send(p)
 }
 
@@ -97,6 +105,50 @@ func (c *Client) SendMCCmd(cmd string) (uuid.UUID, error) {
 	return c.sendMCCmd(packet.CommandTypeNormal, cmd)
 }
 
+// sendMCCmdWithResponse sends the command and waits for the response with the same RequestID,
+// the server waits for 30 seconds if timeout is 0
+func (c *Client) sendMCCmdWithResponse(commandType byte, cmd string, timeout time.Duration) (*packet.CommandResponsePacket, error) {
+	requestID := atomic.AddUint32(&c.nextRequestID, 1)
+	waitChan := make(chan *packet.CommandResponsePacket, 1)
+	c.pendingCommandsLock.Lock()
+	c.pendingCommands[requestID] = waitChan
+	c.pendingCommandsLock.Unlock()
+	defer func() {
+		c.pendingCommandsLock.Lock()
+		delete(c.pendingCommands, requestID)
+		c.pendingCommandsLock.Unlock()
+	}()
+	err := c.Send(&packet.CommandRequestPacket{
+		RequestID:   requestID,
+		CommandType: commandType,
+		TimeoutMS:   uint32(timeout / time.Millisecond),
+		Command:     cmd,
+	})
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case response := <-waitChan:
+		if response.Error != "" {
+			return response, fmt.Errorf("%s", response.Error)
+		}
+		return response, nil
+	case <-c.close:
+		return nil, ErrRecvOnClosedConnection
+	}
+}
+
+// SendMCCmdWithResponse sends cmd as the bot and waits for its output,
+// timeout is how long the server waits for the output (30 seconds if 0)
+func (c *Client) SendMCCmdWithResponse(cmd string, timeout time.Duration) (*packet.CommandResponsePacket, error) {
+	return c.sendMCCmdWithResponse(packet.CommandTypeNormal, cmd, timeout)
+}
+
+// SendWSCmdWithResponse is like SendMCCmdWithResponse, but cmd is sent as a websocket command
+func (c *Client) SendWSCmdWithResponse(cmd string, timeout time.Duration) (*packet.CommandResponsePacket, error) {
+	return c.sendMCCmdWithResponse(packet.CommandTypeWebsocket, cmd, timeout)
+}
+
 func (c *Client) Send(pk packet.Packet) error {
 	return c.SendFrame(packet.Serialize(pk))
 }
@@ -144,38 +196,121 @@ func (c *Client) RecvDecodedGamePacket() (pk mc_packet.Packet, err error) {
 	if err != nil {
 		return nil, err
 	}
-	pkFn, hasK := TypePool[uint32(mcPkt[0])]
-	if !hasK {
-		return nil, fmt.Errorf("cannot decode packet %v", mcPkt[0])
-	}
-	pk = pkFn()
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("%v", r)
-			//bytes.NewReader().Read()
-			//pk.Unmarshal(protocol.NewReader(&NoEOFByteReader{s: mcPkt[1:]}, 0))
-		}
-	}()
-	pk.Unmarshal(protocol.NewReader(&NoEOFByteReader{s: mcPkt[1:]}, 0))
-	return pk, nil
+	return DecodeGamePacket(mcPkt)
 }
 
-// RequestUQHolder currently support request "*" only
+// RequestUQHolder currently support request "*" only, which requires ScopeReadPackets
 func (c *Client) RequestUQHolder(request string) (*uqHolder.UQHolder, error) {
-	err := c.Send(&packet.UQHolderRequestPacket{QueryString: []byte(request)})
-	if err != nil {
-		return nil, err
+	return c.RequestUQHolderContext(context.Background(), request)
+}
+
+// RequestUQHolderContext is like RequestUQHolder, but returns ctx.Err() once ctx is done.
+// A request already sent is still waited for in the background,
+// so that its late response is discarded instead of answering the next request.
+func (c *Client) RequestUQHolderContext(ctx context.Context, request string) (*uqHolder.UQHolder, error) {
+	if !c.Scopes.Has(ScopeReadPackets) {
+		return nil, fmt.Errorf("RequestUQHolder: the scope %v is not granted", ScopeReadPackets)
+	}
+	type result struct {
+		content []byte
+		err     error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		c.uqHolderLock.Lock()
+		defer c.uqHolderLock.Unlock()
+		if err := ctx.Err(); err != nil {
+			resultChan <- result{err: err}
+			return
+		}
+		err := c.Send(&packet.UQHolderRequestPacket{QueryString: []byte(request)})
+		if err != nil {
+			resultChan <- result{err: err}
+			return
+		}
+		select {
+		case cont := <-c.uqHolderWaitChan:
+			resultChan <- result{content: cont}
+		case <-c.close:
+			resultChan <- result{err: ErrRecvOnClosedConnection}
+		}
+	}()
+	var r result
+	select {
+	case r = <-resultChan:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		return nil, r.err
 	}
-	cont := <-c.uqHolderWaitChan
 	//fmt.Println(len(cont))
 	uq := uqHolder.NewUQHolder(0)
-	if err = uq.UnMarshal(cont); err != nil {
+	if err := uq.UnMarshal(r.content); err != nil {
 		return nil, err
 	} else {
 		return uq, nil
 	}
 }
 
+// SetPacketFilter makes the server only send the game packets with the specified IDs,
+// all packets are sent if packetIDs is empty
+func (c *Client) SetPacketFilter(packetIDs []uint32) error {
+	return c.Send(&packet.GamePacketFilterPacket{PacketIDs: packetIDs})
+}
+
+// SetConsumerOptions configures the buffer of game packets on the server,
+// see packet.OverflowPolicyDisconnect and the other policies
+func (c *Client) SetConsumerOptions(bufferSize uint32, overflowPolicy uint8, droppablePacketIDs []uint32) error {
+	return c.Send(&packet.ConsumerOptionsPacket{
+		BufferSize:         bufferSize,
+		OverflowPolicy:     overflowPolicy,
+		DroppablePacketIDs: droppablePacketIDs,
+	})
+}
+
+// RequestConsumerStats returns the statistics of the buffer of game packets on the server
+func (c *Client) RequestConsumerStats() (packet.ConsumerStats, error) {
+	err := c.Send(&packet.ConsumerStatsRequestPacket{})
+	if err != nil {
+		return packet.ConsumerStats{}, err
+	}
+	select {
+	case stats := <-c.statsWaitChan:
+		return stats, nil
+	case <-c.close:
+		return packet.ConsumerStats{}, ErrRecvOnClosedConnection
+	}
+}
+
+// RequestTaskList returns the tasks held by FastBuilder, which requires ScopeFBCommands.
+// It is answered after the FB commands sent before it are processed,
+// so the tasks created by them are listed unless they have already finished.
+func (c *Client) RequestTaskList() (*packet.TaskListResponsePacket, error) {
+	if !c.Scopes.Has(ScopeFBCommands) {
+		return nil, fmt.Errorf("RequestTaskList: the scope %v is not granted", ScopeFBCommands)
+	}
+	c.taskListLock.Lock()
+	defer c.taskListLock.Unlock()
+	err := c.Send(&packet.TaskListRequestPacket{})
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case response := <-c.taskListWaitChan:
+		return response, nil
+	case <-c.close:
+		return nil, ErrRecvOnClosedConnection
+	}
+}
+
+// SetOnConnectionStatus sets the function called when the bot is disconnected from
+// or reconnected to the server, the connection to the bot itself stays open meanwhile
+func (c *Client) SetOnConnectionStatus(fn func(*packet.ConnectionStatusPacket)) {
+	c.onConnectionStatus = fn
+}
+
+// Deprecated: the server now excludes the packet entirely when dropBy > 1, use SetPacketFilter instead
 func (c *Client) ReducePacket(pktID uint8, dropBy uint8) error {
 	return c.Send(&packet.GamePacketReducePacket{
 		PacketID: pktID,
@@ -250,37 +385,59 @@ func (c *Client) routine() {
 				c.gamePackets <- p.Content
 			case *packet.UQHolderResponsePacket:
 				c.uqHolderWaitChan <- p.Content
+			case *packet.ConsumerStatsResponsePacket:
+				c.statsWaitChan <- p.Stats
+			case *packet.TaskListResponsePacket:
+				c.taskListWaitChan <- p
+			case *packet.ConnectionStatusPacket:
+				if c.onConnectionStatus != nil {
+					go c.onConnectionStatus(p)
+				}
+			case *packet.CommandResponsePacket:
+				c.pendingCommandsLock.Lock()
+				waitChan, found := c.pendingCommands[p.RequestID]
+				c.pendingCommandsLock.Unlock()
+				if found {
+					waitChan <- p
+				}
 			}
 		}
 	}
 }
 
-func NewClient(address string) *Client {
-	retChan := make(chan ReliableConnection)
+func NewClient(address string, credential *ClientCredential) *Client {
+	type dialResult struct {
+		conn   ReliableConnection
+		scopes Scope
+	}
+	retChan := make(chan dialResult, 1)
 	go func() {
-		conn, err := ClientDial(address)
+		conn, scopes, err := ClientDial(address, credential)
 		if err != nil {
-			retChan <- nil
+			fmt.Printf("Failed to connect to %v: %v\n", address, err)
+			retChan <- dialResult{}
+			return
 		}
-		retChan <- conn
+		retChan <- dialResult{conn: conn, scopes: scopes}
 	}()
 	select {
-	case <-time.After(3 * time.Second):
+	case <-time.After(HANDSHAKE_TIMEOUT):
 		return nil
-	case conn := <-retChan:
-		if conn == nil {
+	case result := <-retChan:
+		if result.conn == nil {
 			return nil
 		}
 		c := &Client{
-			conn:             conn,
+			conn:             result.conn,
+			Scopes:           result.scopes,
 			close:            make(chan struct{}),
 			closed:           false,
 			pongDeadline:     time.Now().Add(3 * time.Second),
 			gamePackets:      make(chan []byte, 1024),
-			uqHolderWaitChan: make(chan []byte),
-		}
-		if TypePool == nil {
-			TypePool = mc_packet.NewPool()
+			uqHolderWaitChan: make(chan []byte, 1),
+			statsWaitChan:    make(chan packet.ConsumerStats, 1),
+			taskListWaitChan: make(chan *packet.TaskListResponsePacket, 1),
+			pendingCommands:  map[uint32]chan *packet.CommandResponsePacket{},
 		}
 		go c.routine()
 		return c
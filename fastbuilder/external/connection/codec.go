@@ -0,0 +1,95 @@
+package connection
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"phoenixbuilder/minecraft/protocol"
+	mc_packet "phoenixbuilder/minecraft/protocol/packet"
+	"reflect"
+	"strings"
+)
+
+var TypePool mc_packet.Pool = mc_packet.NewPool()
+
+// PacketTypes maps the type names of game packets to their IDs, e.g. Text -> mc_packet.IDText
+var PacketTypes = map[string]uint32{}
+
+func init() {
+	for id, fn := range TypePool {
+		PacketTypes[reflect.TypeOf(fn()).Elem().Name()] = id
+	}
+}
+
+// PacketTypeToID accepts both Text and IDText
+func PacketTypeToID(packetType string) (uint32, error) {
+	if id, ok := PacketTypes[packetType]; ok {
+		return id, nil
+	}
+	if id, ok := PacketTypes[strings.TrimPrefix(packetType, "ID")]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown packet type %#v", packetType)
+}
+
+func PacketTypeName(pk mc_packet.Packet) string {
+	return reflect.TypeOf(pk).Elem().Name()
+}
+
+// DecodeGamePacket decodes the bytes of a game packet, which starts with its header
+func DecodeGamePacket(pktBytes []byte) (pk mc_packet.Packet, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot decode packet: %v", r)
+		}
+	}()
+	reader := protocol.NewReader(&NoEOFByteReader{s: pktBytes}, 0)
+	header := uint32(0)
+	reader.Varuint32(&header)
+	pkFn, found := TypePool[header&0x3ff]
+	if !found {
+		return nil, fmt.Errorf("cannot decode packet %v", header&0x3ff)
+	}
+	pk = pkFn()
+	pk.Unmarshal(reader)
+	return pk, nil
+}
+
+// EncodeGamePacket encodes pk along with its header
+func EncodeGamePacket(pk mc_packet.Packet) []byte {
+	b := &bytes.Buffer{}
+	w := protocol.NewWriter(b, 0)
+	hdr := pk.ID()
+	w.Varuint32(&hdr)
+	pk.Marshal(w)
+	return b.Bytes()
+}
+
+// GamePacketBytesToJSON decodes the bytes of a game packet as is into JSON
+func GamePacketBytesToJSON(pktBytes []byte) ([]byte, error) {
+	pk, err := DecodeGamePacket(pktBytes)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(pk)
+}
+
+// JSONToGamePacket parses the JSON body of the packet with ID packetID
+func JSONToGamePacket(packetID uint32, body []byte) (mc_packet.Packet, error) {
+	pkFn, found := TypePool[packetID]
+	if !found {
+		return nil, fmt.Errorf("unknown packet %v", packetID)
+	}
+	pk := pkFn()
+	if err := json.Unmarshal(body, pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// GamePacketID reads the packet ID from the header of a game packet without decoding it
+func GamePacketID(pktBytes []byte) uint32 {
+	header, _ := binary.Uvarint(pktBytes)
+	return uint32(header) & 0x3ff
+}
@@ -4,12 +4,14 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"time"
 
 	kcp "github.com/xtaci/kcp-go/v5"
 )
@@ -23,38 +25,51 @@ type ReliableConnection interface {
 
 type ReliableConnectionServerHandler interface {
 	Listen(address string) error
-	SetOnNewConnection(func(ReliableConnection))
+	SetOnNewConnection(func(*AuthenticatedConnection))
 	SetOnAcceptNewConnectionFail(func(error))
 	SetOnServerDown(func(interface{}))
 }
 
 type ConnectionServerHandler struct {
+	// Every connection has to pass the authentication before being handled
+	Authenticator             *Authenticator
 	onAcceptNewConnectionFail func(error)
-	onNewConnection           func(ReliableConnection)
+	onNewConnection           func(*AuthenticatedConnection)
 	OnNewKCPConnection        func(*kcp.UDPSession)
 	onServerDown              func(interface{})
+	listener                  net.Listener
+	closed                    bool
 }
 
 const (
 	DATA_SHARDS   = 10
 	PARITY_SHARDS = 3
-	ENCRYPTION_ON = false
+	// The encryption and authentication should be finished in this duration
+	HANDSHAKE_TIMEOUT = 10 * time.Second
 )
 
 func (s *ConnectionServerHandler) Listen(address string) error {
+	if s.Authenticator == nil {
+		return fmt.Errorf("listen fail (err=no authenticator specified)")
+	}
 	//listener, err := kcp.ListenWithOptions(address, nil, DATA_SHARDS, PARITY_SHARDS)
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return fmt.Errorf("listen fail (err=%v)", err)
 	}
-	fmt.Println("listen @ address", err)
+	s.listener = listener
 	go func() {
 		defer func() {
 			r := recover()
-			s.onServerDown(r)
+			if !s.closed {
+				s.onServerDown(r)
+			}
 		}()
 		for {
 			proxyConn, err := listener.Accept()
+			if s.closed {
+				return
+			}
 			if err != nil {
 				fmt.Printf("Transfer: accept new connection fail\n\t(err=%v)\n", err)
 				if s.onAcceptNewConnectionFail != nil {
@@ -62,33 +77,64 @@ func (s *ConnectionServerHandler) Listen(address string) error {
 				}
 				continue
 			}
-			remoteDescription := proxyConn.RemoteAddr().String()
-			fmt.Printf("Transfer: accept new connection @ %v\n", remoteDescription)
-			var chann ReliableConnection
-			chann = &StreamChannelWrapper{reader: proxyConn, writer: proxyConn, writeLock: sync.Mutex{}}
-			connectionCloser := func() {
-				proxyConn.Close()
-			}
-			if ENCRYPTION_ON {
-				encryptionConn := &EncryptedChannel{
-					connection: chann,
-					isInitator: false,
-					closer:     connectionCloser,
-				}
-				if encryptionConn.Init() != nil {
-					//fmt.Printf("Transfer: encryption init fail\n")
-					s.onAcceptNewConnectionFail(fmt.Errorf("encryption init fail: %v", err))
-					continue
-				}
-			}
-			s.onNewConnection(chann)
+			go s.handshake(proxyConn)
 		}
 
 	}()
 	return nil
 }
 
-func (s *ConnectionServerHandler) SetOnNewConnection(fn func(ReliableConnection)) {
+// Close stops accepting new connections, so that the address can be listened on again
+func (s *ConnectionServerHandler) Close() error {
+	if s.listener == nil || s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.listener.Close()
+}
+
+// handshake establishes the encrypted session and authenticates the client,
+// the connection is closed if either of them fails.
+func (s *ConnectionServerHandler) handshake(proxyConn net.Conn) {
+	remoteDescription := proxyConn.RemoteAddr().String()
+	// The data of an unauthenticated client must never bring the process down
+	defer func() {
+		if r := recover(); r != nil {
+			proxyConn.Close()
+			if s.onAcceptNewConnectionFail != nil {
+				s.onAcceptNewConnectionFail(fmt.Errorf("handshake panicked @ %v: %v", remoteDescription, r))
+			}
+		}
+	}()
+	proxyConn.SetDeadline(time.Now().Add(HANDSHAKE_TIMEOUT))
+	encryptedConn := &EncryptedChannel{
+		connection: &StreamChannelWrapper{reader: proxyConn, writer: proxyConn, writeLock: sync.Mutex{}},
+		isInitator: false,
+		closer: func() {
+			proxyConn.Close()
+		},
+	}
+	if err := encryptedConn.Init(); err != nil {
+		proxyConn.Close()
+		if s.onAcceptNewConnectionFail != nil {
+			s.onAcceptNewConnectionFail(fmt.Errorf("encryption init fail @ %v: %v", remoteDescription, err))
+		}
+		return
+	}
+	authenticatedConn, err := s.Authenticator.Authenticate(encryptedConn)
+	if err != nil {
+		proxyConn.Close()
+		if s.onAcceptNewConnectionFail != nil {
+			s.onAcceptNewConnectionFail(fmt.Errorf("authentication fail @ %v: %v", remoteDescription, err))
+		}
+		return
+	}
+	proxyConn.SetDeadline(time.Time{})
+	fmt.Printf("Transfer: accept new connection @ %v (client=%v, scopes=%v)\n", remoteDescription, authenticatedConn.ClientName, authenticatedConn.Scopes)
+	s.onNewConnection(authenticatedConn)
+}
+
+func (s *ConnectionServerHandler) SetOnNewConnection(fn func(*AuthenticatedConnection)) {
 	s.onNewConnection = fn
 }
 func (s *ConnectionServerHandler) SetOnAcceptNewConnectionFail(fn func(error)) {
@@ -98,25 +144,33 @@ func (s *ConnectionServerHandler) SetOnServerDown(fn func(r interface{})) {
 	s.onServerDown = fn
 }
 
-func ClientDial(address string) (ReliableConnection, error) {
+// ClientDial connects to address, establishes the encrypted session and authenticates with credential,
+// the scopes granted by the server are returned along with the connection.
+func ClientDial(address string, credential *ClientCredential) (ReliableConnection, Scope, error) {
 	//conn, err := kcp.DialWithOptions(address, nil, DATA_SHARDS, PARITY_SHARDS)
 	conn, err := net.Dial("tcp", address)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	var chann ReliableConnection
-	chann = &StreamChannelWrapper{reader: conn, writer: conn, writeLock: sync.Mutex{}}
-	if ENCRYPTION_ON {
-		encryptedConn := &EncryptedChannel{
-			connection: chann,
-			isInitator: true,
-		}
-		if err := encryptedConn.Init(); err != nil {
-			return nil, err
-		}
-		chann = encryptedConn
+	conn.SetDeadline(time.Now().Add(HANDSHAKE_TIMEOUT))
+	encryptedConn := &EncryptedChannel{
+		connection: &StreamChannelWrapper{reader: conn, writer: conn, writeLock: sync.Mutex{}},
+		isInitator: true,
+		closer: func() {
+			conn.Close()
+		},
+	}
+	if err := encryptedConn.Init(); err != nil {
+		conn.Close()
+		return nil, 0, err
 	}
-	return chann, nil
+	scopes, err := credential.authenticate(encryptedConn)
+	if err != nil {
+		conn.Close()
+		return nil, 0, err
+	}
+	conn.SetDeadline(time.Time{})
+	return encryptedConn, scopes, nil
 }
 
 // Frame -> Stream -> Frame
@@ -146,6 +200,13 @@ type StreamChannelWrapper struct {
 
 const MAX_STD_HEADER_LEN = 1 << 15
 
+// The largest frame RecvFrame accepts, the length comes from the peer,
+// which may not even be authenticated yet, so it is checked before allocating
+const MAX_FRAME_LEN = 1 << 26
+
+var ErrFrameTooLarge = fmt.Errorf("frame exceeds the limit of %d bytes", MAX_FRAME_LEN)
+var ErrEmptyHandshakeFrame = fmt.Errorf("empty frame received when establishing an encrypted session")
+
 func (_ *StreamChannelWrapper) Close() {
 	// Not exported
 }
@@ -167,6 +228,10 @@ func (scw *StreamChannelWrapper) RecvFrame() ([]byte, error) {
 			scw.isClosed = true
 			return nil, err
 		}
+		if uvarint > MAX_FRAME_LEN {
+			scw.isClosed = true
+			return nil, ErrFrameTooLarge
+		}
 		length = uvarint
 	} else {
 		// ok
@@ -191,6 +256,9 @@ func (scw *StreamChannelWrapper) SendFrame(data []byte) error {
 	scw.writeLock.Lock()
 	defer scw.writeLock.Unlock()
 	length := len(data)
+	if length > MAX_FRAME_LEN {
+		return ErrFrameTooLarge
+	}
 	headerBytes := make([]byte, binary.MaxVarintLen64+2) // 2 is the length of short(std) head
 	headerBytesLen := 0
 	if length > MAX_STD_HEADER_LEN {
@@ -226,6 +294,10 @@ type EncryptedChannel struct {
 	connection ReliableConnection
 	isInitator bool
 	encryptor  *EncryptionSession
+	// hash of the salt and both public keys,
+	// used to bind the authentication to this session
+	transcript []byte
+	sendLock   sync.Mutex
 	isClosed   bool
 	closer     func()
 }
@@ -249,6 +321,9 @@ func (i *EncryptedChannel) initiateEncryptSession() error {
 	if err != nil {
 		return fmt.Errorf("cannot get public key from responder: %v", err)
 	}
+	if len(data) == 0 {
+		return ErrEmptyHandshakeFrame
+	}
 	if data[0] != 0x03 {
 		return fmt.Errorf("Got unexpected command %d when establishing an encrypted session", data[0])
 	}
@@ -262,6 +337,7 @@ func (i *EncryptedChannel) initiateEncryptSession() error {
 		return fmt.Errorf("expected ECDSA public key, but got %v", responderPubKeyData)
 	}
 	*responderPublicKey = *ecdsaKey
+	i.transcript = sessionTranscript(salt, encodedInitiatorPublicKey, data[1:])
 	i.encryptor = &EncryptionSession{PublicKey: responderPublicKey, PrivateKey: initiatorPrivateKey, Salt: salt}
 	return i.encryptor.Init()
 }
@@ -271,9 +347,15 @@ func (r *EncryptedChannel) waitForEncryptSession() error {
 	if err != nil {
 		return fmt.Errorf("cannot read data from Initiator: %v", err)
 	}
+	if len(initiatorPubkeyAndSaltData) == 0 {
+		return ErrEmptyHandshakeFrame
+	}
 	if initiatorPubkeyAndSaltData[0] != 0x04 {
 		return fmt.Errorf("Unexcepted Packet ID %d received", initiatorPubkeyAndSaltData[0])
 	}
+	if len(initiatorPubkeyAndSaltData) < 17 {
+		return fmt.Errorf("the public key and salt of initiator are too short (%d bytes)", len(initiatorPubkeyAndSaltData))
+	}
 	responderPrivateKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
 	encodedResponderPublicKey, _ := x509.MarshalPKIXPublicKey(&responderPrivateKey.PublicKey)
 	err = r.connection.SendFrame(append([]byte{0x03}, encodedResponderPublicKey...))
@@ -292,10 +374,19 @@ func (r *EncryptedChannel) waitForEncryptSession() error {
 		return fmt.Errorf("expected ECDSA public key, but got %v", initiatorPublicKeyData)
 	}
 	*initiatorPublicKey = *ecdsaKey
+	r.transcript = sessionTranscript(peerSalt, peerPublicKey, encodedResponderPublicKey)
 	r.encryptor = &EncryptionSession{PublicKey: initiatorPublicKey, PrivateKey: responderPrivateKey, Salt: peerSalt}
 	return r.encryptor.Init()
 }
 
+func sessionTranscript(salt []byte, initiatorPublicKey []byte, responderPublicKey []byte) []byte {
+	transcript := sha256.New()
+	transcript.Write(salt)
+	transcript.Write(initiatorPublicKey)
+	transcript.Write(responderPublicKey)
+	return transcript.Sum(nil)
+}
+
 func (e *EncryptedChannel) Init() error {
 	if e.isInitator {
 		return e.initiateEncryptSession()
@@ -305,9 +396,12 @@ func (e *EncryptedChannel) Init() error {
 }
 
 func (e *EncryptedChannel) SendFrame(data []byte) error {
-	encyptedData := data[:]
-	e.encryptor.Encrypt(encyptedData)
-	return e.connection.SendFrame(append([]byte{0x06}, encyptedData...))
+	// the stream cipher has to be fed in the same order as the frames are sent
+	e.sendLock.Lock()
+	defer e.sendLock.Unlock()
+	encyptedData := append([]byte{0x06}, data...)
+	e.encryptor.Encrypt(encyptedData[1:])
+	return e.connection.SendFrame(encyptedData)
 }
 
 func (e *EncryptedChannel) RecvFrame() ([]byte, error) {
@@ -316,9 +410,9 @@ func (e *EncryptedChannel) RecvFrame() ([]byte, error) {
 		e.isClosed = true
 		return nil, err
 	}
-	if encryptedData[0] != 0x06 {
+	if len(encryptedData) == 0 || encryptedData[0] != 0x06 {
 		e.Close()
-		return nil, fmt.Errorf("Data with unknown type %d incorrectly handled by EncryptedChannel.", encryptedData[0])
+		return nil, fmt.Errorf("Data with unknown type incorrectly handled by EncryptedChannel.")
 	}
 	e.encryptor.Decrypt(encryptedData[1:])
 	return encryptedData[1:], nil
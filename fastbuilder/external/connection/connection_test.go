@@ -0,0 +1,101 @@
+package connection
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// listenForTest starts a server on a free port, the failed handshakes are sent to the returned channel
+func listenForTest(t *testing.T) (string, chan error) {
+	t.Helper()
+	authFile := filepath.Join(t.TempDir(), "external_auth.json")
+	if _, err := CreateAuthenticatorFile(authFile); err != nil {
+		t.Fatalf("CreateAuthenticatorFile: %v", err)
+	}
+	authenticator, err := LoadAuthenticator(authFile)
+	if err != nil {
+		t.Fatalf("LoadAuthenticator: %v", err)
+	}
+	failures := make(chan error, 1)
+	server := &ConnectionServerHandler{Authenticator: authenticator}
+	server.SetOnNewConnection(func(conn *AuthenticatedConnection) { conn.Close() })
+	server.SetOnAcceptNewConnectionFail(func(err error) { failures <- err })
+	server.SetOnServerDown(func(_ interface{}) {})
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server.listener.Addr().String(), failures
+}
+
+func TestHandshakeRejectsEmptyFrame(t *testing.T) {
+	address, failures := listenForTest(t)
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	// A frame of zero length
+	if _, err := conn.Write([]byte{0, 0}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	select {
+	case err := <-failures:
+		if !strings.Contains(err.Error(), ErrEmptyHandshakeFrame.Error()) {
+			t.Fatalf("got %v, want %v", err, ErrEmptyHandshakeFrame)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handshake didn't fail")
+	}
+}
+
+func TestInitiatorRejectsEmptyFrame(t *testing.T) {
+	initiatorConn, responderConn := net.Pipe()
+	defer initiatorConn.Close()
+	defer responderConn.Close()
+	responder := &StreamChannelWrapper{reader: responderConn, writer: responderConn, writeLock: sync.Mutex{}}
+	go func() {
+		if _, err := responder.RecvFrame(); err == nil {
+			responder.SendFrame([]byte{})
+		}
+	}()
+	initiator := &EncryptedChannel{
+		connection: &StreamChannelWrapper{reader: initiatorConn, writer: initiatorConn, writeLock: sync.Mutex{}},
+		isInitator: true,
+		closer:     func() { initiatorConn.Close() },
+	}
+	if err := initiator.Init(); err != ErrEmptyHandshakeFrame {
+		t.Fatalf("got %v, want %v", err, ErrEmptyHandshakeFrame)
+	}
+}
+
+func TestRecvFrameRejectsOversizedLength(t *testing.T) {
+	header := make([]byte, 2+binary.MaxVarintLen64)
+	binary.LittleEndian.PutUint16(header, MAX_STD_HEADER_LEN+1)
+	n := binary.PutUvarint(header[2:], 1<<40)
+	wrapper := &StreamChannelWrapper{reader: bytes.NewReader(header[:2+n])}
+	if _, err := wrapper.RecvFrame(); err != ErrFrameTooLarge {
+		t.Fatalf("got %v, want %v", err, ErrFrameTooLarge)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	wrapper := &StreamChannelWrapper{reader: buffer, writer: buffer}
+	for _, size := range []int{0, 10, MAX_STD_HEADER_LEN + 1} {
+		data := bytes.Repeat([]byte{0x5a}, size)
+		if err := wrapper.SendFrame(data); err != nil {
+			t.Fatalf("SendFrame(%d bytes): %v", size, err)
+		}
+		received, err := wrapper.RecvFrame()
+		if err != nil || !bytes.Equal(received, data) {
+			t.Fatalf("RecvFrame(%d bytes): got %d bytes, %v", size, len(received), err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package external
+
+import (
+	"phoenixbuilder/fastbuilder/external/packet"
+	"sync"
+)
+
+const (
+	// The default count of game packets buffered for each consumer
+	DefaultConsumerBufferSize = 1024 * 8
+	// The largest buffer a consumer may ask for
+	MaximumConsumerBufferSize = 1024 * 64
+)
+
+// consumer buffers the game packets for an external client in a bounded ring,
+// so that a slow client never blocks the delivery to the others.
+type consumer struct {
+	clientName string
+	lock       sync.Mutex
+	ring       [][]byte
+	ringIDs    []uint32
+	head       int
+	size       int
+	policy     uint8
+	droppable  map[uint32]bool
+	// only the packets in allowed are buffered, all if nil
+	allowed map[uint32]bool
+	// packets excluded by the deprecated GamePacketReducePacket
+	excluded    map[uint32]bool
+	sent        uint64
+	dropped     uint64
+	droppedByID map[uint32]uint64
+	// receives a value when packets are buffered
+	notify    chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newConsumer(clientName string, bufferSize int) *consumer {
+	return &consumer{
+		clientName:  clientName,
+		ring:        make([][]byte, bufferSize),
+		ringIDs:     make([]uint32, bufferSize),
+		policy:      packet.OverflowPolicyDisconnect,
+		droppable:   map[uint32]bool{},
+		excluded:    map[uint32]bool{},
+		droppedByID: map[uint32]uint64{},
+		notify:      make(chan struct{}, 1),
+		closed:      make(chan struct{}),
+	}
+}
+
+func (c *consumer) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+}
+
+func (c *consumer) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// drop removes the buffered packet at the offset index from the oldest one
+func (c *consumer) drop(index int) {
+	position := (c.head + index) % len(c.ring)
+	c.recordDrop(c.ringIDs[position])
+	for i := index; i > 0; i-- {
+		current := (c.head + i) % len(c.ring)
+		previous := (c.head + i - 1) % len(c.ring)
+		c.ring[current], c.ringIDs[current] = c.ring[previous], c.ringIDs[previous]
+	}
+	c.ring[c.head] = nil
+	c.head = (c.head + 1) % len(c.ring)
+	c.size--
+}
+
+func (c *consumer) recordDrop(id uint32) {
+	c.dropped++
+	c.droppedByID[id]++
+}
+
+// push buffers pkt, it never blocks.
+// The overflow policy is applied when the buffer is full.
+func (c *consumer) push(id uint32, pkt []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.excluded[id] || (c.allowed != nil && !c.allowed[id]) {
+		return
+	}
+	if c.size == len(c.ring) {
+		switch c.policy {
+		case packet.OverflowPolicyDropOldest:
+			c.drop(0)
+		case packet.OverflowPolicyDropByPacketID:
+			index := -1
+			for i := 0; i < c.size; i++ {
+				if c.droppable[c.ringIDs[(c.head+i)%len(c.ring)]] {
+					index = i
+					break
+				}
+			}
+			if index == -1 && c.droppable[id] {
+				c.recordDrop(id)
+				return
+			}
+			if index == -1 {
+				index = 0
+			}
+			c.drop(index)
+		default:
+			c.recordDrop(id)
+			c.close()
+			return
+		}
+	}
+	position := (c.head + c.size) % len(c.ring)
+	c.ring[position], c.ringIDs[position] = pkt, id
+	c.size++
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// popAll takes all the buffered packets
+func (c *consumer) popAll() [][]byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	packets := make([][]byte, 0, c.size)
+	for c.size > 0 {
+		packets = append(packets, c.ring[c.head])
+		c.ring[c.head] = nil
+		c.head = (c.head + 1) % len(c.ring)
+		c.size--
+	}
+	c.sent += uint64(len(packets))
+	return packets
+}
+
+func (c *consumer) setFilter(ids []uint32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(ids) == 0 {
+		c.allowed = nil
+		return
+	}
+	c.allowed = map[uint32]bool{}
+	for _, id := range ids {
+		c.allowed[id] = true
+	}
+}
+
+func (c *consumer) exclude(id uint32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.excluded[id] = true
+}
+
+// setOptions changes the overflow policy and resizes the buffer,
+// the newest packets are kept when the buffer shrinks.
+func (c *consumer) setOptions(options *packet.ConsumerOptionsPacket) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.policy = options.OverflowPolicy
+	c.droppable = map[uint32]bool{}
+	for _, id := range options.DroppablePacketIDs {
+		c.droppable[id] = true
+	}
+	bufferSize := int(options.BufferSize)
+	if bufferSize > MaximumConsumerBufferSize {
+		bufferSize = MaximumConsumerBufferSize
+	}
+	if bufferSize == 0 || bufferSize == len(c.ring) {
+		return
+	}
+	for c.size > bufferSize {
+		c.drop(0)
+	}
+	ring := make([][]byte, bufferSize)
+	ringIDs := make([]uint32, bufferSize)
+	for i := 0; i < c.size; i++ {
+		position := (c.head + i) % len(c.ring)
+		ring[i], ringIDs[i] = c.ring[position], c.ringIDs[position]
+	}
+	c.ring, c.ringIDs, c.head = ring, ringIDs, 0
+}
+
+func (c *consumer) stats() packet.ConsumerStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	droppedByID := make(map[uint32]uint64, len(c.droppedByID))
+	for id, count := range c.droppedByID {
+		droppedByID[id] = count
+	}
+	return packet.ConsumerStats{
+		ClientName:     c.clientName,
+		Buffered:       c.size,
+		BufferSize:     len(c.ring),
+		OverflowPolicy: c.policy,
+		Sent:           c.sent,
+		Dropped:        c.dropped,
+		DroppedByID:    droppedByID,
+	}
+}
@@ -54,8 +54,8 @@ class JsonStrAsIsGamePacketBytes_return(ctypes.Structure):
 
 
 def InitLib(LIB):
-    # struct ConnectFB_return ConnectFB(char* address);
-    LIB.ConnectFB.argtypes = [GoString]
+    # struct ConnectFB_return ConnectFB(char* address, char* credential);
+    LIB.ConnectFB.argtypes = [GoString, GoString]
     LIB.ConnectFB.restype = ConnectFB_return
 
     # ReleaseConnByID(GoInt id);
@@ -161,8 +161,9 @@ else:
     LIB = ctypes.CDLL(libpath)
 LIB=InitLib(LIB)
 
-def ConnectFB(address: str) -> int:
-    r = LIB.ConnectFB(to_GoString(address))
+def ConnectFB(address: str, credential: str) -> int:
+    # credential 为 token 或 PEM 格式的 EC 私钥
+    r = LIB.ConnectFB(to_GoString(address), to_GoString(credential))
     check_err_in_struct(r)
     return r.connID
 
@@ -234,9 +235,10 @@ IDPlayerMove=19
 
 if __name__ == '__main__':
     # 首先启动 FB: fastbuilder --listen-external 0.0.0.0:3456
+    # 首次启动时， FB 会在 ~/.config/fastbuilder/external_auth.json 中生成一个拥有所有权限的 token
 
     # 连接到 FB
-    connID = ConnectFB("localhost:3456")
+    connID = ConnectFB("localhost:3456", "<token>")
 
     # 发送 FB 命令
     SendFBCommand(connID, "set 0 0 0")
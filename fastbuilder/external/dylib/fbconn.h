@@ -82,7 +82,7 @@ struct ConnectFB_return {
 	GoInt r0; /* connID */
 	char* r1; /* err */
 };
-extern struct ConnectFB_return ConnectFB(char* address);
+extern struct ConnectFB_return ConnectFB(char* address, char* credential);
 extern char* ReleaseConnByID(GoInt id);
 
 /* Return type for RecvGamePacket */
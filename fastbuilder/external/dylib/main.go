@@ -7,14 +7,9 @@ package main
 */
 import "C"
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"phoenixbuilder/fastbuilder/external/connection"
 	"phoenixbuilder/fastbuilder/external/packet"
-	"phoenixbuilder/minecraft/protocol"
-	mc_packet "phoenixbuilder/minecraft/protocol/packet"
 	"unsafe"
 )
 
@@ -43,11 +38,17 @@ func FreeMem(address unsafe.Pointer) {
 	C.free(address)
 }
 
+// credential is either a token or a PEM encoded EC private key
+//
 //export ConnectFB
-func ConnectFB(address *C.char) (connID int, err *C.char) {
+func ConnectFB(address *C.char, credential *C.char) (connID int, err *C.char) {
 	str := C.GoString(address)
 	// fmt.Println(str)
-	client := connection.NewClient(str)
+	clientCredential, _err := connection.ParseClientCredential(C.GoString(credential))
+	if _err != nil {
+		return -1, C.CString(_err.Error())
+	}
+	client := connection.NewClient(str, clientCredential)
 	if client == nil {
 		return -1, C.CString("connect fail")
 	}
@@ -167,67 +168,22 @@ func ReducePacket(connID int, pktID byte, rate byte) (err *C.char) {
 	return toCErrStr(_err)
 }
 
-type NoEOFByteReader struct {
-	s []byte
-	i int
-}
-
-func (nbr *NoEOFByteReader) Read(b []byte) (n int, err error) {
-	if len(b) == 0 {
-		return 0, nil
-	}
-	if nbr.i >= len(nbr.s) {
-		return 0, io.EOF
-	}
-	n = copy(b, nbr.s[nbr.i:])
-	nbr.i += n
-	return
-}
-
-func (nbr *NoEOFByteReader) ReadByte() (b byte, err error) {
-	if nbr.i >= len(nbr.s) {
-		return 0, io.EOF
-	}
-	b = nbr.s[nbr.i]
-	nbr.i++
-	return b, nil
-}
-func safeDecode(pktByte []byte) (pkt mc_packet.Packet) {
-	pktID := uint32(pktByte[0])
-	defer func() {
-		if r := recover(); r != nil {
-			// fmt.Println(pktID, "decode fail")
-		}
-		return
-	}()
-	pkt = connection.TypePool[pktID]()
-	pkt.Unmarshal(protocol.NewReader(&NoEOFByteReader{s: pktByte[1:]}, 0))
-	return
-}
-
 //export GamePacketBytesAsIsJsonStr
 func GamePacketBytesAsIsJsonStr(pktBytes []byte) (jsonStr *C.char, err *C.char) {
-	pk := safeDecode(pktBytes)
-	marshal, _err := json.Marshal(pk)
+	marshal, _err := connection.GamePacketBytesToJSON(pktBytes)
 	if _err != nil {
 		return nil, C.CString(_err.Error())
 	}
-	return C.CString(string(marshal)), toCErrStr(_err)
+	return C.CString(string(marshal)), nil
 }
 
 //export JsonStrAsIsGamePacketBytes
 func JsonStrAsIsGamePacketBytes(packetID int, jsonStr *C.char) (pktBytes *C.char, l int, err *C.char) {
-	pk := connection.TypePool[uint32(packetID)]()
-	_err := json.Unmarshal([]byte(C.GoString(jsonStr)), &pk)
+	pk, _err := connection.JSONToGamePacket(uint32(packetID), []byte(C.GoString(jsonStr)))
 	if _err != nil {
 		return nil, 0, C.CString(_err.Error())
 	}
-	b := &bytes.Buffer{}
-	w := protocol.NewWriter(b, 0)
-	hdr := pk.ID()
-	w.Varuint32(&hdr)
-	pk.Marshal(w)
-	bs := b.Bytes()
+	bs := connection.EncodeGamePacket(pk)
 	l = len(bs)
 	return bytesToCharArr(bs), l, nil
 }
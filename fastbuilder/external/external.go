@@ -3,24 +3,32 @@ package external
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"phoenixbuilder/fastbuilder/environment"
 	"phoenixbuilder/fastbuilder/external/connection"
 	"phoenixbuilder/fastbuilder/external/packet"
 	"phoenixbuilder/fastbuilder/uqHolder"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
 	"phoenixbuilder/minecraft"
 	"phoenixbuilder/minecraft/protocol"
 	mc_packet "phoenixbuilder/minecraft/protocol/packet"
+	"sync"
 	"time"
 )
 
 type ExternalConnectionHandler struct {
-	listener              *connection.ConnectionServerHandler
-	env                   *environment.PBEnvironment
-	PacketChannel         chan []byte
-	DistributeChannel     chan []byte
-	LeaveConsumerChannel  chan int
-	NewConsumerChannel    chan int
-	AcceptConsumerChannel chan interface{}
+	listener      *connection.ConnectionServerHandler
+	env           *environment.PBEnvironment
+	PacketChannel chan []byte
+	// clients with the scope to read packets
+	consumers     map[*consumer]struct{}
+	consumersLock sync.RWMutex
+	// closed when the environment is destroyed, every connection is then closed
+	stopped chan struct{}
+	// all the authenticated clients, used to broadcast notifications
+	connections     map[*connection.AuthenticatedConnection]struct{}
+	connectionsLock sync.Mutex
 }
 type NoEOFByteReader struct {
 	s []byte
@@ -48,144 +56,224 @@ func (nbr *NoEOFByteReader) ReadByte() (b byte, err error) {
 	return b, nil
 }
 
-func (handler *ExternalConnectionHandler) acceptConnection(conn connection.ReliableConnection) {
+func (handler *ExternalConnectionHandler) acceptConnection(conn *connection.AuthenticatedConnection) {
 	env := handler.env
-	allAlive := true
-	handler.NewConsumerChannel <- (1)
-	<-handler.AcceptConsumerChannel
 	pingDeadline := time.Now().Add(time.Second * 5)
-	bufferChan := make(chan []byte, 1024*8)
-	clientPacketChan := make(chan []byte, 1024)
-	skipMap := make(map[uint8]uint8)
-	hitMap := make(map[uint8]uint8)
-	setSkip := func(ID uint8, possib uint8) {
-		skipMap[ID] = possib
-		hitMap[ID] = 0
+	pingLock := sync.Mutex{}
+	c := newConsumer(conn.ClientName, DefaultConsumerBufferSize)
+	handler.connectionsLock.Lock()
+	handler.connections[conn] = struct{}{}
+	handler.connectionsLock.Unlock()
+	canReadPackets := conn.Scopes.Has(connection.ScopeReadPackets)
+	if canReadPackets {
+		handler.consumersLock.Lock()
+		handler.consumers[c] = struct{}{}
+		handler.consumersLock.Unlock()
 	}
-	go func() {
-		for {
-			select {
-			case clientPackets := <-clientPacketChan:
-				pingDeadline = time.Now().Add(time.Second * 5)
-				pkt, canParse := packet.Deserialize(clientPackets)
-				if !canParse {
-					packet.SerializeAndSend(&packet.PacketViolationWarningPacket{
-						Text: "Unparsable packet received!",
-					}, conn)
-				}
-				switch p := pkt.(type) {
-				case *packet.PingPacket:
-					pingDeadline = time.Now().Add(time.Second * 5)
-					packet.SerializeAndSend(&packet.PongPacket{}, conn)
-				case *packet.PongPacket:
-					break
-				case *packet.ByePacket:
-					packet.SerializeAndSend(&packet.ByePacket{}, conn)
-				case *packet.PacketViolationWarningPacket:
-					break
-				case *packet.EvalPBCommandPacket:
-					handler.env.FunctionHolder.Process(p.Command)
-				case *packet.GameCommandPacket:
-					if p.CommandType == packet.CommandTypeSettings {
-						env.GameInterface.SendSettingsCommand(p.Command, false)
-						break
-					} else if p.CommandType == packet.CommandTypeNormal {
-						env.Connection.(*minecraft.Conn).WritePacket(
-							&mc_packet.CommandRequest{
-								CommandLine: p.Command,
-								CommandOrigin: protocol.CommandOrigin{
-									Origin:    protocol.CommandOriginAutomationPlayer,
-									UUID:      p.UUID,
-									RequestID: "96045347-a6a3-4114-94c0-1bc4cc561694",
-								},
-								Internal:  false,
-								UnLimited: false,
-							},
-						)
-					} else {
-						env.Connection.(*minecraft.Conn).WritePacket(
-							&mc_packet.CommandRequest{
-								CommandLine: p.Command,
-								CommandOrigin: protocol.CommandOrigin{
-									Origin:    protocol.CommandOriginPlayer,
-									UUID:      p.UUID,
-									RequestID: "96045347-a6a3-4114-94c0-1bc4cc561694",
-								},
-								Internal:  false,
-								UnLimited: false,
-							},
-						)
-					}
-				case *packet.GamePacket:
-					(env.Connection).(*minecraft.Conn).Write(p.Content)
-				case *packet.GamePacketReducePacket:
-					setSkip(p.PacketID, p.DropBy)
-				case *packet.UQHolderRequestPacket:
-					//q:=string(p.QueryString)
-					//if q=="*"
-					packet.SerializeAndSend(&packet.UQHolderResponsePacket{
-						Content: (env.UQHolder).(*uqHolder.UQHolder).Marshal(),
-					}, conn)
-				}
-			case gamePacket := <-bufferChan:
-				if !allAlive {
-					return
-				}
-				// fmt.Println("send: ", gamePacket[0])
-				packet.SerializeAndSend(&packet.GamePacket{
-					Content: gamePacket,
+	permitted := func(scope connection.Scope, action string) bool {
+		if conn.Scopes.Has(scope) {
+			return true
+		}
+		packet.SerializeAndSend(&packet.PacketViolationWarningPacket{
+			Text: fmt.Sprintf("Permission denied: %s requires the scope %v", action, scope),
+		}, conn)
+		return false
+	}
+	handleClientPacket := func(clientPackets []byte) {
+		pingLock.Lock()
+		pingDeadline = time.Now().Add(time.Second * 5)
+		pingLock.Unlock()
+		pkt, canParse := packet.Deserialize(clientPackets)
+		if !canParse {
+			packet.SerializeAndSend(&packet.PacketViolationWarningPacket{
+				Text: "Unparsable packet received!",
+			}, conn)
+		}
+		switch p := pkt.(type) {
+		case *packet.PingPacket:
+			packet.SerializeAndSend(&packet.PongPacket{}, conn)
+		case *packet.PongPacket:
+			break
+		case *packet.ByePacket:
+			packet.SerializeAndSend(&packet.ByePacket{}, conn)
+		case *packet.PacketViolationWarningPacket:
+			break
+		case *packet.EvalPBCommandPacket:
+			if !permitted(connection.ScopeFBCommands, "EvalPBCommandPacket") {
+				break
+			}
+			handler.env.FunctionHolder.Process(p.Command)
+		case *packet.TaskListRequestPacket:
+			// Handled in the same goroutine as EvalPBCommandPacket,
+			// so the tasks created by the previous commands are always listed
+			if !permitted(connection.ScopeFBCommands, "TaskListRequestPacket") {
+				break
+			}
+			packet.SerializeAndSend(listTasks(env), conn)
+		case *packet.GameCommandPacket:
+			if !permitted(connection.ScopeMCCommands, "GameCommandPacket") {
+				break
+			}
+			if p.CommandType == packet.CommandTypeSettings {
+				env.CurrentGameInterface().SendSettingsCommand(p.Command, false)
+				break
+			} else if p.CommandType == packet.CommandTypeNormal {
+				env.Connection.(*minecraft.Conn).WritePacket(
+					&mc_packet.CommandRequest{
+						CommandLine: p.Command,
+						CommandOrigin: protocol.CommandOrigin{
+							Origin:    protocol.CommandOriginAutomationPlayer,
+							UUID:      p.UUID,
+							RequestID: "96045347-a6a3-4114-94c0-1bc4cc561694",
+						},
+						Internal:  false,
+						UnLimited: false,
+					},
+				)
+			} else {
+				env.Connection.(*minecraft.Conn).WritePacket(
+					&mc_packet.CommandRequest{
+						CommandLine: p.Command,
+						CommandOrigin: protocol.CommandOrigin{
+							Origin:    protocol.CommandOriginPlayer,
+							UUID:      p.UUID,
+							RequestID: "96045347-a6a3-4114-94c0-1bc4cc561694",
+						},
+						Internal:  false,
+						UnLimited: false,
+					},
+				)
+			}
+		case *packet.CommandRequestPacket:
+			if !permitted(connection.ScopeMCCommands, "CommandRequestPacket") {
+				packet.SerializeAndSend(&packet.CommandResponsePacket{
+					RequestID: p.RequestID,
+					ErrorType: ResourcesControl.ErrCommandRequestOthers,
+					Error:     "permission denied",
 				}, conn)
+				break
+			}
+			go func() {
+				packet.SerializeAndSend(handleCommandRequest(env, p), conn)
+			}()
+		case *packet.GamePacket:
+			if !permitted(connection.ScopeRawPackets, "GamePacket") {
+				break
 			}
+			(env.Connection).(*minecraft.Conn).Write(p.Content)
+		case *packet.GamePacketReducePacket:
+			// The sampling is replaced by GamePacketFilterPacket,
+			// reducing a packet now excludes it entirely
+			if p.DropBy > 1 {
+				c.exclude(uint32(p.PacketID))
+			}
+			packet.SerializeAndSend(&packet.PacketViolationWarningPacket{
+				Text: "GamePacketReducePacket is deprecated, use GamePacketFilterPacket instead",
+			}, conn)
+		case *packet.GamePacketFilterPacket:
+			c.setFilter(p.PacketIDs)
+		case *packet.ConsumerOptionsPacket:
+			c.setOptions(p)
+		case *packet.ConsumerStatsRequestPacket:
+			packet.SerializeAndSend(&packet.ConsumerStatsResponsePacket{Stats: c.stats()}, conn)
+		case *packet.UQHolderRequestPacket:
+			if !permitted(connection.ScopeReadPackets, "UQHolderRequestPacket") {
+				break
+			}
+			//q:=string(p.QueryString)
+			//if q=="*"
+			packet.SerializeAndSend(&packet.UQHolderResponsePacket{
+				Content: (env.UQHolder).(*uqHolder.UQHolder).Marshal(),
+			}, conn)
 		}
-	}()
+	}
 	go func() {
+		defer c.close()
 		for {
 			rawPacket, err := conn.RecvFrame()
-			if err != nil || !allAlive {
-				allAlive = false
+			if err != nil || c.isClosed() {
 				return
 			}
-			clientPacketChan <- rawPacket
+			handleClientPacket(rawPacket)
 		}
 	}()
 	go func() {
-		//packetCount := 0
-		//startTime := time.Now()
-		for {
-			//fmt.Println("buffering, now", len(bufferChan))
-			//packetCount++
-			//if packetCount%200 == 0 {
-			//	fmt.Println(float32(packetCount) / float32(time.Now().Sub(startTime).Seconds()))
-			//	fmt.Println(len(bufferChan))
-			//	packetCount = 0
-			//	startTime = time.Now()
-			//}
-			pkt := <-handler.DistributeChannel
-			pktID := pkt[0]
-			if t, hasK := skipMap[pktID]; hasK {
-				hitMap[pktID]++
-				if hitMap[pktID] == t {
-					hitMap[pktID] = 0
-				} else {
-					handler.LeaveConsumerChannel <- 0
-					continue
-				}
+		ticker := time.NewTicker(time.Second)
+		defer func() {
+			ticker.Stop()
+			handler.consumersLock.Lock()
+			delete(handler.consumers, c)
+			handler.consumersLock.Unlock()
+			handler.connectionsLock.Lock()
+			delete(handler.connections, conn)
+			handler.connectionsLock.Unlock()
+			conn.Close()
+			if stats := c.stats(); stats.Dropped > 0 {
+				fmt.Printf("External connection handler: %s disconnected, %d packets dropped\n", stats.ClientName, stats.Dropped)
 			}
-			if len(bufferChan) > 1024*7 || pingDeadline.Before(time.Now()) {
-				// fmt.Println("kick client")
-				allAlive = false
-			}
-			if !allAlive {
-				// fmt.Println("notify consumer leave")
-				handler.LeaveConsumerChannel <- (-1)
+		}()
+		for {
+			select {
+			case <-c.closed:
+				return
+			case <-handler.stopped:
+				c.close()
 				return
+			case <-ticker.C:
+				pingLock.Lock()
+				expired := pingDeadline.Before(time.Now())
+				pingLock.Unlock()
+				if expired {
+					c.close()
+					return
+				}
+			case <-c.notify:
+				for _, gamePacket := range c.popAll() {
+					err := conn.SendFrame(packet.Serialize(&packet.GamePacket{
+						Content: gamePacket,
+					}))
+					if err != nil {
+						c.close()
+						return
+					}
+				}
 			}
-			bufferChan <- pkt
-			handler.LeaveConsumerChannel <- 0
 		}
 	}()
 }
 
+// Stats returns the buffer statistics of the clients reading packets
+func (handler *ExternalConnectionHandler) Stats() []packet.ConsumerStats {
+	handler.consumersLock.RLock()
+	defer handler.consumersLock.RUnlock()
+	stats := make([]packet.ConsumerStats, 0, len(handler.consumers))
+	for c := range handler.consumers {
+		stats = append(stats, c.stats())
+	}
+	return stats
+}
+
+// NotifyConnectionStatus tells every client that the bot has been disconnected from
+// or reconnected to the server, see packet.ConnectionStatusDisconnected
+func (handler *ExternalConnectionHandler) NotifyConnectionStatus(status uint8, reason string) {
+	handler.connectionsLock.Lock()
+	defer handler.connectionsLock.Unlock()
+	for conn := range handler.connections {
+		packet.SerializeAndSend(&packet.ConnectionStatusPacket{Status: status, Reason: reason}, conn)
+	}
+}
+
+// NotifyConnectionStatus notifies the clients of both the external listener
+// and the WebSocket gateway of env, if they are running
+func NotifyConnectionStatus(env *environment.PBEnvironment, status uint8, reason string) {
+	if handler, ok := env.ExternalConnectionHandler.(*ExternalConnectionHandler); ok && handler != nil {
+		handler.NotifyConnectionStatus(status, reason)
+	}
+	if gateway, ok := env.WebSocketGateway.(*WebSocketGateway); ok && gateway != nil {
+		gateway.NotifyConnectionStatus(status, reason)
+	}
+}
+
 func (_ *ExternalConnectionHandler) acceptConnectionFail(err error) {
 	fmt.Printf("External connection handler: Failed to accept a connection: %v\n", err)
 }
@@ -194,68 +282,77 @@ func (_ *ExternalConnectionHandler) downError(_ interface{}) {
 	fmt.Printf("ERROR: External connection handler's server stopped unexpectedly.\n")
 }
 
+// epoll hands every packet to the buffer of each consumer,
+// a consumer never blocks the others as the buffers apply their overflow policies instead.
 func (e *ExternalConnectionHandler) epoll() {
-	consumers := 0
-	//TypePool := mc_packet.NewPool()
+	for pkt := range e.PacketChannel {
+		id := connection.GamePacketID(pkt)
+		e.consumersLock.RLock()
+		for c := range e.consumers {
+			c.push(id, pkt)
+		}
+		e.consumersLock.RUnlock()
+	}
+}
 
-	for {
-		select {
-		case <-e.NewConsumerChannel:
-			consumers++
-			e.AcceptConsumerChannel <- true
-			// fmt.Println("consumers come, current: ", consumers)
-		case pkt := <-e.PacketChannel:
-			//pkFn, hasK := TypePool[uint32(pkt[0])]
-			//
-			//if !hasK {
-			//	panic(fmt.Errorf("cannot decode packet %v", pkt[0]))
-			//}
-			//pk := pkFn()
-			//pk.Unmarshal(protocol.NewReader(&NoEOFByteReader{s: pkt[1:]}, 0))
-			if consumers > 0 {
-				for i := 0; i < consumers; i++ {
-					// fmt.Println("distributing...")
-					e.DistributeChannel <- pkt
-				}
-				consumerChange := 0
-				for i := 0; i < consumers; i++ {
-					// s := <-e.LeaveConsumerChannel
-					// fmt.Println("recv consumer", i, " status ", s)
-					consumerChange += <-e.LeaveConsumerChannel
-				}
-				consumers += consumerChange
-				// if consumerChange != 0 {
-				// 	fmt.Println("consumers leave, current: ", consumers)
-				// }
-			}
+// The default path of the file containing the clients allowed to connect
+func DefaultAuthFile() string {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		homedir = "."
+	}
+	return filepath.Join(homedir, ".config", "fastbuilder", "external_auth.json")
+}
+
+// loadAuthenticator loads the authorized clients from authFile,
+// if it doesn't exist, a token with all scopes will be generated in it.
+func loadAuthenticator(authFile string) (*connection.Authenticator, error) {
+	if authFile == "" {
+		authFile = DefaultAuthFile()
+	}
+	if _, err := os.Stat(authFile); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(authFile), 0755); err != nil {
+			return nil, err
+		}
+		if _, err := connection.CreateAuthenticatorFile(authFile); err != nil {
+			return nil, err
 		}
+		fmt.Printf("No authorized external client is configured, a token with all scopes has been generated in %s\n", authFile)
 	}
+	return connection.LoadAuthenticator(authFile)
 }
 
-func ListenExt(env *environment.PBEnvironment, address string) {
+// ListenExt listens for external connections on address,
+// only the clients listed in authFile (or DefaultAuthFile() if empty) are accepted.
+func ListenExt(env *environment.PBEnvironment, address string, authFile string) {
+	authenticator, err := loadAuthenticator(authFile)
+	if err != nil {
+		fmt.Printf("Failed to load the authorized external clients: %v\n", err)
+		return
+	}
 	handlerStruct := &ExternalConnectionHandler{
-		listener:              &connection.ConnectionServerHandler{},
-		PacketChannel:         make(chan []byte, 1024),
-		DistributeChannel:     make(chan []byte, 1024),
-		LeaveConsumerChannel:  make(chan int, 0),
-		NewConsumerChannel:    make(chan int, 0),
-		AcceptConsumerChannel: make(chan interface{}, 0),
-		env:                   env,
+		listener:      &connection.ConnectionServerHandler{Authenticator: authenticator},
+		PacketChannel: make(chan []byte, 1024),
+		consumers:     map[*consumer]struct{}{},
+		stopped:       make(chan struct{}),
+		connections:   map[*connection.AuthenticatedConnection]struct{}{},
+		env:           env,
 	}
 	go handlerStruct.epoll()
 	env.ExternalConnectionHandler = handlerStruct
 	env.Destructors = append(env.Destructors, func() {
+		handlerStruct.listener.Close()
 		close(handlerStruct.PacketChannel)
-		close(handlerStruct.LeaveConsumerChannel)
+		close(handlerStruct.stopped)
 	})
 	listener := handlerStruct.listener
-	err := listener.Listen(address)
+	listener.SetOnNewConnection(handlerStruct.acceptConnection)
+	listener.SetOnAcceptNewConnectionFail(handlerStruct.acceptConnectionFail)
+	listener.SetOnServerDown(handlerStruct.downError)
+	err = listener.Listen(address)
 	if err != nil {
 		fmt.Printf("Failed to listen on address %s: %v\n", address, err)
 		return
 	}
-	listener.SetOnNewConnection(handlerStruct.acceptConnection)
-	listener.SetOnAcceptNewConnectionFail(handlerStruct.acceptConnectionFail)
-	listener.SetOnServerDown(handlerStruct.downError)
 	fmt.Printf("Listening for external connection on address %s\n", address)
 }
@@ -0,0 +1,77 @@
+package packet
+
+import (
+	"encoding/binary"
+	"encoding/json"
+)
+
+// CommandRequestPacket sends a command and asks for its output,
+// which is returned by a CommandResponsePacket with the same RequestID.
+type CommandRequestPacket struct {
+	// Chosen by the client to correlate the response
+	RequestID uint32
+	// CommandTypeNormal or CommandTypeWebsocket
+	CommandType uint8
+	// 0 for the default timeout
+	TimeoutMS uint32
+	Command   string
+}
+
+func (pkt *CommandRequestPacket) Marshal() []byte {
+	buf := make([]byte, 9)
+	binary.LittleEndian.PutUint32(buf, pkt.RequestID)
+	buf[4] = pkt.CommandType
+	binary.LittleEndian.PutUint32(buf[5:], pkt.TimeoutMS)
+	return append(buf, []byte(pkt.Command)...)
+}
+
+func (pkt *CommandRequestPacket) Parse(cont []byte) bool {
+	if len(cont) < 9 {
+		return false
+	}
+	pkt.RequestID = binary.LittleEndian.Uint32(cont)
+	pkt.CommandType = cont[4]
+	pkt.TimeoutMS = binary.LittleEndian.Uint32(cont[5:])
+	pkt.Command = string(cont[9:])
+	return true
+}
+
+func (_ *CommandRequestPacket) ID() uint8 {
+	return IDCommandRequestPacket
+}
+
+func (_ *CommandRequestPacket) Name() string {
+	return "CommandRequestPacket"
+}
+
+type CommandOutputMessage struct {
+	Success    bool     `json:"success"`
+	Message    string   `json:"message"`
+	Parameters []string `json:"parameters"`
+}
+
+type CommandResponsePacket struct {
+	RequestID uint32 `json:"request_id"`
+	// Same as the error types of ResourcesControl, 0 if succeeded
+	ErrorType      uint8                  `json:"error_type"`
+	Error          string                 `json:"error,omitempty"`
+	SuccessCount   uint32                 `json:"success_count"`
+	OutputMessages []CommandOutputMessage `json:"output_messages"`
+}
+
+func (pkt *CommandResponsePacket) Marshal() []byte {
+	content, _ := json.Marshal(pkt)
+	return content
+}
+
+func (pkt *CommandResponsePacket) Parse(cont []byte) bool {
+	return json.Unmarshal(cont, pkt) == nil
+}
+
+func (_ *CommandResponsePacket) ID() uint8 {
+	return IDCommandResponsePacket
+}
+
+func (_ *CommandResponsePacket) Name() string {
+	return "CommandResponsePacket"
+}
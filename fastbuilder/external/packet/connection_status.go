@@ -0,0 +1,37 @@
+package packet
+
+const (
+	// The bot lost its connection to the server and is reconnecting
+	ConnectionStatusDisconnected = iota
+	// The bot is connected to the server again,
+	// the packet filters and buffers of the client remain unchanged
+	ConnectionStatusReconnected
+)
+
+// ConnectionStatusPacket notifies the client when the connection
+// between the bot and the server is lost or re-established.
+type ConnectionStatusPacket struct {
+	Status uint8
+	Reason string
+}
+
+func (pkt *ConnectionStatusPacket) Marshal() []byte {
+	return append([]byte{pkt.Status}, []byte(pkt.Reason)...)
+}
+
+func (pkt *ConnectionStatusPacket) Parse(cont []byte) bool {
+	if len(cont) < 1 {
+		return false
+	}
+	pkt.Status = cont[0]
+	pkt.Reason = string(cont[1:])
+	return true
+}
+
+func (_ *ConnectionStatusPacket) ID() uint8 {
+	return IDConnectionStatusPacket
+}
+
+func (_ *ConnectionStatusPacket) Name() string {
+	return "ConnectionStatusPacket"
+}
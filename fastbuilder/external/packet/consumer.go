@@ -0,0 +1,158 @@
+package packet
+
+import (
+	"encoding/binary"
+	"encoding/json"
+)
+
+// What to do when the buffer of a consumer is full
+const (
+	// Disconnect the consumer
+	OverflowPolicyDisconnect = iota
+	// Drop the oldest buffered packet
+	OverflowPolicyDropOldest
+	// Drop the oldest buffered packet whose ID is droppable,
+	// or the new packet if it is droppable,
+	// otherwise fall back to dropping the oldest packet
+	OverflowPolicyDropByPacketID
+)
+
+func marshalPacketIDs(ids []uint32) []byte {
+	buf := make([]byte, 2+4*len(ids))
+	binary.LittleEndian.PutUint16(buf, uint16(len(ids)))
+	for i, id := range ids {
+		binary.LittleEndian.PutUint32(buf[2+4*i:], id)
+	}
+	return buf
+}
+
+func parsePacketIDs(cont []byte) ([]uint32, []byte, bool) {
+	if len(cont) < 2 {
+		return nil, nil, false
+	}
+	count := int(binary.LittleEndian.Uint16(cont))
+	if len(cont) < 2+4*count {
+		return nil, nil, false
+	}
+	ids := make([]uint32, count)
+	for i := range ids {
+		ids[i] = binary.LittleEndian.Uint32(cont[2+4*i:])
+	}
+	return ids, cont[2+4*count:], true
+}
+
+// GamePacketFilterPacket makes the server only send the game packets with the specified IDs,
+// all packets are sent if PacketIDs is empty.
+type GamePacketFilterPacket struct {
+	PacketIDs []uint32
+}
+
+func (pkt *GamePacketFilterPacket) Marshal() []byte {
+	return marshalPacketIDs(pkt.PacketIDs)
+}
+
+func (pkt *GamePacketFilterPacket) Parse(cont []byte) bool {
+	ids, rest, ok := parsePacketIDs(cont)
+	if !ok || len(rest) != 0 {
+		return false
+	}
+	pkt.PacketIDs = ids
+	return true
+}
+
+func (_ *GamePacketFilterPacket) ID() uint8 {
+	return IDGamePacketFilterPacket
+}
+
+func (_ *GamePacketFilterPacket) Name() string {
+	return "GamePacketFilterPacket"
+}
+
+// ConsumerOptionsPacket configures the buffer of game packets for the client
+type ConsumerOptionsPacket struct {
+	// The count of packets buffered, 0 to keep the current size
+	BufferSize     uint32
+	OverflowPolicy uint8
+	// The packets that may be dropped by OverflowPolicyDropByPacketID
+	DroppablePacketIDs []uint32
+}
+
+func (pkt *ConsumerOptionsPacket) Marshal() []byte {
+	buf := make([]byte, 5)
+	binary.LittleEndian.PutUint32(buf, pkt.BufferSize)
+	buf[4] = pkt.OverflowPolicy
+	return append(buf, marshalPacketIDs(pkt.DroppablePacketIDs)...)
+}
+
+func (pkt *ConsumerOptionsPacket) Parse(cont []byte) bool {
+	if len(cont) < 5 {
+		return false
+	}
+	pkt.BufferSize = binary.LittleEndian.Uint32(cont)
+	pkt.OverflowPolicy = cont[4]
+	ids, rest, ok := parsePacketIDs(cont[5:])
+	if !ok || len(rest) != 0 {
+		return false
+	}
+	pkt.DroppablePacketIDs = ids
+	return true
+}
+
+func (_ *ConsumerOptionsPacket) ID() uint8 {
+	return IDConsumerOptionsPacket
+}
+
+func (_ *ConsumerOptionsPacket) Name() string {
+	return "ConsumerOptionsPacket"
+}
+
+type ConsumerStatsRequestPacket struct {
+}
+
+func (_ *ConsumerStatsRequestPacket) Marshal() []byte {
+	return []byte{}
+}
+
+func (_ *ConsumerStatsRequestPacket) Parse(_ []byte) bool {
+	return true
+}
+
+func (_ *ConsumerStatsRequestPacket) ID() uint8 {
+	return IDConsumerStatsRequestPacket
+}
+
+func (_ *ConsumerStatsRequestPacket) Name() string {
+	return "ConsumerStatsRequestPacket"
+}
+
+// ConsumerStats describes the buffer of game packets for a client
+type ConsumerStats struct {
+	ClientName     string            `json:"client_name"`
+	Buffered       int               `json:"buffered"`
+	BufferSize     int               `json:"buffer_size"`
+	OverflowPolicy uint8             `json:"overflow_policy"`
+	Sent           uint64            `json:"sent"`
+	Dropped        uint64            `json:"dropped"`
+	DroppedByID    map[uint32]uint64 `json:"dropped_by_id"`
+}
+
+type ConsumerStatsResponsePacket struct {
+	Stats ConsumerStats
+}
+
+func (pkt *ConsumerStatsResponsePacket) Marshal() []byte {
+	content, _ := json.Marshal(pkt.Stats)
+	return content
+}
+
+func (pkt *ConsumerStatsResponsePacket) Parse(cont []byte) bool {
+	return json.Unmarshal(cont, &pkt.Stats) == nil
+}
+
+func (_ *ConsumerStatsResponsePacket) ID() uint8 {
+	return IDConsumerStatsResponsePacket
+}
+
+func (_ *ConsumerStatsResponsePacket) Name() string {
+	return "ConsumerStatsResponsePacket"
+}
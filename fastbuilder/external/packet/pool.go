@@ -11,6 +11,15 @@ const (
 	IDUQHolderRequestPacket
 	IDUQHolderResponsePacket
 	IDGamePacketReducePacket
+	IDGamePacketFilterPacket
+	IDConsumerOptionsPacket
+	IDConsumerStatsRequestPacket
+	IDConsumerStatsResponsePacket
+	IDCommandRequestPacket
+	IDCommandResponsePacket
+	IDConnectionStatusPacket
+	IDTaskListRequestPacket
+	IDTaskListResponsePacket
 )
 
 var PacketPool map[uint8]func() Packet = map[uint8]func() Packet{
@@ -24,4 +33,13 @@ var PacketPool map[uint8]func() Packet = map[uint8]func() Packet{
 	IDUQHolderRequestPacket:        func() Packet { return &UQHolderRequestPacket{} },
 	IDUQHolderResponsePacket:       func() Packet { return &UQHolderResponsePacket{} },
 	IDGamePacketReducePacket:       func() Packet { return &GamePacketReducePacket{} },
+	IDGamePacketFilterPacket:       func() Packet { return &GamePacketFilterPacket{} },
+	IDConsumerOptionsPacket:        func() Packet { return &ConsumerOptionsPacket{} },
+	IDConsumerStatsRequestPacket:   func() Packet { return &ConsumerStatsRequestPacket{} },
+	IDConsumerStatsResponsePacket:  func() Packet { return &ConsumerStatsResponsePacket{} },
+	IDCommandRequestPacket:         func() Packet { return &CommandRequestPacket{} },
+	IDCommandResponsePacket:        func() Packet { return &CommandResponsePacket{} },
+	IDConnectionStatusPacket:       func() Packet { return &ConnectionStatusPacket{} },
+	IDTaskListRequestPacket:        func() Packet { return &TaskListRequestPacket{} },
+	IDTaskListResponsePacket:       func() Packet { return &TaskListResponsePacket{} },
 }
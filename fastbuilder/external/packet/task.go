@@ -0,0 +1,60 @@
+package packet
+
+import (
+	"encoding/json"
+)
+
+// TaskListRequestPacket asks for the tasks currently held by FastBuilder,
+// which are returned by a TaskListResponsePacket
+type TaskListRequestPacket struct {
+}
+
+func (_ *TaskListRequestPacket) Marshal() []byte {
+	return []byte{}
+}
+
+func (_ *TaskListRequestPacket) Parse(_ []byte) bool {
+	return true
+}
+
+func (_ *TaskListRequestPacket) ID() uint8 {
+	return IDTaskListRequestPacket
+}
+
+func (_ *TaskListRequestPacket) Name() string {
+	return "TaskListRequestPacket"
+}
+
+type TaskInfo struct {
+	TaskID      int64  `json:"task_id"`
+	CommandLine string `json:"command_line"`
+	// Same as the task states of fastbuilder/task, e.g. 1 for running and 2 for paused
+	State uint8 `json:"state"`
+	// Same as types.TaskTypeAsync and types.TaskTypeSync
+	Type  uint8 `json:"type"`
+	Built int   `json:"built"`
+	Total int   `json:"total"`
+}
+
+type TaskListResponsePacket struct {
+	// The ID of the most recently created task, including the finished ones
+	LastTaskID int64      `json:"last_task_id"`
+	Tasks      []TaskInfo `json:"tasks"`
+}
+
+func (pkt *TaskListResponsePacket) Marshal() []byte {
+	content, _ := json.Marshal(pkt)
+	return content
+}
+
+func (pkt *TaskListResponsePacket) Parse(cont []byte) bool {
+	return json.Unmarshal(cont, pkt) == nil
+}
+
+func (_ *TaskListResponsePacket) ID() uint8 {
+	return IDTaskListResponsePacket
+}
+
+func (_ *TaskListResponsePacket) Name() string {
+	return "TaskListResponsePacket"
+}
@@ -0,0 +1,95 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"phoenixbuilder/fastbuilder/external/connection"
+	"phoenixbuilder/fastbuilder/external/packet"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"time"
+)
+
+type CommandOutput struct {
+	SuccessCount uint32
+	Messages     []packet.CommandOutputMessage
+}
+
+// CommandError is returned when FastBuilder failed to get the output of a command
+type CommandError struct {
+	// Same as the error types of ResourcesControl, e.g. ResourcesControl.ErrCommandRequestTimeOut
+	Type    uint8
+	Message string
+}
+
+func (e *CommandError) Error() string {
+	return e.Message
+}
+
+// Timeout reports whether the server didn't respond in time
+func (e *CommandError) Timeout() bool {
+	return e.Type == ResourcesControl.ErrCommandRequestTimeOut
+}
+
+// Command sends cmd as the bot and blocks until its output arrives or ctx is done,
+// the deadline of ctx is also how long FastBuilder waits for the output (30 seconds if none).
+func (b *Bot) Command(ctx context.Context, cmd string) (CommandOutput, error) {
+	return b.command(ctx, cmd, (*connection.Client).SendMCCmdWithResponse)
+}
+
+// WSCommand is like Command, but cmd is sent as a websocket command
+func (b *Bot) WSCommand(ctx context.Context, cmd string) (CommandOutput, error) {
+	return b.command(ctx, cmd, (*connection.Client).SendWSCmdWithResponse)
+}
+
+func (b *Bot) command(
+	ctx context.Context,
+	cmd string,
+	send func(*connection.Client, string, time.Duration) (*packet.CommandResponsePacket, error),
+) (CommandOutput, error) {
+	client, err := b.Client()
+	if err != nil {
+		return CommandOutput{}, err
+	}
+	timeout := time.Duration(0)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+		if timeout < time.Millisecond {
+			return CommandOutput{}, context.DeadlineExceeded
+		}
+	}
+	response, err := wait(ctx, func() (*packet.CommandResponsePacket, error) {
+		return send(client, cmd, timeout)
+	})
+	if response == nil {
+		return CommandOutput{}, err
+	}
+	if response.Error != "" {
+		return CommandOutput{}, &CommandError{Type: response.ErrorType, Message: response.Error}
+	}
+	return CommandOutput{
+		SuccessCount: response.SuccessCount,
+		Messages:     response.OutputMessages,
+	}, nil
+}
+
+// SettingsCommand sends cmd without waiting for any output
+func (b *Bot) SettingsCommand(cmd string) error {
+	client, err := b.Client()
+	if err != nil {
+		return err
+	}
+	return client.SendNoResponseMCCmd(cmd)
+}
+
+// FBCommand runs cmd as a command of FastBuilder, e.g. "task list",
+// its output is printed by FastBuilder and not returned.
+func (b *Bot) FBCommand(cmd string) error {
+	client, err := b.Client()
+	if err != nil {
+		return err
+	}
+	if !client.Scopes.Has(connection.ScopeFBCommands) {
+		return fmt.Errorf("FBCommand: the scope %v is not granted", connection.ScopeFBCommands)
+	}
+	return client.SendFBCmd(cmd)
+}
@@ -0,0 +1,232 @@
+package sdk
+
+import (
+	"phoenixbuilder/fastbuilder/external/connection"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	mc_packet "phoenixbuilder/minecraft/protocol/packet"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// The bot lost its connection to the Minecraft server and FastBuilder is reconnecting,
+	// commands fail until EventServerReconnected
+	EventServerDisconnected = iota
+	// The bot is connected to the Minecraft server again
+	EventServerReconnected
+	// The connection to FastBuilder is lost, the Bot is reconnecting unless DisableReconnect is set
+	EventLinkLost
+	// The connection to FastBuilder is re-established and the subscriptions are restored
+	EventLinkRestored
+)
+
+type ConnectionEvent struct {
+	Type   int
+	Reason string
+}
+
+type ChatMessage struct {
+	// The name of the sender without the decorations of the server
+	Sender  string
+	Message string
+	Whisper bool
+	Packet  *mc_packet.Text
+}
+
+type Player struct {
+	UUID           uuid.UUID
+	EntityUniqueID int64
+	Username       string
+	XUID           string
+}
+
+// handlers of one kind keyed by their subscription IDs
+type handlers[T any] map[uint64]func(T)
+
+func (h handlers[T]) list() []func(T) {
+	list := make([]func(T), 0, len(h))
+	for _, fn := range h {
+		list = append(list, fn)
+	}
+	return list
+}
+
+// subscribe adds fn to set, the returned function removes it and calls onRemoved if not nil
+func subscribe[T any](b *Bot, set handlers[T], fn func(T), onRemoved func()) func() {
+	b.handlersLock.Lock()
+	b.nextHandlerID++
+	id := b.nextHandlerID
+	set[id] = fn
+	b.handlersLock.Unlock()
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			b.handlersLock.Lock()
+			delete(set, id)
+			b.handlersLock.Unlock()
+			if onRemoved != nil {
+				onRemoved()
+			}
+		})
+	}
+}
+
+// OnPacket calls fn with every game packet of type T and returns a function to unsubscribe,
+// e.g. sdk.OnPacket(bot, func(pk *packet.AddPlayer) {...}).
+// If T is packet.Packet itself, fn receives all the game packets.
+func OnPacket[T mc_packet.Packet](b *Bot, fn func(T)) func() {
+	handler := func(pk mc_packet.Packet) {
+		if typed, ok := pk.(T); ok {
+			fn(typed)
+		}
+	}
+	var zero T
+	var unsubscribe func()
+	if any(zero) == nil {
+		unsubscribe = subscribe(b, b.allPacketHandlers, handler, b.updatePacketFilter)
+	} else {
+		// The ID methods of the packets don't dereference the receiver
+		id := zero.ID()
+		b.handlersLock.Lock()
+		set, found := b.packetHandlers[id]
+		if !found {
+			set = handlers[mc_packet.Packet]{}
+			b.packetHandlers[id] = set
+		}
+		b.handlersLock.Unlock()
+		unsubscribe = subscribe(b, set, handler, b.updatePacketFilter)
+	}
+	b.updatePacketFilter()
+	return unsubscribe
+}
+
+// OnChat calls fn with the chat and whisper messages sent by the players
+func (b *Bot) OnChat(fn func(ChatMessage)) func() {
+	return OnPacket(b, func(pk *mc_packet.Text) {
+		if pk.TextType != mc_packet.TextTypeChat && pk.TextType != mc_packet.TextTypeWhisper {
+			return
+		}
+		fn(ChatMessage{
+			Sender:  uqHolder.ToPlainName(pk.SourceName),
+			Message: pk.Message,
+			Whisper: pk.TextType == mc_packet.TextTypeWhisper,
+			Packet:  pk,
+		})
+	})
+}
+
+// OnPlayerJoin calls fn when a player is added to the player list.
+// The players online when subscribing are looked up in the UQHolder if possible,
+// so that they are not reported again when the server resends the list after reconnecting.
+func (b *Bot) OnPlayerJoin(fn func(Player)) func() {
+	return b.onPlayerList(fn, nil)
+}
+
+// OnPlayerLeave calls fn when a player is removed from the player list
+func (b *Bot) OnPlayerLeave(fn func(Player)) func() {
+	return b.onPlayerList(nil, fn)
+}
+
+// onPlayerList tracks the player list for each subscription, as the removals carry only the UUIDs
+func (b *Bot) onPlayerList(onJoin func(Player), onLeave func(Player)) func() {
+	known := map[uuid.UUID]Player{}
+	if b.Scopes().Has(connection.ScopeReadPackets) {
+		if client, err := b.Client(); err == nil {
+			if holder, err := client.RequestUQHolder("*"); err == nil {
+				for _, player := range holder.PlayersByEntityID {
+					known[player.UUID] = Player{
+						UUID:           player.UUID,
+						EntityUniqueID: player.EntityUniqueID,
+						Username:       player.Username,
+					}
+				}
+			}
+		}
+	}
+	return OnPacket(b, func(pk *mc_packet.PlayerList) {
+		for _, entry := range pk.Entries {
+			if pk.ActionType == mc_packet.PlayerListActionRemove {
+				player, found := known[entry.UUID]
+				if !found {
+					continue
+				}
+				delete(known, entry.UUID)
+				if onLeave != nil {
+					onLeave(player)
+				}
+				continue
+			}
+			if _, found := known[entry.UUID]; found {
+				continue
+			}
+			player := Player{
+				UUID:           entry.UUID,
+				EntityUniqueID: entry.EntityUniqueID,
+				Username:       entry.Username,
+				XUID:           entry.XUID,
+			}
+			known[entry.UUID] = player
+			if onJoin != nil {
+				onJoin(player)
+			}
+		}
+	})
+}
+
+// OnConnectionEvent calls fn in a new goroutine when the connection between the bot and the server,
+// or between the Bot and FastBuilder, is lost or re-established, see EventServerDisconnected
+func (b *Bot) OnConnectionEvent(fn func(ConnectionEvent)) func() {
+	return subscribe(b, b.eventHandlers, fn, nil)
+}
+
+func (b *Bot) emitEvent(event ConnectionEvent) {
+	b.handlersLock.RLock()
+	list := b.eventHandlers.list()
+	b.handlersLock.RUnlock()
+	for _, fn := range list {
+		go fn(event)
+	}
+}
+
+// updatePacketFilter asks FastBuilder to send only the packets subscribed,
+// or all of them if any handler subscribes to all packets or there is no handler at all
+func (b *Bot) updatePacketFilter() {
+	ids := []uint32{}
+	b.handlersLock.RLock()
+	if len(b.allPacketHandlers) == 0 {
+		for id, set := range b.packetHandlers {
+			if len(set) > 0 {
+				ids = append(ids, id)
+			}
+		}
+	}
+	b.handlersLock.RUnlock()
+	client, err := b.Client()
+	if err != nil {
+		// Applied again after reconnecting
+		return
+	}
+	client.SetPacketFilter(ids)
+}
+
+// dispatch decodes the game packet only if it is subscribed
+func (b *Bot) dispatch(content []byte) {
+	id := connection.GamePacketID(content)
+	b.handlersLock.RLock()
+	list := b.allPacketHandlers.list()
+	if set, found := b.packetHandlers[id]; found {
+		list = append(list, set.list()...)
+	}
+	b.handlersLock.RUnlock()
+	if len(list) == 0 {
+		return
+	}
+	pk, err := connection.DecodeGamePacket(content)
+	if err != nil {
+		return
+	}
+	for _, fn := range list {
+		fn(pk)
+	}
+}
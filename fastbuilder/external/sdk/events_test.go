@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"phoenixbuilder/fastbuilder/uqHolder"
+	"phoenixbuilder/minecraft/protocol"
+	mc_packet "phoenixbuilder/minecraft/protocol/packet"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestOnPlayerJoinSkipsKnownPlayers(t *testing.T) {
+	alice := protocol.PlayerListEntry{UUID: uuid.New(), EntityUniqueID: 1, Username: "Alice"}
+	bob := protocol.PlayerListEntry{UUID: uuid.New(), EntityUniqueID: 2, Username: "Bob"}
+	holder := uqHolder.NewUQHolder(0)
+	holder.PlayersByEntityID[1] = &uqHolder.Player{UUID: alice.UUID, EntityUniqueID: 1, Username: "Alice"}
+	server := startTestServer(t, holder)
+	bot := dialForTest(t, server, tokenAll)
+
+	joined := make(chan Player, 16)
+	left := make(chan Player, 16)
+	bot.OnPlayerJoin(func(player Player) { joined <- player })
+	bot.OnPlayerLeave(func(player Player) { left <- player })
+	syncFilter(t, bot)
+
+	// The list resent after reconnecting contains the players already known
+	server.send(&mc_packet.PlayerList{ActionType: mc_packet.PlayerListActionAdd, Entries: []protocol.PlayerListEntry{alice, bob}})
+	server.send(&mc_packet.PlayerList{ActionType: mc_packet.PlayerListActionAdd, Entries: []protocol.PlayerListEntry{bob}})
+	server.send(&mc_packet.PlayerList{ActionType: mc_packet.PlayerListActionRemove, Entries: []protocol.PlayerListEntry{{UUID: alice.UUID}}})
+	server.send(&mc_packet.PlayerList{ActionType: mc_packet.PlayerListActionRemove, Entries: []protocol.PlayerListEntry{{UUID: alice.UUID}}})
+
+	select {
+	case player := <-left:
+		if player.Username != "Alice" {
+			t.Fatalf("left: got %+v", player)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Alice didn't leave")
+	}
+	// The packets are dispatched in order, so every join has been reported by now
+	close(joined)
+	players := []Player{}
+	for player := range joined {
+		players = append(players, player)
+	}
+	if len(players) != 1 || players[0].UUID != bob.UUID || players[0].Username != "Bob" {
+		t.Fatalf("joined: got %+v, want only Bob", players)
+	}
+	select {
+	case player := <-left:
+		t.Fatalf("left twice: got %+v", player)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,247 @@
+// Package sdk is a typed client for controlling FastBuilder through its external connection,
+// see examples/external_ctrl/go for complete programs.
+//
+// A Bot keeps the connection to FastBuilder alive and reconnects when it is lost,
+// dispatches the game packets to the subscribed handlers, and wraps the commands,
+// the tasks and the UQHolder of FastBuilder.
+//
+// The packet handlers are called one by one in the goroutine receiving the packets,
+// so a handler should hand long running work, e.g. waiting for a Command, over to another goroutine.
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"phoenixbuilder/fastbuilder/external/connection"
+	"phoenixbuilder/fastbuilder/external/packet"
+	mc_packet "phoenixbuilder/minecraft/protocol/packet"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultReconnectDelay       = time.Second
+	DefaultMaxReconnectDelay    = 30 * time.Second
+	DefaultUQHolderPollInterval = 5 * time.Second
+)
+
+var ErrClosed = fmt.Errorf("bot closed")
+var ErrNotConnected = fmt.Errorf("not connected to FastBuilder")
+
+type Options struct {
+	// The address FastBuilder listens on for external connections, e.g. 127.0.0.1:3456
+	Address string
+	// A token or a PEM encoded private key authorized in the external_auth.json of FastBuilder
+	Credential string
+	// Close the Bot instead of reconnecting when the connection to FastBuilder is lost
+	DisableReconnect bool
+	// The delay before the first reconnection attempt, doubled after every failure
+	ReconnectDelay time.Duration
+	// The upper limit of the delay between reconnection attempts
+	MaxReconnectDelay time.Duration
+	// How often the UQHolder is fetched while there are OnUQHolderChange handlers
+	UQHolderPollInterval time.Duration
+}
+
+type Bot struct {
+	options    Options
+	credential *connection.ClientCredential
+
+	clientLock sync.RWMutex
+	client     *connection.Client
+
+	// every kind of handler is guarded by handlersLock
+	handlersLock      sync.RWMutex
+	nextHandlerID     uint64
+	packetHandlers    map[uint32]handlers[mc_packet.Packet]
+	allPacketHandlers handlers[mc_packet.Packet]
+	eventHandlers     handlers[ConnectionEvent]
+	uqHolderHandlers  handlers[*UQHolderChange]
+
+	// serializes CreateTask, see there
+	taskLock sync.Mutex
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to FastBuilder, the connection is established only once here,
+// afterwards it is re-established automatically unless DisableReconnect is set.
+func Dial(ctx context.Context, options Options) (*Bot, error) {
+	if options.ReconnectDelay <= 0 {
+		options.ReconnectDelay = DefaultReconnectDelay
+	}
+	if options.MaxReconnectDelay < options.ReconnectDelay {
+		options.MaxReconnectDelay = DefaultMaxReconnectDelay
+		if options.MaxReconnectDelay < options.ReconnectDelay {
+			options.MaxReconnectDelay = options.ReconnectDelay
+		}
+	}
+	if options.UQHolderPollInterval <= 0 {
+		options.UQHolderPollInterval = DefaultUQHolderPollInterval
+	}
+	credential, err := connection.ParseClientCredential(options.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("Dial: %v", err)
+	}
+	b := &Bot{
+		options:           options,
+		credential:        credential,
+		packetHandlers:    map[uint32]handlers[mc_packet.Packet]{},
+		allPacketHandlers: handlers[mc_packet.Packet]{},
+		eventHandlers:     handlers[ConnectionEvent]{},
+		uqHolderHandlers:  handlers[*UQHolderChange]{},
+		closed:            make(chan struct{}),
+	}
+	client, err := wait(ctx, b.dial)
+	if err != nil {
+		return nil, fmt.Errorf("Dial: %v", err)
+	}
+	b.setClient(client)
+	go b.run(client)
+	go b.pollUQHolder()
+	return b, nil
+}
+
+func (b *Bot) dial() (*connection.Client, error) {
+	client := connection.NewClient(b.options.Address, b.credential)
+	if client == nil {
+		return nil, fmt.Errorf("failed to connect to %s", b.options.Address)
+	}
+	return client, nil
+}
+
+func (b *Bot) setClient(client *connection.Client) {
+	client.SetOnConnectionStatus(func(status *packet.ConnectionStatusPacket) {
+		event := ConnectionEvent{Type: EventServerReconnected, Reason: status.Reason}
+		if status.Status == packet.ConnectionStatusDisconnected {
+			event.Type = EventServerDisconnected
+		}
+		b.emitEvent(event)
+	})
+	b.clientLock.Lock()
+	b.client = client
+	b.clientLock.Unlock()
+	b.updatePacketFilter()
+}
+
+// Client returns the underlying connection for what the Bot doesn't cover,
+// it is replaced after reconnecting, so don't keep it.
+func (b *Bot) Client() (*connection.Client, error) {
+	b.clientLock.RLock()
+	client := b.client
+	b.clientLock.RUnlock()
+	if b.IsClosed() {
+		return nil, ErrClosed
+	}
+	if client == nil || client.IsClosed() {
+		return nil, ErrNotConnected
+	}
+	return client, nil
+}
+
+// Scopes returns the scopes FastBuilder granted to the credential
+func (b *Bot) Scopes() connection.Scope {
+	b.clientLock.RLock()
+	defer b.clientLock.RUnlock()
+	return b.client.Scopes
+}
+
+// run receives the game packets and reconnects when the connection is lost,
+// until the Bot is closed.
+func (b *Bot) run(client *connection.Client) {
+	for {
+		for {
+			content, err := client.RecvGamePacket()
+			if err != nil {
+				break
+			}
+			b.dispatch(content)
+		}
+		if b.IsClosed() {
+			return
+		}
+		if b.options.DisableReconnect {
+			b.emitEvent(ConnectionEvent{Type: EventLinkLost})
+			b.Close()
+			return
+		}
+		b.emitEvent(ConnectionEvent{Type: EventLinkLost, Reason: "reconnecting"})
+		client = b.reconnect()
+		if client == nil {
+			return
+		}
+		b.setClient(client)
+		b.emitEvent(ConnectionEvent{Type: EventLinkRestored})
+	}
+}
+
+// reconnect retries dialing with an exponential backoff, nil is returned if the Bot is closed meanwhile
+func (b *Bot) reconnect() *connection.Client {
+	delay := b.options.ReconnectDelay
+	for {
+		select {
+		case <-b.closed:
+			return nil
+		case <-time.After(delay):
+		}
+		client, err := b.dial()
+		if err == nil {
+			if b.IsClosed() {
+				client.Close()
+				return nil
+			}
+			return client
+		}
+		delay *= 2
+		if delay > b.options.MaxReconnectDelay {
+			delay = b.options.MaxReconnectDelay
+		}
+	}
+}
+
+// Close disconnects from FastBuilder and stops reconnecting
+func (b *Bot) Close() {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+		b.clientLock.RLock()
+		client := b.client
+		b.clientLock.RUnlock()
+		client.Close()
+	})
+}
+
+func (b *Bot) IsClosed() bool {
+	select {
+	case <-b.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done is closed when the Bot is closed, either by Close
+// or by losing the connection with DisableReconnect set
+func (b *Bot) Done() <-chan struct{} {
+	return b.closed
+}
+
+// wait calls fn in another goroutine and returns its result, or ctx.Err() if ctx is done first
+func wait[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		resultChan <- result{value: value, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case r := <-resultChan:
+		return r.value, r.err
+	}
+}
@@ -0,0 +1,226 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/external"
+	"phoenixbuilder/fastbuilder/external/connection"
+	fbtask "phoenixbuilder/fastbuilder/task"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	mc_packet "phoenixbuilder/minecraft/protocol/packet"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	tokenAll      = "all-scopes-token"
+	tokenCommands = "fb-commands-token"
+)
+
+// taskCreatingFunctionHolder creates a task for each FB command like FastBuilder does.
+// A command starting with "finished" creates a task that has already ended,
+// and "nothing" creates no task at all.
+type taskCreatingFunctionHolder struct {
+	holder *fbtask.TaskHolder
+}
+
+func (f taskCreatingFunctionHolder) Process(command string) bool {
+	if command == "nothing" {
+		return true
+	}
+	id := f.holder.TaskIdCounter.Add(1)
+	if strings.HasPrefix(command, "finished") {
+		return true
+	}
+	f.holder.TaskMap.Store(id, &fbtask.Task{
+		TaskId:      id,
+		CommandLine: command,
+		State:       TaskStateRunning,
+	})
+	return true
+}
+
+// testServer is the external listener of FastBuilder running in the test
+type testServer struct {
+	address  string
+	authFile string
+	env      *environment.PBEnvironment
+}
+
+// startTestServer listens on a free port, tokenAll is granted all scopes
+// and tokenCommands only connection.ScopeFBCommands
+func startTestServer(t *testing.T, holder *uqHolder.UQHolder) *testServer {
+	t.Helper()
+	authFile := filepath.Join(t.TempDir(), "external_auth.json")
+	content, _ := json.Marshal(map[string]interface{}{
+		"clients": []map[string]interface{}{
+			{"name": "all", "token": tokenAll, "scopes": []string{"all"}},
+			{"name": "commands", "token": tokenCommands, "scopes": []string{"fb_commands"}},
+		},
+	})
+	if err := os.WriteFile(authFile, content, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	server := &testServer{address: listener.Addr().String(), authFile: authFile}
+	listener.Close()
+	server.start(holder)
+	t.Cleanup(server.stop)
+	return server
+}
+
+// start listens on the address of the server again with a new environment
+func (s *testServer) start(holder *uqHolder.UQHolder) {
+	taskHolder := fbtask.NewTaskHolder()
+	s.env = &environment.PBEnvironment{
+		UQHolder:       holder,
+		TaskHolder:     taskHolder,
+		FunctionHolder: taskCreatingFunctionHolder{holder: taskHolder},
+	}
+	external.ListenExt(s.env, s.address, s.authFile)
+}
+
+// stop closes the listener and every connection
+func (s *testServer) stop() {
+	s.env.Stop()
+}
+
+func (s *testServer) handler() *external.ExternalConnectionHandler {
+	return s.env.ExternalConnectionHandler.(*external.ExternalConnectionHandler)
+}
+
+// send hands pk to the clients reading packets, as if the bot received it
+func (s *testServer) send(pk mc_packet.Packet) {
+	s.handler().PacketChannel <- connection.EncodeGamePacket(pk)
+}
+
+// syncFilter waits until FastBuilder has applied the packet filter sent by bot
+func syncFilter(t *testing.T, bot *Bot) {
+	t.Helper()
+	client, err := bot.Client()
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	// The packets of a connection are handled in order
+	if _, err := client.RequestConsumerStats(); err != nil {
+		t.Fatalf("RequestConsumerStats: %v", err)
+	}
+}
+
+func dialForTest(t *testing.T, server *testServer, token string) *Bot {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	bot, err := Dial(ctx, Options{
+		Address:              server.address,
+		Credential:           token,
+		ReconnectDelay:       10 * time.Millisecond,
+		MaxReconnectDelay:    40 * time.Millisecond,
+		UQHolderPollInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(bot.Close)
+	return bot
+}
+
+// testContext is cancelled when the test ends
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// waitEvent waits for the event of eventType, skipping the others
+func waitEvent(t *testing.T, events <-chan ConnectionEvent, eventType int) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type == eventType {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("event %d didn't happen", eventType)
+		}
+	}
+}
+
+func TestReconnectRestoresPacketFilter(t *testing.T) {
+	server := startTestServer(t, uqHolder.NewUQHolder(0))
+	bot := dialForTest(t, server, tokenAll)
+	events := make(chan ConnectionEvent, 16)
+	bot.OnConnectionEvent(func(event ConnectionEvent) { events <- event })
+	messages := make(chan ChatMessage, 16)
+	bot.OnChat(func(message ChatMessage) { messages <- message })
+
+	server.stop()
+	waitEvent(t, events, EventLinkLost)
+	// Refused a few times before listening again, so the backoff is applied
+	time.Sleep(100 * time.Millisecond)
+	server.start(uqHolder.NewUQHolder(0))
+	waitEvent(t, events, EventLinkRestored)
+	syncFilter(t, bot)
+
+	server.send(&mc_packet.SetTime{Time: 1000})
+	server.send(&mc_packet.Text{TextType: mc_packet.TextTypeChat, SourceName: "Alice", Message: "hello"})
+	select {
+	case message := <-messages:
+		if message.Sender != "Alice" || message.Message != "hello" {
+			t.Fatalf("got %+v", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the chat message is not received after reconnecting")
+	}
+	// SetTime is not subscribed, so the restored filter keeps it on FastBuilder
+	if stats := server.handler().Stats(); len(stats) != 1 || stats[0].Sent != 1 {
+		t.Fatalf("got %+v, want only the chat message sent", stats)
+	}
+}
+
+func TestCloseStopsReconnecting(t *testing.T) {
+	server := startTestServer(t, uqHolder.NewUQHolder(0))
+	bot := dialForTest(t, server, tokenAll)
+	events := make(chan ConnectionEvent, 16)
+	bot.OnConnectionEvent(func(event ConnectionEvent) { events <- event })
+
+	server.stop()
+	waitEvent(t, events, EventLinkLost)
+	bot.Close()
+	server.start(uqHolder.NewUQHolder(0))
+	time.Sleep(200 * time.Millisecond)
+	if stats := server.handler().Stats(); len(stats) != 0 {
+		t.Fatalf("the closed Bot reconnected: %+v", stats)
+	}
+	if _, err := bot.Client(); err != ErrClosed {
+		t.Fatalf("Client: got %v, want ErrClosed", err)
+	}
+}
+
+func TestDisableReconnect(t *testing.T) {
+	server := startTestServer(t, uqHolder.NewUQHolder(0))
+	bot, err := Dial(testContext(t), Options{
+		Address:          server.address,
+		Credential:       tokenAll,
+		DisableReconnect: true,
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	server.stop()
+	select {
+	case <-bot.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the Bot is not closed after losing the connection")
+	}
+}
@@ -0,0 +1,92 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"phoenixbuilder/fastbuilder/external/packet"
+)
+
+// Same as the task states of fastbuilder/task
+const (
+	TaskStateUnknown = iota
+	TaskStateRunning
+	TaskStatePaused
+	TaskStateDied
+	TaskStateCalculating
+	TaskStateSpecialBrk
+)
+
+type TaskInfo = packet.TaskInfo
+
+var ErrTaskNotCreated = fmt.Errorf("no task is created, see the output of FastBuilder for the reason")
+
+func (b *Bot) taskList(ctx context.Context) (*packet.TaskListResponsePacket, error) {
+	client, err := b.Client()
+	if err != nil {
+		return nil, err
+	}
+	return wait(ctx, client.RequestTaskList)
+}
+
+// ListTasks returns the unfinished tasks sorted by their IDs
+func (b *Bot) ListTasks(ctx context.Context) ([]TaskInfo, error) {
+	response, err := b.taskList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return response.Tasks, nil
+}
+
+// CreateTask runs commandLine, e.g. "round -r 5", and returns the task created by it.
+// The returned state is TaskStateDied if the task has already finished when being looked up.
+//
+// FastBuilder doesn't tell which client created a task, so the task is recognized by its command line,
+// and a finished task by being the only task created meanwhile. Another client creating tasks
+// at the same time may therefore have its task returned, or make ErrTaskNotCreated returned.
+func (b *Bot) CreateTask(ctx context.Context, commandLine string) (TaskInfo, error) {
+	// FastBuilder handles the packets of a connection in order,
+	// so the task is among the ones created between the two lists
+	b.taskLock.Lock()
+	defer b.taskLock.Unlock()
+	before, err := b.taskList(ctx)
+	if err != nil {
+		return TaskInfo{}, fmt.Errorf("CreateTask: %v", err)
+	}
+	if err := b.FBCommand(commandLine); err != nil {
+		return TaskInfo{}, fmt.Errorf("CreateTask: %v", err)
+	}
+	after, err := b.taskList(ctx)
+	if err != nil {
+		return TaskInfo{}, fmt.Errorf("CreateTask: %v", err)
+	}
+	for _, task := range after.Tasks {
+		if task.TaskID > before.LastTaskID && task.CommandLine == commandLine {
+			return task, nil
+		}
+	}
+	if after.LastTaskID == before.LastTaskID+1 {
+		return TaskInfo{
+			TaskID:      after.LastTaskID,
+			CommandLine: commandLine,
+			State:       TaskStateDied,
+		}, nil
+	}
+	return TaskInfo{}, ErrTaskNotCreated
+}
+
+func (b *Bot) PauseTask(taskID int64) error {
+	return b.FBCommand(fmt.Sprintf("task pause %d", taskID))
+}
+
+func (b *Bot) ResumeTask(taskID int64) error {
+	return b.FBCommand(fmt.Sprintf("task resume %d", taskID))
+}
+
+func (b *Bot) BreakTask(taskID int64) error {
+	return b.FBCommand(fmt.Sprintf("task break %d", taskID))
+}
+
+// SetTaskDelay changes the delay of a task, whose unit depends on its delay mode
+func (b *Bot) SetTaskDelay(taskID int64, delay int) error {
+	return b.FBCommand(fmt.Sprintf("task setdelay %d %d", taskID, delay))
+}
@@ -0,0 +1,41 @@
+package sdk
+
+import (
+	"errors"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	"testing"
+)
+
+func TestCreateTask(t *testing.T) {
+	server := startTestServer(t, uqHolder.NewUQHolder(0))
+	bot := dialForTest(t, server, tokenAll)
+	ctx := testContext(t)
+
+	running, err := bot.CreateTask(ctx, "round -r 5")
+	if err != nil {
+		t.Fatalf("running task: %v", err)
+	}
+	if running.TaskID != 1 || running.CommandLine != "round -r 5" || running.State != TaskStateRunning {
+		t.Fatalf("running task: got %+v", running)
+	}
+
+	finished, err := bot.CreateTask(ctx, "finished set")
+	if err != nil {
+		t.Fatalf("finished task: %v", err)
+	}
+	if finished.TaskID != 2 || finished.CommandLine != "finished set" || finished.State != TaskStateDied {
+		t.Fatalf("finished task: got %+v", finished)
+	}
+
+	if _, err := bot.CreateTask(ctx, "nothing"); !errors.Is(err, ErrTaskNotCreated) {
+		t.Fatalf("no task: got %v, want ErrTaskNotCreated", err)
+	}
+
+	tasks, err := bot.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].TaskID != running.TaskID {
+		t.Fatalf("ListTasks: got %+v", tasks)
+	}
+}
@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"phoenixbuilder/fastbuilder/external/connection"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	"reflect"
+	"time"
+)
+
+// UQHolderChange describes the differences between two consecutive snapshots of the UQHolder
+type UQHolderChange struct {
+	Previous *uqHolder.UQHolder
+	Current  *uqHolder.UQHolder
+	// The players appearing in or disappearing from PlayersByEntityID
+	JoinedPlayers    []*uqHolder.Player
+	LeftPlayers      []*uqHolder.Player
+	BotMoved         bool
+	GameRulesChanged bool
+	InventoryChanged bool
+}
+
+func (c *UQHolderChange) empty() bool {
+	return len(c.JoinedPlayers) == 0 &&
+		len(c.LeftPlayers) == 0 &&
+		!c.BotMoved &&
+		!c.GameRulesChanged &&
+		!c.InventoryChanged
+}
+
+func diffUQHolder(previous, current *uqHolder.UQHolder) *UQHolderChange {
+	change := &UQHolderChange{
+		Previous:         previous,
+		Current:          current,
+		JoinedPlayers:    []*uqHolder.Player{},
+		LeftPlayers:      []*uqHolder.Player{},
+		BotMoved:         previous.BotPos.Position != current.BotPos.Position,
+		GameRulesChanged: !reflect.DeepEqual(previous.GameRules, current.GameRules),
+		InventoryChanged: !reflect.DeepEqual(previous.InventoryContent, current.InventoryContent),
+	}
+	for id, player := range current.PlayersByEntityID {
+		if _, found := previous.PlayersByEntityID[id]; !found {
+			change.JoinedPlayers = append(change.JoinedPlayers, player)
+		}
+	}
+	for id, player := range previous.PlayersByEntityID {
+		if _, found := current.PlayersByEntityID[id]; !found {
+			change.LeftPlayers = append(change.LeftPlayers, player)
+		}
+	}
+	return change
+}
+
+// Snapshot returns a copy of the UQHolder of FastBuilder, which requires connection.ScopeReadPackets
+func (b *Bot) Snapshot(ctx context.Context) (*uqHolder.UQHolder, error) {
+	client, err := b.Client()
+	if err != nil {
+		return nil, err
+	}
+	if !client.Scopes.Has(connection.ScopeReadPackets) {
+		return nil, fmt.Errorf("Snapshot: the scope %v is not granted", connection.ScopeReadPackets)
+	}
+	return client.RequestUQHolderContext(ctx, "*")
+}
+
+// OnUQHolderChange calls fn when the UQHolder, polled every UQHolderPollInterval, changes.
+// The first snapshot taken after subscribing is compared with the next one.
+func (b *Bot) OnUQHolderChange(fn func(*UQHolderChange)) func() {
+	return subscribe(b, b.uqHolderHandlers, fn, nil)
+}
+
+// pollUQHolder fetches the UQHolder only while there are handlers
+func (b *Bot) pollUQHolder() {
+	ticker := time.NewTicker(b.options.UQHolderPollInterval)
+	defer ticker.Stop()
+	var previous *uqHolder.UQHolder
+	for {
+		select {
+		case <-b.closed:
+			return
+		case <-ticker.C:
+		}
+		b.handlersLock.RLock()
+		list := b.uqHolderHandlers.list()
+		b.handlersLock.RUnlock()
+		if len(list) == 0 {
+			previous = nil
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), b.options.UQHolderPollInterval)
+		current, err := b.Snapshot(ctx)
+		cancel()
+		if err != nil {
+			continue
+		}
+		if previous != nil {
+			change := diffUQHolder(previous, current)
+			if !change.empty() {
+				for _, fn := range list {
+					fn(change)
+				}
+			}
+		}
+		previous = current
+	}
+}
@@ -0,0 +1,94 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSnapshotRequiresReadPackets(t *testing.T) {
+	server := startTestServer(t, uqHolder.NewUQHolder(0))
+	bot := dialForTest(t, server, tokenCommands)
+	started := time.Now()
+	_, err := bot.Snapshot(testContext(t))
+	if err == nil || !strings.Contains(err.Error(), "not granted") {
+		t.Fatalf("got %v, want the scope to be refused", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("the refusal took %v", elapsed)
+	}
+}
+
+func TestSnapshotIgnoresAnswersOfCancelledRequests(t *testing.T) {
+	holder := uqHolder.NewUQHolder(0)
+	holder.PlayersByEntityID[1] = &uqHolder.Player{UUID: uuid.New(), EntityUniqueID: 1, Username: "Alice"}
+	server := startTestServer(t, holder)
+	bot := dialForTest(t, server, tokenAll)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := bot.Snapshot(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled snapshot: got %v", err)
+	}
+	// The requests abandoned halfway are answered after their callers returned,
+	// which must not be taken as the answers of the others
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			timeout := 5 * time.Second
+			if i%2 == 0 {
+				timeout = time.Duration(i) * time.Microsecond
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			snapshot, err := bot.Snapshot(ctx)
+			if i%2 == 0 {
+				return
+			}
+			if err != nil {
+				t.Errorf("snapshot %d: %v", i, err)
+				return
+			}
+			if player := snapshot.PlayersByEntityID[1]; player == nil || player.Username != "Alice" {
+				t.Errorf("snapshot %d: got the players %v", i, snapshot.PlayersByEntityID)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestDiffUQHolder(t *testing.T) {
+	alice := &uqHolder.Player{UUID: uuid.New(), EntityUniqueID: 1, Username: "Alice"}
+	bob := &uqHolder.Player{UUID: uuid.New(), EntityUniqueID: 2, Username: "Bob"}
+	previous := uqHolder.NewUQHolder(0)
+	previous.PlayersByEntityID[1] = alice
+	current := uqHolder.NewUQHolder(0)
+	current.PlayersByEntityID[2] = bob
+
+	if change := diffUQHolder(previous, previous); !change.empty() {
+		t.Fatalf("the same snapshot: got %+v", change)
+	}
+	change := diffUQHolder(previous, current)
+	if len(change.JoinedPlayers) != 1 || change.JoinedPlayers[0] != bob {
+		t.Errorf("JoinedPlayers: got %v", change.JoinedPlayers)
+	}
+	if len(change.LeftPlayers) != 1 || change.LeftPlayers[0] != alice {
+		t.Errorf("LeftPlayers: got %v", change.LeftPlayers)
+	}
+	if change.BotMoved || change.GameRulesChanged || change.InventoryChanged {
+		t.Errorf("only the players changed: got %+v", change)
+	}
+
+	current.BotPos.Position[0] = 1
+	if change := diffUQHolder(previous, current); !change.BotMoved {
+		t.Errorf("BotMoved: got %+v", change)
+	}
+}
@@ -0,0 +1,37 @@
+package external
+
+import (
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/external/packet"
+	fbtask "phoenixbuilder/fastbuilder/task"
+	"sort"
+)
+
+// listTasks describes the tasks of env sorted by their IDs
+func listTasks(env *environment.PBEnvironment) *packet.TaskListResponsePacket {
+	response := &packet.TaskListResponsePacket{Tasks: []packet.TaskInfo{}}
+	holder, ok := env.TaskHolder.(*fbtask.TaskHolder)
+	if !ok || holder == nil {
+		return response
+	}
+	response.LastTaskID = holder.TaskIdCounter.Load()
+	holder.TaskMap.Range(func(_ interface{}, value interface{}) bool {
+		task, ok := value.(*fbtask.Task)
+		if !ok {
+			return true
+		}
+		response.Tasks = append(response.Tasks, packet.TaskInfo{
+			TaskID:      task.TaskId,
+			CommandLine: task.CommandLine,
+			State:       task.State,
+			Type:        task.Type,
+			Built:       task.AsyncInfo.Built,
+			Total:       task.AsyncInfo.Total,
+		})
+		return true
+	})
+	sort.Slice(response.Tasks, func(i, j int) bool {
+		return response.Tasks[i].TaskID < response.Tasks[j].TaskID
+	})
+	return response
+}
@@ -0,0 +1,406 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/external/connection"
+	"phoenixbuilder/fastbuilder/external/packet"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The JSON messages exchanged over the WebSocket gateway.
+// Every request may carry an "id", which is echoed back in the corresponding result or error,
+// so that responses can be correlated with requests.
+const (
+	WSRequestAuth        = "auth"
+	WSRequestFBCommand   = "fb_command"
+	WSRequestMCCommand   = "mc_command"
+	WSRequestSubscribe   = "subscribe"
+	WSRequestUnsubscribe = "unsubscribe"
+	WSRequestUQHolder    = "uqholder"
+	WSRequestSendPacket  = "send_packet"
+
+	WSResponseResult = "result"
+	WSResponseError  = "error"
+	WSResponsePacket = "packet"
+	WSResponseEvent  = "event"
+)
+
+// Events sent to every session without being requested
+const (
+	// The bot lost its connection to the server, subscriptions stop receiving packets
+	WSEventDisconnected = "disconnected"
+	// The bot is connected again, the subscriptions have to be made again
+	WSEventReconnected = "reconnected"
+)
+
+// Origins of the commands sent by mc_command
+const (
+	WSCommandOriginWebSocket = "websocket"
+	WSCommandOriginPlayer    = "player"
+	WSCommandOriginSettings  = "settings"
+)
+
+const (
+	// The count of packets buffered for each subscription
+	WSSubscriptionBufferSize = 128
+	// The time for the client to send the auth message
+	WSAuthTimeout = 10 * time.Second
+)
+
+type WSRequest struct {
+	ID   json.RawMessage `json:"id,omitempty"`
+	Type string          `json:"type"`
+	// auth
+	Token string `json:"token,omitempty"`
+	// fb_command, mc_command
+	Command string `json:"command,omitempty"`
+	// mc_command, one of websocket (default), player and settings
+	Origin    string `json:"origin,omitempty"`
+	TimeoutMS int64  `json:"timeout_ms,omitempty"`
+	// subscribe, type names such as Text or IDText
+	Packets []string `json:"packets,omitempty"`
+	// unsubscribe
+	Subscription int `json:"subscription,omitempty"`
+	// send_packet
+	Packet string          `json:"packet,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+type WSResponse struct {
+	ID           json.RawMessage `json:"id,omitempty"`
+	Type         string          `json:"type"`
+	Error        string          `json:"error,omitempty"`
+	Result       interface{}     `json:"result,omitempty"`
+	Subscription int             `json:"subscription,omitempty"`
+	Packet       string          `json:"packet,omitempty"`
+	PacketID     uint32          `json:"packet_id,omitempty"`
+	Body         interface{}     `json:"body,omitempty"`
+	Event        string          `json:"event,omitempty"`
+	Reason       string          `json:"reason,omitempty"`
+}
+
+type WebSocketGateway struct {
+	env           *environment.PBEnvironment
+	authenticator *connection.Authenticator
+	upgrader      websocket.Upgrader
+	server        *http.Server
+	sessions      map[*wsSession]struct{}
+	sessionsLock  sync.Mutex
+}
+
+// ListenWS serves the operations of the external protocol as JSON messages over WebSocket on address,
+// clients authenticate with the tokens in authFile (or DefaultAuthFile() if empty),
+// either by the query parameter "token", the header "Authorization: Bearer <token>" or an auth message.
+func ListenWS(env *environment.PBEnvironment, address string, authFile string) {
+	authenticator, err := loadAuthenticator(authFile)
+	if err != nil {
+		fmt.Printf("Failed to load the authorized external clients: %v\n", err)
+		return
+	}
+	gateway := &WebSocketGateway{
+		env:           env,
+		authenticator: authenticator,
+		upgrader: websocket.Upgrader{
+			// Every client has to authenticate, so requests from any page are allowed
+			CheckOrigin: func(_ *http.Request) bool { return true },
+		},
+		sessions: map[*wsSession]struct{}{},
+	}
+	gateway.server = &http.Server{Addr: address, Handler: gateway}
+	env.WebSocketGateway = gateway
+	env.Destructors = append(env.Destructors, func() {
+		gateway.server.Close()
+	})
+	go func() {
+		err := gateway.server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Failed to listen on address %s: %v\n", address, err)
+		}
+	}()
+	fmt.Printf("Listening for WebSocket connection on address %s\n", address)
+}
+
+func (g *WebSocketGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := g.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		token = strings.TrimPrefix(authorization, "Bearer ")
+	}
+	session := &wsSession{
+		gateway:       g,
+		conn:          conn,
+		subscriptions: map[int]func(){},
+	}
+	defer session.close()
+	if client, ok := g.authenticator.AuthenticateToken(token); ok {
+		session.scopes = client.Scopes
+	} else {
+		request := WSRequest{}
+		conn.SetReadDeadline(time.Now().Add(WSAuthTimeout))
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+		client, ok := g.authenticator.AuthenticateToken(request.Token)
+		if request.Type != WSRequestAuth || !ok {
+			session.send(WSResponse{ID: request.ID, Type: WSResponseError, Error: "authentication failed"})
+			return
+		}
+		session.scopes = client.Scopes
+		session.send(WSResponse{ID: request.ID, Type: WSResponseResult, Result: session.scopes.String()})
+		conn.SetReadDeadline(time.Time{})
+	}
+	fmt.Printf("WebSocket: accept new connection @ %v (scopes=%v)\n", r.RemoteAddr, session.scopes)
+	g.addSession(session)
+	defer g.removeSession(session)
+	for {
+		request := WSRequest{}
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+		go session.handle(request)
+	}
+}
+
+func (g *WebSocketGateway) addSession(session *wsSession) {
+	g.sessionsLock.Lock()
+	defer g.sessionsLock.Unlock()
+	g.sessions[session] = struct{}{}
+}
+
+func (g *WebSocketGateway) removeSession(session *wsSession) {
+	g.sessionsLock.Lock()
+	defer g.sessionsLock.Unlock()
+	delete(g.sessions, session)
+}
+
+// NotifyConnectionStatus sends WSEventDisconnected or WSEventReconnected to every session,
+// the subscriptions of the sessions are dropped when reconnected
+func (g *WebSocketGateway) NotifyConnectionStatus(status uint8, reason string) {
+	event := WSEventDisconnected
+	if status == packet.ConnectionStatusReconnected {
+		event = WSEventReconnected
+	}
+	g.sessionsLock.Lock()
+	defer g.sessionsLock.Unlock()
+	for session := range g.sessions {
+		if status == packet.ConnectionStatusReconnected {
+			session.dropSubscriptions()
+		}
+		session.send(WSResponse{Type: WSResponseEvent, Event: event, Reason: reason})
+	}
+}
+
+type wsSession struct {
+	gateway   *WebSocketGateway
+	conn      *websocket.Conn
+	scopes    connection.Scope
+	writeLock sync.Mutex
+	// stop functions of the subscriptions
+	subscriptions    map[int]func()
+	subscriptionLock sync.Mutex
+	nextSubscription int
+	closed           bool
+}
+
+func (s *wsSession) send(response WSResponse) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	return s.conn.WriteJSON(response)
+}
+
+func (s *wsSession) close() {
+	s.subscriptionLock.Lock()
+	s.closed = true
+	s.subscriptionLock.Unlock()
+	s.dropSubscriptions()
+	s.conn.Close()
+}
+
+func (s *wsSession) dropSubscriptions() {
+	s.subscriptionLock.Lock()
+	defer s.subscriptionLock.Unlock()
+	for id, stop := range s.subscriptions {
+		stop()
+		delete(s.subscriptions, id)
+	}
+}
+
+func (s *wsSession) resources() (*ResourcesControl.Resources, error) {
+	resources, ok := s.gateway.env.Resources.(*ResourcesControl.Resources)
+	if !ok || resources == nil {
+		return nil, fmt.Errorf("not connected to the server yet")
+	}
+	return resources, nil
+}
+
+func (s *wsSession) handle(request WSRequest) {
+	result, err := s.process(request)
+	if err != nil {
+		s.send(WSResponse{ID: request.ID, Type: WSResponseError, Error: err.Error()})
+		return
+	}
+	s.send(WSResponse{ID: request.ID, Type: WSResponseResult, Result: result})
+}
+
+func (s *wsSession) require(scope connection.Scope, request string) error {
+	if !s.scopes.Has(scope) {
+		return fmt.Errorf("permission denied: %s requires the scope %v", request, scope)
+	}
+	return nil
+}
+
+func (s *wsSession) process(request WSRequest) (interface{}, error) {
+	env := s.gateway.env
+	switch request.Type {
+	case WSRequestFBCommand:
+		if err := s.require(connection.ScopeFBCommands, request.Type); err != nil {
+			return nil, err
+		}
+		return env.FunctionHolder.Process(request.Command), nil
+	case WSRequestMCCommand:
+		if err := s.require(connection.ScopeMCCommands, request.Type); err != nil {
+			return nil, err
+		}
+		if _, err := s.resources(); err != nil {
+			return nil, err
+		}
+		return s.sendCommand(request)
+	case WSRequestSubscribe:
+		if err := s.require(connection.ScopeReadPackets, request.Type); err != nil {
+			return nil, err
+		}
+		return s.subscribe(request.Packets)
+	case WSRequestUnsubscribe:
+		s.subscriptionLock.Lock()
+		defer s.subscriptionLock.Unlock()
+		stop, found := s.subscriptions[request.Subscription]
+		if !found {
+			return nil, fmt.Errorf("subscription %d not found", request.Subscription)
+		}
+		stop()
+		delete(s.subscriptions, request.Subscription)
+		return true, nil
+	case WSRequestUQHolder:
+		if err := s.require(connection.ScopeReadPackets, request.Type); err != nil {
+			return nil, err
+		}
+		holder, ok := env.UQHolder.(*uqHolder.UQHolder)
+		if !ok || holder == nil {
+			return nil, fmt.Errorf("not connected to the server yet")
+		}
+		return holder, nil
+	case WSRequestSendPacket:
+		if err := s.require(connection.ScopeRawPackets, request.Type); err != nil {
+			return nil, err
+		}
+		if _, err := s.resources(); err != nil {
+			return nil, err
+		}
+		id, err := connection.PacketTypeToID(request.Packet)
+		if err != nil {
+			return nil, err
+		}
+		pk, err := connection.JSONToGamePacket(id, request.Body)
+		if err != nil {
+			return nil, err
+		}
+		return true, env.Connection.(*minecraft.Conn).WritePacket(pk)
+	default:
+		return nil, fmt.Errorf("unknown request type %#v", request.Type)
+	}
+}
+
+// sendCommand sends the command and waits for its output unless it is sent as a settings command
+func (s *wsSession) sendCommand(request WSRequest) (interface{}, error) {
+	env := s.gateway.env
+	var commandType uint8
+	switch request.Origin {
+	case WSCommandOriginSettings:
+		return true, env.CurrentGameInterface().SendSettingsCommand(request.Command, false)
+	case WSCommandOriginPlayer:
+		commandType = packet.CommandTypeNormal
+	case "", WSCommandOriginWebSocket:
+		commandType = packet.CommandTypeWebsocket
+	default:
+		return nil, fmt.Errorf("unknown command origin %#v", request.Origin)
+	}
+	resp := sendCommandWithResponse(env, commandType, request.Command, time.Duration(request.TimeoutMS)*time.Millisecond)
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Respond, nil
+}
+
+// subscribe forwards the packets of the given types to the client as decoded JSON bodies
+func (s *wsSession) subscribe(packetTypes []string) (interface{}, error) {
+	resources, err := s.resources()
+	if err != nil {
+		return nil, err
+	}
+	if len(packetTypes) == 0 {
+		return nil, fmt.Errorf("no packet type specified")
+	}
+	packetsID := []uint32{}
+	for _, packetType := range packetTypes {
+		id, err := connection.PacketTypeToID(packetType)
+		if err != nil {
+			return nil, err
+		}
+		packetsID = append(packetsID, id)
+	}
+	s.subscriptionLock.Lock()
+	defer s.subscriptionLock.Unlock()
+	if s.closed {
+		return nil, fmt.Errorf("connection closed")
+	}
+	s.nextSubscription++
+	subscription := s.nextSubscription
+	uniqueId, packets := resources.Listener.CreateNewListen(packetsID, WSSubscriptionBufferSize)
+	done := make(chan struct{})
+	var once sync.Once
+	s.subscriptions[subscription] = func() {
+		once.Do(func() {
+			close(done)
+			resources.Listener.StopAndDestroy(uniqueId)
+		})
+	}
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-resources.Listener.Closed():
+				// The listener is stopped as the connection is lost
+				s.subscriptionLock.Lock()
+				delete(s.subscriptions, subscription)
+				s.subscriptionLock.Unlock()
+				return
+			case pk := <-packets:
+				err := s.send(WSResponse{
+					Type:         WSResponsePacket,
+					Subscription: subscription,
+					Packet:       connection.PacketTypeName(pk),
+					PacketID:     pk.ID(),
+					Body:         pk,
+				})
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return subscription, nil
+}
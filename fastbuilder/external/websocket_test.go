@@ -0,0 +1,104 @@
+package external
+
+import (
+	"net"
+	"path/filepath"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/external/connection"
+	"phoenixbuilder/fastbuilder/external/packet"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// listenWSForTest starts a gateway on a free port and returns its address and token
+func listenWSForTest(t *testing.T) (*environment.PBEnvironment, string, string) {
+	t.Helper()
+	authFile := filepath.Join(t.TempDir(), "external_auth.json")
+	token, err := connection.CreateAuthenticatorFile(authFile)
+	if err != nil {
+		t.Fatalf("CreateAuthenticatorFile: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	env := &environment.PBEnvironment{}
+	ListenWS(env, address, authFile)
+	t.Cleanup(env.Stop)
+	return env, address, token
+}
+
+// dialWS retries until the gateway is listening
+func dialWS(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err == nil {
+			t.Cleanup(func() { conn.Close() })
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Dial: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) WSResponse {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	response := WSResponse{}
+	if err := conn.ReadJSON(&response); err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	return response
+}
+
+func TestWebSocketGatewayAuthentication(t *testing.T) {
+	env, address, token := listenWSForTest(t)
+
+	byQuery := dialWS(t, "ws://"+address+"/?token="+token)
+	byMessage := dialWS(t, "ws://"+address+"/")
+	if err := byMessage.WriteJSON(WSRequest{Type: WSRequestAuth, Token: token}); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if response := readWS(t, byMessage); response.Type != WSResponseResult {
+		t.Fatalf("auth: got %+v", response)
+	}
+
+	// Both sessions are registered, so they receive the events
+	deadline := time.Now().Add(5 * time.Second)
+	gateway := env.WebSocketGateway.(*WebSocketGateway)
+	for {
+		gateway.sessionsLock.Lock()
+		count := len(gateway.sessions)
+		gateway.sessionsLock.Unlock()
+		if count == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d sessions registered, want 2", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	NotifyConnectionStatus(env, packet.ConnectionStatusDisconnected, "timeout")
+	for _, conn := range []*websocket.Conn{byQuery, byMessage} {
+		response := readWS(t, conn)
+		if response.Type != WSResponseEvent || response.Event != WSEventDisconnected || response.Reason != "timeout" {
+			t.Fatalf("event: got %+v", response)
+		}
+	}
+
+	rejected := dialWS(t, "ws://"+address+"/")
+	if err := rejected.WriteJSON(WSRequest{Type: WSRequestAuth, Token: "wrong"}); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if response := readWS(t, rejected); response.Type != WSResponseError {
+		t.Fatalf("auth with a wrong token: got %+v", response)
+	}
+}
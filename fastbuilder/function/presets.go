@@ -454,6 +454,26 @@ func InitPresetFunctions(fh *FunctionHolder) {
 			env.GameInterface.Output(fmt.Sprintf(I18n.T(I18n.TaskDisplayModeSet), types.MakeTaskDisplayMode(ev)))
 		},
 	})
+	fh.RegisterFunction(&Function{
+		Name:          "map art",
+		OwnedKeywords: []string{"mapart"},
+		FunctionType:  FunctionTypeRegular,
+		FunctionContent: func(env *environment.PBEnvironment, msg string) {
+			task := special_tasks.CreateMapArtTask(msg, env)
+			if task == nil {
+				return
+			}
+			env.GameInterface.Output(fmt.Sprintf("%s, ID=%d.", I18n.T(I18n.TaskCreated), task.TaskId))
+		},
+	})
+	fh.RegisterFunction(&Function{
+		Name:          "nbt analysis",
+		OwnedKeywords: []string{"nbtcheck"},
+		FunctionType:  FunctionTypeRegular,
+		FunctionContent: func(env *environment.PBEnvironment, msg string) {
+			special_tasks.CreateNBTAnalysisTask(msg, env)
+		},
+	})
 	var builderMethods []string
 	for met, _ := range builder.Builder {
 		builderMethods = append(builderMethods, met)
@@ -68,7 +68,7 @@ func UnZip(zipfile io.ReaderAt, size int64, dst_dir string) error {
 	}
 	for _, file := range fr.File {
 		if file.FileInfo().IsDir() {
-			err := os.MkdirAll(file.Name, 0644)
+			err := os.MkdirAll(path.Join(dst_dir, file.Name), 0755)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,147 @@
+package mcstructure
+
+import (
+	"fmt"
+	"math"
+	"phoenixbuilder/fastbuilder/types"
+)
+
+/*
+总是会被导出的实体。
+
+物品展示框与荧光物品展示框在基岩版中是方块实体，
+因此它们会随方块及其 NBT 数据一同被导出，
+而不在此表中
+*/
+var ExportedEntities = map[string]bool{
+	"minecraft:armor_stand": true,
+	"minecraft:painting":    true,
+}
+
+// 从 structure 中提取所有实体的 NBT 数据。
+// structure 应当是完整的结构数据，
+// 请求结构时忽略了实体的话，返回的切片将是空的
+func GetStructureEntities(structure map[string]interface{}) ([]map[string]interface{}, error) {
+	value_structure, normal := structure["structure"].(map[string]interface{})
+	if !normal {
+		return nil, fmt.Errorf("GetStructureEntities: Crashed in structure[\"structure\"]; structure = %#v", structure)
+	}
+	// structure["structure"]
+	if _, ok := value_structure["entities"]; !ok {
+		return []map[string]interface{}{}, nil
+	}
+	value_entities, normal := value_structure["entities"].([]interface{})
+	if !normal {
+		return nil, fmt.Errorf("GetStructureEntities: Crashed in structure[\"structure\"][\"entities\"]; structure = %#v", value_structure)
+	}
+	// structure["structure"]["entities"]
+	entities := make([]map[string]interface{}, 0, len(value_entities))
+	for key, value := range value_entities {
+		entity, normal := value.(map[string]interface{})
+		if !normal {
+			return nil, fmt.Errorf("GetStructureEntities: Crashed in structure[\"structure\"][\"entities\"][%d]; entities = %#v", key, value_entities)
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+	// 返回值
+}
+
+// 取得实体 entity 的坐标
+func GetEntityPosition(entity map[string]interface{}) ([3]float32, error) {
+	pos, normal := entity["Pos"].([]interface{})
+	if !normal || len(pos) != 3 {
+		return [3]float32{}, fmt.Errorf("GetEntityPosition: Crashed in entity[\"Pos\"]; entity = %#v", entity)
+	}
+	ans := [3]float32{}
+	for key, value := range pos {
+		ans[key], normal = value.(float32)
+		if !normal {
+			return [3]float32{}, fmt.Errorf("GetEntityPosition: Crashed in entity[\"Pos\"][%d]; entity = %#v", key, entity)
+		}
+	}
+	return ans, nil
+}
+
+/*
+判断实体 entity 是否应当被导出。
+
+盔甲架和画总是会被导出，
+其他实体只有在被命名或被拴绳拴住时才会被导出，
+这样野外随机生成的生物就不会被一同导出
+*/
+func IsEntityExportable(entity map[string]interface{}) bool {
+	identifier, _ := entity["identifier"].(string)
+	if ExportedEntities[identifier] {
+		return true
+	}
+	if customName, _ := entity["CustomName"].(string); len(customName) != 0 {
+		return true
+	}
+	if leasherID, ok := entity["LeasherID"].(int64); ok && leasherID != -1 {
+		return true
+	}
+	return false
+}
+
+// 从 allAreas 中提取所有应当被导出的实体，
+// 返回的实体坐标是相对于 currentExport 的起点的
+func DumpEntities(allAreas []Mcstructure, currentExport Area) ([]*types.Module, error) {
+	ans := make([]*types.Module, 0)
+	for _, area := range allAreas {
+		got, err := EntitiesToModules(area.entities, currentExport)
+		if err != nil {
+			return []*types.Module{}, fmt.Errorf("DumpEntities: %v", err)
+		}
+		ans = append(ans, got...)
+	}
+	return ans, nil
+}
+
+/*
+将 entities 中所有应当被导出的实体转换为 types.Module 。
+
+返回的实体坐标是相对于 currentExport 的起点的，
+这与 DumpBlocks 所返回的方块坐标一致
+*/
+func EntitiesToModules(entities []map[string]interface{}, currentExport Area) ([]*types.Module, error) {
+	ans := make([]*types.Module, 0)
+	for _, entity := range entities {
+		if !IsEntityExportable(entity) {
+			continue
+		}
+		identifier, _ := entity["identifier"].(string)
+		pos, err := GetEntityPosition(entity)
+		if err != nil {
+			return []*types.Module{}, fmt.Errorf("EntitiesToModules: %v", err)
+		}
+		relative := [3]float64{
+			float64(pos[0]) - float64(currentExport.BeginX),
+			float64(pos[1]) - float64(currentExport.BeginY),
+			float64(pos[2]) - float64(currentExport.BeginZ),
+		}
+		point := [3]float64{
+			math.Floor(relative[0]),
+			math.Floor(relative[1]),
+			math.Floor(relative[2]),
+		}
+		ans = append(ans, &types.Module{
+			Entity: &types.Entity{
+				Identifier: identifier,
+				Offset: [3]float32{
+					float32(relative[0] - point[0]),
+					float32(relative[1] - point[1]),
+					float32(relative[2] - point[2]),
+				},
+				NBT: entity,
+			},
+			Point: types.Position{
+				X: int(point[0]),
+				Y: int(point[1]),
+				Z: int(point[2]),
+			},
+		})
+	}
+	return ans, nil
+	// 返回值
+}
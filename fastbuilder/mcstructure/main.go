@@ -34,6 +34,7 @@ type Mcstructure struct {
 	foreground               []int16                        // 用于描述一个方块的前景层；这里应该用 int32 的，不过 PhoenixBuilder 只能表示 int16 个方块，所以我这里就省一下内存
 	background               []int16                        // 用于描述一个方块的背景层；这里应该用 int32 的，不过 PhoenixBuilder 只能表示 int16 个方块，所以我这里就省一下内存
 	blockNBT                 map[int]map[string]interface{} // 用于存放方块实体数据
+	entities                 []map[string]interface{}       // 用于存放结构中的实体；请求结构时忽略了实体的话，这将是空的
 }
 
 /*
@@ -274,6 +275,11 @@ func GetMCStructureData(area Area, structure map[string]interface{}) (Mcstructur
 		background = append(background, int16(got))
 	}
 	// 然后再去拿背景层方块的索引表
+	entities, err := GetStructureEntities(structure)
+	if err != nil {
+		return Mcstructure{}, fmt.Errorf("GetMCStructureData: %v", err)
+	}
+	// 最后是结构中的实体
 	return Mcstructure{
 		info:                     area,
 		blockPalette:             blockPalette,
@@ -282,6 +288,7 @@ func GetMCStructureData(area Area, structure map[string]interface{}) (Mcstructur
 		foreground:               foreground,
 		background:               background,
 		blockNBT:                 blockNBT,
+		entities:                 entities,
 	}, nil
 	// 返回扒~
 }
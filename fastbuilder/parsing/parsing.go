@@ -90,11 +90,21 @@ func Parse(Message string, defaultConfig *types.MainConfig) (*types.MainConfig,
 	FlagSet.IntVar(&Config.MapX, "mapX", defaultConfig.MapX, "Take X maps in map art")
 	FlagSet.IntVar(&Config.MapZ, "mapZ", defaultConfig.MapZ, "Take Z maps in map art")
 	FlagSet.IntVar(&Config.MapY, "mapY", defaultConfig.MapY, "Available Height (blocks) for 3D map art")
+	FlagSet.BoolVar(&Config.MapAlign, "align", defaultConfig.MapAlign, "Align map art to the map grid")
+	FlagSet.BoolVar(&Config.MapItems, "mapitems", defaultConfig.MapItems, "Give the bot a filled map for each map art tile")
+	FlagSet.BoolVar(&Config.MapWall, "mapwall", defaultConfig.MapWall, "Place the filled maps into an item frame wall starting at the end position")
+	// Paint Configuration
+	FlagSet.StringVar(&Config.Dither, "dither", defaultConfig.Dither, "Dithering method for paint: none, floyd or ordered")
+	FlagSet.StringVar(&Config.ColorDistance, "distance", defaultConfig.ColorDistance, "Color distance for paint: rgb, lab or ciede2000")
+	FlagSet.StringVar(&Config.Palette, "palette", defaultConfig.Palette, "The path of a JSON block palette file for paint")
+	FlagSet.StringVar(&Config.Preview, "preview", defaultConfig.Preview, "Save the original and quantized image side by side to this path")
+	FlagSet.IntVar(&Config.AlphaThreshold, "alpha", defaultConfig.AlphaThreshold, "Pixels with alpha (0~255) below this value are skipped in paint")
 	//Facing, Path, Shape
 	FlagSet.StringVar(&Config.Facing, "facing", defaultConfig.Facing, "Building's facing")
 	FlagSet.StringVar(&Config.Facing, "f", defaultConfig.Facing, "Building's facing")
 	FlagSet.StringVar(&Config.Path, "path", defaultConfig.Path, "The path of file")
 	FlagSet.StringVar(&Config.Path, "p", defaultConfig.Path, "The path of file")
+	FlagSet.StringVar(&Config.Report, "report", defaultConfig.Report, "Write the NBT analysis report as JSON to this path")
 	FlagSet.StringVar(&Config.Shape, "shape", defaultConfig.Shape, "The shape of geometric structure")
 	FlagSet.StringVar(&Config.Shape, "s", defaultConfig.Shape, "The shape of geometric structure")
 	//Block
@@ -0,0 +1,36 @@
+package script_engine
+
+import (
+	"phoenixbuilder/fastbuilder/args"
+)
+
+/*
+设置 consts 对象。
+
+consts 中的值可以被 --script-engine-const 覆盖，
+也可以被 --script-engine-suppress-const 移除
+*/
+func (s *Script) setupConsts() {
+	values := map[string]interface{}{
+		"engine_version": EngineVersion,
+		"server_code":    "",
+		"fb_version":     args.FBVersion,
+		"uc_username":    "",
+	}
+	for key, value := range s.bridge.Consts() {
+		values[key] = value
+	}
+	// 默认值
+	for key, value := range args.CustomSEConsts {
+		values[key] = value
+	}
+	for _, key := range args.CustomSEUndefineConsts {
+		delete(values, key)
+	}
+	// 应用用户提供的常量
+	consts := s.vm.NewObject()
+	for key, value := range values {
+		consts.Set(key, value)
+	}
+	s.vm.Set("consts", consts)
+}
@@ -0,0 +1,52 @@
+package script_engine
+
+import (
+	"github.com/dop251/goja"
+)
+
+// 设置 engine 对象
+func (s *Script) setupEngine() {
+	engine := s.vm.NewObject()
+	engine.Set("setName", func(name string) {
+		s.Name = name
+	})
+	engine.Set("waitConnectionSync", func() {
+		s.waitConnect()
+	})
+	engine.Set("waitConnection", func(callback goja.Value) {
+		function := s.assertFunction("engine.waitConnection", callback)
+		release := s.hold()
+		go func() {
+			if !s.waitConnect() {
+				return
+			}
+			s.post(func() error {
+				release()
+				return s.call(function)
+			})
+		}()
+	})
+	engine.Set("message", func(message string) {
+		s.output(message + "\n")
+	})
+	engine.Set("crash", func(reason string) {
+		s.vm.Interrupt(crashError{reason: reason})
+	})
+	s.vm.Set("engine", engine)
+}
+
+// 等待与租赁服的连接被建立，
+// 返回值为假时代表脚本在此之前已经停止
+func (s *Script) waitConnect() bool {
+	connected := make(chan struct{})
+	go func() {
+		s.bridge.WaitConnect()
+		close(connected)
+	}()
+	select {
+	case <-connected:
+		return true
+	case <-s.stopped:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package script_engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	I18n "phoenixbuilder/fastbuilder/i18n"
+	"regexp"
+	"strings"
+)
+
+// 合法的容器标识符
+var containerIdentifierPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{5,31}$`)
+
+// 返回存放所有脚本容器的目录
+func ContainersDirectory() string {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Println(I18n.T(I18n.Warning_UserHomeDir))
+		homedir = "."
+	}
+	return filepath.Join(homedir, ".config/fastbuilder/script_containers")
+}
+
+// 为脚本创建标识符为 identifier 的容器，
+// 脚本的相对路径都将基于此容器解析
+func (s *Script) RequireContainer(identifier string) error {
+	if s.containerPath != "" {
+		return fmt.Errorf("RequireContainer: A container has already been created at %s", s.containerPath)
+	}
+	if !containerIdentifierPattern.MatchString(identifier) {
+		return fmt.Errorf("RequireContainer: Invalid container identifier %#v", identifier)
+	}
+	path := filepath.Join(ContainersDirectory(), identifier)
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return fmt.Errorf("RequireContainer: %v", err)
+	}
+	s.containerPath = path
+	s.vm.Get("fs").ToObject(s.vm).Set("containerPath", path)
+	return nil
+}
+
+// 设置 fs 对象
+func (s *Script) setupFS() {
+	fs := s.vm.NewObject()
+	fs.Set("containerPath", "")
+	fs.Set("requireContainer", func(identifier string) {
+		if s.bundle != nil {
+			s.throw("fs.requireContainer: The container of a script package is managed by its manifest")
+		}
+		if err := s.RequireContainer(identifier); err != nil {
+			s.throw("fs.requireContainer: %v", err)
+		}
+	})
+	fs.Set("exists", func(path string) bool {
+		_, err := os.Stat(s.resolvePath("fs.exists", path))
+		return err == nil
+	})
+	fs.Set("isDir", func(path string) bool {
+		info, err := os.Stat(s.resolvePath("fs.isDir", path))
+		return err == nil && info.IsDir()
+	})
+	fs.Set("mkdir", func(path string) {
+		if err := os.MkdirAll(s.resolvePath("fs.mkdir", path), 0755); err != nil {
+			s.throw("fs.mkdir: %v", err)
+		}
+	})
+	fs.Set("rename", func(oldpath string, newpath string) {
+		if err := os.Rename(s.resolvePath("fs.rename", oldpath), s.resolvePath("fs.rename", newpath)); err != nil {
+			s.throw("fs.rename: %v", err)
+		}
+	})
+	fs.Set("remove", func(path string) {
+		if err := os.RemoveAll(s.resolvePath("fs.remove", path)); err != nil {
+			s.throw("fs.remove: %v", err)
+		}
+	})
+	fs.Set("readFile", func(path string) string {
+		content, err := os.ReadFile(s.resolvePath("fs.readFile", path))
+		if err != nil {
+			s.throw("fs.readFile: %v", err)
+		}
+		return string(content)
+	})
+	fs.Set("writeFile", func(path string, content string) {
+		if err := os.WriteFile(s.resolvePath("fs.writeFile", path), []byte(content), 0644); err != nil {
+			s.throw("fs.writeFile: %v", err)
+		}
+	})
+	s.vm.Set("fs", fs)
+}
+
+// 将 path 解析为绝对路径。
+// 相对路径基于脚本的容器，
+// 因此在容器被创建前使用相对路径将抛出异常，
+// 而访问容器外的路径(包括通过 .. 离开容器的相对路径)
+// 需要 PermissionFileSystem 权限
+func (s *Script) resolvePath(name string, path string) string {
+	if !filepath.IsAbs(path) {
+		if s.containerPath == "" {
+			s.throw("%s: Relative path %#v requires a container, use fs.requireContainer first", name, path)
+		}
+		path = filepath.Join(s.containerPath, path)
+	}
+	if !s.insideContainer(path) {
+		s.requirePermission(name, PermissionFileSystem)
+	}
+	return path
+}
+
+// 检查绝对路径 path 是否位于脚本的容器内
+func (s *Script) insideContainer(path string) bool {
+	if s.containerPath == "" {
+		return false
+	}
+	relative, err := filepath.Rel(s.containerPath, path)
+	if err != nil {
+		return false
+	}
+	return relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,85 @@
+package script_engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 只提供常量的宿主，
+// 调用其余的方法将导致 panic
+type constsOnlyBridge struct {
+	HostBridge
+}
+
+func (constsOnlyBridge) Consts() map[string]string {
+	return map[string]string{}
+}
+
+// 创建一个未被授予任何权限的脚本，
+// 其容器位于临时目录中，而 secret.txt 位于容器外
+func newContainedScript(t *testing.T) (*Script, string) {
+	t.Helper()
+	directory := t.TempDir()
+	container := filepath.Join(directory, "container")
+	if err := os.MkdirAll(filepath.Join(container, "data"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	for _, name := range []string{"secret.txt", "container-sibling.txt"} {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte("secret"), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	s := NewScript(constsOnlyBridge{})
+	s.containerPath = container
+	s.permissions = map[string]bool{}
+	return s, directory
+}
+
+func TestRelativePathCannotLeaveContainer(t *testing.T) {
+	s, directory := newContainedScript(t)
+	escapes := []string{
+		`fs.readFile("../secret.txt")`,
+		`fs.readFile("data/../../secret.txt")`,
+		`fs.readFile("../container-sibling.txt")`,
+		`fs.writeFile("../written.txt", "x")`,
+		`fs.readFile(` + "`" + filepath.ToSlash(filepath.Join(directory, "secret.txt")) + "`" + `)`,
+	}
+	for _, source := range escapes {
+		_, err := s.vm.RunString(source)
+		if err == nil || !strings.Contains(err.Error(), PermissionFileSystem) {
+			t.Errorf("%s: got %v, want the permission to be required", source, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(directory, "written.txt")); err == nil {
+		t.Errorf("a file was written outside of the container")
+	}
+}
+
+func TestPathInsideContainerNeedsNoPermission(t *testing.T) {
+	s, _ := newContainedScript(t)
+	absolute := filepath.ToSlash(filepath.Join(s.containerPath, "data", "absolute.txt"))
+	sources := []string{
+		`fs.writeFile("data/../note.txt", "hello")`,
+		`fs.readFile("./note.txt")`,
+		`fs.writeFile(` + "`" + absolute + "`" + `, "hello")`,
+	}
+	for _, source := range sources {
+		if _, err := s.vm.RunString(source); err != nil {
+			t.Errorf("%s: %v", source, err)
+		}
+	}
+}
+
+func TestGrantedPermissionAllowsLeavingContainer(t *testing.T) {
+	s, _ := newContainedScript(t)
+	s.permissions[PermissionFileSystem] = true
+	value, err := s.vm.RunString(`fs.readFile("../secret.txt")`)
+	if err != nil {
+		t.Fatalf("fs.readFile: %v", err)
+	}
+	if value.String() != "secret" {
+		t.Fatalf("got %#v, want \"secret\"", value.String())
+	}
+}
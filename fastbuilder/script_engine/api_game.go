@@ -0,0 +1,130 @@
+package script_engine
+
+import (
+	"fmt"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// 设置 game 对象
+func (s *Script) setupGame() {
+	game := s.vm.NewObject()
+	game.Set("eval", func(command string) {
+		s.requirePermission("game.eval", PermissionEval)
+		s.requireConnection("game.eval")
+		// 运行脚本的 script 命令将绕过权限限制，
+		// 因此受限的脚本不能使用它
+		if s.permissions != nil && strings.Split(command, " ")[0] == "script" {
+			s.throw("game.eval: The script command is not available to scripts with restricted permissions")
+		}
+		s.bridge.Eval(command)
+	})
+	game.Set("oneShotCommand", func(command string) {
+		s.requirePermission("game.oneShotCommand", PermissionCommand)
+		s.requireConnection("game.oneShotCommand")
+		if err := s.bridge.SendCommand(command); err != nil {
+			s.throw("game.oneShotCommand: %v", err)
+		}
+	})
+	game.Set("sendCommandSync", func(command string) goja.Value {
+		s.requirePermission("game.sendCommandSync", PermissionCommand)
+		s.requireConnection("game.sendCommandSync")
+		resp, err := s.bridge.SendCommandWithResponse(command)
+		if err != nil {
+			s.throw("game.sendCommandSync: %v", err)
+		}
+		return s.toObject(resp)
+	})
+	game.Set("sendCommand", func(command string, callback goja.Value) {
+		s.requirePermission("game.sendCommand", PermissionCommand)
+		s.requireConnection("game.sendCommand")
+		if callback == nil || goja.IsUndefined(callback) {
+			if err := s.bridge.SendCommand(command); err != nil {
+				s.throw("game.sendCommand: %v", err)
+			}
+			return
+		}
+		function := s.assertFunction("game.sendCommand", callback)
+		release := s.hold()
+		go func() {
+			resp, err := s.bridge.SendCommandWithResponse(command)
+			s.post(func() error {
+				release()
+				if err != nil {
+					return fmt.Errorf("game.sendCommand: %v", err)
+				}
+				return s.call(function, s.toObject(resp))
+			})
+		}()
+	})
+	game.Set("botPos", func() goja.Value {
+		s.requireConnection("game.botPos")
+		pos := s.bridge.BotPosition()
+		result := s.vm.NewObject()
+		result.Set("x", pos[0])
+		result.Set("y", pos[1])
+		result.Set("z", pos[2])
+		return result
+	})
+	game.Set("subscribePacket", func(packetType string, callback goja.Value) goja.Value {
+		s.requirePermission("game.subscribePacket", PermissionPacket)
+		s.requireConnection("game.subscribePacket")
+		id, err := packetTypeToID(packetType)
+		if err != nil {
+			s.throw("game.subscribePacket: %v", err)
+		}
+		function := s.assertFunction("game.subscribePacket", callback)
+		return s.subscribe("game.subscribePacket", []uint32{id}, func(pk packet.Packet) error {
+			return s.call(function, s.toObject(pk))
+		})
+	})
+	game.Set("listenChat", func(callback goja.Value) goja.Value {
+		s.requirePermission("game.listenChat", PermissionChat)
+		s.requireConnection("game.listenChat")
+		function := s.assertFunction("game.listenChat", callback)
+		return s.subscribe("game.listenChat", []uint32{packet.IDText}, func(pk packet.Packet) error {
+			p := pk.(*packet.Text)
+			if p.TextType != packet.TextTypeChat {
+				return nil
+			}
+			return s.call(function, s.vm.ToValue(p.SourceName), s.vm.ToValue(p.Message))
+		})
+	})
+	s.vm.Set("game", game)
+}
+
+// 如果与租赁服的连接尚未建立，
+// 则抛出一个异常
+func (s *Script) requireConnection(name string) {
+	if !s.bridge.IsConnected() {
+		s.throw("%s: Not connected yet, use engine.waitConnection or engine.waitConnectionSync first", name)
+	}
+}
+
+// 订阅 packetsID 所指代的数据包，
+// 并在脚本协程上以 handler 处理它们。
+// 返回的 JavaScript 函数用于取消订阅
+func (s *Script) subscribe(name string, packetsID []uint32, handler func(pk packet.Packet) error) goja.Value {
+	release := s.hold()
+	stop, err := s.bridge.SubscribePacket(packetsID, func(pk packet.Packet) {
+		s.post(func() error {
+			return handler(pk)
+		})
+	})
+	if err != nil {
+		release()
+		s.throw("%s: %v", name, err)
+	}
+	stop = s.track(stop)
+	unsubscribed := false
+	return s.vm.ToValue(func() {
+		if unsubscribed {
+			return
+		}
+		unsubscribed = true
+		stop()
+		release()
+	})
+}
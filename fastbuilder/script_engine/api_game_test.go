@@ -0,0 +1,125 @@
+package script_engine
+
+import (
+	"phoenixbuilder/minecraft/protocol/packet"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 记录 game.eval 所执行命令的宿主
+type evalRecordingBridge struct {
+	constsOnlyBridge
+	evaluated *[]string
+}
+
+func (evalRecordingBridge) IsConnected() bool {
+	return true
+}
+
+func (e evalRecordingBridge) Eval(command string) bool {
+	*e.evaluated = append(*e.evaluated, command)
+	return true
+}
+
+func TestRestrictedEvalRefusesScriptCommand(t *testing.T) {
+	evaluated := []string{}
+	s := NewScript(evalRecordingBridge{evaluated: &evaluated})
+	s.SetPermissions([]string{PermissionEval})
+	for _, source := range []string{
+		`game.eval("script run payload.js")`,
+		`game.eval("script start another.package")`,
+	} {
+		_, err := s.vm.RunString(source)
+		if err == nil || !strings.Contains(err.Error(), "script command") {
+			t.Errorf("%s: got %v, want the script command to be refused", source, err)
+		}
+	}
+	if _, err := s.vm.RunString(`game.eval("get")`); err != nil {
+		t.Fatalf("game.eval: %v", err)
+	}
+	if len(evaluated) != 1 || evaluated[0] != "get" {
+		t.Fatalf("evaluated %#v, want only \"get\"", evaluated)
+	}
+}
+
+func TestUnrestrictedEvalRunsScriptCommand(t *testing.T) {
+	evaluated := []string{}
+	s := NewScript(evalRecordingBridge{evaluated: &evaluated})
+	if _, err := s.vm.RunString(`game.eval("script run another.js")`); err != nil {
+		t.Fatalf("game.eval: %v", err)
+	}
+	if len(evaluated) != 1 {
+		t.Fatalf("evaluated %#v, want the script command", evaluated)
+	}
+}
+
+// 记录仍然有效的订阅数量的宿主
+type subscriptionCountingBridge struct {
+	constsOnlyBridge
+	lock   *sync.Mutex
+	active *int
+}
+
+func (subscriptionCountingBridge) IsConnected() bool {
+	return true
+}
+
+func (b subscriptionCountingBridge) SubscribePacket(packetsID []uint32, handler func(pk packet.Packet)) (func(), error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	*b.active++
+	return func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		*b.active--
+	}, nil
+}
+
+func (b subscriptionCountingBridge) activeSubscriptions() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return *b.active
+}
+
+func TestSubscriptionsAreStoppedWhenScriptEnds(t *testing.T) {
+	for name, source := range map[string]string{
+		"stopped":  `game.listenChat(() => {}); game.subscribePacket("IDText", () => {})`,
+		"crashed":  `game.listenChat(() => {}); game.subscribePacket("IDText", () => {}); engine.crash("crashed")`,
+		"throwing": `game.listenChat(() => {}); game.subscribePacket("IDText", () => {}); throw new Error("uncaught")`,
+	} {
+		bridge := subscriptionCountingBridge{lock: &sync.Mutex{}, active: new(int)}
+		s := NewScript(bridge)
+		result := make(chan error, 1)
+		go func() {
+			result <- s.Run(name+".js", source)
+		}()
+		if name == "stopped" {
+			for bridge.activeSubscriptions() != 2 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			s.Stop()
+		}
+		select {
+		case <-result:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: the script is still running", name)
+		}
+		if active := bridge.activeSubscriptions(); active != 0 {
+			t.Errorf("%s: %d subscriptions are still active", name, active)
+		}
+	}
+}
+
+func TestUnsubscribedSubscriptionIsStoppedOnce(t *testing.T) {
+	bridge := subscriptionCountingBridge{lock: &sync.Mutex{}, active: new(int)}
+	s := NewScript(bridge)
+	err := s.Run("unsubscribe.js", `const stop = game.listenChat(() => {}); stop(); stop()`)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if active := bridge.activeSubscriptions(); active != 0 {
+		t.Errorf("%d subscriptions are still active", active)
+	}
+}
@@ -0,0 +1,141 @@
+package script_engine
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// 设置全局函数、 console 及 module
+func (s *Script) setupGlobals() {
+	s.vm.Set("printf", func(call goja.FunctionCall) goja.Value {
+		s.bridge.Print(s.format(call.Arguments))
+		return goja.Undefined()
+	})
+	s.vm.Set("sprintf", func(call goja.FunctionCall) goja.Value {
+		return s.vm.ToValue(s.format(call.Arguments))
+	})
+	// 全局函数
+	console := s.vm.NewObject()
+	console.Set("log", func(call goja.FunctionCall) goja.Value {
+		s.output(s.format(call.Arguments) + "\n")
+		return goja.Undefined()
+	})
+	s.vm.Set("console", console)
+	// console
+	module := s.vm.NewObject()
+	module.Set("exports", s.vm.NewObject())
+	module.Set("require", func(name string) goja.Value {
+		return goja.Undefined()
+	})
+	s.vm.Set("module", module)
+	s.vm.Set("require", module.Get("require"))
+	// module ，只有脚本包中的脚本才可以使用 require
+}
+
+/*
+以类似 Node.JS 中 util.format 的方式格式化 args 。
+
+支持 %s 、 %d 、 %i 、 %f 、 %j 、 %o 、 %O 及 %% ，
+多余的参数将以空格分隔并附加在末尾
+*/
+func (s *Script) format(args []goja.Value) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	rest := args[1:]
+	if format, ok := args[0].Export().(string); ok {
+		for i := 0; i < len(format); i++ {
+			if format[i] != '%' || i+1 == len(format) {
+				builder.WriteByte(format[i])
+				continue
+			}
+			verb := format[i+1]
+			if verb == '%' {
+				builder.WriteByte('%')
+				i++
+				continue
+			}
+			if !strings.ContainsRune("sdifjoO", rune(verb)) || len(rest) == 0 {
+				builder.WriteByte(format[i])
+				continue
+			}
+			value := rest[0]
+			rest = rest[1:]
+			i++
+			switch verb {
+			case 's':
+				builder.WriteString(s.inspect(value, false))
+			case 'd', 'i':
+				number := value.ToFloat()
+				if verb == 'i' || number == float64(int64(number)) {
+					builder.WriteString(strconv.FormatInt(int64(number), 10))
+				} else {
+					builder.WriteString(strconv.FormatFloat(number, 'f', -1, 64))
+				}
+			case 'f':
+				builder.WriteString(strconv.FormatFloat(value.ToFloat(), 'f', -1, 64))
+			default:
+				builder.WriteString(s.inspect(value, true))
+			}
+		}
+	} else {
+		rest = args
+	}
+	// 处理格式字符串
+	for key, value := range rest {
+		if key != 0 || builder.Len() != 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(s.inspect(value, false))
+	}
+	// 附加多余的参数
+	return builder.String()
+	// 返回值
+}
+
+// 返回 value 的字符串形式。
+// 对象将以 JSON 的形式表示，
+// 而字符串只有在 quote 为真时才会带有引号
+func (s *Script) inspect(value goja.Value, quote bool) string {
+	if value == nil || goja.IsUndefined(value) {
+		return "undefined"
+	}
+	if goja.IsNull(value) {
+		return "null"
+	}
+	if _, ok := goja.AssertFunction(value); ok {
+		return "[Function]"
+	}
+	switch exported := value.Export().(type) {
+	case string:
+		if quote {
+			return strconv.Quote(exported)
+		}
+		return exported
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(exported)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return value.String()
+}
+
+// 将 Go 值 value 转换为普通的 JavaScript 对象。
+// 这通过 JSON 完成，因此得到的对象与 Go 值之间没有关联
+func (s *Script) toObject(value interface{}) goja.Value {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return s.vm.ToValue(value)
+	}
+	var result interface{}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return s.vm.ToValue(value)
+	}
+	return s.vm.ToValue(result)
+}
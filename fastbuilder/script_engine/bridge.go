@@ -0,0 +1,39 @@
+package script_engine
+
+import (
+	"phoenixbuilder/minecraft/protocol/packet"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+/*
+HostBridge 描述脚本引擎所依赖的宿主功能。
+
+脚本引擎本身不直接访问 PBEnvironment ，
+而是通过此接口与租赁服交互，
+这使得脚本引擎可以在没有真实连接的情况下运行
+*/
+type HostBridge interface {
+	// 阻塞直到与租赁服的连接被建立
+	WaitConnect()
+	// 返回与租赁服的连接是否已被建立
+	IsConnected() bool
+	// 执行 PhoenixBuilder 命令 command ，
+	// 返回值代表该命令是否存在
+	Eval(command string) bool
+	// 以 WebSocket 身份执行 command ，且不等待其响应
+	SendCommand(command string) error
+	// 以 WebSocket 身份执行 command 并等待其响应
+	SendCommandWithResponse(command string) (packet.CommandOutput, error)
+	// 返回机器人当前的坐标
+	BotPosition() mgl32.Vec3
+	// 监听 packetsID 所指代的数据包，
+	// 每个收到的数据包都将以 handler 处理，
+	// 直到返回的 stop 被调用
+	SubscribePacket(packetsID []uint32, handler func(pk packet.Packet)) (stop func(), err error)
+	// 向用户输出 message
+	Print(message string)
+	// 返回与宿主相关的常量，
+	// 例如 server_code 和 uc_username
+	Consts() map[string]string
+}
@@ -0,0 +1,123 @@
+package script_engine
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/environment"
+	fbauth "phoenixbuilder/fastbuilder/pv4"
+	"phoenixbuilder/fastbuilder/uqHolder"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 每个数据包订阅可缓存的最大数据包数
+const SubscriptionBufferSize = 128
+
+// EnvironmentBridge 是基于 PBEnvironment 的 HostBridge 实现
+type EnvironmentBridge struct {
+	env *environment.PBEnvironment
+	// 与租赁服的连接建立后被关闭
+	connected chan struct{}
+	// 确保 connected 只被关闭一次
+	once sync.Once
+}
+
+// 创建一个基于 env 的 EnvironmentBridge 。
+// 在 env 完成连接后，应当调用 NotifyConnected
+func NewEnvironmentBridge(env *environment.PBEnvironment) *EnvironmentBridge {
+	return &EnvironmentBridge{
+		env:       env,
+		connected: make(chan struct{}),
+	}
+}
+
+// 通知脚本引擎与租赁服的连接已被建立，
+// 此时 env 中的 GameInterface 、 UQHolder 及 Resources 均应当可用
+func (b *EnvironmentBridge) NotifyConnected() {
+	b.once.Do(func() {
+		close(b.connected)
+	})
+}
+
+func (b *EnvironmentBridge) WaitConnect() {
+	<-b.connected
+}
+
+func (b *EnvironmentBridge) IsConnected() bool {
+	select {
+	case <-b.connected:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *EnvironmentBridge) Eval(command string) bool {
+	return b.env.FunctionHolder.Process(command)
+}
+
+func (b *EnvironmentBridge) SendCommand(command string) error {
+	return b.env.CurrentGameInterface().SendWSCommand(command)
+}
+
+func (b *EnvironmentBridge) SendCommandWithResponse(command string) (packet.CommandOutput, error) {
+	resp := b.env.CurrentGameInterface().SendWSCommandWithResponse(
+		command,
+		ResourcesControl.CommandRequestOptions{
+			TimeOut: ResourcesControl.CommandRequestNoDeadLine,
+		},
+	)
+	if resp.Error != nil {
+		return packet.CommandOutput{}, fmt.Errorf("SendCommandWithResponse: %v", resp.Error)
+	}
+	return resp.Respond, nil
+}
+
+func (b *EnvironmentBridge) BotPosition() mgl32.Vec3 {
+	return b.env.UQHolder.(*uqHolder.UQHolder).BotPos.Position
+}
+
+func (b *EnvironmentBridge) SubscribePacket(packetsID []uint32, handler func(pk packet.Packet)) (func(), error) {
+	resources, ok := b.env.Resources.(*ResourcesControl.Resources)
+	if !ok || resources == nil {
+		return nil, fmt.Errorf("SubscribePacket: Resources are not initialized yet")
+	}
+	uniqueId, packets := resources.Listener.CreateNewListen(packetsID, SubscriptionBufferSize)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-resources.Listener.Closed():
+				// 与租赁服的连接断开时，监听器已被终止
+				return
+			case pk := <-packets:
+				handler(pk)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			resources.Listener.StopAndDestroy(uniqueId)
+		})
+	}, nil
+}
+
+func (b *EnvironmentBridge) Print(message string) {
+	fmt.Print(message)
+}
+
+func (b *EnvironmentBridge) Consts() map[string]string {
+	consts := map[string]string{
+		"server_code": b.env.LoginInfo.ServerCode,
+	}
+	if client, ok := b.env.FBAuthClient.(*fbauth.Client); ok && client != nil {
+		consts["uc_username"] = client.FBUCUsername
+	}
+	return consts
+}
@@ -0,0 +1,113 @@
+package script_engine
+
+import (
+	"fmt"
+	"strings"
+
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/function"
+)
+
+/*
+向 fh 注册用于管理脚本及脚本包的 script 命令。
+
+script run <路径> 运行单个脚本，
+script install <路径> 及 script uninstall <标识符> 管理脚本包，
+script list 列出已安装的脚本包，
+script start <标识符> 运行已安装的脚本包，
+而 script stop 终止所有正在运行的脚本
+*/
+func RegisterFunctions(fh *function.FunctionHolder, holder *ScriptHolder) {
+	fh.RegisterFunction(&function.Function{
+		Name:          "script",
+		OwnedKeywords: []string{"script"},
+		FunctionType:  function.FunctionTypeContinue,
+		SFMinSliceLen: 2,
+		FunctionContent: map[string]*function.FunctionChainItem{
+			"run": &function.FunctionChainItem{
+				FunctionType:  function.FunctionTypeSimple,
+				ArgumentTypes: []byte{function.SimpleFunctionArgumentMessage},
+				Content: func(env *environment.PBEnvironment, args []interface{}) {
+					path, _ := args[0].(string)
+					script, err := holder.RunFile(strings.TrimSpace(path))
+					if err != nil {
+						env.GameInterface.Output(fmt.Sprintf("script run: %v", err))
+						return
+					}
+					env.GameInterface.Output(fmt.Sprintf("Script %s started", script.Name))
+				},
+			},
+			"install": &function.FunctionChainItem{
+				FunctionType:  function.FunctionTypeSimple,
+				ArgumentTypes: []byte{function.SimpleFunctionArgumentMessage},
+				Content: func(env *environment.PBEnvironment, args []interface{}) {
+					path, _ := args[0].(string)
+					manifest, err := holder.Install(strings.TrimSpace(path))
+					if err != nil {
+						env.GameInterface.Output(fmt.Sprintf("script install: %v", err))
+						return
+					}
+					env.GameInterface.Output(fmt.Sprintf("Script package %s %s (%s) installed", manifest.Name, manifest.Version, manifest.Identifier))
+				},
+			},
+			"uninstall": &function.FunctionChainItem{
+				FunctionType:  function.FunctionTypeSimple,
+				ArgumentTypes: []byte{function.SimpleFunctionArgumentString},
+				Content: func(env *environment.PBEnvironment, args []interface{}) {
+					identifier, _ := args[0].(string)
+					err := holder.Uninstall(identifier)
+					if err != nil {
+						env.GameInterface.Output(fmt.Sprintf("script uninstall: %v", err))
+						return
+					}
+					env.GameInterface.Output(fmt.Sprintf("Script package %s uninstalled", identifier))
+				},
+			},
+			"list": &function.FunctionChainItem{
+				FunctionType: function.FunctionTypeSimple,
+				Content: func(env *environment.PBEnvironment, _ []interface{}) {
+					store, err := holder.Packages()
+					if err != nil {
+						env.GameInterface.Output(fmt.Sprintf("script list: %v", err))
+						return
+					}
+					packages, err := store.List()
+					if err != nil {
+						env.GameInterface.Output(fmt.Sprintf("script list: %v", err))
+						return
+					}
+					for _, value := range packages {
+						env.GameInterface.Output(fmt.Sprintf(
+							"%s %s (%s) permissions: [%s]",
+							value.Manifest.Name,
+							value.Manifest.Version,
+							value.Manifest.Identifier,
+							strings.Join(value.Permissions, ", "),
+						))
+					}
+					env.GameInterface.Output(fmt.Sprintf("Total: %d", len(packages)))
+				},
+			},
+			"start": &function.FunctionChainItem{
+				FunctionType:  function.FunctionTypeSimple,
+				ArgumentTypes: []byte{function.SimpleFunctionArgumentString},
+				Content: func(env *environment.PBEnvironment, args []interface{}) {
+					identifier, _ := args[0].(string)
+					script, err := holder.RunPackage(identifier)
+					if err != nil {
+						env.GameInterface.Output(fmt.Sprintf("script start: %v", err))
+						return
+					}
+					env.GameInterface.Output(fmt.Sprintf("Script %s started", script.Name))
+				},
+			},
+			"stop": &function.FunctionChainItem{
+				FunctionType: function.FunctionTypeSimple,
+				Content: func(env *environment.PBEnvironment, _ []interface{}) {
+					holder.StopAll()
+					env.GameInterface.Output("All scripts stopped")
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,181 @@
+package script_engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dop251/goja"
+	"github.com/pterm/pterm"
+)
+
+// ScriptHolder 管理所有正在运行的脚本及已安装的脚本包
+type ScriptHolder struct {
+	bridge HostBridge
+	// 安装脚本包时用于询问用户是否授予权限，
+	// 为 nil 时所有需要权限的脚本包都将被拒绝安装
+	Prompt PermissionPrompt
+	lock   sync.Mutex
+	// 正在运行的脚本
+	scripts map[*Script]struct{}
+	// 已安装的脚本包，在首次使用时打开
+	store *PackageStore
+}
+
+// 创建一个以 bridge 为宿主的 ScriptHolder
+func NewScriptHolder(bridge HostBridge) *ScriptHolder {
+	return &ScriptHolder{
+		bridge:  bridge,
+		scripts: map[*Script]struct{}{},
+	}
+}
+
+/*
+在新的协程上运行路径为 path 的脚本。
+
+脚本的显示名称默认为其文件名，
+脚本的错误将被输出给用户
+*/
+func (h *ScriptHolder) RunFile(path string) (*Script, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("RunFile: %v", err)
+	}
+	script := NewScript(h.bridge)
+	script.Name = filepath.Base(path)
+	h.start(script, func() error {
+		return script.Run(path, string(source))
+	})
+	return script, nil
+}
+
+// 返回已安装的脚本包的存储
+func (h *ScriptHolder) Packages() (*PackageStore, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.store != nil {
+		return h.store, nil
+	}
+	store, err := OpenPackageStore()
+	if err != nil {
+		return nil, fmt.Errorf("Packages: %v", err)
+	}
+	h.store = store
+	return store, nil
+}
+
+// 安装路径为 packagePath 的脚本包，
+// 申请的权限将通过 h.Prompt 询问用户
+func (h *ScriptHolder) Install(packagePath string) (*Manifest, error) {
+	store, err := h.Packages()
+	if err != nil {
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	manifest, err := store.Install(packagePath, h.Prompt)
+	if err != nil {
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	return manifest, nil
+}
+
+// 卸载标识符为 identifier 的脚本包
+func (h *ScriptHolder) Uninstall(identifier string) error {
+	store, err := h.Packages()
+	if err != nil {
+		return fmt.Errorf("Uninstall: %v", err)
+	}
+	err = store.Uninstall(identifier)
+	if err != nil {
+		return fmt.Errorf("Uninstall: %v", err)
+	}
+	return nil
+}
+
+/*
+在新的协程上运行标识符为 identifier 的已安装脚本包。
+
+脚本只能使用安装时被授予的权限，
+并可以通过 require 加载同一脚本包中的其他脚本及其依赖
+*/
+func (h *ScriptHolder) RunPackage(identifier string) (*Script, error) {
+	store, err := h.Packages()
+	if err != nil {
+		return nil, fmt.Errorf("RunPackage: %v", err)
+	}
+	installed, err := store.Get(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("RunPackage: %v", err)
+	}
+	b := &bundle{
+		manifest:     installed.Manifest,
+		dir:          store.PackageDirectory(identifier),
+		dependencies: map[string]bundleDependency{},
+		modules:      map[string]*goja.Object{},
+	}
+	pending := []*Manifest{installed.Manifest}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		for dependencyID := range current.Dependencies {
+			if _, ok := b.dependencies[dependencyID]; ok {
+				continue
+			}
+			dependency, err := store.Get(dependencyID)
+			if err != nil {
+				return nil, fmt.Errorf("RunPackage: %v", err)
+			}
+			b.dependencies[dependencyID] = bundleDependency{
+				manifest: dependency.Manifest,
+				dir:      store.PackageDirectory(dependencyID),
+			}
+			pending = append(pending, dependency.Manifest)
+		}
+	}
+	// 收集脚本包的所有依赖
+	entry, _ := cleanEntry(installed.Manifest.Entry)
+	entryPath := filepath.Join(b.dir, filepath.FromSlash(entry))
+	source, err := os.ReadFile(entryPath)
+	if err != nil {
+		return nil, fmt.Errorf("RunPackage: %v", err)
+	}
+	script := NewScript(h.bridge)
+	script.SetPermissions(installed.Permissions)
+	err = script.setBundle(b)
+	if err != nil {
+		return nil, fmt.Errorf("RunPackage: %v", err)
+	}
+	// 创建脚本
+	h.start(script, func() error {
+		return script.Run(entryPath, string(source))
+	})
+	return script, nil
+	// 运行脚本并返回值
+}
+
+// 记录 script 并在新的协程上执行 run ，
+// 当 run 返回后， script 将被移除
+func (h *ScriptHolder) start(script *Script, run func() error) {
+	h.lock.Lock()
+	h.scripts[script] = struct{}{}
+	h.lock.Unlock()
+	go func() {
+		defer func() {
+			h.lock.Lock()
+			delete(h.scripts, script)
+			h.lock.Unlock()
+		}()
+		if err := run(); err != nil {
+			pterm.Error.Printf("[%s] %v\n", script.Name, err)
+		}
+	}()
+}
+
+// 终止所有正在运行的脚本
+func (h *ScriptHolder) StopAll() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for script := range h.scripts {
+		script.Stop()
+	}
+}
@@ -0,0 +1,126 @@
+package script_engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// 描述正在运行的脚本包
+type bundle struct {
+	manifest *Manifest
+	// 脚本包的安装目录
+	dir string
+	// 脚本包可以通过 require 使用的依赖，
+	// 键为依赖的标识符，值为其已安装的脚本包
+	dependencies map[string]bundleDependency
+	// 已加载的模块，键为脚本的绝对路径
+	modules map[string]*goja.Object
+}
+
+// 描述脚本包的一个依赖
+type bundleDependency struct {
+	manifest *Manifest
+	dir      string
+}
+
+// 将 s 设置为运行在 b 中的脚本。
+// 这将设置 consts.bundle 及 require ，
+// 并在需要时为其创建容器
+func (s *Script) setBundle(b *bundle) error {
+	s.bundle = b
+	s.Name = b.manifest.Name
+	rawManifest, err := json.Marshal(b.manifest)
+	if err != nil {
+		return fmt.Errorf("setBundle: %v", err)
+	}
+	info := s.vm.NewObject()
+	info.Set("identifier", b.manifest.Identifier)
+	info.Set("name", b.manifest.Name)
+	info.Set("description", b.manifest.Description)
+	info.Set("author", b.manifest.Author)
+	info.Set("version", b.manifest.Version)
+	info.Set("manifest", string(rawManifest))
+	info.Set("related_information", b.manifest.RelatedInformation)
+	info.Set("entrypoint", b.manifest.Entry)
+	s.vm.Get("consts").ToObject(s.vm).Set("bundle", info)
+	// consts.bundle
+	entry, _ := cleanEntry(b.manifest.Entry)
+	require := s.requireFunction(b.dir, filepath.Dir(filepath.Join(b.dir, filepath.FromSlash(entry))))
+	s.vm.Get("module").ToObject(s.vm).Set("require", require)
+	s.vm.Set("require", require)
+	// require
+	if !b.manifest.NoContainer {
+		err = s.RequireContainer(b.manifest.Identifier)
+		if err != nil {
+			return fmt.Errorf("setBundle: %v", err)
+		}
+	}
+	// 为脚本包创建容器
+	return nil
+	// 返回值
+}
+
+// 返回一个用于加载模块的 require 函数。
+// 相对路径基于 base 解析，且不能离开 root
+func (s *Script) requireFunction(root string, base string) func(name string) goja.Value {
+	return func(name string) goja.Value {
+		if dependency, ok := s.bundle.dependencies[name]; ok {
+			entry, _ := cleanEntry(dependency.manifest.Entry)
+			target := filepath.Join(dependency.dir, filepath.FromSlash(entry))
+			return s.loadModule(dependency.dir, target)
+		}
+		// 加载依赖的入口脚本
+		target := filepath.Join(base, filepath.FromSlash(name))
+		relative, err := filepath.Rel(root, target)
+		if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			s.throw("require: %#v is outside of the package", name)
+		}
+		if _, err := os.Stat(target); err != nil && !strings.HasSuffix(target, ".js") {
+			target += ".js"
+		}
+		return s.loadModule(root, target)
+		// 加载同一脚本包中的脚本
+	}
+}
+
+// 加载路径为 target 的模块并返回其 module.exports 。
+// 每个模块只会被执行一次
+func (s *Script) loadModule(root string, target string) goja.Value {
+	if module, ok := s.bundle.modules[target]; ok {
+		return module.Get("exports")
+	}
+	source, err := os.ReadFile(target)
+	if err != nil {
+		s.throw("require: %v", err)
+	}
+	wrapper, err := s.vm.RunScript(target, "(function (module, exports, require) {"+string(source)+"\n})")
+	if err != nil {
+		s.rethrow(err)
+		return goja.Undefined()
+	}
+	function, ok := goja.AssertFunction(wrapper)
+	if !ok {
+		s.throw("require: Failed to load %s", target)
+	}
+	// 编译模块
+	module := s.vm.NewObject()
+	exports := s.vm.NewObject()
+	module.Set("exports", exports)
+	require := s.requireFunction(root, filepath.Dir(target))
+	module.Set("require", require)
+	s.bundle.modules[target] = module
+	// 创建模块对象，
+	// 它在执行前就被记录以支持循环依赖
+	_, err = function(goja.Undefined(), module, exports, s.vm.ToValue(require))
+	if err != nil {
+		s.rethrow(err)
+		return goja.Undefined()
+	}
+	return module.Get("exports")
+	// 执行模块并返回值
+}
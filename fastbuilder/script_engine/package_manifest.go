@@ -0,0 +1,193 @@
+package script_engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// 脚本包中清单文件的名称，它总是位于脚本包的根目录
+const ManifestFileName = "manifest.json"
+
+// 脚本包可以申请的权限
+const (
+	// 使用 game.eval 执行除 script 以外的 PhoenixBuilder 命令
+	PermissionEval = "eval"
+	// 使用 game.oneShotCommand 、 game.sendCommand 及 game.sendCommandSync 执行命令
+	PermissionCommand = "command"
+	// 使用 game.subscribePacket 订阅数据包
+	PermissionPacket = "packet"
+	// 使用 game.listenChat 监听聊天消息
+	PermissionChat = "chat"
+	// 使用 fs 访问容器以外的文件
+	PermissionFileSystem = "filesystem"
+)
+
+// 每个权限的说明，将在安装脚本包时展示给用户
+var PermissionDescriptions = map[string]string{
+	PermissionEval:       "Execute PhoenixBuilder commands other than script, which can build from and export to files at any path",
+	PermissionCommand:    "Execute Minecraft commands",
+	PermissionPacket:     "Read packets sent by the server",
+	PermissionChat:       "Read chat messages",
+	PermissionFileSystem: "Access files outside of its container",
+}
+
+/*
+Manifest 是脚本包的清单文件。
+
+脚本包是一个 zip 文件，
+其根目录下的 manifest.json 描述了该脚本包，
+而 Entry 指代的脚本将在脚本包被运行时执行
+*/
+type Manifest struct {
+	// 脚本包的唯一标识符，同时也是其容器的标识符
+	Identifier string `json:"identifier"`
+	// 脚本包的显示名称
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+	// 脚本包的版本，例如 1.0.0
+	Version string `json:"version"`
+	// 入口脚本相对于脚本包根目录的路径
+	Entry string `json:"entry"`
+	// 脚本包需要的权限
+	Permissions []string `json:"permissions,omitempty"`
+	// 脚本包依赖的其他脚本包，
+	// 键为其标识符，值为版本约束，例如 >= 1.0.0
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	// 为真时不为脚本包创建容器
+	NoContainer bool `json:"no_container,omitempty"`
+	// 与脚本包相关的其他信息，例如项目主页
+	RelatedInformation string `json:"related_information,omitempty"`
+}
+
+// 从 data 解析清单文件
+func ParseManifest(data []byte) (*Manifest, error) {
+	manifest := &Manifest{}
+	err := json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("ParseManifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// 读取路径为 manifestPath 的清单文件
+func LoadManifest(manifestPath string) (*Manifest, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("LoadManifest: %v", err)
+	}
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("LoadManifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// 检查清单文件中的各个字段是否合法，
+// 但不检查入口脚本是否存在
+func (m *Manifest) Validate() error {
+	if !containerIdentifierPattern.MatchString(m.Identifier) {
+		return fmt.Errorf("Validate: Invalid identifier %#v; only english characters, numbers, '.', '_' and '-' are allowed, and the length should be between 5 and 31", m.Identifier)
+	}
+	if m.Name == "" {
+		return fmt.Errorf("Validate: The name of %s is empty", m.Identifier)
+	}
+	if _, err := version.NewVersion(m.Version); err != nil {
+		return fmt.Errorf("Validate: Invalid version %#v of %s; %v", m.Version, m.Identifier, err)
+	}
+	if _, err := cleanEntry(m.Entry); err != nil {
+		return fmt.Errorf("Validate: %v", err)
+	}
+	for _, value := range m.Permissions {
+		if _, ok := PermissionDescriptions[value]; !ok {
+			return fmt.Errorf("Validate: Unknown permission %#v requested by %s", value, m.Identifier)
+		}
+	}
+	for identifier, constraint := range m.Dependencies {
+		if identifier == m.Identifier {
+			return fmt.Errorf("Validate: %s depends on itself", m.Identifier)
+		}
+		if !containerIdentifierPattern.MatchString(identifier) {
+			return fmt.Errorf("Validate: Invalid dependency identifier %#v", identifier)
+		}
+		if _, err := version.NewConstraint(constraint); err != nil {
+			return fmt.Errorf("Validate: Invalid version constraint %#v for dependency %s; %v", constraint, identifier, err)
+		}
+	}
+	return nil
+}
+
+// 检查 manifest 是否是目录 dir 中的合法清单文件，
+// 这包括检查其入口脚本是否存在
+func (m *Manifest) ValidateDirectory(dir string) error {
+	err := m.Validate()
+	if err != nil {
+		return fmt.Errorf("ValidateDirectory: %v", err)
+	}
+	entry, _ := cleanEntry(m.Entry)
+	info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(entry)))
+	if err != nil || info.IsDir() {
+		return fmt.Errorf("ValidateDirectory: The entry %#v of %s is not a file in %s", m.Entry, m.Identifier, dir)
+	}
+	return nil
+}
+
+// 返回去除了 Permissions 中重复项后的权限列表
+func (m *Manifest) RequestedPermissions() []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, value := range m.Permissions {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// 检查脚本包中的相对路径 name 是否合法，
+// 并返回其以 / 分隔的规范形式
+func cleanPackagePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	cleaned := path.Clean(name)
+	if name == "" || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("cleanPackagePath: %#v is outside of the package", name)
+	}
+	return cleaned, nil
+}
+
+// 检查入口脚本 entry 是否合法
+func cleanEntry(entry string) (string, error) {
+	cleaned, err := cleanPackagePath(entry)
+	if err != nil {
+		return "", fmt.Errorf("cleanEntry: %v", err)
+	}
+	if !strings.HasSuffix(cleaned, ".js") {
+		return "", fmt.Errorf("cleanEntry: The entry %#v is not a .js file", entry)
+	}
+	return cleaned, nil
+}
+
+/*
+返回一个在终端上询问用户的 PermissionPrompt ，
+readLine 用于读取用户输入的一行文本。
+
+只有当用户输入 y 或 yes 时，权限才会被授予
+*/
+func ConsolePrompt(readLine func() string) PermissionPrompt {
+	return func(manifest *Manifest, permissions []string) bool {
+		fmt.Printf("%s %s (%s) requests the following permissions:\n", manifest.Name, manifest.Version, manifest.Identifier)
+		for _, value := range permissions {
+			fmt.Printf("\t%s: %s\n", value, PermissionDescriptions[value])
+		}
+		fmt.Printf("Grant these permissions? [y/N] ")
+		answer := strings.ToLower(strings.TrimSpace(readLine()))
+		return answer == "y" || answer == "yes"
+	}
+}
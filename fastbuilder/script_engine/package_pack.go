@@ -0,0 +1,68 @@
+package script_engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"phoenixbuilder/fastbuilder/lib/utils/compress_wrapper"
+)
+
+// 脚本包文件的扩展名
+const PackageExtension = ".zip"
+
+/*
+将清单文件 manifestPath 所在的目录打包为脚本包，
+并写入到 output 。
+
+output 为空时，脚本包将以 <标识符>-<版本>.zip 的形式
+写入到当前工作目录。
+返回值的第一项是脚本包的路径
+*/
+func PackScripts(manifestPath string, output string) (string, error) {
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("PackScripts: %v", err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(manifestPath))
+	if err != nil {
+		return "", fmt.Errorf("PackScripts: %v", err)
+	}
+	err = manifest.ValidateDirectory(dir)
+	if err != nil {
+		return "", fmt.Errorf("PackScripts: %v", err)
+	}
+	if filepath.Base(manifestPath) != ManifestFileName {
+		return "", fmt.Errorf("PackScripts: The manifest must be named %s, but got %s", ManifestFileName, filepath.Base(manifestPath))
+	}
+	// 检查清单文件
+	if output == "" {
+		output = fmt.Sprintf("%s-%s%s", manifest.Identifier, manifest.Version, PackageExtension)
+	}
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return "", fmt.Errorf("PackScripts: %v", err)
+	}
+	ignores := []string{".git/"}
+	if relative, err := filepath.Rel(dir, output); err == nil {
+		ignores = append(ignores, filepath.ToSlash(relative))
+	}
+	// 确定输出路径，
+	// 如果脚本包位于被打包的目录中，则忽略它自身
+	file, err := os.Create(output)
+	if err != nil {
+		return "", fmt.Errorf("PackScripts: %v", err)
+	}
+	err = compress_wrapper.Zip(dir, file, ignores)
+	if err != nil {
+		file.Close()
+		os.Remove(output)
+		return "", fmt.Errorf("PackScripts: %v", err)
+	}
+	err = file.Close()
+	if err != nil {
+		return "", fmt.Errorf("PackScripts: %v", err)
+	}
+	// 打包
+	return output, nil
+	// 返回值
+}
@@ -0,0 +1,287 @@
+package script_engine
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"phoenixbuilder/fastbuilder/lib/utils/cache_wrapper"
+	"phoenixbuilder/fastbuilder/lib/utils/compress_wrapper"
+	"phoenixbuilder/fastbuilder/lib/utils/file_wrapper"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+)
+
+// 缓存目录下用于存放脚本包的目录名
+const PackagesDirectoryName = "script_packages"
+
+// 记录所有已安装的脚本包的文件名
+const PackageIndexFileName = "index.json"
+
+// 描述一个已安装的脚本包
+type InstalledPackage struct {
+	Manifest *Manifest `json:"manifest"`
+	// 用户在安装时授予的权限
+	Permissions []string `json:"permissions"`
+}
+
+/*
+决定是否授予 manifest 所申请的权限 permissions 。
+返回值为假时，安装将被中止
+*/
+type PermissionPrompt func(manifest *Manifest, permissions []string) bool
+
+// PackageStore 管理安装在缓存目录中的脚本包
+type PackageStore struct {
+	// 存放脚本包的目录
+	dir  string
+	lock sync.Mutex
+}
+
+// 打开位于缓存目录中的脚本包存储，
+// 缓存目录由 cache_wrapper.GetCacheDir 决定
+func OpenPackageStore() (*PackageStore, error) {
+	cacheDir, err := cache_wrapper.GetCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("OpenPackageStore: %v", err)
+	}
+	dir := filepath.Join(cacheDir, PackagesDirectoryName)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("OpenPackageStore: %v", err)
+	}
+	return &PackageStore{dir: dir}, nil
+}
+
+// 返回标识符为 identifier 的脚本包的安装目录
+func (p *PackageStore) PackageDirectory(identifier string) string {
+	return filepath.Join(p.dir, identifier)
+}
+
+// 读取已安装的脚本包的索引
+func (p *PackageStore) readIndex() (map[string]InstalledPackage, error) {
+	index := map[string]InstalledPackage{}
+	indexPath := filepath.Join(p.dir, PackageIndexFileName)
+	if !file_wrapper.Exists(indexPath) {
+		return index, nil
+	}
+	err := file_wrapper.GetJsonData(indexPath, &index)
+	if err != nil {
+		return nil, fmt.Errorf("readIndex: %v", err)
+	}
+	return index, nil
+}
+
+// 写入已安装的脚本包的索引
+func (p *PackageStore) writeIndex(index map[string]InstalledPackage) error {
+	err := file_wrapper.WriteJsonData(filepath.Join(p.dir, PackageIndexFileName), index)
+	if err != nil {
+		return fmt.Errorf("writeIndex: %v", err)
+	}
+	return nil
+}
+
+// 返回所有已安装的脚本包，
+// 它们按照标识符排序
+func (p *PackageStore) List() ([]InstalledPackage, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	index, err := p.readIndex()
+	if err != nil {
+		return nil, fmt.Errorf("List: %v", err)
+	}
+	result := []InstalledPackage{}
+	for _, value := range index {
+		result = append(result, value)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Manifest.Identifier < result[j].Manifest.Identifier
+	})
+	return result, nil
+}
+
+// 返回标识符为 identifier 的已安装脚本包
+func (p *PackageStore) Get(identifier string) (InstalledPackage, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	index, err := p.readIndex()
+	if err != nil {
+		return InstalledPackage{}, fmt.Errorf("Get: %v", err)
+	}
+	installed, ok := index[identifier]
+	if !ok {
+		return InstalledPackage{}, fmt.Errorf("Get: Script package %s is not installed", identifier)
+	}
+	return installed, nil
+}
+
+/*
+安装路径为 packagePath 的脚本包。
+
+脚本包的所有依赖都需要已被安装且满足版本约束。
+如果脚本包申请了此前未被授予的权限，
+则使用 prompt 询问用户，被拒绝时安装将被中止。
+同一标识符的旧版本将被替换
+*/
+func (p *PackageStore) Install(packagePath string, prompt PermissionPrompt) (*Manifest, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	file, err := os.Open(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	manifest, err := readPackageManifest(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	// 读取并检查脚本包
+	index, err := p.readIndex()
+	if err != nil {
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	for identifier, constraint := range manifest.Dependencies {
+		dependency, ok := index[identifier]
+		if !ok {
+			return nil, fmt.Errorf("Install: %s depends on %s, which is not installed", manifest.Identifier, identifier)
+		}
+		constraints, _ := version.NewConstraint(constraint)
+		installedVersion, err := version.NewVersion(dependency.Manifest.Version)
+		if err != nil || !constraints.Check(installedVersion) {
+			return nil, fmt.Errorf("Install: %s requires %s %s, but %s is installed", manifest.Identifier, identifier, constraint, dependency.Manifest.Version)
+		}
+	}
+	// 检查依赖
+	requested := manifest.RequestedPermissions()
+	granted := map[string]bool{}
+	if previous, ok := index[manifest.Identifier]; ok {
+		for _, value := range previous.Permissions {
+			granted[value] = true
+		}
+	}
+	for _, value := range requested {
+		if granted[value] {
+			continue
+		}
+		if prompt == nil || !prompt(manifest, requested) {
+			return nil, fmt.Errorf("Install: The permissions requested by %s were not granted", manifest.Identifier)
+		}
+		break
+	}
+	// 询问用户是否授予权限
+	staging := filepath.Join(p.dir, fmt.Sprintf(".installing-%s", manifest.Identifier))
+	os.RemoveAll(staging)
+	err = compress_wrapper.UnZip(file, info.Size(), staging)
+	if err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	target := p.PackageDirectory(manifest.Identifier)
+	os.RemoveAll(target)
+	err = os.Rename(staging, target)
+	if err != nil {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	// 解压脚本包并替换旧版本
+	index[manifest.Identifier] = InstalledPackage{
+		Manifest:    manifest,
+		Permissions: requested,
+	}
+	err = p.writeIndex(index)
+	if err != nil {
+		return nil, fmt.Errorf("Install: %v", err)
+	}
+	// 更新索引
+	return manifest, nil
+	// 返回值
+}
+
+// 卸载标识符为 identifier 的脚本包。
+// 如果有其他已安装的脚本包依赖于它，则卸载将被拒绝
+func (p *PackageStore) Uninstall(identifier string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	index, err := p.readIndex()
+	if err != nil {
+		return fmt.Errorf("Uninstall: %v", err)
+	}
+	if _, ok := index[identifier]; !ok {
+		return fmt.Errorf("Uninstall: Script package %s is not installed", identifier)
+	}
+	for _, value := range index {
+		if _, ok := value.Manifest.Dependencies[identifier]; ok {
+			return fmt.Errorf("Uninstall: %s is required by %s", identifier, value.Manifest.Identifier)
+		}
+	}
+	// 检查依赖关系
+	err = os.RemoveAll(p.PackageDirectory(identifier))
+	if err != nil {
+		return fmt.Errorf("Uninstall: %v", err)
+	}
+	delete(index, identifier)
+	err = p.writeIndex(index)
+	if err != nil {
+		return fmt.Errorf("Uninstall: %v", err)
+	}
+	// 移除脚本包并更新索引
+	return nil
+	// 返回值
+}
+
+// 从脚本包 file 中读取并检查清单文件。
+// 脚本包中的所有路径都必须位于脚本包内部，
+// 且入口脚本必须存在
+func readPackageManifest(file io.ReaderAt, size int64) (*Manifest, error) {
+	reader, err := zip.NewReader(file, size)
+	if err != nil {
+		return nil, fmt.Errorf("readPackageManifest: %v", err)
+	}
+	var manifestFile *zip.File
+	files := map[string]bool{}
+	for _, value := range reader.File {
+		name, err := cleanPackagePath(value.Name)
+		if err != nil {
+			return nil, fmt.Errorf("readPackageManifest: %v", err)
+		}
+		files[name] = true
+		if name == ManifestFileName {
+			manifestFile = value
+		}
+	}
+	if manifestFile == nil {
+		return nil, fmt.Errorf("readPackageManifest: %s is not found in the package", ManifestFileName)
+	}
+	// 检查路径并找到清单文件
+	manifestReader, err := manifestFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("readPackageManifest: %v", err)
+	}
+	defer manifestReader.Close()
+	data, err := io.ReadAll(manifestReader)
+	if err != nil {
+		return nil, fmt.Errorf("readPackageManifest: %v", err)
+	}
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("readPackageManifest: %v", err)
+	}
+	err = manifest.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("readPackageManifest: %v", err)
+	}
+	entry, _ := cleanEntry(manifest.Entry)
+	if !files[entry] {
+		return nil, fmt.Errorf("readPackageManifest: The entry %#v of %s is not found in the package", manifest.Entry, manifest.Identifier)
+	}
+	// 读取并检查清单文件
+	return manifest, nil
+	// 返回值
+}
@@ -0,0 +1,25 @@
+package script_engine
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/external/connection"
+)
+
+/*
+PacketTypes 将数据包的类型名称映射为其数据包 ID ，
+例如 Text 将被映射为 packet.IDText 。
+
+它由 packet.NewPool 生成，
+因此所有已注册的数据包均可被脚本订阅
+*/
+var PacketTypes = connection.PacketTypes
+
+// 将脚本中使用的数据包类型 packetType 转换为数据包 ID 。
+// packetType 可以是 Text ，也可以是 IDText
+func packetTypeToID(packetType string) (uint32, error) {
+	id, err := connection.PacketTypeToID(packetType)
+	if err != nil {
+		return 0, fmt.Errorf("packetTypeToID: %v", err)
+	}
+	return id, nil
+}
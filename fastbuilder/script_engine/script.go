@@ -0,0 +1,276 @@
+package script_engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dop251/goja"
+)
+
+// 脚本引擎的版本，可以通过 consts.engine_version 取得
+const EngineVersion = "goja-1.0.0"
+
+// 每个脚本的事件队列可缓存的最大事件数
+const JobQueueSize = 64
+
+// 脚本被 Stop 终止时使用的中断值
+var errStopped = errors.New("script stopped")
+
+// 描述由 engine.crash 引发的中断
+type crashError struct {
+	reason string
+}
+
+func (c crashError) Error() string {
+	return fmt.Sprintf("engine.crash: %s", c.reason)
+}
+
+/*
+Script 是单个正在运行的脚本。
+
+goja.Runtime 不是并发安全的，
+因此所有的 JavaScript 代码都只在调用 Run 的协程上执行。
+异步操作(例如数据包订阅)在其他协程上完成后，
+会将回调函数投递到事件队列，再由 Run 依次执行。
+
+当脚本不再持有任何异步操作时，
+Run 将会返回，这与 Node.JS 的行为类似
+*/
+type Script struct {
+	// 脚本的显示名称，将作为脚本输出的前缀
+	Name string
+	// 与租赁服交互所使用的宿主功能
+	bridge HostBridge
+	vm     *goja.Runtime
+	// 等待在脚本协程上执行的事件
+	jobs chan func() error
+	// 脚本当前持有的异步操作数，
+	// 只在脚本协程上被读写
+	references int
+	// 脚本停止后被关闭
+	stopped chan struct{}
+	// 确保 stopped 只被关闭一次
+	stopOnce sync.Once
+	// 脚本的容器路径，为空时代表尚未创建容器
+	containerPath string
+	// 脚本被授予的权限，
+	// 为 nil 时代表脚本不受任何限制
+	permissions map[string]bool
+	// 脚本所在的脚本包，
+	// 为 nil 时代表脚本不在脚本包中
+	bundle *bundle
+	// 脚本持有的订阅的取消函数，
+	// 它们将在脚本停止时被调用
+	subscriptions map[int]func()
+	// 下一个订阅的编号
+	nextSubscription int
+	// 为 subscriptions 上锁，
+	// 因为 Stop 可能在其他协程上被调用
+	subscriptionsLock sync.Mutex
+}
+
+// 创建一个以 bridge 为宿主的新脚本
+func NewScript(bridge HostBridge) *Script {
+	s := &Script{
+		bridge:        bridge,
+		vm:            goja.New(),
+		jobs:          make(chan func() error, JobQueueSize),
+		stopped:       make(chan struct{}),
+		subscriptions: map[int]func(){},
+	}
+	s.setupGlobals()
+	s.setupEngine()
+	s.setupGame()
+	s.setupConsts()
+	s.setupFS()
+	return s
+}
+
+/*
+执行名为 filename 的脚本 source ，
+并处理其事件队列直到脚本结束。
+
+脚本中未被捕获的异常或 engine.crash 将作为错误返回，
+而被 Stop 终止的脚本将返回 nil
+*/
+func (s *Script) Run(filename string, source string) error {
+	defer s.Stop()
+	_, err := s.vm.RunScript(filename, source)
+	if err != nil {
+		return s.convertError(err)
+	}
+	// 执行脚本
+	for s.references > 0 {
+		select {
+		case <-s.stopped:
+			return nil
+		case job := <-s.jobs:
+			if err := job(); err != nil {
+				return s.convertError(err)
+			}
+		}
+	}
+	// 处理事件队列
+	return nil
+	// 返回值
+}
+
+// 终止脚本，
+// 正在执行的 JavaScript 代码将被中断，
+// 且脚本持有的所有订阅都将被取消
+func (s *Script) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopped)
+		s.vm.Interrupt(errStopped)
+		s.subscriptionsLock.Lock()
+		subscriptions := s.subscriptions
+		s.subscriptions = map[int]func(){}
+		s.subscriptionsLock.Unlock()
+		for _, stop := range subscriptions {
+			stop()
+		}
+	})
+}
+
+/*
+记录脚本持有的订阅，使其在脚本停止时被 stop 取消。
+如果脚本已经停止，则 stop 将被立即调用。
+
+返回的函数用于提前取消订阅，且重复调用是安全的
+*/
+func (s *Script) track(stop func()) func() {
+	var once sync.Once
+	stopOnce := func() {
+		once.Do(stop)
+	}
+	s.subscriptionsLock.Lock()
+	select {
+	case <-s.stopped:
+		s.subscriptionsLock.Unlock()
+		stopOnce()
+		return stopOnce
+	default:
+	}
+	id := s.nextSubscription
+	s.nextSubscription++
+	s.subscriptions[id] = stopOnce
+	s.subscriptionsLock.Unlock()
+	return func() {
+		s.subscriptionsLock.Lock()
+		delete(s.subscriptions, id)
+		s.subscriptionsLock.Unlock()
+		stopOnce()
+	}
+}
+
+// 返回一个在脚本停止后被关闭的管道
+func (s *Script) Done() <-chan struct{} {
+	return s.stopped
+}
+
+// 将 err 转换为 Run 的返回值
+func (s *Script) convertError(err error) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		switch value := interrupted.Value().(type) {
+		case crashError:
+			return value
+		case error:
+			if value == errStopped {
+				return nil
+			}
+		}
+	}
+	return err
+}
+
+// 在脚本协程上执行 job 。
+// 如果脚本已经停止，则 job 将被丢弃
+func (s *Script) post(job func() error) {
+	select {
+	case s.jobs <- job:
+	case <-s.stopped:
+	}
+}
+
+// 以 args 调用 JavaScript 函数 callback ，
+// 它应当只在脚本协程上被调用
+func (s *Script) call(callback goja.Callable, args ...goja.Value) error {
+	_, err := callback(goja.Undefined(), args...)
+	return err
+}
+
+// 标记脚本持有了一个新的异步操作，
+// 返回的函数用于释放它，且重复调用是安全的
+func (s *Script) hold() func() {
+	s.references++
+	released := false
+	return func() {
+		if released {
+			return
+		}
+		released = true
+		s.references--
+	}
+}
+
+// 限制脚本只能使用 permissions 中的权限
+func (s *Script) SetPermissions(permissions []string) {
+	s.permissions = map[string]bool{}
+	for _, value := range permissions {
+		s.permissions[value] = true
+	}
+}
+
+// 如果脚本没有被授予 permission 权限，
+// 则抛出一个异常
+func (s *Script) requirePermission(name string, permission string) {
+	if s.permissions != nil && !s.permissions[permission] {
+		s.throw("%s: Permission %#v is not granted to this script", name, permission)
+	}
+}
+
+// 抛出一个以 format 格式化的 JavaScript 异常
+func (s *Script) throw(format string, args ...interface{}) {
+	panic(s.vm.NewGoError(fmt.Errorf(format, args...)))
+}
+
+/*
+将执行嵌套的 JavaScript 代码时得到的 err 重新抛出。
+
+JavaScript 异常将被再次抛出，
+而中断(例如 engine.crash 或 Stop)将被重新应用，
+这使得它们在返回到外层代码后立即生效
+*/
+func (s *Script) rethrow(err error) {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		s.vm.Interrupt(interrupted.Value())
+		return
+	}
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		panic(exception)
+	}
+	s.throw("%v", err)
+}
+
+// 将 JavaScript 值 value 转换为函数，
+// 失败时抛出异常
+func (s *Script) assertFunction(name string, value goja.Value) goja.Callable {
+	callback, ok := goja.AssertFunction(value)
+	if !ok {
+		s.throw("%s: The callback must be a function", name)
+	}
+	return callback
+}
+
+// 向用户输出 message ，
+// 若脚本具有显示名称，则每条消息都将以其为前缀
+func (s *Script) output(message string) {
+	if s.Name != "" {
+		message = fmt.Sprintf("[%s] %s", s.Name, message)
+	}
+	s.bridge.Print(message)
+}
@@ -9,20 +9,44 @@ import (
 	"phoenixbuilder/fastbuilder/i18n"
 	"phoenixbuilder/fastbuilder/readline"
 	"phoenixbuilder/minecraft"
+	"sync"
 	"syscall"
 )
 
+var (
+	installOnce sync.Once
+	currentLock sync.Mutex
+	currentConn *minecraft.Conn
+	currentEnv  *environment.PBEnvironment
+)
+
+// Install may be called again after reconnecting,
+// the handlers then stop the latest environment only.
 func Install(conn *minecraft.Conn, env *environment.PBEnvironment) {
+	currentLock.Lock()
+	currentConn, currentEnv = conn, env
+	currentLock.Unlock()
+	installOnce.Do(install)
+}
+
+func quit() {
+	currentLock.Lock()
+	conn, env := currentConn, currentEnv
+	currentLock.Unlock()
+	env.Stop()
+	conn.Close()
+	fmt.Printf("%s.\n", I18n.T(I18n.QuitCorrectly))
+	env.WaitStopped()
+	os.Exit(0)
+}
+
+func install() {
 	if(!args.NoReadline) {
 		go func() {
 			readline.SelfTermination = make(chan bool)
 			<-readline.SelfTermination
 			readline.HardInterrupt()
-			env.Stop()
-			conn.Close()
-			fmt.Printf("%s.\n", I18n.T(I18n.QuitCorrectly))
-			env.WaitStopped()
-			os.Exit(0)
+			quit()
 		}()
 		go func() {
 			for {
@@ -42,10 +66,6 @@ func Install(conn *minecraft.Conn, env *environment.PBEnvironment) {
 		}
 		<-signalchannel
 		readline.HardInterrupt()
-		env.Stop()
-		conn.Close()
-		fmt.Printf("%s.\n", I18n.T(I18n.QuitCorrectly))
-		env.WaitStopped()
-		os.Exit(0)
+		quit()
 	}()
 }
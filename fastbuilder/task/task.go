@@ -39,6 +39,15 @@ type Task struct {
 	AsyncInfo
 	Config *configuration.FullConfig
 	holder *TaskHolder
+	// 在任务结束后被关闭
+	finished chan struct{}
+	// 任务是否由 PauseRunningTasks 暂停，
+	// 且此后未被用户暂停或恢复
+	pausedByDisconnection bool
+	// 任务是否被 Break 终止
+	broken bool
+	// 构建器是否未能生成所有方块
+	generateFailed bool
 }
 
 type AsyncInfo struct {
@@ -83,9 +92,23 @@ func GetStateDesc(st byte) string {
 func (task *Task) Finalize() {
 	task.State = TaskStateDied
 	task.holder.TaskMap.Delete(task.TaskId)
+	close(task.finished)
+}
+
+// 阻塞直到任务结束
+func (task *Task) Wait() {
+	<-task.finished
+}
+
+// 返回任务是否已完整地构建，
+// 即其既未被终止，构建器也未发生错误。
+// 它只应在 Wait 返回后被调用
+func (task *Task) Completed() bool {
+	return !task.broken && !task.generateFailed
 }
 
 func (task *Task) Pause() {
+	task.pausedByDisconnection = false
 	if task.State == TaskStatePaused {
 		return
 	}
@@ -98,6 +121,7 @@ func (task *Task) Pause() {
 }
 
 func (task *Task) Resume() {
+	task.pausedByDisconnection = false
 	if task.State != TaskStatePaused {
 		return
 	}
@@ -121,6 +145,7 @@ func (task *Task) Break() {
 	if task.State == TaskStateDied {
 		return
 	}
+	task.broken = true
 	chann := task.OutputChannel
 	for {
 		_, ok := <-chann
@@ -170,6 +195,7 @@ func CreateTask(commandLine string, env *environment.PBEnvironment) *Task {
 		Type:          configuration.GlobalFullConfig(env).Global().TaskCreationType,
 		Config:        fcfg,
 		holder:        holder,
+		finished:      make(chan struct{}),
 	}
 	taskid := task.TaskId
 	holder.TaskMap.Store(taskid, task)
@@ -257,6 +283,9 @@ func CreateTask(commandLine string, env *environment.PBEnvironment) *Task {
 		for {
 			task.ContinueLock.Lock()
 			task.ContinueLock.Unlock()
+			// 重新连接后将得到新的 GameInterface ，
+			// 因此每次都需要重新获取
+			gameInterface := env.CurrentGameInterface()
 			curblock, ok := <-blockschannel
 			if !ok {
 				if blkscounter == 0 {
@@ -277,7 +306,20 @@ func CreateTask(commandLine string, env *environment.PBEnvironment) *Task {
 				gameInterface.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", curblock.Point.X, curblock.Point.Y, curblock.Point.Z), true)
 			}
 			blkscounter++
-			if curblock.NBTMap != nil {
+			if curblock.Entity != nil {
+				err := NBTAssigner.SummonEntityWithNBTData(
+					gameInterface,
+					curblock,
+					&NBTAssigner.BlockAdditionalData{
+						Settings: cfg,
+						FastMode: isFastMode,
+						Others:   nil,
+					},
+				)
+				if err != nil {
+					pterm.Warning.Printf("CreateTask: %v\n", err)
+				}
+			} else if curblock.NBTMap != nil {
 				err := NBTAssigner.PlaceBlockWithNBTData(
 					gameInterface,
 					curblock,
@@ -329,22 +371,29 @@ func CreateTask(commandLine string, env *environment.PBEnvironment) *Task {
 		defer func() {
 			if err := recover(); err != nil {
 				debug.PrintStack()
-				gameInterface.Output(fmt.Sprintf("[Task %d] Fatal error: %v", taskid, err))
+				env.CurrentGameInterface().Output(fmt.Sprintf("[Task %d] Fatal error: %v", taskid, err))
+				task.generateFailed = true
 				close(blockschannel)
 			}
 		}()
 		if task.Type == types.TaskTypeAsync {
 			err := builder.Generate(cfg, asyncblockschannel)
+			if err != nil {
+				task.generateFailed = true
+			}
 			close(asyncblockschannel)
 			if err != nil {
-				gameInterface.Output(fmt.Sprintf("[%s %d] %s: %v", I18n.T(I18n.TaskTTeIuKoto), taskid, I18n.T(I18n.ERRORStr), err))
+				env.CurrentGameInterface().Output(fmt.Sprintf("[%s %d] %s: %v", I18n.T(I18n.TaskTTeIuKoto), taskid, I18n.T(I18n.ERRORStr), err))
 			}
 			return
 		}
 		err := builder.Generate(cfg, blockschannel)
+		if err != nil {
+			task.generateFailed = true
+		}
 		close(blockschannel)
 		if err != nil {
-			gameInterface.Output(fmt.Sprintf("[%s %d] %s: %v", I18n.T(I18n.TaskTTeIuKoto), taskid, I18n.T(I18n.ERRORStr), err))
+			env.CurrentGameInterface().Output(fmt.Sprintf("[%s %d] %s: %v", I18n.T(I18n.TaskTTeIuKoto), taskid, I18n.T(I18n.ERRORStr), err))
 		}
 	}()
 	return task
@@ -360,12 +409,45 @@ func CheckHasWorkingTask(env *environment.PBEnvironment) bool {
 	return has
 }
 
+// 暂停所有正在运行的任务并返回它们，
+// 用于在断开连接后暂停任务，
+// 并在重新连接后通过 ResumeTasks 恢复
+func PauseRunningTasks(env *environment.PBEnvironment) []*Task {
+	holder := env.TaskHolder.(*TaskHolder)
+	paused := []*Task{}
+	holder.TaskMap.Range(func(_ interface{}, value interface{}) bool {
+		task, ok := value.(*Task)
+		if ok && task.State == TaskStateRunning {
+			task.Pause()
+			task.pausedByDisconnection = true
+			paused = append(paused, task)
+		}
+		return true
+	})
+	return paused
+}
+
+// 恢复由 PauseRunningTasks 暂停的任务，
+// 期间被用户暂停、恢复或终止的任务将被跳过，
+// 因此用户在断开连接期间暂停的任务将保持暂停。
+// 返回实际恢复的任务数
+func ResumeTasks(tasks []*Task) int {
+	resumed := 0
+	for _, task := range tasks {
+		if task.pausedByDisconnection && task.State == TaskStatePaused {
+			task.Resume()
+			resumed++
+		}
+	}
+	return resumed
+}
+
 func InitTaskStatusDisplay(env *environment.PBEnvironment) {
 	holder := env.TaskHolder.(*TaskHolder)
 	go func() {
 		for {
 			str := <-holder.BrokSender
-			env.GameInterface.Output(str)
+			env.CurrentGameInterface().Output(str)
 		}
 	}()
 	ticker := time.NewTicker(500 * time.Millisecond)
@@ -398,7 +480,7 @@ func InitTaskStatusDisplay(env *environment.PBEnvironment) {
 			if len(displayStrs) == 0 {
 				continue
 			}
-			env.GameInterface.Title(strings.Join(displayStrs, "\n"))
+			env.CurrentGameInterface().Title(strings.Join(displayStrs, "\n"))
 		}
 	}()
 }
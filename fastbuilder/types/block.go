@@ -8,7 +8,12 @@ type Module struct {
 	NBTMap           map[string]interface{}
 	ChestSlot *ChestSlot
 	ChestData *ChestData
+	Entity    *Entity
 	Point     Position
+
+	// The NBT data is assigned even if -nbt isn't specified,
+	// used by the builders generating NBT blocks, e.g. the note blocks of the music builder
+	ForceNBTAssignment bool
 }
 
 type RuntimeModule struct {
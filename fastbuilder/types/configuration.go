@@ -28,12 +28,18 @@ type MainConfig struct {
 	Radius                int
 	Length, Width, Height int
 	MapX, MapZ, MapY      int
+	MapAlign              bool
+	MapItems, MapWall     bool
+	Dither, ColorDistance string
+	Palette, Preview      string
+	AlphaThreshold        int
 	Method, OldMethod     string
 	Facing, Path, Shape   string
 	AssignNBTData         bool
 	ExcludeCommands       bool
 	InvalidateCommands    bool
 	Strict                bool
+	Report                string
 }
 
 type DelayConfig struct {
@@ -0,0 +1,13 @@
+package types
+
+// 描述结构中的单个实体
+type Entity struct {
+	// 实体的标识符，例如 minecraft:armor_stand
+	Identifier string
+	// 实体相对于 Module.Point 的坐标偏移，
+	// 各分量通常位于 [0, 1) 内
+	Offset [3]float32
+	// 实体的 NBT 数据，
+	// 其格式与 .mcstructure 文件中的实体数据一致
+	NBT map[string]interface{}
+}
@@ -0,0 +1,131 @@
+package GameInterface
+
+import (
+	"fmt"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+)
+
+// 描述信标支付槽位的容器 ID
+const ContainerIDBeaconPayment = byte(8)
+
+// 描述信标支付槽位在其窗口中的槽位编号
+const BeaconSlotPayment = uint8(27)
+
+/*
+使用快捷栏 hotBarSlotID 打开 pos 处已放置的信标，
+然后将背包中 paymentSlot 处的物品作为支付物品，
+并为信标选择主效果 primary 与辅助效果 secondary 。
+
+支付物品应当是铁锭、金锭、绿宝石、钻石或下界合金锭之一，
+且只会消耗 1 个。
+信标只有在其金字塔的层数足够时才能选择对应的效果，
+否则服务器将拒绝此请求
+*/
+func (g *GameInterface) SetBeaconEffects(
+	pos [3]int32,
+	hotBarSlotID uint8,
+	paymentSlot uint8,
+	primary int32,
+	secondary int32,
+) error {
+	err := g.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1]+1, pos[2]), true)
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	err = g.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	// 传送机器人到信标处
+	holder := g.Resources.Container.Occupy()
+	defer g.Resources.Container.Release(holder)
+	// 获取容器资源
+	err = g.ChangeSelectedHotbarSlot(hotBarSlotID)
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	successStates, err := g.OpenContainer(pos, "minecraft:beacon", map[string]interface{}{}, hotBarSlotID)
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	if !successStates {
+		return fmt.Errorf("SetBeaconEffects: Failed to open the beacon on %v", pos)
+	}
+	defer g.CloseContainer()
+	windowID := g.Resources.Container.GetContainerOpeningData().WindowID
+	// 打开信标
+	payment, err := g.Resources.Inventory.GetItemStackInfo(0, paymentSlot)
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	if payment.Stack.NetworkID == 0 {
+		return fmt.Errorf("SetBeaconEffects: Slot %d is empty", paymentSlot)
+	}
+	resp, err := g.MoveItem(
+		ItemLocation{WindowID: 0, ContainerID: ContainerIDInventory, Slot: paymentSlot},
+		ItemLocation{WindowID: windowID, ContainerID: ContainerIDBeaconPayment, Slot: BeaconSlotPayment},
+		1,
+		AirItem,
+		payment,
+	)
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	if len(resp) == 0 || resp[0].Status != protocol.ItemStackResponseStatusOK {
+		return fmt.Errorf("SetBeaconEffects: Failed to move the item in slot %d into the beacon", paymentSlot)
+	}
+	// 放入支付物品
+	newRequestID := g.Resources.ItemStackOperation.GetNewRequestID()
+	err = g.Resources.ItemStackOperation.WriteRequest(
+		newRequestID,
+		map[ResourcesControl.ContainerID]ResourcesControl.StackRequestContainerInfo{
+			ResourcesControl.ContainerID(ContainerIDBeaconPayment): {
+				WindowID: uint32(windowID),
+				ChangeResult: map[uint8]protocol.ItemInstance{
+					BeaconSlotPayment: AirItem,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	err = g.WritePacket(&packet.ItemStackRequest{
+		Requests: []protocol.ItemStackRequest{
+			{
+				RequestID: newRequestID,
+				Actions: []protocol.StackRequestAction{
+					&protocol.BeaconPaymentStackRequestAction{
+						PrimaryEffect:   primary,
+						SecondaryEffect: secondary,
+					},
+					&protocol.ConsumeStackRequestAction{
+						DestroyStackRequestAction: protocol.DestroyStackRequestAction{
+							Count: 1,
+							Source: protocol.StackRequestSlotInfo{
+								ContainerID:    ContainerIDBeaconPayment,
+								Slot:           BeaconSlotPayment,
+								StackNetworkID: payment.StackNetworkID,
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	ans, err := g.Resources.ItemStackOperation.LoadResponseAndDelete(newRequestID)
+	if err != nil {
+		return fmt.Errorf("SetBeaconEffects: %v", err)
+	}
+	if ans.Status != protocol.ItemStackResponseStatusOK {
+		return fmt.Errorf("SetBeaconEffects: The server rejected the effects (%d, %d); status = %d", primary, secondary, ans.Status)
+	}
+	// 选择信标效果并消耗支付物品
+	return nil
+	// 返回值
+}
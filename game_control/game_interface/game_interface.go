@@ -100,6 +100,11 @@ const (
 	ContainerIDBarrel       = byte(58)
 	ContainerIDBrewingStand = byte(59)
 
+	// 酿造台的原料、药水及燃料槽位
+	ContainerIDBrewingStandInput  = byte(9)
+	ContainerIDBrewingStandResult = byte(10)
+	ContainerIDBrewingStandFuel   = byte(11)
+
 	ContainerIDUnknown = byte(255)
 )
 
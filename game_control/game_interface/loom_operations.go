@@ -0,0 +1,290 @@
+package GameInterface
+
+import (
+	"encoding/gob"
+	"fmt"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+)
+
+// 描述织布机各槽位的容器 ID
+const (
+	ContainerIDLoomInput     = byte(41)
+	ContainerIDLoomDye       = byte(42)
+	ContainerIDLoomMaterial  = byte(43)
+	ContainerIDCreatedOutput = byte(60)
+)
+
+// 描述织布机各槽位在其窗口中的槽位编号
+const (
+	LoomSlotInput     = uint8(9)
+	LoomSlotDye       = uint8(10)
+	LoomSlotMaterial  = uint8(11)
+	CreatedOutputSlot = uint8(50)
+)
+
+// 使用织布机为旗帜添加图案时会被使用的结构体
+type BannerPatternRequest struct {
+	// 图案的标识符，例如 bo 或 cre
+	Pattern string
+	// 图案的颜色
+	Color int32
+	// 染料在背包中的槽位，该槽位应恰好有 1 个染料
+	DyeSlot uint8
+	// 旗帜图案物品在背包中的槽位。
+	// 为 nil 时代表该图案不需要旗帜图案物品
+	MaterialSlot *uint8
+}
+
+/*
+在 pos 处放置一个织布机，
+然后使用快捷栏 hotBarSlotID 打开它，
+并依次为背包中 bannerSlot 处的旗帜添加 request 中的图案。
+
+旗帜始终会被放回 bannerSlot 处，
+旗帜图案物品不会被消耗，它们也会被放回原本的槽位。
+
+返回值 int 代表成功添加的图案数，
+任一图案添加失败时，其后的图案将不再被添加
+*/
+func (g *GameInterface) ApplyBannerPatternsByLoom(
+	pos [3]int32,
+	hotBarSlotID uint8,
+	bannerSlot uint8,
+	request []BannerPatternRequest,
+) (int, error) {
+	uniqueId, err := g.BackupStructure(MCStructure{
+		BeginX: pos[0],
+		BeginY: pos[1],
+		BeginZ: pos[2],
+		SizeX:  1,
+		SizeY:  1,
+		SizeZ:  1,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ApplyBannerPatternsByLoom: %v", err)
+	}
+	err = g.SetBlock(pos, "loom", `["direction": 0]`)
+	if err != nil {
+		return 0, fmt.Errorf("ApplyBannerPatternsByLoom: %v", err)
+	}
+	err = g.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1]+1, pos[2]), true)
+	if err != nil {
+		return 0, fmt.Errorf("ApplyBannerPatternsByLoom: %v", err)
+	}
+	err = g.AwaitChangesGeneral()
+	if err != nil {
+		return 0, fmt.Errorf("ApplyBannerPatternsByLoom: %v", err)
+	}
+	// 生成织布机并传送机器人到织布机处
+	holder := g.Resources.Container.Occupy()
+	defer g.Resources.Container.Release(holder)
+	// 获取容器资源
+	err = g.ChangeSelectedHotbarSlot(hotBarSlotID)
+	if err != nil {
+		return 0, fmt.Errorf("ApplyBannerPatternsByLoom: %v", err)
+	}
+	successStates, err := g.OpenContainer(
+		pos,
+		"minecraft:loom",
+		map[string]interface{}{"direction": int32(0)},
+		hotBarSlotID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("ApplyBannerPatternsByLoom: %v", err)
+	}
+	if !successStates {
+		return 0, fmt.Errorf("ApplyBannerPatternsByLoom: Failed to open the loom on %v", pos)
+	}
+	defer func() {
+		g.CloseContainer()
+		g.RevertStructure(uniqueId, BlockPos{pos[0], pos[1], pos[2]})
+	}()
+	// 打开织布机，并在退出时关闭织布机并恢复原本的方块
+	for key, value := range request {
+		err = g.applyBannerPattern(bannerSlot, value)
+		if err != nil {
+			return key, fmt.Errorf("ApplyBannerPatternsByLoom: %v", err)
+		}
+	}
+	// 依次添加图案
+	return len(request), nil
+	// 返回值
+}
+
+// 在已打开的织布机中为背包中 bannerSlot 处的旗帜添加 request 所指代的图案
+func (g *GameInterface) applyBannerPattern(bannerSlot uint8, request BannerPatternRequest) error {
+	containerOpeningData := g.Resources.Container.GetContainerOpeningData()
+	if containerOpeningData == nil {
+		return fmt.Errorf("applyBannerPattern: Loom has been closed")
+	}
+	windowID := containerOpeningData.WindowID
+	// 取得已打开的织布机的数据
+	moveIntoLoom := func(slot uint8, containerID uint8, loomSlot uint8) (protocol.ItemInstance, error) {
+		datas, err := g.Resources.Inventory.GetItemStackInfo(0, slot)
+		if err != nil {
+			return protocol.ItemInstance{}, err
+		}
+		if datas.Stack.NetworkID == 0 {
+			return protocol.ItemInstance{}, fmt.Errorf("Slot %d is empty", slot)
+		}
+		resp, err := g.MoveItem(
+			ItemLocation{WindowID: 0, ContainerID: ContainerIDInventory, Slot: slot},
+			ItemLocation{WindowID: windowID, ContainerID: containerID, Slot: loomSlot},
+			1,
+			AirItem,
+			datas,
+		)
+		if err != nil {
+			return protocol.ItemInstance{}, err
+		}
+		if len(resp) == 0 || resp[0].Status != protocol.ItemStackResponseStatusOK {
+			return protocol.ItemInstance{}, fmt.Errorf("Failed to move the item in slot %d into the loom", slot)
+		}
+		return datas, nil
+	}
+	// 将背包中 slot 处的物品移动到织布机的 loomSlot 处
+	banner, err := moveIntoLoom(bannerSlot, ContainerIDLoomInput, LoomSlotInput)
+	if err != nil {
+		return fmt.Errorf("applyBannerPattern: %v", err)
+	}
+	dye, err := moveIntoLoom(request.DyeSlot, ContainerIDLoomDye, LoomSlotDye)
+	if err != nil {
+		return fmt.Errorf("applyBannerPattern: %v", err)
+	}
+	var material protocol.ItemInstance
+	if request.MaterialSlot != nil {
+		material, err = moveIntoLoom(*request.MaterialSlot, ContainerIDLoomMaterial, LoomSlotMaterial)
+		if err != nil {
+			return fmt.Errorf("applyBannerPattern: %v", err)
+		}
+	}
+	// 放入旗帜、染料及旗帜图案
+	var result protocol.ItemInstance
+	ResourcesControl.DeepCopy(
+		&banner,
+		&result,
+		func() {
+			gob.Register(map[string]interface{}{})
+			gob.Register([]interface{}{})
+		},
+	)
+	if result.Stack.NBTData == nil {
+		result.Stack.NBTData = map[string]interface{}{}
+	}
+	patterns, _ := result.Stack.NBTData["Patterns"].([]interface{})
+	result.Stack.NBTData["Patterns"] = append(patterns, map[string]interface{}{
+		"Color":   request.Color,
+		"Pattern": request.Pattern,
+	})
+	// 预测添加图案后的旗帜数据，这将用于更新本地库存数据
+	newRequestID := g.Resources.ItemStackOperation.GetNewRequestID()
+	err = g.Resources.ItemStackOperation.WriteRequest(
+		newRequestID,
+		map[ResourcesControl.ContainerID]ResourcesControl.StackRequestContainerInfo{
+			ResourcesControl.ContainerID(ContainerIDInventory): {
+				WindowID: 0,
+				ChangeResult: map[uint8]protocol.ItemInstance{
+					bannerSlot: result,
+				},
+			},
+			ResourcesControl.ContainerID(ContainerIDLoomInput): {
+				WindowID: uint32(windowID),
+				ChangeResult: map[uint8]protocol.ItemInstance{
+					LoomSlotInput: AirItem,
+				},
+			},
+			ResourcesControl.ContainerID(ContainerIDLoomDye): {
+				WindowID: uint32(windowID),
+				ChangeResult: map[uint8]protocol.ItemInstance{
+					LoomSlotDye: AirItem,
+				},
+			},
+			ResourcesControl.ContainerID(ContainerIDCreatedOutput): {
+				WindowID: uint32(windowID),
+				ChangeResult: map[uint8]protocol.ItemInstance{
+					CreatedOutputSlot: AirItem,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("applyBannerPattern: %v", err)
+	}
+	placeStackRequestAction := protocol.PlaceStackRequestAction{}
+	placeStackRequestAction.Count = 1
+	placeStackRequestAction.Source = protocol.StackRequestSlotInfo{
+		ContainerID:    ContainerIDCreatedOutput,
+		Slot:           CreatedOutputSlot,
+		StackNetworkID: newRequestID,
+	}
+	placeStackRequestAction.Destination = protocol.StackRequestSlotInfo{
+		ContainerID:    ContainerIDInventory,
+		Slot:           bannerSlot,
+		StackNetworkID: 0,
+	}
+	// 将合成结果放回背包
+	err = g.WritePacket(&packet.ItemStackRequest{
+		Requests: []protocol.ItemStackRequest{
+			{
+				RequestID: newRequestID,
+				Actions: []protocol.StackRequestAction{
+					&protocol.CraftLoomRecipeStackRequestAction{
+						Pattern: request.Pattern,
+					},
+					&protocol.ConsumeStackRequestAction{
+						DestroyStackRequestAction: protocol.DestroyStackRequestAction{
+							Count: 1,
+							Source: protocol.StackRequestSlotInfo{
+								ContainerID:    ContainerIDLoomInput,
+								Slot:           LoomSlotInput,
+								StackNetworkID: banner.StackNetworkID,
+							},
+						},
+					},
+					&protocol.ConsumeStackRequestAction{
+						DestroyStackRequestAction: protocol.DestroyStackRequestAction{
+							Count: 1,
+							Source: protocol.StackRequestSlotInfo{
+								ContainerID:    ContainerIDLoomDye,
+								Slot:           LoomSlotDye,
+								StackNetworkID: dye.StackNetworkID,
+							},
+						},
+					},
+					&placeStackRequestAction,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("applyBannerPattern: %v", err)
+	}
+	ans, err := g.Resources.ItemStackOperation.LoadResponseAndDelete(newRequestID)
+	if err != nil {
+		return fmt.Errorf("applyBannerPattern: %v", err)
+	}
+	if ans.Status != protocol.ItemStackResponseStatusOK {
+		return fmt.Errorf("applyBannerPattern: The server rejected pattern %#v; status = %d", request.Pattern, ans.Status)
+	}
+	// 合成并取回旗帜
+	if request.MaterialSlot != nil {
+		resp, err := g.MoveItem(
+			ItemLocation{WindowID: windowID, ContainerID: ContainerIDLoomMaterial, Slot: LoomSlotMaterial},
+			ItemLocation{WindowID: 0, ContainerID: ContainerIDInventory, Slot: *request.MaterialSlot},
+			1,
+			AirItem,
+			material,
+		)
+		if err != nil {
+			return fmt.Errorf("applyBannerPattern: %v", err)
+		}
+		if len(resp) == 0 || resp[0].Status != protocol.ItemStackResponseStatusOK {
+			return fmt.Errorf("applyBannerPattern: Failed to take back the banner pattern")
+		}
+	}
+	// 取回旗帜图案
+	return nil
+	// 返回值
+}
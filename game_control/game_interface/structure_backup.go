@@ -20,10 +20,27 @@ type BlockPos mcstructure.BlockPos
 // 返回一个 uuid.UUID 对象，
 // 其 uuid_to_safe_string(uuid.UUID) 形式代表被备份结构的名称
 func (g *GameInterface) BackupStructure(structure MCStructure) (uuid.UUID, error) {
+	return g.backupStructure(structure, "")
+}
+
+/*
+备份 structure 所指代的区域为结构，但不包括其中的实体。
+
+当区域中存在已生成的实体时，
+使用 BackupStructure 备份并恢复该区域会导致这些实体被复制，
+此时应当使用此函数
+*/
+func (g *GameInterface) BackupBlocks(structure MCStructure) (uuid.UUID, error) {
+	return g.backupStructure(structure, " false memory true")
+}
+
+// 备份 structure 所指代的区域为结构。
+// options 将被附加在 structure save 命令的末尾
+func (g *GameInterface) backupStructure(structure MCStructure, options string) (uuid.UUID, error) {
 	uniqueId := ResourcesControl.GenerateUUID()
 	// get new uuid
 	request := fmt.Sprintf(
-		`structure save "%s" %d %d %d %d %d %d`,
+		`structure save "%s" %d %d %d %d %d %d%s`,
 		uuid_to_safe_string(uniqueId),
 		structure.BeginX,
 		structure.BeginY,
@@ -31,6 +48,7 @@ func (g *GameInterface) BackupStructure(structure MCStructure) (uuid.UUID, error
 		structure.BeginX+structure.SizeX-1,
 		structure.BeginY+structure.SizeY-1,
 		structure.BeginZ+structure.SizeZ-1,
+		options,
 	)
 	// get command to backup structure
 	resp := g.SendWSCommandWithResponse(
@@ -0,0 +1,98 @@
+package MockServer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"phoenixbuilder/minecraft/protocol/login"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// 描述登录链中由模拟的验证服务器签发的声明
+type chainClaims struct {
+	jwt.Claims
+	ExtraData         *login.IdentityData `json:"extraData,omitempty"`
+	IdentityPublicKey string              `json:"identityPublicKey"`
+}
+
+/*
+用于登录到模拟服务器的验证器，
+它实现了 minecraft.Authenticator 接口。
+
+与真实的验证服务器类似，
+它会签发一条长度为 2 的登录链，
+客户端在此之前插入自签名的声明后，
+服务器端即可按照网易的规则完成解析
+*/
+type authenticator struct {
+	// 模拟服务器的地址
+	address string
+	// 机器人的身份信息
+	identity login.IdentityData
+}
+
+// 创建一个登录到 address 的验证器，
+// 机器人的游戏昵称将被设置为 displayName
+func newAuthenticator(address string, displayName string) *authenticator {
+	return &authenticator{
+		address: address,
+		identity: login.IdentityData{
+			XUID:        "1",
+			Identity:    uuid.New().String(),
+			DisplayName: displayName,
+		},
+	}
+}
+
+// 签发一条以 publicKey 为终点的登录链，
+// 并返回模拟服务器的地址
+func (a *authenticator) GetAccess(ctx context.Context, publicKey []byte) (string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("GetAccess: %v", err)
+	}
+	keyData := login.MarshalPublicKey(&key.PublicKey)
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Key: key, Algorithm: jose.ES384},
+		&jose.SignerOptions{ExtraHeaders: map[jose.HeaderKey]any{"x5u": keyData}},
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("GetAccess: %v", err)
+	}
+	// 生成签发者的密钥
+	claims := jwt.Claims{
+		Issuer:    "NetEase",
+		Expiry:    jwt.NewNumericDate(time.Now().Add(time.Hour * 6)),
+		NotBefore: jwt.NewNumericDate(time.Now().Add(-time.Hour * 6)),
+	}
+	authority, err := jwt.Signed(signer).Claims(chainClaims{
+		Claims:            claims,
+		IdentityPublicKey: keyData,
+	}).CompactSerialize()
+	if err != nil {
+		return "", "", fmt.Errorf("GetAccess: %v", err)
+	}
+	identity, err := jwt.Signed(signer).Claims(chainClaims{
+		Claims:            claims,
+		ExtraData:         &a.identity,
+		IdentityPublicKey: base64.StdEncoding.EncodeToString(publicKey),
+	}).CompactSerialize()
+	if err != nil {
+		return "", "", fmt.Errorf("GetAccess: %v", err)
+	}
+	// 签发声明
+	chain, err := json.Marshal(map[string][]string{"chain": {authority, identity}})
+	if err != nil {
+		return "", "", fmt.Errorf("GetAccess: %v", err)
+	}
+	return a.address, string(chain), nil
+	// 返回值
+}
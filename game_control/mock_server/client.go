@@ -0,0 +1,97 @@
+package MockServer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft"
+	"time"
+)
+
+// 登录到模拟服务器所使用的机器人昵称
+const ClientDisplayName = "MockBot"
+
+// 客户端完成登录及出生流程的最长时间
+const SpawnDeadLine = time.Second * 30
+
+// 描述一个已连接到模拟服务器的客户端
+type Client struct {
+	// 与模拟服务器的连接
+	Conn *minecraft.Conn
+	// 基于该连接的 GameInterface ，
+	// 其初始化方式与正式运行时保持一致
+	GameInterface *GameInterface.GameInterface
+	// 客户端停止处理数据包后被关闭
+	closed chan struct{}
+}
+
+// 连接到模拟服务器 s ，
+// 并返回一个可以直接使用的 GameInterface
+func (s *Server) Connect() (*Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SpawnDeadLine)
+	defer cancel()
+	conn, err := minecraft.Dialer{
+		ErrorLog:      log.New(io.Discard, "", 0),
+		Authenticator: newAuthenticator(s.Address(), ClientDisplayName),
+	}.DialContext(ctx, "raknet")
+	if err != nil {
+		return nil, fmt.Errorf("Connect: %v", err)
+	}
+	err = conn.DoSpawnContext(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("Connect: %v", err)
+	}
+	err = s.awaitSpawn(SpawnDeadLine)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("Connect: %v", err)
+	}
+	// 登录并完成出生流程
+	resources := &ResourcesControl.Resources{}
+	updater := resources.Init()
+	client := &Client{
+		Conn: conn,
+		GameInterface: &GameInterface.GameInterface{
+			WritePacket: conn.WritePacket,
+			ClientInfo: GameInterface.ClientInfo{
+				DisplayName:     conn.IdentityData().DisplayName,
+				ClientIdentity:  conn.IdentityData().Identity,
+				XUID:            conn.IdentityData().XUID,
+				EntityRuntimeID: conn.GameData().EntityRuntimeID,
+				EntityUniqueID:  conn.GameData().EntityUniqueID,
+			},
+			Resources: resources,
+		},
+		closed: make(chan struct{}),
+	}
+	go func() {
+		defer close(client.closed)
+		for {
+			pk, err := conn.ReadPacket()
+			if err != nil {
+				return
+			}
+			updater(&pk)
+		}
+	}()
+	// 初始化 GameInterface 并开始处理数据包
+	err = client.GameInterface.AwaitChangesGeneral()
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("Connect: %v", err)
+	}
+	// 等待出生时发送的背包数据被处理
+	return client, nil
+	// 返回值
+}
+
+// 断开客户端与模拟服务器的连接
+func (c *Client) Close() error {
+	err := c.Conn.Close()
+	<-c.closed
+	return err
+}
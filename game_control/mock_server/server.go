@@ -0,0 +1,319 @@
+package MockServer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"phoenixbuilder/minecraft"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 描述模拟服务器对单个数据包的响应方式。
+// 返回的数据包将被依次发送给客户端
+type Responder func(pk packet.Packet) []packet.Packet
+
+// 描述模拟服务器对单条命令的响应方式。
+// 返回值将作为 packet.CommandOutput 的 OutputMessages 字段
+type CommandResponder func(commandLine string) []protocol.CommandOutputMessage
+
+// 描述一个已注册的命令响应
+type commandResponder struct {
+	// 命令的前缀，例如 setblock
+	prefix string
+	// 响应方式
+	responder CommandResponder
+}
+
+// 机器人背包的槽位数量
+const InventorySize = 36
+
+// 以下数据包对测试没有意义，因此不会被记录
+var ignoredPackets = map[uint32]bool{
+	packet.IDPlayerAuthInput:             true,
+	packet.IDMovePlayer:                  true,
+	packet.IDRequestChunkRadius:          true,
+	packet.IDSetLocalPlayerAsInitialised: true,
+	packet.IDClientCacheStatus:           true,
+	packet.IDTickSync:                    true,
+}
+
+/*
+一个运行在本地的模拟租赁服，
+用于在 go test 中运行需要与租赁服交互的代码。
+
+它会按照预设的方式回应 CommandRequest 、 ItemStackRequest 、
+方块点击(以 ContainerOpen 打开容器)、 ContainerClose 、
+StructureTemplateDataRequest 及 TickSync 数据包，
+并按顺序记录客户端发来的其他数据包，
+以便测试断言准确的数据包序列
+*/
+type Server struct {
+	listener *minecraft.Listener
+	conn     *minecraft.Conn
+	// 客户端完成登录后被关闭
+	spawned chan struct{}
+	// 服务器停止后被关闭
+	closed chan struct{}
+
+	lock sync.Mutex
+	// 已记录的数据包
+	received []packet.Packet
+	// 按数据包 ID 覆盖的响应方式
+	responders map[uint32]Responder
+	// 按前缀匹配的命令响应方式
+	commands []commandResponder
+	// 可被打开的容器，键为容器坐标，值为容器类型
+	containers map[protocol.BlockPos]byte
+	// 用于回应 StructureTemplateDataRequest 的结构
+	structures map[string]map[string]any
+	// 当前的游戏刻
+	tick int64
+	// 下一个被打开的容器的窗口 ID
+	windowID byte
+}
+
+// 在本地的随机端口上启动一个模拟服务器
+func NewServer() (*Server, error) {
+	listener, err := minecraft.ListenConfig{
+		ErrorLog:               log.New(io.Discard, "", 0),
+		AuthenticationDisabled: true,
+	}.Listen("raknet", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("NewServer: %v", err)
+	}
+	s := &Server{
+		listener:   listener,
+		spawned:    make(chan struct{}),
+		closed:     make(chan struct{}),
+		responders: map[uint32]Responder{},
+		containers: map[protocol.BlockPos]byte{},
+		structures: map[string]map[string]any{},
+		windowID:   1,
+	}
+	go s.serve()
+	return s, nil
+}
+
+// 返回模拟服务器的监听地址
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// 关闭模拟服务器及其连接
+func (s *Server) Close() error {
+	select {
+	case <-s.closed:
+		return nil
+	default:
+		close(s.closed)
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	return s.listener.Close()
+}
+
+// 接受首个连接，完成出生流程后开始处理数据包
+func (s *Server) serve() {
+	netConn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	s.conn = netConn.(*minecraft.Conn)
+	err = s.conn.StartGameTimeout(minecraft.GameData{
+		WorldName:       "mock",
+		EntityUniqueID:  1,
+		EntityRuntimeID: 1,
+		PlayerGameMode:  1,
+		WorldGameMode:   1,
+		BaseGameVersion: "*",
+	}, time.Second*30)
+	if err != nil {
+		return
+	}
+	err = s.conn.WritePacket(&packet.InventoryContent{
+		WindowID: 0,
+		Content:  make([]protocol.ItemInstance, InventorySize),
+	})
+	if err != nil {
+		return
+	}
+	close(s.spawned)
+	// 完成出生流程并发送空的背包
+	for {
+		pk, err := s.conn.ReadPacket()
+		if err != nil {
+			return
+		}
+		for _, response := range s.respond(pk) {
+			if err := s.conn.WritePacket(response); err != nil {
+				return
+			}
+		}
+		_ = s.conn.Flush()
+	}
+	// 处理数据包
+}
+
+// 记录 pk 并取得其响应
+func (s *Server) respond(pk packet.Packet) []packet.Packet {
+	s.lock.Lock()
+	if !ignoredPackets[pk.ID()] {
+		s.received = append(s.received, pk)
+	}
+	responder, ok := s.responders[pk.ID()]
+	s.lock.Unlock()
+	// 记录数据包
+	if ok {
+		return responder(pk)
+	}
+	switch p := pk.(type) {
+	case *packet.CommandRequest:
+		return []packet.Packet{s.respondCommand(p)}
+	case *packet.TickSync:
+		s.lock.Lock()
+		s.tick += 2
+		tick := s.tick
+		s.lock.Unlock()
+		return []packet.Packet{&packet.TickSync{
+			ClientRequestTimestamp:   p.ClientRequestTimestamp,
+			ServerReceptionTimestamp: tick,
+		}}
+	case *packet.ItemStackRequest:
+		response := &packet.ItemStackResponse{}
+		for _, request := range p.Requests {
+			response.Responses = append(response.Responses, protocol.ItemStackResponse{
+				Status:    protocol.ItemStackResponseStatusOK,
+				RequestID: request.RequestID,
+			})
+		}
+		return []packet.Packet{response}
+	case *packet.InventoryTransaction:
+		return s.respondClick(p)
+	case *packet.ContainerClose:
+		return []packet.Packet{&packet.ContainerClose{WindowID: p.WindowID, ServerSide: false}}
+	case *packet.StructureTemplateDataRequest:
+		s.lock.Lock()
+		template, ok := s.structures[p.StructureName]
+		s.lock.Unlock()
+		return []packet.Packet{&packet.StructureTemplateDataResponse{
+			StructureName:     p.StructureName,
+			Success:           ok,
+			ResponseType:      packet.StructureTemplateResponseExport,
+			StructureTemplate: template,
+		}}
+	}
+	return nil
+	// 默认的响应方式
+}
+
+// 回应命令请求 p 。
+// 未注册的命令将被视为执行成功
+func (s *Server) respondCommand(p *packet.CommandRequest) packet.Packet {
+	messages := []protocol.CommandOutputMessage{{Success: true, Message: "commands.generic.success"}}
+	commandLine := strings.TrimPrefix(p.CommandLine, "/")
+	s.lock.Lock()
+	for _, value := range s.commands {
+		if strings.HasPrefix(commandLine, value.prefix) {
+			messages = value.responder(commandLine)
+			break
+		}
+	}
+	s.lock.Unlock()
+	// 取得命令的执行结果
+	successCount := uint32(0)
+	for _, value := range messages {
+		if value.Success {
+			successCount++
+		}
+	}
+	return &packet.CommandOutput{
+		CommandOrigin:  p.CommandOrigin,
+		OutputType:     packet.CommandOutputTypeAllOutput,
+		SuccessCount:   successCount,
+		OutputMessages: messages,
+	}
+	// 返回值
+}
+
+// 回应方块点击 p 。
+// 如果被点击的方块是已注册的容器，则打开该容器
+func (s *Server) respondClick(p *packet.InventoryTransaction) []packet.Packet {
+	data, ok := p.TransactionData.(*protocol.UseItemTransactionData)
+	if !ok || data.ActionType != protocol.UseItemActionClickBlock {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	containerType, ok := s.containers[data.BlockPosition]
+	if !ok {
+		return nil
+	}
+	windowID := s.windowID
+	s.windowID++
+	return []packet.Packet{&packet.ContainerOpen{
+		WindowID:          windowID,
+		ContainerType:     containerType,
+		ContainerPosition: data.BlockPosition,
+	}}
+}
+
+// 等待客户端完成出生流程
+func (s *Server) awaitSpawn(timeout time.Duration) error {
+	select {
+	case <-s.spawned:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("awaitSpawn: The client did not spawn in %v", timeout)
+	}
+}
+
+// 使用 responder 回应 ID 为 id 的数据包，
+// 这将覆盖默认的响应方式
+func (s *Server) Handle(id uint32, responder Responder) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responders[id] = responder
+}
+
+// 使用 responder 回应以 prefix 开头的命令。
+// 先注册的响应方式具有更高的优先级
+func (s *Server) HandleCommand(prefix string, responder CommandResponder) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.commands = append(s.commands, commandResponder{prefix: prefix, responder: responder})
+}
+
+// 将 pos 处的方块注册为类型为 containerType 的容器，
+// 点击该方块时服务器将发送 packet.ContainerOpen
+func (s *Server) SetContainer(pos protocol.BlockPos, containerType byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.containers[pos] = containerType
+}
+
+// 设置名为 name 的结构，
+// 它将被用于回应 StructureTemplateDataRequest
+func (s *Server) SetStructure(name string, template map[string]any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.structures[name] = template
+}
+
+// 返回已记录的所有数据包
+func (s *Server) Packets() []packet.Packet {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]packet.Packet{}, s.received...)
+}
+
+// 清空已记录的数据包
+func (s *Server) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.received = nil
+}
@@ -0,0 +1,85 @@
+package MockServer
+
+import (
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+	"testing"
+)
+
+// 启动模拟服务器并连接到它
+func connect(t *testing.T) (*Server, *Client) {
+	t.Helper()
+	server, err := NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	client, err := server.Connect()
+	if err != nil {
+		server.Close()
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return server, client
+}
+
+func TestCommandResponse(t *testing.T) {
+	server, client := connect(t)
+	server.HandleCommand("testforblock", func(commandLine string) []protocol.CommandOutputMessage {
+		return []protocol.CommandOutputMessage{{Success: false, Message: "commands.testforblock.failed.tile"}}
+	})
+	resp := client.GameInterface.SendWSCommandWithResponse("testforblock 0 0 0 air", ResourcesControl.CommandRequestOptions{
+		TimeOut: ResourcesControl.CommandRequestDefaultDeadLine,
+	})
+	if resp.Error != nil {
+		t.Fatalf("SendWSCommandWithResponse: %v", resp.Error)
+	}
+	if resp.Respond.SuccessCount != 0 || resp.Respond.OutputMessages[0].Message != "commands.testforblock.failed.tile" {
+		t.Errorf("unexpected response %#v", resp.Respond)
+	}
+	// 已注册的命令
+	server.Reset()
+	err := client.GameInterface.SetBlock([3]int32{1, 2, 3}, "stone", "[]")
+	if err != nil {
+		t.Fatalf("SetBlock: %v", err)
+	}
+	packets := server.Packets()
+	if len(packets) != 1 {
+		t.Fatalf("got %d packets, want 1", len(packets))
+	}
+	if got := packets[0].(*packet.CommandRequest).CommandLine; got != "setblock 1 2 3 stone [] " {
+		t.Errorf("CommandLine = %q", got)
+	}
+	// 默认视为成功的命令
+}
+
+func TestAwaitChanges(t *testing.T) {
+	_, client := connect(t)
+	if err := client.GameInterface.AwaitChangesGeneral(); err != nil {
+		t.Fatalf("AwaitChangesGeneral: %v", err)
+	}
+}
+
+func TestOpenContainer(t *testing.T) {
+	server, client := connect(t)
+	server.SetContainer(protocol.BlockPos{1, 2, 3}, 0)
+	holder := client.GameInterface.Resources.Container.Occupy()
+	defer client.GameInterface.Resources.Container.Release(holder)
+	// 占用容器资源
+	success, err := client.GameInterface.OpenContainer([3]int32{1, 2, 3}, "minecraft:chest", map[string]interface{}{"facing_direction": int32(2)}, 0)
+	if err != nil || !success {
+		t.Fatalf("OpenContainer: %v, %v", success, err)
+	}
+	if got := client.GameInterface.Resources.Container.GetContainerOpeningData().ContainerPosition; got != (protocol.BlockPos{1, 2, 3}) {
+		t.Errorf("ContainerPosition = %v", got)
+	}
+	// 打开容器
+	success, err = client.GameInterface.CloseContainer()
+	if err != nil || !success {
+		t.Fatalf("CloseContainer: %v, %v", success, err)
+	}
+	// 关闭容器
+}
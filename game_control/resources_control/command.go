@@ -78,19 +78,24 @@ func (c *commandRequestWithResponse) LoadResponseAndDelete(key uuid.UUID) Comman
 	}
 	// convert data
 	{
-		if options_got.TimeOut == CommandRequestNoDeadLine {
-			res := <-response_got
-			c.request.Delete(key)
-			c.response.Delete(key)
-			return CommandRespond{Respond: res}
+		var timeOut <-chan time.Time
+		if options_got.TimeOut != CommandRequestNoDeadLine {
+			timeOut = time.After(options_got.TimeOut)
 		}
-		// if there is no time limit
+		// if there is no time limit, then timeOut is nil and never fires
 		select {
 		case res := <-response_got:
 			c.request.Delete(key)
 			c.response.Delete(key)
 			return CommandRespond{Respond: res}
-		case <-time.After(options_got.TimeOut):
+		case <-c.closed:
+			c.request.Delete(key)
+			c.response.Delete(key)
+			return CommandRespond{
+				Error:     fmt.Errorf(`LoadResponseAndDelete: Request "%v" failed; err = %v`, key.String(), ErrResourcesClosed),
+				ErrorType: ErrCommandRequestResourcesClosed,
+			}
+		case <-timeOut:
 			c.request.Delete(key)
 			c.response.Delete(key)
 			return CommandRespond{
@@ -98,7 +103,7 @@ func (c *commandRequestWithResponse) LoadResponseAndDelete(key uuid.UUID) Comman
 				ErrorType: ErrCommandRequestTimeOut,
 			}
 		}
-		// if there's a time limit
+		// wait for the response
 	}
 	// process and return
 }
@@ -1,6 +1,9 @@
 package ResourcesControl
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // 描述请求的最长截止时间
 const (
@@ -20,7 +23,11 @@ const (
 	ErrCommandRequestConversionFailure
 	ErrCommandRequestTimeOut
 	ErrCommandRequestOthers
+	ErrCommandRequestResourcesClosed
 )
 
+// 资源管理中心被关闭后，等待中的请求将返回此错误
+var ErrResourcesClosed = errors.New("the resources are closed because the connection is lost")
+
 // 描述单个数据包监听器中允许的最大协程运行数量
 const MaximumCoroutinesRunningCount int32 = 255
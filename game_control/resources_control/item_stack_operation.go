@@ -65,9 +65,14 @@ func (i *itemStackRequestWithResponse) LoadResponseAndDelete(key int32) (protoco
 		return protocol.ItemStackResponse{}, fmt.Errorf("tryToWriteResponse: Failed to convert value into singleItemStackRequestWithResponse; value = %#v", value)
 	}
 	// convert data
-	ret := <-get.resp
-	i.requestWithResponse.Delete(key)
-	return ret, nil
+	select {
+	case ret := <-get.resp:
+		i.requestWithResponse.Delete(key)
+		return ret, nil
+	case <-i.closed:
+		i.requestWithResponse.Delete(key)
+		return protocol.ItemStackResponse{}, fmt.Errorf("LoadResponseAndDelete: %v", ErrResourcesClosed)
+	}
 	// return
 }
 
@@ -58,9 +58,14 @@ func (o *others) Load_TickSync_Packet_Responce_and_Delete_Request(
 		return 0, fmt.Errorf("Load_TickSync_Packet_Responce_and_Delete_Request: Failed to convert value into (chan int64); value = %#v", value)
 	}
 	// convert data
-	res := <-chanGet
-	o.currentTickRequestWithResp.Delete(key)
-	return res, nil
+	select {
+	case res := <-chanGet:
+		o.currentTickRequestWithResp.Delete(key)
+		return res, nil
+	case <-o.closed:
+		o.currentTickRequestWithResp.Delete(key)
+		return 0, fmt.Errorf("Load_TickSync_Packet_Responce_and_Delete_Request: %v", ErrResourcesClosed)
+	}
 	// return
 }
 
@@ -116,3 +116,22 @@ func (p *packetListener) StopAndDestroy(listener uuid.UUID) error {
 	return nil
 	// return
 }
+
+// 返回一个在资源管理中心被关闭后被关闭的管道。
+// 届时所有的监听器都已被终止，
+// 因此长期存在的监听者应当以此结束监听
+func (p *packetListener) Closed() <-chan struct{} {
+	return p.closed
+}
+
+// 终止并关闭所有的监听器。
+// 属于私有实现
+func (p *packetListener) stopAll() {
+	p.listenerWithData.Range(func(key, value any) bool {
+		if singleListen, success := value.(singleListen); success {
+			singleListen.stop()
+		}
+		p.listenerWithData.Delete(key)
+		return true
+	})
+}
@@ -27,6 +27,11 @@ type Resources struct {
 	// 管理和保存其他小型的资源，
 	// 例如游戏刻相关
 	Others others
+	// 在资源管理中心被关闭后被关闭，
+	// 届时所有等待中的请求都将失败
+	closed chan struct{}
+	// 确保 closed 只被关闭一次
+	closeOnce sync.Once
 }
 
 // ------------------------- commandRequestWithResponce -------------------------
@@ -48,6 +53,8 @@ type commandRequestWithResponse struct {
 	// 存放命令请求的响应体。
 	// 数据类型为 map[uuid.UUID](chan packet.CommandOutput)
 	response sync.Map
+	// 在资源管理中心被关闭后被关闭
+	closed <-chan struct{}
 }
 
 // 描述命令请求的响应体
@@ -103,6 +110,8 @@ type itemStackRequestWithResponse struct {
 		因此，绝对地，请使用已提供的 API 发送物品操作请求，否则将导致程序 panic
 	*/
 	currentRequestID int32
+	// 在资源管理中心被关闭后被关闭
+	closed <-chan struct{}
 }
 
 // 每个物品操作请求都会使用这样一个结构体，它用于描述单个的物品操作请求
@@ -173,6 +182,8 @@ type mcstructure struct {
 	resourcesOccupy
 	// 保存结构请求的响应体
 	resp chan packet.StructureTemplateDataResponse
+	// 在资源管理中心被关闭后被关闭
+	closed <-chan struct{}
 }
 
 // ------------------------- packetListener -------------------------
@@ -201,6 +212,8 @@ type packetListener struct {
 	// 数据类型为 map[uuid.UUID]singleListen 。
 	// 键代表监听器，而值代表此监听器下已保存的数据
 	listenerWithData sync.Map
+	// 在资源管理中心被关闭后被关闭
+	closed <-chan struct{}
 }
 
 // ------------------------- others -------------------------
@@ -211,4 +224,6 @@ type others struct {
 	// 它用于获取当前的游戏刻。
 	// 数据类型为 map[uuid.UUID]chan int64
 	currentTickRequestWithResp sync.Map
+	// 在资源管理中心被关闭后被关闭
+	closed <-chan struct{}
 }
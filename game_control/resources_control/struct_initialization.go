@@ -14,10 +14,12 @@ import (
 否则会发生无法解决的冲突性问题
 */
 func (r *Resources) Init() func(pk *packet.Packet) {
+	closed := make(chan struct{})
 	*r = Resources{
 		Command: commandRequestWithResponse{
 			request:  sync.Map{},
 			response: sync.Map{},
+			closed:   closed,
 		},
 		Inventory: inventoryContents{
 			lockDown: sync.RWMutex{},
@@ -26,6 +28,7 @@ func (r *Resources) Init() func(pk *packet.Packet) {
 		ItemStackOperation: itemStackRequestWithResponse{
 			requestWithResponse: sync.Map{},
 			currentRequestID:    1,
+			closed:              closed,
 		},
 		Container: container{
 			lockDown:             sync.RWMutex{},
@@ -42,16 +45,38 @@ func (r *Resources) Init() func(pk *packet.Packet) {
 				lockDown: sync.Mutex{},
 				holder:   "",
 			},
-			resp: make(chan packet.StructureTemplateDataResponse, 1),
+			resp:   make(chan packet.StructureTemplateDataResponse, 1),
+			closed: closed,
 		},
 		Listener: packetListener{
 			listenerWithData: sync.Map{},
+			closed:           closed,
 		},
 		Others: others{
 			currentTickRequestWithResp: sync.Map{},
+			closed:                     closed,
 		},
+		closed:    closed,
+		closeOnce: sync.Once{},
 	}
 	// init struct
 	return r.handlePacket
 	// return
 }
+
+/*
+关闭资源管理中心。
+
+在与租赁服断开连接后，租赁服将不再响应任何请求，
+因此所有正在等待响应的请求(例如命令请求和游戏刻请求)
+都将立即以 ErrResourcesClosed 失败，
+而所有的数据包监听器都将被终止并关闭。
+
+重复调用此函数是安全的
+*/
+func (r *Resources) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+		r.Listener.stopAll()
+	})
+}
@@ -0,0 +1,71 @@
+package ResourcesControl
+
+import (
+	"phoenixbuilder/minecraft/protocol/packet"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 在 timeout 内等待 result ，超时则使测试失败
+func waitResult[T any](t *testing.T, name string, result <-chan T) T {
+	t.Helper()
+	select {
+	case value := <-result:
+		return value
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s is still waiting after Close", name)
+	}
+	panic("unreachable")
+}
+
+func TestCloseFailsPendingRequests(t *testing.T) {
+	r := &Resources{}
+	r.Init()
+
+	commandKey := GenerateUUID()
+	if err := r.Command.WriteRequest(commandKey, CommandRequestOptions{TimeOut: CommandRequestNoDeadLine}); err != nil {
+		t.Fatalf("WriteRequest: %v", err)
+	}
+	command := make(chan CommandRespond, 1)
+	go func() { command <- r.Command.LoadResponseAndDelete(commandKey) }()
+
+	tickKey := GenerateUUID()
+	if err := r.Others.WriteCurrentTickRequest(tickKey); err != nil {
+		t.Fatalf("WriteCurrentTickRequest: %v", err)
+	}
+	tick := make(chan error, 1)
+	go func() {
+		_, err := r.Others.Load_TickSync_Packet_Responce_and_Delete_Request(tickKey)
+		tick <- err
+	}()
+
+	r.Structure.WriteRequest()
+	structure := make(chan error, 1)
+	go func() {
+		_, err := r.Structure.LoadResponse()
+		structure <- err
+	}()
+
+	listener, _ := r.Listener.CreateNewListen([]uint32{packet.IDText}, 1)
+
+	r.Close()
+	r.Close()
+
+	if resp := waitResult(t, "the command request", command); resp.ErrorType != ErrCommandRequestResourcesClosed {
+		t.Errorf("command request: got %+v, want ErrCommandRequestResourcesClosed", resp)
+	}
+	for name, result := range map[string]<-chan error{"the tick request": tick, "the structure request": structure} {
+		if err := waitResult(t, name, result); err == nil || !strings.Contains(err.Error(), ErrResourcesClosed.Error()) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrResourcesClosed)
+		}
+	}
+	select {
+	case <-r.Listener.Closed():
+	default:
+		t.Errorf("Listener.Closed is not closed")
+	}
+	if err := r.Listener.StopAndDestroy(listener); err == nil {
+		t.Errorf("the listener is still recorded after Close")
+	}
+}
@@ -1,6 +1,9 @@
 package ResourcesControl
 
-import "phoenixbuilder/minecraft/protocol/packet"
+import (
+	"fmt"
+	"phoenixbuilder/minecraft/protocol/packet"
+)
 
 // 提交结构请求
 func (m *mcstructure) WriteRequest() {
@@ -15,6 +18,11 @@ func (m *mcstructure) writeResponse(data packet.StructureTemplateDataResponse) {
 }
 
 // 从管道读取结构请求的返回值
-func (m *mcstructure) LoadResponse() packet.StructureTemplateDataResponse {
-	return <-m.resp
+func (m *mcstructure) LoadResponse() (packet.StructureTemplateDataResponse, error) {
+	select {
+	case resp := <-m.resp:
+		return resp, nil
+	case <-m.closed:
+		return packet.StructureTemplateDataResponse{}, fmt.Errorf("LoadResponse: %v", ErrResourcesClosed)
+	}
 }
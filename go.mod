@@ -5,13 +5,18 @@ go 1.18
 require (
 	github.com/cheggaaa/pb v1.0.29
 	github.com/df-mc/goleveldb v1.1.9
+	github.com/dop251/goja v0.0.0-20230812105242-81d76064690d
 	github.com/hashicorp/go-version v1.6.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	github.com/atomicgo/cursor v0.0.1 // indirect
 	github.com/df-mc/atomic v1.10.0 // indirect
+	github.com/dlclark/regexp2 v1.7.0 // indirect
+	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/golang/snappy v0.0.1 // indirect
+	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
 	github.com/gookit/color v1.4.2 // indirect
 	github.com/klauspost/cpuid v1.3.1 // indirect
 	github.com/klauspost/reedsolomon v1.9.9 // indirect
@@ -9,11 +9,13 @@ import (
 	"phoenixbuilder/fastbuilder/bdump"
 	"phoenixbuilder/fastbuilder/configuration"
 	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/mcstructure"
 	"phoenixbuilder/fastbuilder/parsing"
 	fbauth "phoenixbuilder/fastbuilder/pv4"
 	"phoenixbuilder/fastbuilder/task"
 	"phoenixbuilder/fastbuilder/task/fetcher"
 	"phoenixbuilder/fastbuilder/types"
+	"phoenixbuilder/minecraft/nbt"
 	"phoenixbuilder/minecraft/protocol/packet"
 	"phoenixbuilder/mirror"
 	"phoenixbuilder/mirror/chunk"
@@ -145,198 +147,54 @@ func CreateExportTask(commandLine string, env *environment.PBEnvironment) *task.
 						continue
 					}
 					var cbdata *types.CommandBlockData = nil
-					var chestData *types.ChestData = nil
 					var nbtData []byte = nil
-					/*if(block=="chest"||block=="minecraft:chest"||strings.Contains(block,"shulker_box")) {
-						content:=item["Items"].([]interface{})
-						chest:=make(types.ChestData, len(content))
-						for index, iface := range content {
-							i:=iface.(map[string]interface{})
-							name:=i["Name"].(string)
-							count:=i["Count"].(uint8)
-							damage:=i["Damage"].(int16)
-							slot:=i["Slot"].(uint8)
-							name_mcnk:=name[10:]
-							chest[index]=types.ChestSlot {
-								Name: name_mcnk,
-								Count: count,
-								Damage: uint16(int(damage)),
-								Slot: slot,
-							}
-						}
-						chestData=&chest
-					}*/
-					// TODO ^ Hope someone could help me to do that, just like what I did below ^
-					if strings.Contains(block, "command_block") {
-						/*
-							=========
-							Reference
-							=========
-							Types for command blocks are checked by their names
-							Whether a command block is conditional is checked through its data value.
-							SINCE IT IS NOT INCLUDED IN NBT DATA.
-
-							The content of __tag is NBT data w/o keys, flatten placed,
-							in such order:
-
-							isMovable:byte
-							CustomName:string
-							UserCustomData:string
-							powered:byte
-							auto:byte
-							conditionMet:byte
-							LPConditionalMode:byte
-							LPRedstoneMode:byte
-							LPCommandMode:byte
-							Command:string
-							Version:VarInt32
-							SuccessCount:VarInt32
-							CustomName:string
-							LastOutput:string
-							LastOutputParams:list[string]
-							TrackOutput:byte
-							LastExecution:VarInt64
-							TickDelay:VarInt32
-							ExecuteOnFirstTick:byte
-						*/
-						__tag := []byte(item["__tag"].(string))
-						//fmt.Printf("CMDBLK %#v\n\n",item["__tag"])
-						var mode uint32
-						if block == "command_block" || block == "minecraft:command_block" {
-							mode = packet.CommandBlockImpulse
-						} else if block == "repeating_command_block" || block == "minecraft:repeating_command_block" {
-							mode = packet.CommandBlockRepeating
-						} else if block == "chain_command_block" || block == "minecraft:chain_command_block" {
-							mode = packet.CommandBlockChain
-						}
-						tagContent := bytes.NewBuffer(__tag)
-						tagContent.Next(1)
-						// ^ Skip: [isMovable:byte]
-						_, err := readNBTString(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ Skip: [CustomName:string]
-						_, err = readNBTString(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ Skip: [UserCustomData:string]
-						tagContent.Next(1)
-						// ^ Skip: [powered:byte]
-						aut, err := tagContent.ReadByte()
-						if err != nil {
-							panic(err)
-						}
-						// ^ Read: [auto:byte]
-						tagContent.Next(4)
-						// ^ Skip: [conditionMet:byte]
-						//   Skip: [LPConditionMode:byte]
-						//   Skip: [LPRedstoneMode:byte]
-						//   Skip: [LPCommandMode:byte]
-						cmd, err := readNBTString(tagContent)
+					var nbtMap map[string]interface{} = nil
+					var blockNBT []byte = nil
+					opaqueTag, hasOpaqueTag := item["__tag"].(string)
+					// 部分服务器会将方块实体数据以无键的 __tag 形式下发，
+					// 其余情况下 item 即为已解码的 NBT 数据
+					if strings.Contains(block, "command_block") && hasOpaqueTag {
+						var err error
+						cbdata, err = decodeCommandBlockTag(block, opaqueTag, static_item)
 						if err != nil {
-							panic(err)
+							env.GameInterface.Output(fmt.Sprintf("EXPORT >> Note: The command block at (%d, %d, %d) is exported without its data since the following error was trapped: %v", x, y, z, err))
 						}
-						// ^ Read: [Command:string]
-						_, err = readVarint32(tagContent)
+					} else if hasOpaqueTag {
+						nbtData = []byte(opaqueTag)
+					} else if len(item) != 0 {
+						var err error
+						blockNBT, err = nbt.MarshalEncoding(item, nbt.LittleEndian)
 						if err != nil {
-							panic(err)
-						}
-						// ^ Skip: [Version:VarInt32]
-						_, err = readVarint32(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ Skip: [SuccessCount:VarInt32]
-						cusname, err := readNBTString(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ Read: [CustomName:string]
-						lo, err := readNBTString(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ Read: [LastOutput:string]
-						lop_in, err := readVarint32(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ PartialRead: **LENGTH OF** [LastOutputParams:list[string]]
-						for i := 0; i < int(lop_in); i++ {
-							_, err = readNBTString(tagContent)
-							if err != nil {
-								panic(err)
-							}
-							// ^ PartialRead: **CONTENT OF** [LastOutputParams:list[string]]
-						}
-						// ^ Skip: [LastOutputParams:list[string]]
-						trackoutput, err := tagContent.ReadByte()
-						if err != nil {
-							panic(err)
-						}
-						// ^ Read: [TrackOutput:byte]
-						_, err = readVarint64(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ Skip: [LastExecution:VarInt64]
-						tickdelay, err := readVarint32(tagContent)
-						if err != nil {
-							panic(err)
-						}
-						// ^ Read: [TickDelay:VarInt32]
-						exeft, err := tagContent.ReadByte()
-						if err != nil {
-							panic(err)
-						}
-						// ^ Read: [ExecuteOnFirstTick:byte]
-						if tagContent.Len() != 0 {
-							panic("Unterminated command block tag")
-						}
-						conb_bit := static_item["conditional_bit"].(uint8)
-						conb := false
-						if conb_bit == 1 {
-							conb = true
-						}
-						var exeftb bool
-						if exeft == 0 {
-							exeftb = true
-						} else {
-							exeftb = true
-						}
-						var tob bool
-						if trackoutput == 1 {
-							tob = true
-						} else {
-							tob = false
-						}
-						var nrb bool
-						if aut == 1 {
-							nrb = false
-							//REVERSED!!
+							env.GameInterface.Output(fmt.Sprintf("EXPORT >> Note: The block entity at (%d, %d, %d) is exported without its NBT data since the following error was trapped: %v", x, y, z, err))
 						} else {
-							nrb = true
+							nbtMap = item
 						}
-						cbdata = &types.CommandBlockData{
-							Mode:               mode,
-							Command:            cmd,
-							CustomName:         cusname,
-							ExecuteOnFirstTick: exeftb,
-							LastOutput:         lo,
-							TickDelay:          tickdelay,
-							TrackOutput:        tob,
-							Conditional:        conb,
-							NeedsRedstone:      nrb,
-						}
-						//fmt.Printf("%#v\n",cbdata)
-					} else {
-						pnd, hasNBT := item["__tag"]
-						if hasNBT {
-							nbtData = []byte(pnd.(string))
+					}
+					// 将方块实体数据编码为 PlaceBlockWithNBTData 所使用的格式，
+					// 以使其能够被 NBT 分配器还原
+					if nbtMap != nil {
+						name := strings.TrimPrefix(block, "minecraft:")
+						blockStates, err := mcstructure.MarshalBlockStates(static_item)
+						if err == nil {
+							blocks[counter] = &types.Module{
+								Block: &types.Block{
+									Name:        &name,
+									BlockStates: blockStates,
+								},
+								NBTData: blockNBT,
+								NBTMap:  nbtMap,
+								Point: types.Position{
+									X: x,
+									Y: y,
+									Z: z,
+								},
+							}
+							counter++
+							continue
 						}
+						env.GameInterface.Output(fmt.Sprintf("EXPORT >> Note: The block entity at (%d, %d, %d) is exported without its NBT data since the following error was trapped: %v", x, y, z, err))
 					}
+					// 方块实体
 					// it's ok to ignore "found", because it will set lb to air if not found
 					lb, _ := chunk.RuntimeIDToLegacyBlock(runtimeId)
 					blocks[counter] = &types.Module{
@@ -345,7 +203,6 @@ func CreateExportTask(commandLine string, env *environment.PBEnvironment) *task.
 							Data: uint16(lb.Val),
 						},
 						CommandBlockData: cbdata,
-						ChestData:        chestData,
 						DebugNBTData:     nbtData,
 						Point: types.Position{
 							X: x,
@@ -359,8 +216,14 @@ func CreateExportTask(commandLine string, env *environment.PBEnvironment) *task.
 		}
 		blocks = blocks[:counter]
 		runtime.GC()
+		env.GameInterface.Output("EXPORT >> Fetching entities")
+		entities, err := fetchEntities(env, beginPos, endPos)
+		if err != nil {
+			env.GameInterface.Output(fmt.Sprintf("EXPORT >> Note: Entities are not exported since the following error was trapped: %v", err))
+		}
 		out := bdump.BDump{
-			Blocks: blocks,
+			Blocks:   blocks,
+			Entities: entities,
 		}
 		if strings.LastIndex(cfg.Path, ".bdx") != len(cfg.Path)-4 || len(cfg.Path) < 4 {
 			cfg.Path += ".bdx"
@@ -380,3 +243,171 @@ func CreateExportTask(commandLine string, env *environment.PBEnvironment) *task.
 	}()
 	return nil
 }
+
+// decodeCommandBlockTag 解码以无键的 __tag 形式下发的命令方块数据
+func decodeCommandBlockTag(block string, opaqueTag string, static_item map[string]interface{}) (*types.CommandBlockData, error) {
+	/*
+		=========
+		Reference
+		=========
+		Types for command blocks are checked by their names
+		Whether a command block is conditional is checked through its data value.
+		SINCE IT IS NOT INCLUDED IN NBT DATA.
+
+		The content of __tag is NBT data w/o keys, flatten placed,
+		in such order:
+
+		isMovable:byte
+		CustomName:string
+		UserCustomData:string
+		powered:byte
+		auto:byte
+		conditionMet:byte
+		LPConditionalMode:byte
+		LPRedstoneMode:byte
+		LPCommandMode:byte
+		Command:string
+		Version:VarInt32
+		SuccessCount:VarInt32
+		CustomName:string
+		LastOutput:string
+		LastOutputParams:list[string]
+		TrackOutput:byte
+		LastExecution:VarInt64
+		TickDelay:VarInt32
+		ExecuteOnFirstTick:byte
+	*/
+	__tag := []byte(opaqueTag)
+	//fmt.Printf("CMDBLK %#v\n\n",item["__tag"])
+	var mode uint32
+	if block == "command_block" || block == "minecraft:command_block" {
+		mode = packet.CommandBlockImpulse
+	} else if block == "repeating_command_block" || block == "minecraft:repeating_command_block" {
+		mode = packet.CommandBlockRepeating
+	} else if block == "chain_command_block" || block == "minecraft:chain_command_block" {
+		mode = packet.CommandBlockChain
+	}
+	tagContent := bytes.NewBuffer(__tag)
+	tagContent.Next(1)
+	// ^ Skip: [isMovable:byte]
+	_, err := readNBTString(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Skip: [CustomName:string]
+	_, err = readNBTString(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Skip: [UserCustomData:string]
+	tagContent.Next(1)
+	// ^ Skip: [powered:byte]
+	aut, err := tagContent.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Read: [auto:byte]
+	tagContent.Next(4)
+	// ^ Skip: [conditionMet:byte]
+	//   Skip: [LPConditionMode:byte]
+	//   Skip: [LPRedstoneMode:byte]
+	//   Skip: [LPCommandMode:byte]
+	cmd, err := readNBTString(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Read: [Command:string]
+	_, err = readVarint32(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Skip: [Version:VarInt32]
+	_, err = readVarint32(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Skip: [SuccessCount:VarInt32]
+	cusname, err := readNBTString(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Read: [CustomName:string]
+	lo, err := readNBTString(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Read: [LastOutput:string]
+	lop_in, err := readVarint32(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ PartialRead: **LENGTH OF** [LastOutputParams:list[string]]
+	for i := 0; i < int(lop_in); i++ {
+		_, err = readNBTString(tagContent)
+		if err != nil {
+			return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+		}
+		// ^ PartialRead: **CONTENT OF** [LastOutputParams:list[string]]
+	}
+	// ^ Skip: [LastOutputParams:list[string]]
+	trackoutput, err := tagContent.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Read: [TrackOutput:byte]
+	_, err = readVarint64(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Skip: [LastExecution:VarInt64]
+	tickdelay, err := readVarint32(tagContent)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Read: [TickDelay:VarInt32]
+	exeft, err := tagContent.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("decodeCommandBlockTag: %v", err)
+	}
+	// ^ Read: [ExecuteOnFirstTick:byte]
+	if tagContent.Len() != 0 {
+		return nil, fmt.Errorf("decodeCommandBlockTag: Unterminated command block tag")
+	}
+	conb_bit, _ := static_item["conditional_bit"].(uint8)
+	conb := false
+	if conb_bit == 1 {
+		conb = true
+	}
+	var exeftb bool
+	if exeft == 0 {
+		exeftb = true
+	} else {
+		exeftb = true
+	}
+	var tob bool
+	if trackoutput == 1 {
+		tob = true
+	} else {
+		tob = false
+	}
+	var nrb bool
+	if aut == 1 {
+		nrb = false
+		//REVERSED!!
+	} else {
+		nrb = true
+	}
+	cbdata := &types.CommandBlockData{
+		Mode:               mode,
+		Command:            cmd,
+		CustomName:         cusname,
+		ExecuteOnFirstTick: exeftb,
+		LastOutput:         lo,
+		TickDelay:          tickdelay,
+		TrackOutput:        tob,
+		Conditional:        conb,
+		NeedsRedstone:      nrb,
+	}
+	//fmt.Printf("%#v\n",cbdata)
+	return cbdata, nil
+}
@@ -0,0 +1,91 @@
+//go:build !is_tweak
+// +build !is_tweak
+
+package special_tasks
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/mcstructure"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft"
+	"phoenixbuilder/minecraft/protocol"
+	"phoenixbuilder/minecraft/protocol/packet"
+)
+
+/*
+取得 beginPos 至 endPos 所在区域中所有应当被导出的实体。
+
+区块数据中不包含实体，
+因此这里以只包含实体的结构请求逐个获取 64*64 大小的区域。
+返回的实体坐标为绝对坐标，这与 export 所导出的方块坐标一致
+*/
+func fetchEntities(env *environment.PBEnvironment, beginPos types.Position, endPos types.Position) ([]*types.Module, error) {
+	gameInterface := env.GameInterface.(*GameInterface.GameInterface)
+	splittedAreas, _, _ := mcstructure.SplitArea(
+		mcstructure.BlockPos{int32(beginPos.X), int32(beginPos.Y), int32(beginPos.Z)},
+		mcstructure.BlockPos{int32(endPos.X), int32(endPos.Y), int32(endPos.Z)},
+		64, 64, true,
+	)
+	ans := make([]*types.Module, 0)
+	for _, value := range splittedAreas {
+		gameInterface.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", value.BeginX+value.SizeX/2, value.BeginY+value.SizeY/2, value.BeginZ+value.SizeZ/2), true)
+		testAreaIsLoaded := fmt.Sprintf(
+			"testforblocks %d %d %d %d %d %d %d %d %d",
+			value.BeginX, value.BeginY, value.BeginZ,
+			value.BeginX+value.SizeX-1, value.BeginY+value.SizeY-1, value.BeginZ+value.SizeZ-1,
+			value.BeginX, value.BeginY, value.BeginZ,
+		)
+		for {
+			resp := gameInterface.SendWSCommandWithResponse(
+				testAreaIsLoaded,
+				ResourcesControl.CommandRequestOptions{
+					TimeOut: ResourcesControl.CommandRequestNoDeadLine,
+				},
+			)
+			if resp.Respond.OutputMessages[0].Message != "commands.generic.outOfWorld" {
+				break
+			}
+		}
+		// 等待当前被访问的区域加载完成
+		holder := gameInterface.Resources.Structure.Occupy()
+		exportData, err := gameInterface.SendStructureRequestWithResponse(
+			&packet.StructureTemplateDataRequest{
+				StructureName: "mystructure:entities",
+				Position:      protocol.BlockPos{value.BeginX, value.BeginY, value.BeginZ},
+				Settings: protocol.StructureSettings{
+					PaletteName:               "default",
+					IgnoreEntities:            false,
+					IgnoreBlocks:              true,
+					Size:                      protocol.BlockPos{value.SizeX, value.SizeY, value.SizeZ},
+					Offset:                    protocol.BlockPos{0, 0, 0},
+					LastEditingPlayerUniqueID: env.Connection.(*minecraft.Conn).GameData().EntityUniqueID,
+					Rotation:                  0,
+					Mirror:                    0,
+					Integrity:                 100,
+					Seed:                      0,
+					AllowNonTickingChunks:     false,
+				},
+				RequestType: packet.StructureTemplateRequestExportFromSave,
+			},
+		)
+		gameInterface.Resources.Structure.Release(holder)
+		if err != nil {
+			return nil, fmt.Errorf("fetchEntities: %v", err)
+		}
+		// 获取只包含实体的结构
+		entities, err := mcstructure.GetStructureEntities(exportData.StructureTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("fetchEntities: %v", err)
+		}
+		got, err := mcstructure.EntitiesToModules(entities, mcstructure.Area{})
+		if err != nil {
+			return nil, fmt.Errorf("fetchEntities: %v", err)
+		}
+		ans = append(ans, got...)
+	}
+	return ans, nil
+	// 返回值
+}
@@ -118,7 +118,7 @@ func CreateLegacyExportTask(commandLine string, env *environment.PBEnvironment)
 					Position:      protocol.BlockPos{int32(value.BeginX), int32(value.BeginY), int32(value.BeginZ)},
 					Settings: protocol.StructureSettings{
 						PaletteName:               "default",
-						IgnoreEntities:            true,
+						IgnoreEntities:            false,
 						IgnoreBlocks:              false,
 						Size:                      protocol.BlockPos{int32(value.SizeX), int32(value.SizeY), int32(value.SizeZ)},
 						Offset:                    protocol.BlockPos{0, 0, 0},
@@ -144,20 +144,28 @@ func CreateLegacyExportTask(commandLine string, env *environment.PBEnvironment)
 		env.GameInterface.Output(pterm.Info.Sprint("Data received, processing......"))
 		env.GameInterface.Output(pterm.Info.Sprint("Extracting blocks......"))
 
-		processedData, err := mcstructure.DumpBlocks(allAreas, reversedMap, mcstructure.Area{
+		currentExport := mcstructure.Area{
 			BeginX: int32(beginPos.X),
 			BeginY: int32(beginPos.Y),
 			BeginZ: int32(beginPos.Z),
 			SizeX:  int32(endPos.X - beginPos.X + 1),
 			SizeY:  int32(endPos.Y - beginPos.Y + 1),
 			SizeZ:  int32(endPos.Z - beginPos.Z + 1),
-		})
+		}
+		processedData, err := mcstructure.DumpBlocks(allAreas, reversedMap, currentExport)
+		if err != nil {
+			panic(err)
+		}
+		env.GameInterface.Output(pterm.Info.Sprint("Extracting entities......"))
+		entities, err := mcstructure.DumpEntities(allAreas, currentExport)
 		if err != nil {
 			panic(err)
 		}
+		// 盔甲架、画以及被命名或被拴住的生物
 
 		outputResult := bdump.BDump{
-			Blocks: processedData,
+			Blocks:   processedData,
+			Entities: entities,
 		}
 		if strings.LastIndex(cfg.Path, ".bdx") != len(cfg.Path)-4 || len(cfg.Path) < 4 {
 			cfg.Path += ".bdx"
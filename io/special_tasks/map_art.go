@@ -0,0 +1,239 @@
+package special_tasks
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/builder"
+	"phoenixbuilder/fastbuilder/configuration"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/parsing"
+	"phoenixbuilder/fastbuilder/task"
+	"phoenixbuilder/fastbuilder/types"
+	GameInterface "phoenixbuilder/game_control/game_interface"
+	"phoenixbuilder/minecraft/protocol"
+	"runtime/debug"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// 机器人手持地图等待其被渲染的时间
+const MapArtRenderWaitTime = 5 * time.Second
+
+// 用作物品展示框墙的背板方块
+const MapArtWallBase string = "minecraft:smooth_stone"
+
+// 物品展示框朝向南方时的 facing_direction
+const mapArtFrameFacing = int32(3)
+
+/*
+创建一个地图画任务。
+
+除了放置地图画的方块外，
+若启用了 --mapitems ，则还会为每个地图网格生成一张已填充的地图；
+若启用了 --mapwall ，则还会以 End 为左下角，
+沿 +X 和 +Y 方向生成一面朝南的物品展示框墙，
+并按照地图画的排列将地图放入其中
+*/
+func CreateMapArtTask(commandLine string, env *environment.PBEnvironment) *task.Task {
+	cfg, err := parsing.Parse(commandLine, configuration.GlobalFullConfig(env).Main())
+	if err != nil {
+		env.GameInterface.Output(fmt.Sprintf("Failed to parse command: %v", err))
+		return nil
+	}
+	buildTask := task.CreateTask(commandLine, env)
+	if buildTask == nil || (!cfg.MapItems && !cfg.MapWall) {
+		return buildTask
+	}
+	if !cfg.MapAlign {
+		env.GameInterface.Output(pterm.Warning.Sprint("Map art is not aligned to the map grid (--align), the generated maps may be offset"))
+	}
+	go func() {
+		defer func() {
+			r := recover()
+			if r != nil {
+				debug.PrintStack()
+				fmt.Println("go routine @ fastbuilder.task mapart crashed ", r)
+			}
+		}()
+		buildTask.Wait()
+		if !buildTask.Completed() {
+			env.GameInterface.Output(pterm.Warning.Sprint("MAPART >> The map art was not completely built, maps are not generated"))
+			return
+		}
+		// 只有完整构建的地图画才需要生成地图
+		err := generateMapItems(env.GameInterface.(*GameInterface.GameInterface), cfg)
+		if err != nil {
+			env.GameInterface.Output(pterm.Error.Sprintf("MAPART >> %v", err))
+			return
+		}
+		env.GameInterface.Output("MAPART >> All maps are generated")
+	}()
+	return buildTask
+}
+
+// 为地图画的每个网格生成一张地图，
+// 并视情况将其放入物品展示框墙中
+func generateMapItems(api *GameInterface.GameInterface, cfg *types.MainConfig) error {
+	origin := builder.MapArtOrigin(cfg)
+	viewY := origin.Y + cfg.MapY + 16
+	// 机器人在地图网格上方的高度
+	if !cfg.MapWall && cfg.MapX*cfg.MapZ > 35 {
+		return fmt.Errorf("generateMapItems: %d maps could not be kept in the inventory, use --mapwall instead", cfg.MapX*cfg.MapZ)
+	}
+	// 未启用物品展示框墙时，地图将被存放在背包中
+	for tileZ := 0; tileZ < cfg.MapZ; tileZ++ {
+		for tileX := 0; tileX < cfg.MapX; tileX++ {
+			index := tileZ*cfg.MapX + tileX
+			err := fillMap(
+				api,
+				origin.X+tileX*builder.MapTileSize+builder.MapTileSize/2,
+				viewY,
+				origin.Z+tileZ*builder.MapTileSize+builder.MapTileSize/2,
+			)
+			if err != nil {
+				return fmt.Errorf("generateMapItems: Failed to fill the map of tile (%d, %d); err = %v", tileX, tileZ, err)
+			}
+			// 生成已填充的地图到快捷栏 0
+			if cfg.MapWall {
+				err = placeMapIntoFrame(
+					api,
+					[3]int32{
+						int32(cfg.End.X + tileX),
+						int32(cfg.End.Y + cfg.MapZ - 1 - tileZ),
+						int32(cfg.End.Z),
+					},
+				)
+				if err != nil {
+					return fmt.Errorf("generateMapItems: Failed to place the map of tile (%d, %d); err = %v", tileX, tileZ, err)
+				}
+				continue
+			}
+			// 放入物品展示框墙
+			err = keepMapInInventory(api, uint8(index+1))
+			if err != nil {
+				return fmt.Errorf("generateMapItems: Failed to keep the map of tile (%d, %d); err = %v", tileX, tileZ, err)
+			}
+			// 存放在背包
+		}
+	}
+	return nil
+	// 返回值
+}
+
+// 在快捷栏 0 获取一张空地图，
+// 然后传送到 (x, y, z) 并使用它以生成已填充的地图。
+// 机器人会手持该地图一段时间以等待其被渲染
+func fillMap(api *GameInterface.GameInterface, x int, y int, z int) error {
+	err := api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", x, y, z), true)
+	if err != nil {
+		return fmt.Errorf("fillMap: %v", err)
+	}
+	// 传送到地图网格的中心
+	err = api.ReplaceItemInInventory(
+		GameInterface.TargetMySelf,
+		GameInterface.ItemGenerateLocation{
+			Path: "slot.hotbar",
+			Slot: 0,
+		},
+		types.ChestSlot{
+			Name:   "empty_map",
+			Count:  1,
+			Damage: 0,
+		},
+		"",
+		true,
+	)
+	if err != nil {
+		return fmt.Errorf("fillMap: %v", err)
+	}
+	err = api.ChangeSelectedHotbarSlot(0)
+	if err != nil {
+		return fmt.Errorf("fillMap: %v", err)
+	}
+	// 获取空地图并手持
+	err = api.ClickAir(0)
+	if err != nil {
+		return fmt.Errorf("fillMap: %v", err)
+	}
+	// 使用空地图
+	time.Sleep(MapArtRenderWaitTime)
+	err = api.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("fillMap: %v", err)
+	}
+	// 等待地图被渲染
+	return nil
+	// 返回值
+}
+
+// 在 pos 处放置一个朝南的物品展示框，
+// 然后将快捷栏 0 中的地图放入其中
+func placeMapIntoFrame(api *GameInterface.GameInterface, pos [3]int32) error {
+	err := api.SendSettingsCommand(fmt.Sprintf("tp %d %d %d", pos[0], pos[1], pos[2]+2), true)
+	if err != nil {
+		return fmt.Errorf("placeMapIntoFrame: %v", err)
+	}
+	// 传送到物品展示框前方
+	err = api.SetBlock([3]int32{pos[0], pos[1], pos[2] - 1}, MapArtWallBase, "[]")
+	if err != nil {
+		return fmt.Errorf("placeMapIntoFrame: %v", err)
+	}
+	err = api.SetBlock(pos, "frame", fmt.Sprintf(`["facing_direction": %d]`, mapArtFrameFacing))
+	if err != nil {
+		return fmt.Errorf("placeMapIntoFrame: %v", err)
+	}
+	// 放置背板和物品展示框
+	err = api.ClickBlock(GameInterface.UseItemOnBlocks{
+		HotbarSlotID: 0,
+		BlockPos:     pos,
+		BlockName:    "minecraft:frame",
+		BlockStates: map[string]interface{}{
+			"facing_direction":     mapArtFrameFacing,
+			"item_frame_map_bit":   byte(0),
+			"item_frame_photo_bit": byte(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("placeMapIntoFrame: %v", err)
+	}
+	err = api.AwaitChangesGeneral()
+	if err != nil {
+		return fmt.Errorf("placeMapIntoFrame: %v", err)
+	}
+	// 将地图放入物品展示框
+	return nil
+	// 返回值
+}
+
+// 将快捷栏 0 中的地图移动到背包的 slot 槽位
+func keepMapInInventory(api *GameInterface.GameInterface, slot uint8) error {
+	itemData, err := api.Resources.Inventory.GetItemStackInfo(0, 0)
+	if err != nil {
+		return fmt.Errorf("keepMapInInventory: %v", err)
+	}
+	// 获取已填充的地图的数据
+	res, err := api.MoveItem(
+		GameInterface.ItemLocation{
+			WindowID:    0,
+			ContainerID: GameInterface.ContainerIDInventory,
+			Slot:        0,
+		},
+		GameInterface.ItemLocation{
+			WindowID:    0,
+			ContainerID: GameInterface.ContainerIDInventory,
+			Slot:        slot,
+		},
+		1,
+		GameInterface.AirItem,
+		itemData,
+	)
+	if err != nil {
+		return fmt.Errorf("keepMapInInventory: %v", err)
+	}
+	if len(res) == 0 || res[0].Status != protocol.ItemStackResponseStatusOK {
+		return fmt.Errorf("keepMapInInventory: Failed to move the map to slot %d", slot)
+	}
+	// 移动地图
+	return nil
+	// 返回值
+}
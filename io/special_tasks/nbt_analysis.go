@@ -0,0 +1,141 @@
+package special_tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	NBTAssigner "phoenixbuilder/fastbuilder/bdump/nbt_assigner"
+	"phoenixbuilder/fastbuilder/builder"
+	"phoenixbuilder/fastbuilder/configuration"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/parsing"
+	"phoenixbuilder/fastbuilder/types"
+	"runtime/debug"
+	"sort"
+
+	"github.com/pterm/pterm"
+)
+
+// 在控制台中逐项列出的丢失数据的最大条数
+const nbtAnalysisMaxLostLines = 20
+
+/*
+分析 -p 所指定的 BDX 文件中的所有方块实体，
+并统计启用 -nbt 导入时它们能被完全支持、部分支持或不被支持的数量。
+
+此过程不会放置任何方块；
+若指定了 -report ，则还会将完整的分析结果以 JSON 格式写入该路径
+*/
+func CreateNBTAnalysisTask(commandLine string, env *environment.PBEnvironment) {
+	cfg, err := parsing.Parse(commandLine, configuration.GlobalFullConfig(env).Main())
+	if err != nil {
+		env.GameInterface.Output(fmt.Sprintf("Failed to parse command: %v", err))
+		return
+	}
+	if len(cfg.Path) == 0 {
+		env.GameInterface.Output(pterm.Error.Sprint("NBTCHECK >> Please specify the BDX file with -p"))
+		return
+	}
+	cfg.Execute = "bdump"
+	// 解析命令
+	go func() {
+		defer func() {
+			r := recover()
+			if r != nil {
+				debug.PrintStack()
+				fmt.Println("go routine @ fastbuilder.task nbtcheck crashed ", r)
+			}
+		}()
+		summary, err := analyzeNBTOfFile(cfg)
+		if err != nil {
+			env.GameInterface.Output(pterm.Error.Sprintf("NBTCHECK >> %v", err))
+			return
+		}
+		for _, value := range formatNBTAnalysisSummary(summary) {
+			env.GameInterface.Output(value)
+		}
+		if len(cfg.Report) == 0 {
+			return
+		}
+		err = writeNBTAnalysisReport(cfg.Report, summary)
+		if err != nil {
+			env.GameInterface.Output(pterm.Error.Sprintf("NBTCHECK >> %v", err))
+			return
+		}
+		env.GameInterface.Output(fmt.Sprintf("NBTCHECK >> The report is written to %s", cfg.Report))
+	}()
+}
+
+// 读取 cfg.Path 所指代的 BDX 文件，
+// 然后分析其中每个带有 NBT 数据的方块
+func analyzeNBTOfFile(cfg *types.MainConfig) (*NBTAssigner.NBTAnalysisSummary, error) {
+	blockschannel := make(chan *types.Module, 10240)
+	result := make(chan error, 1)
+	go func() {
+		err := builder.Generate(cfg, blockschannel)
+		close(blockschannel)
+		result <- err
+	}()
+	// 解析文件
+	summary := NBTAssigner.NewNBTAnalysisSummary()
+	for curblock := range blockschannel {
+		if curblock.Entity != nil || curblock.NBTMap == nil {
+			continue
+		}
+		summary.Add(NBTAssigner.AnalyzeBlockModule(curblock))
+	}
+	// 分析方块实体
+	err := <-result
+	if err != nil {
+		return nil, fmt.Errorf("analyzeNBTOfFile: %v", err)
+	}
+	return summary, nil
+	// 返回值
+}
+
+// 将 summary 转换为便于在控制台阅读的多行文本
+func formatNBTAnalysisSummary(summary *NBTAssigner.NBTAnalysisSummary) []string {
+	result := []string{
+		fmt.Sprintf(
+			"NBTCHECK >> %d block entities: %d fully supported, %d partially supported, %d unsupported",
+			summary.Total, summary.Full, summary.Partial, summary.Unsupported,
+		),
+	}
+	names := make([]string, 0, len(summary.Blocks))
+	for key := range summary.Blocks {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	for _, value := range names {
+		count := summary.Blocks[value]
+		result = append(result, fmt.Sprintf("  %s: %d full, %d partial, %d unsupported", value, count.Full, count.Partial, count.Unsupported))
+	}
+	// 按方块名统计
+	lost := summary.SortedLost()
+	if len(lost) > 0 {
+		result = append(result, "NBTCHECK >> Data that will be lost:")
+	}
+	for key, value := range lost {
+		if key >= nbtAnalysisMaxLostLines {
+			result = append(result, fmt.Sprintf("  ... and %d more, use -report to see all of them", len(lost)-key))
+			break
+		}
+		result = append(result, fmt.Sprintf("  %s (x%d)", value, summary.Lost[value]))
+	}
+	// 将会丢失的数据
+	return result
+	// 返回值
+}
+
+// 将 summary 以 JSON 格式写入 path
+func writeNBTAnalysisReport(path string, summary *NBTAssigner.NBTAnalysisSummary) error {
+	data, err := json.MarshalIndent(summary, "", "\t")
+	if err != nil {
+		return fmt.Errorf("writeNBTAnalysisReport: %v", err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("writeNBTAnalysisReport: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package special_tasks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"phoenixbuilder/fastbuilder/bdump"
+	NBTAssigner "phoenixbuilder/fastbuilder/bdump/nbt_assigner"
+	"phoenixbuilder/fastbuilder/mcstructure"
+	"phoenixbuilder/fastbuilder/types"
+	"phoenixbuilder/minecraft/nbt"
+	"strings"
+	"testing"
+)
+
+// 创建位于 (x, 64, 0) 且 NBT 数据为 blockNBT 的方块 name
+func nbtBlockModule(t *testing.T, name string, x int, blockNBT map[string]interface{}) *types.Module {
+	t.Helper()
+	blockStates, err := mcstructure.MarshalBlockStates(map[string]interface{}{"facing_direction": int32(2)})
+	if err != nil {
+		t.Fatalf("MarshalBlockStates: %v", err)
+	}
+	data, err := nbt.MarshalEncoding(blockNBT, nbt.LittleEndian)
+	if err != nil {
+		t.Fatalf("MarshalEncoding: %v", err)
+	}
+	return &types.Module{
+		Block:   &types.Block{Name: &name, BlockStates: blockStates},
+		NBTData: data,
+		Point:   types.Position{X: x, Y: 64, Z: 0},
+	}
+}
+
+func TestAnalyzeNBTOfFile(t *testing.T) {
+	types.ForwardedBrokSender = make(chan string)
+	go func() {
+		for range types.ForwardedBrokSender {
+		}
+	}()
+	defer close(types.ForwardedBrokSender)
+	// BDX 的读取过程会发送签名状态等消息
+	stone := "stone"
+	file := bdump.BDump{Blocks: []*types.Module{
+		{Block: &types.Block{Name: &stone}, Point: types.Position{X: 0, Y: 64, Z: 0}},
+		nbtBlockModule(t, "beacon", 1, map[string]interface{}{
+			"id": "Beacon", "primary": int32(1), "secondary": int32(10),
+		}),
+		nbtBlockModule(t, "beacon", 2, map[string]interface{}{
+			"id": "Beacon", "primary": int32(1), "CustomName": "lighthouse",
+		}),
+		nbtBlockModule(t, "enchanting_table", 3, map[string]interface{}{
+			"id": "EnchantTable", "rott": float32(1),
+		}),
+	}}
+	path := filepath.Join(t.TempDir(), "sample.bdx")
+	if err, _ := file.WriteToFile(path, "", ""); err != nil {
+		t.Fatalf("WriteToFile: %v", err)
+	}
+	// 写入一个含有方块实体的小型 BDX 文件，
+	// 其中的坐标在写入时被转换为相对坐标
+	summary, err := analyzeNBTOfFile(&types.MainConfig{Path: path, Execute: "bdump"})
+	if err != nil {
+		t.Fatalf("analyzeNBTOfFile: %v", err)
+	}
+	if summary.Total != 3 || summary.Full != 1 || summary.Partial != 1 || summary.Unsupported != 1 {
+		t.Fatalf("got %+v, want 1 full, 1 partial and 1 unsupported block entity", summary)
+	}
+	if count := summary.Blocks["beacon"]; count == nil || count.Full != 1 || count.Partial != 1 {
+		t.Errorf("beacon: got %+v", count)
+	}
+	if summary.Lost["beacon: tag CustomName"] != 1 {
+		t.Errorf("Lost = %v, want the custom name of the beacon", summary.Lost)
+	}
+	if len(summary.Details) != 2 || summary.Details[1].Support != NBTAssigner.NBTSupportNone || summary.Details[1].Position != [3]int32{3, 0, 0} {
+		t.Errorf("Details = %+v, want the partial beacon and the enchanting table", summary.Details)
+	}
+	// 分析
+	lines := formatNBTAnalysisSummary(summary)
+	if !strings.Contains(lines[0], "3 block entities: 1 fully supported, 1 partially supported, 1 unsupported") {
+		t.Errorf("got %q", lines[0])
+	}
+	report := filepath.Join(t.TempDir(), "report.json")
+	if err := writeNBTAnalysisReport(report, summary); err != nil {
+		t.Fatalf("writeNBTAnalysisReport: %v", err)
+	}
+	content, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	got := NBTAssigner.NBTAnalysisSummary{}
+	if err := json.Unmarshal(content, &got); err != nil || got.Total != 3 {
+		t.Errorf("the report is %s, err = %v", content, err)
+	}
+	// 输出结果
+}
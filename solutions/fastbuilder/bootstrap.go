@@ -6,7 +6,10 @@ import (
 	"os/exec"
 	"phoenixbuilder/fastbuilder/args"
 	"phoenixbuilder/fastbuilder/credentials"
+	"phoenixbuilder/fastbuilder/environment"
 	I18n "phoenixbuilder/fastbuilder/i18n"
+	"phoenixbuilder/fastbuilder/lib/utils/cache_wrapper"
+	"phoenixbuilder/fastbuilder/script_engine"
 	"phoenixbuilder/fastbuilder/utils"
 
 	"github.com/pterm/pterm"
@@ -35,6 +38,8 @@ func setup() {
 	if !args.NoReadline {
 		readline.InitReadline()
 	}
+	cache_wrapper.AddCandicatesDir([]string{}, ".config/fastbuilder/cache")
+	cache_wrapper.AddCandicatesDir([]string{".fastbuilder_cache"}, "")
 }
 
 func display_info() {
@@ -65,6 +70,10 @@ func check_update() {
 func Bootstrap() {
 	//args.ParseArgs()
 	// ^^ Argument parser would parse arguments before go runtime starts now
+	if args.PackScripts != "" {
+		packScripts()
+		return
+	}
 	setup()
 	display_info()
 	defer Fatal()
@@ -72,6 +81,10 @@ func Bootstrap() {
 		init_and_run_debug_client()
 		return
 	}
+	if args.RobotConfig != "" {
+		runRobot(args.RobotConfig)
+		return
+	}
 	if !args.ShouldDisableVersionCheck {
 		check_update()
 	}
@@ -100,16 +113,45 @@ func runInteractiveClient(token, username, password string) {
 	if !ptoken_succ {
 		panic("Failed to load token")
 	}
+	runStartupScript(env)
 	EstablishConnectionAndInitEnv(env)
 	go EnterReadlineThread(env, nil)
 	defer DestroyEnv(env)
-	EnterWorkerThread(env, nil)
+	if err := KeepConnected(env, nil); err != nil {
+		panic(err)
+	}
 }
 
 func init_and_run_debug_client() {
 	env := ConfigDebugEnvironment()
+	runStartupScript(env)
 	EstablishConnectionAndInitEnv(env)
 	go EnterReadlineThread(env, nil)
 	defer DestroyEnv(env)
-	EnterWorkerThread(env, nil)
+	if err := EnterWorkerThread(env, nil); err != nil {
+		panic(err)
+	}
+}
+
+// Creates a script package from the manifest specified by
+// --pack-scripts without connecting to any server.
+func packScripts() {
+	output, err := script_engine.PackScripts(args.PackScripts, args.PackScriptsOut)
+	if err != nil {
+		pterm.Error.Println(err)
+		os.Exit(1)
+	}
+	pterm.Success.Printf("Script package created: %s\n", output)
+}
+
+// Starts the script specified by -S before connecting,
+// so that it may wait for the connection by itself.
+func runStartupScript(env *environment.PBEnvironment) {
+	if args.StartupScript == "" {
+		return
+	}
+	_, err := env.ScriptHolder.(*script_engine.ScriptHolder).RunFile(args.StartupScript)
+	if err != nil {
+		pterm.Error.Println(err)
+	}
 }
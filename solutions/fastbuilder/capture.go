@@ -0,0 +1,24 @@
+package fastbuilder
+
+import (
+	"phoenixbuilder/fastbuilder/args"
+	"phoenixbuilder/fastbuilder/capture"
+
+	"github.com/pterm/pterm"
+)
+
+// Opened once for the whole process by openPacketCapture, nil if --capture isn't specified
+var packetCapture *capture.Writer
+
+func openPacketCapture() {
+	if args.CaptureOutputFile == "" || packetCapture != nil {
+		return
+	}
+	writer, err := capture.Open(args.CaptureOutputFile)
+	if err != nil {
+		pterm.Error.Printf("Failed to open the capture file %s: %v\n", args.CaptureOutputFile, err)
+		return
+	}
+	packetCapture = writer
+	pterm.Info.Printf("Capturing packets to %s\n", args.CaptureOutputFile)
+}
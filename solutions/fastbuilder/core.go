@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"phoenixbuilder/fastbuilder/args"
+	"phoenixbuilder/fastbuilder/capture"
 	"phoenixbuilder/fastbuilder/core"
 	"phoenixbuilder/fastbuilder/environment"
 	"phoenixbuilder/fastbuilder/external"
@@ -16,6 +18,7 @@ import (
 	fbauth "phoenixbuilder/fastbuilder/pv4"
 	"phoenixbuilder/fastbuilder/py_rpc"
 	"phoenixbuilder/fastbuilder/readline"
+	"phoenixbuilder/fastbuilder/script_engine"
 	"phoenixbuilder/fastbuilder/signalhandler"
 	fbtask "phoenixbuilder/fastbuilder/task"
 	"phoenixbuilder/fastbuilder/types"
@@ -30,6 +33,7 @@ import (
 	"phoenixbuilder/mirror/io/lru"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pterm/pterm"
@@ -40,7 +44,6 @@ func EnterReadlineThread(env *environment.PBEnvironment, breaker chan struct{})
 		return
 	}
 	defer Fatal()
-	gameInterface := env.GameInterface
 	functionHolder := env.FunctionHolder.(*function.FunctionHolder)
 	for {
 		if breaker != nil {
@@ -54,6 +57,8 @@ func EnterReadlineThread(env *environment.PBEnvironment, breaker chan struct{})
 		if len(cmd) == 0 {
 			continue
 		}
+		// GameInterface is replaced when reconnecting
+		gameInterface := env.CurrentGameInterface()
 		if cmd[0] == '.' {
 			resp := gameInterface.SendCommandWithResponse(
 				cmd[1:],
@@ -97,10 +102,35 @@ func EnterReadlineThread(env *environment.PBEnvironment, breaker chan struct{})
 	}
 }
 
-func EnterWorkerThread(env *environment.PBEnvironment, breaker chan struct{}) {
+// EnterWorkerThread handles the packets of the current connection until it is lost,
+// the reason is returned, or nil if breaker is closed.
+func EnterWorkerThread(env *environment.PBEnvironment, breaker chan struct{}) error {
 	conn := env.Connection.(*minecraft.Conn)
 	functionHolder := env.FunctionHolder.(*function.FunctionHolder)
 
+	// Unix nano of the latest S2CHeartBeat, 0 until the first one arrives
+	lastHeartBeat := int64(0)
+	heartBeatLost := int32(0)
+	workerExited := make(chan struct{})
+	defer close(workerExited)
+	go func() {
+		ticker := time.NewTicker(time.Second * 5)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-workerExited:
+				return
+			case <-ticker.C:
+				last := atomic.LoadInt64(&lastHeartBeat)
+				if last != 0 && time.Since(time.Unix(0, last)) > HeartBeatTimeout {
+					atomic.StoreInt32(&heartBeatLost, 1)
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
 	chunkAssembler := assembler.NewAssembler(assembler.REQUEST_AGGRESSIVE, time.Second*5)
 	// max 100 chunk request per second
 	chunkAssembler.CreateRequestScheduler(func(pk *packet.SubChunkRequest) {
@@ -112,14 +142,17 @@ func EnterWorkerThread(env *environment.PBEnvironment, breaker chan struct{}) {
 		if breaker != nil {
 			select {
 			case <-breaker:
-				return
+				return nil
 			default:
 			}
 		}
 
 		pk, data, err := conn.ReadPacketAndBytes()
 		if err != nil {
-			panic(err)
+			if atomic.LoadInt32(&heartBeatLost) == 1 {
+				return fmt.Errorf("EnterWorkerThread: no heartbeat received in %v", HeartBeatTimeout)
+			}
+			return fmt.Errorf("EnterWorkerThread: %v", err)
 		}
 
 		{
@@ -132,6 +165,7 @@ func EnterWorkerThread(env *environment.PBEnvironment, breaker chan struct{}) {
 				command := pyrpc_val[0].(string)
 				data := pyrpc_val[1].([]interface{})
 				if command == "S2CHeartBeat" {
+					atomic.StoreInt64(&lastHeartBeat, time.Now().UnixNano())
 					conn.WritePacket(&packet.PyRpc{
 						Value: py_rpc.FromGo([]interface{}{
 							"C2SHeartBeat",
@@ -196,6 +230,8 @@ func EnterWorkerThread(env *environment.PBEnvironment, breaker chan struct{}) {
 		// 	pterm.Info.Println("ClientCacheStatus", p)
 		// case *packet.ClientCacheBlobStatus:
 		// 	pterm.Info.Println("ClientCacheBlobStatus", p)
+		case *packet.Disconnect:
+			return fmt.Errorf("EnterWorkerThread: disconnected by the server: %s", p.Message)
 		case *packet.Text:
 			if p.TextType == packet.TextTypeChat {
 				if args.InGameResponse {
@@ -284,28 +320,18 @@ func EstablishConnectionAndInitEnv(env *environment.PBEnvironment) {
 	}
 	pterm.Println(pterm.Yellow(fmt.Sprintf("%s: %s", I18n.T(I18n.ServerCodeTrans), env.LoginInfo.ServerCode)))
 
-	options := []core.Option{}
-	if env.IsDebug {
-		options = append(options, core.OptionDebug)
-	}
 	if args.ExternalListenAddress != "" {
-		external.ListenExt(env, args.ExternalListenAddress)
+		external.ListenExt(env, args.ExternalListenAddress, args.ExternalAuthFile)
 	}
+	if args.WebSocketListenAddress != "" {
+		external.ListenWS(env, args.WebSocketListenAddress, args.ExternalAuthFile)
+	}
+	openPacketCapture()
 
-	ctx, _ := context.WithTimeout(context.Background(), time.Second*30)
-	authenticator := fbauth.NewAccessWrapper(
-		env.FBAuthClient.(*fbauth.Client),
-		env.LoginInfo.ServerCode,
-		env.LoginInfo.ServerPasscode,
-		env.LoginInfo.Token,
-		env.LoginInfo.Username,
-		env.LoginInfo.Password,
-	)
-	conn, err := core.InitializeMinecraftConnection(ctx, authenticator, options...)
-
+	err := connectAndInitSession(env)
 	if err != nil {
 		pterm.Error.Println(err)
-		if runtime.GOOS == "windows" {
+		if runtime.GOOS == "windows" && !args.NoReadline {
 			pterm.Error.Println(I18n.T(I18n.Crashed_OS_Windows))
 			_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
 		}
@@ -319,6 +345,48 @@ func EstablishConnectionAndInitEnv(env *environment.PBEnvironment) {
 		}
 	}
 
+	functionHolder := env.FunctionHolder.(*function.FunctionHolder)
+	function.InitPresetFunctions(functionHolder)
+	fbtask.InitTaskStatusDisplay(env)
+
+	env.ScriptBridge.(*script_engine.EnvironmentBridge).NotifyConnected()
+}
+
+// connectAndInitSession connects to the server and builds the states bound to the connection,
+// it is called again when reconnecting. A new GameInterface is published by SetGameInterface,
+// and the tasks pick it up through CurrentGameInterface once they are resumed.
+func connectAndInitSession(env *environment.PBEnvironment) error {
+	options := []core.Option{}
+	if env.IsDebug {
+		options = append(options, core.OptionDebug)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	authenticator := fbauth.NewAccessWrapper(
+		env.FBAuthClient.(*fbauth.Client),
+		env.LoginInfo.ServerCode,
+		env.LoginInfo.ServerPasscode,
+		env.LoginInfo.Token,
+		env.LoginInfo.Username,
+		env.LoginInfo.Password,
+	)
+	// Every packet of the connection, including the login sequence, is recorded by the session
+	captureSession := packetCapture.NewSession()
+	var packetFunc func(header packet.Header, payload []byte, src, dst net.Addr)
+	if captureSession != nil {
+		packetFunc = captureSession.PacketFunc
+	}
+	conn, err := core.InitializeMinecraftConnection(ctx, authenticator, packetFunc, options...)
+	if err != nil {
+		return err
+	}
+	captureSession.Start(capture.SessionInfo{
+		ServerCode:      env.LoginInfo.ServerCode,
+		EntityRuntimeID: conn.GameData().EntityRuntimeID,
+		EntityUniqueID:  conn.GameData().EntityUniqueID,
+	})
+
 	env.Connection = conn
 	pterm.Println(pterm.Yellow(I18n.T(I18n.ConnectionEstablished)))
 
@@ -328,20 +396,18 @@ func EstablishConnectionAndInitEnv(env *environment.PBEnvironment) {
 
 	env.Resources = &ResourcesControl.Resources{}
 	env.ResourcesUpdater = env.Resources.(*ResourcesControl.Resources).Init()
-	env.GameInterface = &GameInterface.GameInterface{
-		WritePacket: env.Connection.(*minecraft.Conn).WritePacket,
+	gameInterface := &GameInterface.GameInterface{
+		WritePacket: conn.WritePacket,
 		ClientInfo: GameInterface.ClientInfo{
-			DisplayName:     env.Connection.(*minecraft.Conn).IdentityData().DisplayName,
-			ClientIdentity:  env.Connection.(*minecraft.Conn).IdentityData().Identity,
-			XUID:            env.Connection.(*minecraft.Conn).IdentityData().XUID,
-			EntityRuntimeID: env.Connection.(*minecraft.Conn).GameData().EntityRuntimeID,
-			EntityUniqueID:  env.Connection.(*minecraft.Conn).GameData().EntityUniqueID,
+			DisplayName:     conn.IdentityData().DisplayName,
+			ClientIdentity:  conn.IdentityData().Identity,
+			XUID:            conn.IdentityData().XUID,
+			EntityRuntimeID: conn.GameData().EntityRuntimeID,
+			EntityUniqueID:  conn.GameData().EntityUniqueID,
 		},
 		Resources: env.Resources.(*ResourcesControl.Resources),
 	}
-	functionHolder := env.FunctionHolder.(*function.FunctionHolder)
-	function.InitPresetFunctions(functionHolder)
-	fbtask.InitTaskStatusDisplay(env)
+	env.SetGameInterface(gameInterface)
 
 	move.ConnectTime = time.Time{}
 	move.Position = conn.GameData().PlayerPosition
@@ -356,6 +422,7 @@ func EstablishConnectionAndInitEnv(env *environment.PBEnvironment) {
 	types.ForwardedBrokSender = taskholder.BrokSender
 
 	env.UQHolder.(*uqHolder.UQHolder).UpdateFromConn(conn)
+	return nil
 }
 
 func getUserInputMD5() (string, error) {
@@ -5,6 +5,8 @@ import (
 	"phoenixbuilder/fastbuilder/environment"
 	"phoenixbuilder/fastbuilder/function"
 	fbauth "phoenixbuilder/fastbuilder/pv4"
+	"phoenixbuilder/fastbuilder/readline"
+	"phoenixbuilder/fastbuilder/script_engine"
 	fbtask "phoenixbuilder/fastbuilder/task"
 	"phoenixbuilder/minecraft"
 	"phoenixbuilder/mirror/io/global"
@@ -25,6 +27,18 @@ func create_environment() *environment.PBEnvironment {
 	functionHolder := function.NewFunctionHolder(env)
 	env.FunctionHolder = functionHolder
 	env.Destructors = []func(){}
+	scriptBridge := script_engine.NewEnvironmentBridge(env)
+	scriptHolder := script_engine.NewScriptHolder(scriptBridge)
+	if !args.NoReadline {
+		// Permissions are never granted without the user's input
+		scriptHolder.Prompt = script_engine.ConsolePrompt(func() string {
+			return readline.Readline(env)
+		})
+	}
+	env.ScriptBridge = scriptBridge
+	env.ScriptHolder = scriptHolder
+	script_engine.RegisterFunctions(functionHolder, scriptHolder)
+	env.Destructors = append(env.Destructors, scriptHolder.StopAll)
 	env.LRUMemoryChunkCacher = lru.NewLRUMemoryChunkCacher(12, false)
 	env.ChunkFeeder = global.NewChunkFeeder()
 	return env
@@ -0,0 +1,85 @@
+package fastbuilder
+
+import (
+	"fmt"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/fastbuilder/external"
+	"phoenixbuilder/fastbuilder/external/packet"
+	fbtask "phoenixbuilder/fastbuilder/task"
+	ResourcesControl "phoenixbuilder/game_control/resources_control"
+	"phoenixbuilder/minecraft"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+const (
+	// The connection is considered lost if no S2CHeartBeat arrives in this duration
+	HeartBeatTimeout = time.Minute
+	// Give up reconnecting after failing for this many times in a row
+	MaxReconnectAttempts  = 10
+	reconnectInitialDelay = 2 * time.Second
+	reconnectMaxDelay     = time.Minute
+)
+
+// KeepConnected runs the worker thread and reconnects whenever the connection is lost.
+// Running tasks are paused while reconnecting and resumed afterwards,
+// and the external clients are notified of both.
+// An error is returned only if reconnecting failed for MaxReconnectAttempts times.
+func KeepConnected(env *environment.PBEnvironment, breaker chan struct{}) error {
+	for {
+		err := EnterWorkerThread(env, breaker)
+		if err == nil {
+			return nil
+		}
+		pterm.Warning.Printf("Connection lost: %v\n", err)
+		if conn, ok := env.Connection.(*minecraft.Conn); ok && conn != nil {
+			conn.Close()
+		}
+		pausedTasks := fbtask.PauseRunningTasks(env)
+		// The requests waiting for the lost connection would never be answered,
+		// fail them so that the tasks blocked on them reach the point where they pause
+		if resources, ok := env.Resources.(*ResourcesControl.Resources); ok && resources != nil {
+			resources.Close()
+		}
+		external.NotifyConnectionStatus(env, packet.ConnectionStatusDisconnected, err.Error())
+		if err := reconnect(env); err != nil {
+			return err
+		}
+		if resumed := fbtask.ResumeTasks(pausedTasks); resumed > 0 {
+			pterm.Info.Printf("%d task(s) resumed\n", resumed)
+		}
+		external.NotifyConnectionStatus(env, packet.ConnectionStatusReconnected, "")
+	}
+}
+
+// reconnect retries connectAndInitSession with an exponential backoff
+func reconnect(env *environment.PBEnvironment) error {
+	delay := reconnectInitialDelay
+	for attempt := 1; ; attempt++ {
+		pterm.Warning.Printf("Reconnecting in %v (attempt %d/%d)\n", delay, attempt, MaxReconnectAttempts)
+		time.Sleep(delay)
+		err := tryConnectAndInitSession(env)
+		if err == nil {
+			return nil
+		}
+		pterm.Error.Println(err)
+		if attempt >= MaxReconnectAttempts {
+			return fmt.Errorf("reconnect: gave up after %d attempts: %v", attempt, err)
+		}
+		delay *= 2
+		if delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+}
+
+// The authentication panics on some failures, which shouldn't stop the retries
+func tryConnectAndInitSession(env *environment.PBEnvironment) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return connectAndInitSession(env)
+}
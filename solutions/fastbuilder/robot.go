@@ -0,0 +1,168 @@
+package fastbuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"phoenixbuilder/fastbuilder/args"
+	"phoenixbuilder/fastbuilder/credentials"
+	"phoenixbuilder/fastbuilder/environment"
+	"phoenixbuilder/minecraft"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultRobotRestartDelay    = 5
+	defaultRobotMaxRestartDelay = 300
+	// The backoff is reset once a session has lasted this long
+	robotStableSessionDuration = 10 * time.Minute
+)
+
+// RobotConfig is the content of robot.json (or a YAML file with the same keys),
+// see examples/external_ctrl/readme.md
+type RobotConfig struct {
+	// The content of the FBToken, the saved one is used if empty
+	Token        string `json:"token" yaml:"token"`
+	ServerNumber string `json:"server_number" yaml:"server_number"`
+	ServerPasswd string `json:"server_passwd" yaml:"server_passwd"`
+	// The address to listen on for external connections, a bare port means localhost
+	TransferPort string `json:"transfer_port" yaml:"transfer_port"`
+	IgnoreUpdate bool   `json:"ignore_update" yaml:"ignore_update"`
+	AutoRestart  bool   `json:"auto_restart" yaml:"auto_restart"`
+	// Give up after restarting for this many times, 0 for never
+	MaxRestarts int `json:"max_restarts" yaml:"max_restarts"`
+	// The delay before the first restart in seconds, doubled after every failure
+	RestartDelay int `json:"restart_delay" yaml:"restart_delay"`
+	// The upper limit of the delay in seconds
+	MaxRestartDelay int `json:"max_restart_delay" yaml:"max_restart_delay"`
+	// Same as --external-auth
+	ExternalAuth string `json:"external_auth" yaml:"external_auth"`
+	// Same as --listen-ws
+	ListenWS string `json:"listen_ws" yaml:"listen_ws"`
+}
+
+// LoadRobotConfig reads path as YAML if it ends with .yaml or .yml, and as JSON otherwise
+func LoadRobotConfig(path string) (*RobotConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &RobotConfig{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, config)
+	default:
+		err = json.Unmarshal(content, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if config.ServerNumber == "" {
+		return nil, fmt.Errorf("%s: server_number is required", path)
+	}
+	if config.TransferPort != "" && !strings.Contains(config.TransferPort, ":") {
+		config.TransferPort = "127.0.0.1:" + config.TransferPort
+	}
+	if config.RestartDelay <= 0 {
+		config.RestartDelay = defaultRobotRestartDelay
+	}
+	if config.MaxRestartDelay < config.RestartDelay {
+		config.MaxRestartDelay = defaultRobotMaxRestartDelay
+		if config.MaxRestartDelay < config.RestartDelay {
+			config.MaxRestartDelay = config.RestartDelay
+		}
+	}
+	return config, nil
+}
+
+// Runs without user input as configured by --robot,
+// the connection is re-established with an exponential backoff if auto_restart is set.
+func runRobot(configPath string) {
+	config, err := LoadRobotConfig(configPath)
+	if err != nil {
+		pterm.Error.Println(err)
+		os.Exit(1)
+	}
+	args.ShouldDisableVersionCheck = args.ShouldDisableVersionCheck || config.IgnoreUpdate
+	if !args.ShouldDisableVersionCheck {
+		check_update()
+	}
+	if config.TransferPort != "" {
+		args.ExternalListenAddress = config.TransferPort
+	}
+	if config.ExternalAuth != "" {
+		args.ExternalAuthFile = config.ExternalAuth
+	}
+	if config.ListenWS != "" {
+		args.WebSocketListenAddress = config.ListenWS
+	}
+	token := config.Token
+	if token == "" {
+		token, err = credentials.ReadToken(credentials.LoadTokenPath())
+		if err != nil {
+			pterm.Error.Printf("Robot: no token specified and failed to read the saved one: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	initialDelay := time.Duration(config.RestartDelay) * time.Second
+	maxDelay := time.Duration(config.MaxRestartDelay) * time.Second
+	delay := initialDelay
+	restarts := 0
+	for {
+		startTime := time.Now()
+		err := runRobotSession(config, token)
+		pterm.Error.Printf("Robot: session ended: %v\n", err)
+		if !config.AutoRestart {
+			os.Exit(1)
+		}
+		if config.MaxRestarts > 0 && restarts >= config.MaxRestarts {
+			pterm.Error.Printf("Robot: gave up after %d restarts\n", restarts)
+			os.Exit(1)
+		}
+		if time.Since(startTime) >= robotStableSessionDuration {
+			delay = initialDelay
+		}
+		restarts++
+		pterm.Warning.Printf("Robot: restart #%d in %v\n", restarts, delay)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// runRobotSession logs in and handles the packets until reconnecting fails,
+// the environment is always destroyed before returning.
+func runRobotSession(config *RobotConfig, token string) (err error) {
+	env := ConfigRealEnvironment(token, config.ServerNumber, config.ServerPasswd, "", "")
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+		destroyRobotEnv(env)
+	}()
+	if !credentials.ProcessTokenDefault(env) {
+		return fmt.Errorf("failed to load token")
+	}
+	runStartupScript(env)
+	EstablishConnectionAndInitEnv(env)
+	if err := KeepConnected(env, nil); err != nil {
+		return err
+	}
+	return fmt.Errorf("worker thread exited")
+}
+
+// Unlike DestroyEnv, the connection may not have been established
+func destroyRobotEnv(env *environment.PBEnvironment) {
+	env.Stop()
+	env.WaitStopped()
+	if conn, ok := env.Connection.(*minecraft.Conn); ok && conn != nil {
+		conn.Close()
+	}
+}